@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/cache"
 	"github.com/pulkyeet/eth-devstack/backend/internal/config"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
 	"github.com/pulkyeet/eth-devstack/backend/internal/utils"
+	"github.com/pulkyeet/eth-devstack/backend/internal/verifier"
 	"github.com/pulkyeet/eth-devstack/backend/internal/api"
 )
 
@@ -41,7 +46,41 @@ func main() {
 	}
 	defer db.Close()
 
-	server := api.NewServer(db, logger, cfg.Server.Port)
+	chainManager, err := blockchain.NewChainManager(cfg.Chains.ConfigPath, logger)
+	if err != nil {
+		sugar.Fatalw("Failed to initialise chain manager", "error", err)
+	}
+	defer chainManager.Close()
+
+	contractVerifier := verifier.NewVerifier(db, chainManager, cfg.Verifier.SolcPath, cfg.Verifier.SolcDir, logger)
+
+	// Every API process listens for the indexer's events and fans them out
+	// to its own live subscribers
+	bus := events.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	listener := events.NewListener(db, cfg.Database.ConnectionString(), bus, logger)
+	go func() {
+		if err := listener.Run(ctx); err != nil {
+			sugar.Errorw("Chain event listener stopped", "error", err)
+		}
+	}()
+
+	var responseCache cache.Store
+	if cfg.Cache.Enabled {
+		if cfg.Cache.RedisURL != "" {
+			redisCache, err := cache.NewRedis(ctx, cfg.Cache.RedisURL, "eth-devstack:http:")
+			if err != nil {
+				sugar.Fatalw("Failed to initialise response cache", "error", err)
+			}
+			defer redisCache.Close()
+			responseCache = redisCache
+		} else {
+			responseCache = cache.NewLRU(cfg.Cache.MaxMB << 20)
+		}
+	}
+
+	server := api.NewServer(db, chainManager, contractVerifier, bus, cfg.Auth, cfg.Webhooks, responseCache, logger, cfg.Server.Port)
 
 	go func() {
 		if err := server.Start(); err != nil {
@@ -56,6 +95,7 @@ func main() {
 	<-quit
 
 	sugar.Info("Shutting down server...")
+	cancel()
 	if err := server.Shutdown(); err != nil {
 		sugar.Errorw("Error shutting down", "error", err)
 	}
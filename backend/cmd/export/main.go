@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/export"
+	"go.uber.org/zap"
+)
+
+func main() {
+	var (
+		chainID       = flag.Int64("chain", 1337, "Chain ID")
+		dataset       = flag.String("dataset", "transactions", "Dataset: blocks, transactions, token-transfers, internal-transactions or logs; for parquet a comma separated list or all")
+		format        = flag.String("format", "csv", "Output format: csv, ndjson or parquet")
+		address       = flag.String("address", "", "Only rows involving this address or contract")
+		fromBlock     = flag.Int64("from-block", -1, "First block (inclusive)")
+		toBlock       = flag.Int64("to-block", -1, "Last block (inclusive)")
+		fromTime      = flag.String("from-time", "", "Start time, unix seconds or RFC3339 (inclusive)")
+		toTime        = flag.String("to-time", "", "End time, unix seconds or RFC3339 (inclusive)")
+		out           = flag.String("out", "-", "Output file, - for stdout")
+		dir           = flag.String("dir", "", "Output directory for parquet")
+		partitionSize = flag.Int64("partition-size", export.DefaultPartitionSize, "Blocks per parquet file")
+	)
+	flag.Parse()
+
+	if *format == "parquet" {
+		if *dir == "" {
+			log.Fatal("parquet exports need -dir")
+		}
+		if *address != "" || *fromTime != "" || *toTime != "" {
+			log.Fatal("parquet exports are partitioned by block and take only -from-block and -to-block")
+		}
+		opts := &export.ParquetOptions{
+			Dir:           *dir,
+			ChainID:       *chainID,
+			FromBlock:     max(*fromBlock, 0),
+			PartitionSize: *partitionSize,
+			OnPartition: func(dataset database.ExportDataset, p *export.Partition) {
+				log.Printf("Wrote %d %s rows for blocks %d-%d", p.Rows, dataset, p.FromBlock, p.ToBlock)
+			},
+		}
+		if *toBlock >= 0 {
+			opts.ToBlock = toBlock
+		}
+		datasets, err := parseDatasets(*dataset)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Datasets = datasets
+		db, ctx, cancel := connect()
+		defer cancel()
+		defer db.Close()
+
+		start := time.Now()
+		if err := export.WriteParquet(ctx, db, opts); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		log.Printf("Exported to %s in %s", *dir, time.Since(start).Round(time.Millisecond))
+		return
+	}
+
+	filter := &database.ExportFilter{ChainID: *chainID}
+	var err error
+	if filter.Dataset, err = export.ParseDataset(*dataset); err != nil {
+		log.Fatal(err)
+	}
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *address != "" {
+		if !common.IsHexAddress(*address) {
+			log.Fatalf("invalid address: %s", *address)
+		}
+		a := common.HexToAddress(*address).Hex()
+		filter.Address = &a
+	}
+	if *fromBlock >= 0 {
+		filter.FromBlock = fromBlock
+	}
+	if *toBlock >= 0 {
+		filter.ToBlock = toBlock
+	}
+	if filter.FromTime, err = parseTime(*fromTime); err != nil {
+		log.Fatal(err)
+	}
+	if filter.ToTime, err = parseTime(*toTime); err != nil {
+		log.Fatal(err)
+	}
+
+	db, ctx, cancel := connect()
+	defer cancel()
+	defer db.Close()
+
+	output := os.Stdout
+	if *out != "-" {
+		if output, err = os.Create(*out); err != nil {
+			log.Fatal(err)
+		}
+		defer output.Close()
+	}
+	w := bufio.NewWriter(output)
+
+	start := time.Now()
+	n, err := export.Write(ctx, db, filter, f, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Export failed after %d rows: %v", n, err)
+	}
+	log.Printf("Exported %d %s rows in %s", n, *dataset, time.Since(start).Round(time.Millisecond))
+}
+
+// connect opens the database and a context cancelled on interrupt.
+func connect() (*database.DB, context.Context, context.CancelFunc) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config", err)
+	}
+	// Logs go to stdout, which may be the export itself
+	db, err := database.NewDB(
+		cfg.Database.ConnectionString(),
+		cfg.Database.MaxConnections,
+		cfg.Database.MaxIdleConns,
+		zap.NewNop(),
+	)
+	if err != nil {
+		log.Fatal("Failed to initialise database: ", err)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return db, ctx, cancel
+}
+
+// parseDatasets reads a comma separated list of datasets, or all of them.
+func parseDatasets(value string) ([]database.ExportDataset, error) {
+	if value == "all" {
+		return []database.ExportDataset{
+			database.ExportBlocks,
+			database.ExportTransactions,
+			database.ExportTokenTransfers,
+			database.ExportInternalTransactions,
+			database.ExportLogs,
+		}, nil
+	}
+	var datasets []database.ExportDataset
+	for _, name := range strings.Split(value, ",") {
+		dataset, err := export.ParseDataset(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, dataset)
+	}
+	return datasets, nil
+}
+
+// parseTime reads unix seconds or an RFC3339 time; empty means unset.
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(seconds, 0).UTC()
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time: %s", value)
+	}
+	t = t.UTC()
+	return &t, nil
+}
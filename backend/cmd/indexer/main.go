@@ -11,7 +11,9 @@ import (
 	"github.com/pulkyeet/eth-devstack/backend/internal/config"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
 	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/pulkyeet/eth-devstack/backend/internal/stats"
 	"github.com/pulkyeet/eth-devstack/backend/internal/utils"
+	"github.com/pulkyeet/eth-devstack/backend/internal/webhooks"
 )
 
 func main() {
@@ -51,6 +53,12 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Deliveries queued by the indexer are sent from the same process
+	go webhooks.NewWorker(db, cfg.Webhooks, logger).Run(ctx)
+	// Stats rollups and rankings follow the indexed data
+	go stats.NewAggregator(db, logger).Run(ctx)
+	go stats.NewRankingRefresher(db, logger).Run(ctx)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -0,0 +1,254 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// maxEventLogs caps the logs reported with an event_emitted alert
+const maxEventLogs = 10
+
+// outcome is what an evaluation observed and whether the rule should fire.
+type outcome struct {
+	firing  bool
+	details map[string]interface{}
+}
+
+// condition is a rule type's validated params.
+type condition interface {
+	evaluate(ctx context.Context, src source, chainID int64) (*outcome, error)
+}
+
+// balanceBelow fires while an address's native or token balance is below
+// threshold.
+type balanceBelow struct {
+	Address   string  `json:"address"`
+	Token     *string `json:"token,omitempty"`
+	Threshold string  `json:"threshold"`
+}
+
+// eventEmitted fires while a matching log was emitted in the last
+// WindowSeconds. Event is a signature such as "Transfer(address,address,uint256)"
+// and may be given instead of Topic0.
+type eventEmitted struct {
+	Address       *string `json:"address,omitempty"`
+	Event         *string `json:"event,omitempty"`
+	Topic0        string  `json:"topic0"`
+	WindowSeconds int     `json:"window_seconds"`
+}
+
+// failedTxRate fires while more than ThresholdPercent of an address's
+// transactions in the last WindowSeconds failed, once there are at least
+// MinTransactions.
+type failedTxRate struct {
+	Address          string  `json:"address"`
+	ThresholdPercent float64 `json:"threshold_percent"`
+	WindowSeconds    int     `json:"window_seconds"`
+	MinTransactions  int64   `json:"min_transactions"`
+}
+
+// noNewBlock fires while the chain head hasn't advanced for Seconds, which
+// includes the indexer having stopped.
+type noNewBlock struct {
+	Seconds int `json:"seconds"`
+}
+
+// ParseParams validates a rule's params and returns them normalised, with
+// defaults filled in, addresses checksummed and event signatures hashed.
+func ParseParams(ruleType string, raw json.RawMessage) (json.RawMessage, error) {
+	cond, err := parse(ruleType, raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cond)
+}
+
+func parse(ruleType string, raw json.RawMessage) (condition, error) {
+	if len(raw) == 0 {
+		raw = json.RawMessage(`{}`)
+	}
+	var err error
+	decode := func(v interface{}) error {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("invalid %s params: %w", ruleType, err)
+		}
+		return nil
+	}
+
+	switch ruleType {
+	case models.AlertRuleBalanceBelow:
+		c := &balanceBelow{}
+		if err := decode(c); err != nil {
+			return nil, err
+		}
+		if c.Address, err = checksum("address", c.Address); err != nil {
+			return nil, err
+		}
+		if c.Token != nil {
+			token, err := checksum("token", *c.Token)
+			if err != nil {
+				return nil, err
+			}
+			c.Token = &token
+		}
+		threshold, ok := new(big.Int).SetString(c.Threshold, 10)
+		if !ok || threshold.Sign() <= 0 {
+			return nil, fmt.Errorf("threshold must be a positive integer in wei or token base units")
+		}
+		c.Threshold = threshold.String()
+		return c, nil
+
+	case models.AlertRuleEventEmitted:
+		c := &eventEmitted{WindowSeconds: 60}
+		if err := decode(c); err != nil {
+			return nil, err
+		}
+		if c.Address != nil {
+			address, err := checksum("address", *c.Address)
+			if err != nil {
+				return nil, err
+			}
+			c.Address = &address
+		}
+		if c.Event != nil {
+			sig := strings.ReplaceAll(*c.Event, " ", "")
+			if !strings.HasSuffix(sig, ")") || strings.Index(sig, "(") < 1 {
+				return nil, fmt.Errorf("event must be a signature such as Transfer(address,address,uint256)")
+			}
+			topic := crypto.Keccak256Hash([]byte(sig)).Hex()
+			if c.Topic0 != "" && !strings.EqualFold(c.Topic0, topic) {
+				return nil, fmt.Errorf("topic0 does not match event %s", sig)
+			}
+			c.Event, c.Topic0 = &sig, topic
+		}
+		c.Topic0 = strings.ToLower(c.Topic0)
+		if len(c.Topic0) != 66 || !strings.HasPrefix(c.Topic0, "0x") {
+			return nil, fmt.Errorf("event or topic0 is required")
+		}
+		if c.WindowSeconds < 1 {
+			return nil, fmt.Errorf("window_seconds must be positive")
+		}
+		return c, nil
+
+	case models.AlertRuleFailedTxRate:
+		c := &failedTxRate{WindowSeconds: 600, MinTransactions: 1}
+		if err := decode(c); err != nil {
+			return nil, err
+		}
+		if c.Address, err = checksum("address", c.Address); err != nil {
+			return nil, err
+		}
+		if c.ThresholdPercent < 0 || c.ThresholdPercent >= 100 {
+			return nil, fmt.Errorf("threshold_percent must be at least 0 and below 100")
+		}
+		if c.WindowSeconds < 1 || c.MinTransactions < 1 {
+			return nil, fmt.Errorf("window_seconds and min_transactions must be positive")
+		}
+		return c, nil
+
+	case models.AlertRuleNoNewBlock:
+		c := &noNewBlock{Seconds: 60}
+		if err := decode(c); err != nil {
+			return nil, err
+		}
+		if c.Seconds < 1 {
+			return nil, fmt.Errorf("seconds must be positive")
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown rule type: %s", ruleType)
+}
+
+func checksum(name, address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("%s must be a valid address", name)
+	}
+	return common.HexToAddress(address).Hex(), nil
+}
+
+func (c *balanceBelow) evaluate(ctx context.Context, src source, chainID int64) (*outcome, error) {
+	balance, err := src.balance(ctx, chainID, c.Address, c.Token)
+	if err != nil {
+		return nil, err
+	}
+	threshold, _ := new(big.Int).SetString(c.Threshold, 10)
+	details := map[string]interface{}{
+		"address":   c.Address,
+		"balance":   balance.String(),
+		"threshold": c.Threshold,
+	}
+	if c.Token != nil {
+		details["token"] = *c.Token
+	}
+	return &outcome{firing: balance.Cmp(threshold) < 0, details: details}, nil
+}
+
+func (c *eventEmitted) evaluate(ctx context.Context, src source, chainID int64) (*outcome, error) {
+	since := time.Now().Add(-time.Duration(c.WindowSeconds) * time.Second)
+	logs, err := src.recentLogs(ctx, chainID, c.Address, c.Topic0, since, maxEventLogs)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{
+		"topic0":         c.Topic0,
+		"window_seconds": c.WindowSeconds,
+		"logs":           logs,
+	}
+	if c.Address != nil {
+		details["address"] = *c.Address
+	}
+	if c.Event != nil {
+		details["event"] = *c.Event
+	}
+	return &outcome{firing: len(logs) > 0, details: details}, nil
+}
+
+func (c *failedTxRate) evaluate(ctx context.Context, src source, chainID int64) (*outcome, error) {
+	since := time.Now().Add(-time.Duration(c.WindowSeconds) * time.Second)
+	total, failed, err := src.txCounts(ctx, chainID, c.Address, since)
+	if err != nil {
+		return nil, err
+	}
+	rate := 0.0
+	if total > 0 {
+		rate = float64(failed) * 100 / float64(total)
+	}
+	return &outcome{
+		firing: total >= c.MinTransactions && rate > c.ThresholdPercent,
+		details: map[string]interface{}{
+			"address":           c.Address,
+			"transactions":      total,
+			"failed":            failed,
+			"failed_percent":    rate,
+			"threshold_percent": c.ThresholdPercent,
+			"window_seconds":    c.WindowSeconds,
+		},
+	}, nil
+}
+
+func (c *noNewBlock) evaluate(ctx context.Context, src source, chainID int64) (*outcome, error) {
+	status, err := src.syncStatus(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{"seconds": c.Seconds}
+	if status == nil || status.LatestBlockAge == nil {
+		// Nothing has been synced yet, so there is no head to go stale
+		return &outcome{details: details}, nil
+	}
+	details["latest_block"] = status.LatestBlock
+	details["last_synced_block"] = status.LastSyncedBlock
+	details["seconds_since_block"] = *status.LatestBlockAge
+	return &outcome{firing: *status.LatestBlockAge > float64(c.Seconds), details: details}, nil
+}
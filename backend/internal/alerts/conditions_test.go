@@ -0,0 +1,210 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const wallet = "0x000000000000000000000000000000000000bEEF"
+
+type fakeSource struct {
+	balances      map[string]*big.Int
+	logs          []logRef
+	total, failed int64
+	status        *models.SyncStatus
+	// blockTimes, when set, limits recentLogs to logs in blocks mined since,
+	// as the database resolves the window through blocks
+	blockTimes map[int64]time.Time
+
+	since time.Time
+}
+
+func (f *fakeSource) balance(_ context.Context, _ int64, address string, token *string) (*big.Int, error) {
+	key := address
+	if token != nil {
+		key = *token + ":" + address
+	}
+	if b, ok := f.balances[key]; ok {
+		return b, nil
+	}
+	return new(big.Int), nil
+}
+
+func (f *fakeSource) recentLogs(_ context.Context, _ int64, _ *string, _ string, since time.Time, _ int) ([]logRef, error) {
+	f.since = since
+	if f.blockTimes == nil {
+		return f.logs, nil
+	}
+	var logs []logRef
+	for _, l := range f.logs {
+		if !f.blockTimes[l.BlockNumber].Before(since) {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (f *fakeSource) txCounts(_ context.Context, _ int64, _ string, since time.Time) (int64, int64, error) {
+	f.since = since
+	return f.total, f.failed, nil
+}
+
+func (f *fakeSource) syncStatus(context.Context, int64) (*models.SyncStatus, error) {
+	return f.status, nil
+}
+
+func rule(ruleType, params string) *models.AlertRule {
+	return &models.AlertRule{ID: 1, ChainID: 1337, RuleType: ruleType, Params: json.RawMessage(params)}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams(models.AlertRuleEventEmitted,
+		json.RawMessage(`{"event": "Transfer(address, address, uint256)"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"event": "Transfer(address,address,uint256)",
+		"topic0": "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		"window_seconds": 60
+	}`, string(params))
+
+	params, err = ParseParams(models.AlertRuleBalanceBelow,
+		json.RawMessage(`{"address": "0x000000000000000000000000000000000000beef", "threshold": "1000"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"address": "`+wallet+`", "threshold": "1000"}`, string(params))
+
+	params, err = ParseParams(models.AlertRuleNoNewBlock, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"seconds": 60}`, string(params))
+
+	for name, invalid := range map[string][2]string{
+		"unknown type":    {"price_above", `{}`},
+		"unknown field":   {models.AlertRuleNoNewBlock, `{"seconds": 30, "chain": 1}`},
+		"bad address":     {models.AlertRuleBalanceBelow, `{"address": "0x12", "threshold": "1"}`},
+		"no threshold":    {models.AlertRuleBalanceBelow, `{"address": "` + wallet + `"}`},
+		"no event":        {models.AlertRuleEventEmitted, `{}`},
+		"topic mismatch":  {models.AlertRuleEventEmitted, `{"event": "Approval(address,address,uint256)", "topic0": "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"}`},
+		"percent too big": {models.AlertRuleFailedTxRate, `{"address": "` + wallet + `", "threshold_percent": 100}`},
+		"zero seconds":    {models.AlertRuleNoNewBlock, `{"seconds": 0}`},
+	} {
+		_, err := ParseParams(invalid[0], json.RawMessage(invalid[1]))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestBalanceBelow(t *testing.T) {
+	src := &fakeSource{balances: map[string]*big.Int{wallet: big.NewInt(500)}}
+	r := rule(models.AlertRuleBalanceBelow, `{"address": "`+wallet+`", "threshold": "1000"}`)
+
+	result, err := check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.True(t, result.firing)
+	assert.Equal(t, "500", result.details["balance"])
+
+	src.balances[wallet] = big.NewInt(1000)
+	result, err = check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.False(t, result.firing)
+}
+
+func TestEventEmitted(t *testing.T) {
+	src := &fakeSource{}
+	r := rule(models.AlertRuleEventEmitted,
+		`{"topic0": "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", "window_seconds": 120}`)
+
+	result, err := check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.False(t, result.firing)
+	assert.WithinDuration(t, time.Now().Add(-2*time.Minute), src.since, 5*time.Second)
+
+	src.logs = []logRef{{BlockNumber: 10, TransactionHash: "0x1"}}
+	result, err = check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.True(t, result.firing)
+}
+
+func TestEventEmittedQuietChain(t *testing.T) {
+	// The only matching log is an hour old and no block was mined since
+	src := &fakeSource{
+		logs:       []logRef{{BlockNumber: 10, TransactionHash: "0x1"}},
+		blockTimes: map[int64]time.Time{10: time.Now().Add(-time.Hour)},
+	}
+	r := rule(models.AlertRuleEventEmitted,
+		`{"topic0": "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", "window_seconds": 60}`)
+
+	result, err := check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.False(t, result.firing)
+
+	src.logs = append(src.logs, logRef{BlockNumber: 11, TransactionHash: "0x2"})
+	src.blockTimes[11] = time.Now()
+	result, err = check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.True(t, result.firing)
+}
+
+func TestFailedTxRate(t *testing.T) {
+	r := rule(models.AlertRuleFailedTxRate,
+		`{"address": "`+wallet+`", "threshold_percent": 50, "min_transactions": 4}`)
+
+	for name, tc := range map[string]struct {
+		total, failed int64
+		firing        bool
+	}{
+		"idle":          {0, 0, false},
+		"too few":       {3, 3, false},
+		"at threshold":  {4, 2, false},
+		"over":          {4, 3, true},
+		"all succeeded": {10, 0, false},
+	} {
+		src := &fakeSource{total: tc.total, failed: tc.failed}
+		result, err := check(context.Background(), src, r)
+		require.NoError(t, err, name)
+		assert.Equal(t, tc.firing, result.firing, name)
+		assert.WithinDuration(t, time.Now().Add(-10*time.Minute), src.since, 5*time.Second, name)
+	}
+}
+
+func TestNoNewBlock(t *testing.T) {
+	r := rule(models.AlertRuleNoNewBlock, `{"seconds": 60}`)
+	age := func(seconds float64) *fakeSource {
+		return &fakeSource{status: &models.SyncStatus{LatestBlock: 42, LatestBlockAge: &seconds}}
+	}
+
+	result, err := check(context.Background(), &fakeSource{}, r)
+	require.NoError(t, err)
+	assert.False(t, result.firing, "never synced")
+
+	result, err = check(context.Background(), age(12), r)
+	require.NoError(t, err)
+	assert.False(t, result.firing)
+
+	result, err = check(context.Background(), age(61), r)
+	require.NoError(t, err)
+	assert.True(t, result.firing)
+	assert.Equal(t, int64(42), result.details["latest_block"])
+}
+
+func TestTokenBalanceBelowReadsNode(t *testing.T) {
+	const token = "0x000000000000000000000000000000000000c0DE"
+	node := blockchaintest.NewNode(t)
+	node.SetContract(token, (&blockchaintest.Token{
+		Decimals: 6,
+		Balances: map[string]*big.Int{wallet: big.NewInt(500)},
+	}).Contract())
+	src := &dbSource{chains: node.Chains(t)}
+	r := rule(models.AlertRuleBalanceBelow, `{"address": "`+wallet+`", "token": "`+token+`", "threshold": "1000"}`)
+
+	result, err := check(context.Background(), src, r)
+	require.NoError(t, err)
+	assert.True(t, result.firing)
+	assert.Equal(t, "500", result.details["balance"])
+	assert.Equal(t, token, result.details["token"])
+}
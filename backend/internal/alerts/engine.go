@@ -0,0 +1,181 @@
+// Package alerts evaluates the alert rules stored in Postgres against the
+// index, the chain and the indexer's sync status. A rule fires when its
+// condition starts holding and resolves when it stops; each transition is
+// recorded once as an alert event and, if the rule has a webhook, queued as a
+// signed delivery on the webhook queue.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/webhooks"
+	"go.uber.org/zap"
+)
+
+const (
+	// evaluateInterval is how often every enabled rule is evaluated. Indexed
+	// blocks evaluate their chain's rules in between.
+	evaluateInterval = 10 * time.Second
+	evaluateTimeout  = 30 * time.Second
+)
+
+// Payload is the webhook body for an alert firing or resolving. It is told
+// apart from block deliveries by its type.
+type Payload struct {
+	Type      string       `json:"type"`
+	WebhookID int64        `json:"webhook_id"`
+	Alert     Notification `json:"alert"`
+}
+
+// Notification describes the transition and what was observed.
+type Notification struct {
+	EventID  int64           `json:"event_id"`
+	RuleID   int64           `json:"rule_id"`
+	Name     string          `json:"name"`
+	RuleType string          `json:"rule_type"`
+	ChainID  int64           `json:"chain_id"`
+	State    string          `json:"state"`
+	Details  json.RawMessage `json:"details,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+func NewPayload(webhookID int64, rule *models.AlertRule, event *models.AlertEvent) *Payload {
+	return &Payload{
+		Type:      webhooks.PayloadAlert,
+		WebhookID: webhookID,
+		Alert: Notification{
+			EventID:  event.ID,
+			RuleID:   rule.ID,
+			Name:     rule.Name,
+			RuleType: rule.RuleType,
+			ChainID:  rule.ChainID,
+			State:    event.State,
+			Details:  event.Details,
+			At:       event.CreatedAt,
+		},
+	}
+}
+
+// Engine evaluates alert rules on a timer and whenever the indexer reports a
+// block.
+type Engine struct {
+	db       *database.DB
+	source   source
+	logger   *zap.SugaredLogger
+	interval time.Duration
+	trigger  chan int64
+}
+
+func NewEngine(db *database.DB, chains *blockchain.ChainManager, logger *zap.Logger) *Engine {
+	return &Engine{
+		db:       db,
+		source:   &dbSource{db: db, chains: chains},
+		logger:   logger.Sugar(),
+		interval: evaluateInterval,
+		trigger:  make(chan int64, 16),
+	}
+}
+
+// Publish makes the engine an events.Publisher, so an events.Listener can
+// feed it the indexer's blocks. A chain already waiting to be evaluated isn't
+// queued twice.
+func (e *Engine) Publish(event *events.Event) {
+	select {
+	case e.trigger <- event.ChainID:
+	default:
+	}
+}
+
+// Run evaluates rules until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	e.evaluate(ctx, nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate(ctx, nil)
+		case chainID := <-e.trigger:
+			e.evaluate(ctx, &chainID)
+		}
+	}
+}
+
+// evaluate runs the enabled rules, on one chain if chainID is set.
+func (e *Engine) evaluate(ctx context.Context, chainID *int64) {
+	ctx, cancel := context.WithTimeout(ctx, evaluateTimeout)
+	defer cancel()
+
+	enabled := true
+	rules, err := e.db.GetAlertRules(ctx, &database.AlertRuleFilter{ChainID: chainID, Enabled: &enabled})
+	if err != nil {
+		e.logger.Errorw("Failed to load alert rules", "error", err)
+		return
+	}
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+		e.evaluateRule(ctx, rule)
+	}
+}
+
+func (e *Engine) evaluateRule(ctx context.Context, rule *models.AlertRule) {
+	result, err := check(ctx, e.source, rule)
+	var evalErr *string
+	if err != nil {
+		msg := err.Error()
+		evalErr = &msg
+		e.logger.Warnw("Alert rule evaluation failed", "rule", rule.ID, "error", err)
+	}
+	if err := e.db.MarkAlertRuleEvaluated(ctx, rule.ID, evalErr); err != nil {
+		e.logger.Errorw("Failed to record alert evaluation", "rule", rule.ID, "error", err)
+	}
+	// A failed evaluation leaves the state alone rather than guessing
+	if result == nil {
+		return
+	}
+
+	state := models.AlertStateOK
+	if result.firing {
+		state = models.AlertStateFiring
+	}
+	if state == rule.State {
+		return
+	}
+	details, err := json.Marshal(result.details)
+	if err != nil {
+		e.logger.Errorw("Failed to encode alert details", "rule", rule.ID, "error", err)
+		return
+	}
+	event, err := e.db.TransitionAlertRule(ctx, rule, state, details,
+		func(webhookID int64, event *models.AlertEvent) (json.RawMessage, error) {
+			return json.Marshal(NewPayload(webhookID, rule, event))
+		})
+	if err != nil {
+		e.logger.Errorw("Failed to record alert transition", "rule", rule.ID, "error", err)
+		return
+	}
+	if event != nil {
+		e.logger.Infow("Alert "+event.State, "rule", rule.ID, "name", rule.Name, "chain_id", rule.ChainID)
+	}
+}
+
+// check evaluates a rule's condition. A nil outcome means it couldn't be
+// evaluated.
+func check(ctx context.Context, src source, rule *models.AlertRule) (*outcome, error) {
+	cond, err := parse(rule.RuleType, rule.Params)
+	if err != nil {
+		return nil, err
+	}
+	return cond.evaluate(ctx, src, rule.ChainID)
+}
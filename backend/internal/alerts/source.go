@@ -0,0 +1,98 @@
+package alerts
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// logRef identifies a log reported with an event_emitted alert.
+type logRef struct {
+	BlockNumber     int64  `json:"block_number"`
+	TransactionHash string `json:"transaction_hash"`
+	LogIndex        int    `json:"log_index"`
+	Address         string `json:"address"`
+}
+
+// source is what conditions read: the index, the chain and sync_status.
+type source interface {
+	// balance is an address's native balance, or its balance of token if set
+	balance(ctx context.Context, chainID int64, address string, token *string) (*big.Int, error)
+	recentLogs(ctx context.Context, chainID int64, address *string, topic0 string, since time.Time, limit int) ([]logRef, error)
+	txCounts(ctx context.Context, chainID int64, address string, since time.Time) (total, failed int64, err error)
+	syncStatus(ctx context.Context, chainID int64) (*models.SyncStatus, error)
+}
+
+type dbSource struct {
+	db     *database.DB
+	chains *blockchain.ChainManager
+}
+
+// balance reads balances from the node rather than the index: the index
+// only has transaction values and the balances of holders seen transferring.
+func (s *dbSource) balance(ctx context.Context, chainID int64, address string, token *string) (*big.Int, error) {
+	client, err := s.chains.GetClient(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return client.GetBalance(ctx, address, nil)
+	}
+	return client.BalanceOf(ctx, *token, address, nil)
+}
+
+// recentLogs resolves since through blocks, as logs carry no timestamp; a
+// window no block was mined in matches no logs.
+func (s *dbSource) recentLogs(ctx context.Context, chainID int64, address *string, topic0 string, since time.Time, limit int) ([]logRef, error) {
+	filter := &database.LogFilter{
+		ChainID:    chainID,
+		BlockRange: database.BlockRange{FromTime: &since},
+		Order:      database.OrderDesc,
+		Limit:      limit,
+	}
+	filter.Topics[0] = []string{topic0}
+	if address != nil {
+		filter.Addresses = []string{*address}
+	}
+	logs, err := s.db.GetLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]logRef, 0, len(logs))
+	for _, l := range logs {
+		refs = append(refs, logRef{
+			BlockNumber:     l.BlockNumber,
+			TransactionHash: l.TransactionHash,
+			LogIndex:        l.LogIndex,
+			Address:         l.Address,
+		})
+	}
+	return refs, nil
+}
+
+func (s *dbSource) txCounts(ctx context.Context, chainID int64, address string, since time.Time) (int64, int64, error) {
+	filter := &database.TransactionFilter{
+		ChainID:    chainID,
+		Party:      database.Party{Address: &address},
+		BlockRange: database.BlockRange{FromTime: &since},
+	}
+	total, err := s.db.CountTransactionsByFilter(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	failed := 0
+	filter.Status = &failed
+	failedCount, err := s.db.CountTransactionsByFilter(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, failedCount, nil
+}
+
+func (s *dbSource) syncStatus(ctx context.Context, chainID int64) (*models.SyncStatus, error) {
+	return s.db.GetSyncStatus(ctx, chainID)
+}
@@ -1,17 +1,36 @@
 package handlers
 
 import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
 )
 
+const (
+	// maxCounterparties is how many counterparties a summary lists
+	maxCounterparties = 10
+	// nativeDecimals are the decimals of every configured chain's native
+	// currency
+	nativeDecimals = 18
+	balanceTimeout = 5 * time.Second
+)
+
 type AddressHandler struct {
-	db *database.DB
+	db     *database.DB
+	chains *blockchain.ChainManager
 }
 
-func NewAddressHandler(db *database.DB) *AddressHandler {
-	return &AddressHandler{db: db}
+func NewAddressHandler(db *database.DB, chains *blockchain.ChainManager) *AddressHandler {
+	return &AddressHandler{db: db, chains: chains}
 }
 
 func (h *AddressHandler) GetAddress(c *fiber.Ctx) error {
@@ -27,40 +46,278 @@ func (h *AddressHandler) GetAddress(c *fiber.Ctx) error {
 	}
 
 	cID := int64(chainID)
+	attachLabels(c.Context(), h.db, cID, addr)
 	return responses.Success(c, addr, &cID)
 }
 
+// GetAddressTransactions lists an address's transactions. It takes the same
+// filters as GetTransactions, with direction and counterparty relative to the
+// address in the path.
 func (h *AddressHandler) GetAddressTransactions(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	filter := &database.TransactionFilter{ChainID: chainID}
+	filter.Address = &address
+	if err := parseTransactionFilter(c, filter); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	return listTransactions(c, h.db, filter, fiber.Map{"address": address})
+}
+
+// GetAddressTokens lists the tokens an address holds with their balances.
+func (h *AddressHandler) GetAddressTokens(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	tokens, err := h.db.GetTokensByAddress(c.Context(), int64(chainID), address)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch tokens", err.Error())
+	}
+	for _, holding := range tokens {
+		holding.BalanceFormatted = formatUnits(&holding.Balance, holding.Decimals)
+		holding.TotalSupplyFormatted = formatUnits(holding.TotalSupply, holding.Decimals)
+	}
+
+	cID := int64(chainID)
+	return responses.Success(c, fiber.Map{"tokens": tokens}, &cID)
+}
+
+// GetAddressApprovals lists the live token allowances and operator approvals
+// granted by an address, along with the transaction that set each one.
+// Allowances are read from their tokens when the node answers.
+func (h *AddressHandler) GetAddressApprovals(c *fiber.Ctx) error {
 	chainID := c.QueryInt("chain_id", 1337)
 	address := c.Params("address")
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 20)
-	if limit > 100 {
-		limit = 100
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
 	}
+	address = common.HexToAddress(address).Hex()
+	page, limit := pageParams(c)
 	offset := (page - 1) * limit
 
-	txs, err := h.db.GetTransactionsByAddress(c.Context(), int64(chainID), address, limit, offset)
+	approvals, err := h.db.GetLiveApprovalsByOwner(c.Context(), int64(chainID), address, limit, offset)
 	if err != nil {
-		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch transactions", err.Error())
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch approvals", err.Error())
 	}
+	var reader allowanceReader
+	if client, err := h.chains.GetClient(int64(chainID)); err == nil {
+		reader = client
+	}
+	approvals = currentAllowances(c.Context(), reader, approvals)
 
 	cID := int64(chainID)
 	return responses.Success(c, fiber.Map{
-		"address":      address,
-		"transactions": txs,
+		"address":   address,
+		"approvals": approvals,
 	}, &cID)
 }
 
-func (h *AddressHandler) GetAddressTokens(c *fiber.Ctx) error {
-	chainID := c.QueryInt("chain_id", 1337)
+// allowanceReader reads an ERC20 allowance from the chain.
+type allowanceReader interface {
+	Allowance(ctx context.Context, token, owner, spender string) (*big.Int, error)
+}
+
+// currentAllowances replaces each allowance's last approved value with what
+// the token reports now, since transferFrom spends allowances without an
+// Approval event, and drops those spent to zero. Allowances the node can't
+// read keep the last approved value.
+func currentAllowances(ctx context.Context, reader allowanceReader, approvals []*models.TokenApproval) []*models.TokenApproval {
+	values := make([]*big.Int, len(approvals))
+	if reader != nil {
+		readCtx, cancel := context.WithTimeout(ctx, balanceTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for i, approval := range approvals {
+			if approval.ApprovalType != "ALLOWANCE" {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, approval *models.TokenApproval) {
+				defer wg.Done()
+				value, err := reader.Allowance(readCtx, approval.TokenAddress, approval.OwnerAddress, approval.SpenderAddress)
+				if err == nil {
+					values[i] = value
+				}
+			}(i, approval)
+		}
+		wg.Wait()
+	}
+
+	live := []*models.TokenApproval{}
+	for i, approval := range approvals {
+		if approval.ApprovalType == "ALLOWANCE" {
+			approval.ValueSource = models.BalanceSourceIndexed
+			if values[i] != nil {
+				if values[i].Sign() == 0 {
+					continue
+				}
+				value := values[i].String()
+				approval.Value = &value
+				approval.ValueSource = models.BalanceSourceNode
+			}
+		}
+		live = append(live, approval)
+	}
+	return live
+}
+
+// tokenBalanceReader reads an ERC20 balance from the chain.
+type tokenBalanceReader interface {
+	BalanceOf(ctx context.Context, token, holder string, blockNumber *big.Int) (*big.Int, error)
+}
+
+// currentHoldings replaces each holding's indexed balance with what the token
+// reports now, which also covers balances changed without a Transfer event,
+// and drops those now empty. Holdings the node can't read keep the indexed
+// balance.
+func currentHoldings(ctx context.Context, reader tokenBalanceReader, holder string, holdings []*models.TokenHolding) []*models.TokenHolding {
+	balances := make([]*big.Int, len(holdings))
+	if reader != nil {
+		readCtx, cancel := context.WithTimeout(ctx, balanceTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for i, holding := range holdings {
+			wg.Add(1)
+			go func(i int, holding *models.TokenHolding) {
+				defer wg.Done()
+				balance, err := reader.BalanceOf(readCtx, holding.Address, holder, nil)
+				if err == nil {
+					balances[i] = balance
+				}
+			}(i, holding)
+		}
+		wg.Wait()
+	}
+
+	current := []*models.TokenHolding{}
+	for i, holding := range holdings {
+		holding.BalanceSource = models.BalanceSourceIndexed
+		if balances[i] != nil {
+			if balances[i].Sign() == 0 {
+				continue
+			}
+			holding.Balance = balances[i].String()
+			holding.BalanceSource = models.BalanceSourceNode
+		}
+		current = append(current, holding)
+	}
+	return current
+}
+
+// GetAddressSummary returns an address's native balance, token holdings,
+// first and last activity, transaction counts, gas spent and busiest
+// counterparties.
+func (h *AddressHandler) GetAddressSummary(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
 	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
 
-	tokens, err := h.db.GetTokensByAddress(c.Context(), int64(chainID), address)
+	summary, err := h.summarize(c.Context(), chainID, address)
 	if err != nil {
-		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch tokens", err.Error())
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch address summary", err.Error())
+	}
+	if summary == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Address not found", nil)
 	}
+	return responses.Success(c, summary, &chainID)
+}
 
-	cID := int64(chainID)
-	return responses.Success(c, fiber.Map{"tokens": tokens}, &cID)
-}
\ No newline at end of file
+// GetPortfolio summarises an address on every active chain it has been seen
+// on, ordered by chain ID.
+func (h *AddressHandler) GetPortfolio(c *fiber.Ctx) error {
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	chains := h.chains.GetActiveChains()
+	summaries := make([]*models.AddressSummary, len(chains))
+	errs := make([]error, len(chains))
+	var wg sync.WaitGroup
+	for i, chain := range chains {
+		wg.Add(1)
+		go func(i int, chainID int64) {
+			defer wg.Done()
+			summaries[i], errs[i] = h.summarize(c.Context(), chainID, address)
+		}(i, chain.ChainID)
+	}
+	wg.Wait()
+
+	portfolio := []*models.PortfolioChain{}
+	var totals models.TxCounts
+	for i, chain := range chains {
+		if errs[i] != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch address summary", errs[i].Error())
+		}
+		s := summaries[i]
+		if s == nil {
+			continue
+		}
+		portfolio = append(portfolio, &models.PortfolioChain{Name: chain.Name, NativeSymbol: chain.NativeSymbol, AddressSummary: s})
+		totals.Total += s.TxCounts.Total
+		totals.Sent += s.TxCounts.Sent
+		totals.Received += s.TxCounts.Received
+		totals.Success += s.TxCounts.Success
+		totals.Failed += s.TxCounts.Failed
+	}
+	sort.Slice(portfolio, func(i, j int) bool { return portfolio[i].ChainID < portfolio[j].ChainID })
+
+	return responses.Success(c, fiber.Map{
+		"address":   address,
+		"chains":    portfolio,
+		"tx_counts": totals,
+	}, nil)
+}
+
+// summarize builds an address's summary on one chain, or returns nil if the
+// chain has never seen it. The native balance is read from the node when it
+// answers, so it is current even for addresses the indexer last saw long ago.
+func (h *AddressHandler) summarize(ctx context.Context, chainID int64, address string) (*models.AddressSummary, error) {
+	summary, err := h.db.GetAddressSummary(ctx, chainID, address, maxCounterparties)
+	if err != nil || summary == nil {
+		return summary, err
+	}
+	holdings, err := h.db.GetTokensByAddress(ctx, chainID, address)
+	if err != nil {
+		return nil, err
+	}
+	var reader tokenBalanceReader
+	client, clientErr := h.chains.GetClient(chainID)
+	if clientErr == nil {
+		reader = client
+	}
+	summary.TokenHoldings = currentHoldings(ctx, reader, address, holdings)
+	for _, holding := range summary.TokenHoldings {
+		holding.BalanceFormatted = formatUnits(&holding.Balance, holding.Decimals)
+		holding.TotalSupplyFormatted = formatUnits(holding.TotalSupply, holding.Decimals)
+	}
+
+	if clientErr == nil {
+		balanceCtx, cancel := context.WithTimeout(ctx, balanceTimeout)
+		balance, err := client.GetBalance(balanceCtx, address, nil)
+		cancel()
+		if err == nil {
+			summary.Balance = balance.String()
+			summary.BalanceSource = models.BalanceSourceNode
+		}
+	}
+	decimals := nativeDecimals
+	summary.BalanceFormatted = formatUnits(&summary.Balance, &decimals)
+	summary.GasSpent.FeesPaidFormatted = formatUnits(&summary.GasSpent.FeesPaid, &decimals)
+
+	attachLabels(ctx, h.db, chainID, summary)
+	return summary, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAllowances answers allowance() by token; tokens it doesn't know fail.
+type fakeAllowances map[string]*big.Int
+
+func (f fakeAllowances) Allowance(_ context.Context, token, _, _ string) (*big.Int, error) {
+	value, ok := f[token]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return value, nil
+}
+
+func TestCurrentAllowances(t *testing.T) {
+	approvals := func() []*models.TokenApproval {
+		return []*models.TokenApproval{
+			{TokenAddress: "0xspent", ApprovalType: "ALLOWANCE", Value: strPtr("100")},
+			{TokenAddress: "0xpartly", ApprovalType: "ALLOWANCE", Value: strPtr("100")},
+			{TokenAddress: "0xreverts", ApprovalType: "ALLOWANCE", Value: strPtr("100")},
+			{TokenAddress: "0xnft", ApprovalType: "APPROVAL_FOR_ALL", Approved: true},
+		}
+	}
+	reader := fakeAllowances{"0xspent": big.NewInt(0), "0xpartly": big.NewInt(40)}
+
+	live := currentAllowances(context.Background(), reader, approvals())
+	require.Len(t, live, 3)
+	assert.Equal(t, "0xpartly", live[0].TokenAddress)
+	assert.Equal(t, "40", *live[0].Value)
+	assert.Equal(t, models.BalanceSourceNode, live[0].ValueSource)
+	// An allowance the token won't report keeps the last approved value
+	assert.Equal(t, "100", *live[1].Value)
+	assert.Equal(t, models.BalanceSourceIndexed, live[1].ValueSource)
+	assert.Empty(t, live[2].ValueSource)
+
+	// Without a node every allowance is the last approved value
+	live = currentAllowances(context.Background(), nil, approvals())
+	require.Len(t, live, 4)
+	for _, approval := range live[:3] {
+		assert.Equal(t, "100", *approval.Value)
+		assert.Equal(t, models.BalanceSourceIndexed, approval.ValueSource)
+	}
+	assert.Empty(t, currentAllowances(context.Background(), reader, nil))
+}
+
+// fakeBalances answers balanceOf() by token; tokens it doesn't know fail.
+type fakeBalances map[string]*big.Int
+
+func (f fakeBalances) BalanceOf(_ context.Context, token, _ string, _ *big.Int) (*big.Int, error) {
+	value, ok := f[token]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return value, nil
+}
+
+func TestCurrentHoldings(t *testing.T) {
+	holdings := func() []*models.TokenHolding {
+		return []*models.TokenHolding{
+			{Token: models.Token{Address: "0xemptied"}, Balance: "100"},
+			{Token: models.Token{Address: "0xchanged"}, Balance: "100"},
+			{Token: models.Token{Address: "0xreverts"}, Balance: "100"},
+		}
+	}
+	reader := fakeBalances{"0xemptied": big.NewInt(0), "0xchanged": big.NewInt(250)}
+
+	current := currentHoldings(context.Background(), reader, "0xholder", holdings())
+	require.Len(t, current, 2)
+	assert.Equal(t, "0xchanged", current[0].Address)
+	assert.Equal(t, "250", current[0].Balance)
+	assert.Equal(t, models.BalanceSourceNode, current[0].BalanceSource)
+	// A token that won't report a balance keeps the indexed one
+	assert.Equal(t, "100", current[1].Balance)
+	assert.Equal(t, models.BalanceSourceIndexed, current[1].BalanceSource)
+
+	// Without a node every holding is as indexed
+	current = currentHoldings(context.Background(), nil, "0xholder", holdings())
+	require.Len(t, current, 3)
+	for _, holding := range current {
+		assert.Equal(t, "100", holding.Balance)
+		assert.Equal(t, models.BalanceSourceIndexed, holding.BalanceSource)
+	}
+	assert.Empty(t, currentHoldings(context.Background(), reader, "0xholder", nil))
+}
+
+func TestGetAddressApprovalsRejectsInvalidAddress(t *testing.T) {
+	app := fiber.New()
+	app.Get("/addresses/:address/approvals", NewAddressHandler(nil, nil).GetAddressApprovals)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/addresses/0x12/approvals", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/alerts"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+type AlertHandler struct {
+	db *database.DB
+}
+
+func NewAlertHandler(db *database.DB) *AlertHandler {
+	return &AlertHandler{db: db}
+}
+
+type createAlertRuleRequest struct {
+	Name        string          `json:"name"`
+	Description *string         `json:"description"`
+	ChainID     *int64          `json:"chain_id"`
+	Type        string          `json:"type"`
+	Params      json.RawMessage `json:"params"`
+	WebhookID   *int64          `json:"webhook_id"`
+	Enabled     *bool           `json:"enabled"`
+}
+
+type updateAlertRuleRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// rule validates a request into a rule with normalised params.
+func (r *createAlertRuleRequest) rule() (*models.AlertRule, error) {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	params, err := alerts.ParseParams(r.Type, r.Params)
+	if err != nil {
+		return nil, err
+	}
+	rule := &models.AlertRule{
+		Name:        name,
+		Description: r.Description,
+		ChainID:     1337,
+		RuleType:    r.Type,
+		Params:      params,
+		WebhookID:   r.WebhookID,
+		Enabled:     true,
+	}
+	if r.ChainID != nil {
+		rule.ChainID = *r.ChainID
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	return rule, nil
+}
+
+// CreateAlertRule stores a rule. It is picked up by the alerts service on its
+// next evaluation.
+func (h *AlertHandler) CreateAlertRule(c *fiber.Ctx) error {
+	var req createAlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	rule, err := req.rule()
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_ALERT_RULE", err.Error(), nil)
+	}
+	chain, err := h.db.GetChain(c.Context(), rule.ChainID)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chain", err.Error())
+	}
+	if chain == nil {
+		return responses.Error(c, 400, "INVALID_CHAIN", "Unknown chain", rule.ChainID)
+	}
+	if rule.WebhookID != nil {
+		hook, err := h.db.GetWebhook(c.Context(), *rule.WebhookID)
+		if err != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch webhook", err.Error())
+		}
+		if hook == nil {
+			return responses.Error(c, 400, "INVALID_WEBHOOK", "Unknown webhook", *rule.WebhookID)
+		}
+	}
+
+	if err := h.db.CreateAlertRule(c.Context(), rule); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to create alert rule", err.Error())
+	}
+	c.Status(fiber.StatusCreated)
+	return responses.Success(c, rule, &rule.ChainID)
+}
+
+func (h *AlertHandler) GetAlertRules(c *fiber.Ctx) error {
+	filter := &database.AlertRuleFilter{}
+	if c.Query("chain_id") != "" {
+		chainID := int64(c.QueryInt("chain_id"))
+		filter.ChainID = &chainID
+	}
+	if s := c.Query("state"); s != "" {
+		if s != models.AlertStateOK && s != models.AlertStateFiring {
+			return responses.Error(c, 400, "INVALID_FILTER", "state must be ok or firing", s)
+		}
+		filter.State = &s
+	}
+	page, limit := pageParams(c)
+	filter.Limit, filter.Offset = limit, (page-1)*limit
+
+	rules, err := h.db.GetAlertRules(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch alert rules", err.Error())
+	}
+	total, _ := h.db.CountAlertRules(c.Context(), filter)
+
+	return responses.Success(c, fiber.Map{
+		"rules":      rules,
+		"pagination": pageMeta(page, limit, total),
+	}, filter.ChainID)
+}
+
+func (h *AlertHandler) GetAlertRule(c *fiber.Ctx) error {
+	rule, err := h.lookupRule(c)
+	if rule == nil {
+		return err
+	}
+	return responses.Success(c, rule, &rule.ChainID)
+}
+
+// UpdateAlertRule enables or disables a rule. Disabling a firing rule clears
+// it without a resolution.
+func (h *AlertHandler) UpdateAlertRule(c *fiber.Ctx) error {
+	rule, err := h.lookupRule(c)
+	if rule == nil {
+		return err
+	}
+	var req updateAlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if req.Enabled == nil {
+		return responses.Error(c, 400, "INVALID_ALERT_RULE", "enabled is required", nil)
+	}
+	updated, err := h.db.SetAlertRuleEnabled(c.Context(), rule.ID, *req.Enabled)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to update alert rule", err.Error())
+	}
+	if updated == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Alert rule not found", nil)
+	}
+	return responses.Success(c, updated, &updated.ChainID)
+}
+
+func (h *AlertHandler) DeleteAlertRule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid alert rule id", nil)
+	}
+	deleted, err := h.db.DeleteAlertRule(c.Context(), int64(id))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to delete alert rule", err.Error())
+	}
+	if !deleted {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Alert rule not found", nil)
+	}
+	return responses.Success(c, fiber.Map{"id": id, "deleted": true}, nil)
+}
+
+// GetAlertEvents is a rule's firing and resolution history, newest first.
+func (h *AlertHandler) GetAlertEvents(c *fiber.Ctx) error {
+	rule, err := h.lookupRule(c)
+	if rule == nil {
+		return err
+	}
+	page, limit := pageParams(c)
+
+	events, err := h.db.GetAlertEvents(c.Context(), rule.ID, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch alert events", err.Error())
+	}
+	return responses.Success(c, fiber.Map{
+		"rule_id":    rule.ID,
+		"events":     events,
+		"pagination": responses.PageMeta{Page: page, Limit: limit},
+	}, &rule.ChainID)
+}
+
+// lookupRule resolves the rule in the path, like lookupWebhook.
+func (h *AlertHandler) lookupRule(c *fiber.Ctx) (*models.AlertRule, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return nil, responses.Error(c, 400, "INVALID_ID", "Invalid alert rule id", nil)
+	}
+	rule, err := h.db.GetAlertRule(c.Context(), int64(id))
+	if err != nil {
+		return nil, responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch alert rule", err.Error())
+	}
+	if rule == nil {
+		return nil, responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Alert rule not found", nil)
+	}
+	return rule, nil
+}
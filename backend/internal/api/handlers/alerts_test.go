@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAlertRuleRequest(t *testing.T) {
+	disabled := false
+	req := &createAlertRuleRequest{
+		Name:    "  hot wallet low  ",
+		Type:    "balance_below",
+		Params:  json.RawMessage(`{"address": "0x000000000000000000000000000000000000beef", "threshold": "1000"}`),
+		Enabled: &disabled,
+	}
+	rule, err := req.rule()
+	require.NoError(t, err)
+	assert.Equal(t, "hot wallet low", rule.Name)
+	assert.Equal(t, int64(1337), rule.ChainID)
+	assert.False(t, rule.Enabled)
+	assert.JSONEq(t, `{"address": "0x000000000000000000000000000000000000bEEF", "threshold": "1000"}`, string(rule.Params))
+
+	for name, invalid := range map[string]*createAlertRuleRequest{
+		"no name":      {Type: req.Type, Params: req.Params},
+		"unknown type": {Name: "x", Type: "price_above", Params: req.Params},
+		"bad params":   {Name: "x", Type: req.Type, Params: json.RawMessage(`{"address": "0x12"}`)},
+	} {
+		_, err := invalid.rule()
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/apikeys"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+const maxAPIKeyNameLength = 255
+
+// APIKeyHandler serves the admin endpoints that manage API keys.
+type APIKeyHandler struct {
+	db   *database.DB
+	keys *apikeys.Store
+}
+
+func NewAPIKeyHandler(db *database.DB, keys *apikeys.Store) *APIKeyHandler {
+	return &APIKeyHandler{db: db, keys: keys}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Tier      string     `json:"tier"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (h *APIKeyHandler) GetTiers(c *fiber.Ctx) error {
+	tiers, err := h.db.GetAPITiers(c.Context())
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch tiers", err.Error())
+	}
+	return responses.Success(c, fiber.Map{"tiers": tiers}, nil)
+}
+
+// CreateAPIKey issues a key on a tier, free by default. The key is only in
+// this response; afterwards only its prefix is shown.
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req createAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	key := &models.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Tier:      &models.APITier{Name: req.Tier},
+		ExpiresAt: req.ExpiresAt,
+	}
+	if key.Name == "" || len(key.Name) > maxAPIKeyNameLength {
+		return responses.Error(c, 400, "INVALID_KEY_REQUEST", "name must be 1 to 255 characters", nil)
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return responses.Error(c, 400, "INVALID_KEY_REQUEST", "expires_at must be in the future", nil)
+	}
+	if key.Tier.Name == "" {
+		key.Tier.Name = "free"
+	}
+	tiers, err := h.db.GetAPITiers(c.Context())
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch tiers", err.Error())
+	}
+	known := make([]string, len(tiers))
+	for i, tier := range tiers {
+		known[i] = tier.Name
+	}
+	if !slices.Contains(known, key.Tier.Name) {
+		return responses.Error(c, 400, "INVALID_KEY_REQUEST", "Unknown tier", fiber.Map{"tier": key.Tier.Name, "tiers": known})
+	}
+
+	if err := apikeys.New(key); err != nil {
+		return responses.Error(c, 500, "INTERNAL_ERROR", "Failed to generate key", err.Error())
+	}
+	if err := h.db.CreateAPIKey(c.Context(), key); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to create API key", err.Error())
+	}
+	c.Status(fiber.StatusCreated)
+	return responses.Success(c, key, nil)
+}
+
+// GetAPIKeys lists keys newest first, revoked ones included.
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+	keys, err := h.db.GetAPIKeys(c.Context(), limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch API keys", err.Error())
+	}
+	total, _ := h.db.CountAPIKeys(c.Context())
+
+	return responses.Success(c, fiber.Map{
+		"api_keys":   keys,
+		"pagination": pageMeta(page, limit, total),
+	}, nil)
+}
+
+func (h *APIKeyHandler) GetAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid API key id", nil)
+	}
+	key, err := h.db.GetAPIKey(c.Context(), int64(id))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch API key", err.Error())
+	}
+	if key == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "API key not found", nil)
+	}
+	return responses.Success(c, key, nil)
+}
+
+// RevokeAPIKey stops a key authenticating requests, at once in this process
+// and within apikeys.DefaultCacheTTL in others. Revoked keys stay listed.
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid API key id", nil)
+	}
+	key, err := h.db.RevokeAPIKey(c.Context(), int64(id))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to revoke API key", err.Error())
+	}
+	if key == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "API key not found", nil)
+	}
+	h.keys.Invalidate(key.KeyHash)
+	return responses.Success(c, key, nil)
+}
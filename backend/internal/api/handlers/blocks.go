@@ -4,8 +4,10 @@ import (
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
 	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
 type BlockHandler struct {
@@ -18,45 +20,73 @@ func NewBlockHandler(db *database.DB) *BlockHandler {
 
 func (h *BlockHandler) GetBlocks(c *fiber.Ctx) error {
 	chainID := c.QueryInt("chain_id", 1337)
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 20)
+	p, err := parseListPage(c, 20, 100)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_CURSOR", "Invalid cursor", nil)
+	}
+	if p.legacy() {
+		return h.getBlocksByPage(c, int64(chainID), p)
+	}
 
-	if limit > 100 {
-		limit = 100
+	var after, before *int64
+	if pos := p.after(); pos != nil {
+		after = &pos.BlockNumber
 	}
-	offset := (page - 1) * limit
+	if pos := p.before(); pos != nil {
+		before = &pos.BlockNumber
+	}
+	blocks, err := h.db.GetBlocksPage(c.Context(), int64(chainID), after, before, p.limit+1)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch blocks", err.Error())
+	}
+	blocks, meta := paginate(p, blocks, func(b *models.Block) database.Position {
+		return database.Position{BlockNumber: b.BlockNumber}
+	})
+	attachLabels(c.Context(), h.db, int64(chainID), blocks)
 
-	blocks, err := h.db.GetBlocks(c.Context(), int64(chainID), limit, offset)
+	cID := int64(chainID)
+	httpcache.For(c, httpcache.HeadTTL)
+	return responses.Success(c, fiber.Map{
+		"blocks":     blocks,
+		"pagination": meta,
+	}, &cID)
+}
+
+// getBlocksByPage serves the legacy page/limit mode.
+func (h *BlockHandler) getBlocksByPage(c *fiber.Ctx, chainID int64, p *listPage) error {
+	blocks, err := h.db.GetBlocks(c.Context(), chainID, p.limit, p.offset())
 	if err !=nil {
 		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch blocks", err.Error())
 	}
 
-	total, _ := h.db.CountBlocks(c.Context(), int64(chainID))
-	totalPages := int(total) / limit
-	if int(total)%limit != 0 {
+	attachLabels(c.Context(), h.db, chainID, blocks)
+
+	total, _ := h.db.CountBlocks(c.Context(), chainID)
+	totalPages := int(total) / p.limit
+	if int(total)%p.limit != 0 {
 		totalPages++
 	}
 
-	cID := int64(chainID)
+	httpcache.For(c, httpcache.HeadTTL)
 	return responses.Success(c, fiber.Map{
 		"blocks": blocks,
 		"pagination": responses.PaginationMeta{
-			Page: page,
-			Limit: limit,
+			Page: p.page,
+			Limit: p.limit,
 			Total: total,
 			TotalPages: totalPages,
 		},
-	}, &cID)
+	}, &chainID)
 }
 
 func (h *BlockHandler) GetBlock(c *fiber.Ctx) error {
 	chainID := c.QueryInt("chain_id", 1337)
 	blockID := c.Params("id")
 
-	var block interface{}
+	var block *models.Block
 	var err error
 
-	if num, parseErr := strconv.ParseInt(blockID, 10,64); parseErr!=nil {
+	if num, parseErr := strconv.ParseInt(blockID, 10,64); parseErr == nil {
 		block, err = h.db.GetBlockByNumber(c.Context(), int64(chainID), num)
 	} else {
 		block, err = h.db.GetBlockByHash(c.Context(), int64(chainID), blockID)
@@ -71,5 +101,11 @@ func (h *BlockHandler) GetBlock(c *fiber.Ctx) error {
 	}
 
 	cID := int64(chainID)
+	// Labels can change, so labelled responses are never immutable
+	if attachLabels(c.Context(), h.db, cID, block) {
+		httpcache.For(c, httpcache.HeadTTL)
+	} else {
+		httpcache.Block(c, block.BlockNumber)
+	}
 	return responses.Success(c, block, &cID)
 }
\ No newline at end of file
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+type ContractHandler struct {
+	db *database.DB
+}
+
+func NewContractHandler(db *database.DB) *ContractHandler {
+	return &ContractHandler{db: db}
+}
+
+type uploadABIRequest struct {
+	Name *string         `json:"name"`
+	ABI  json.RawMessage `json:"abi"`
+}
+
+func (h *ContractHandler) GetProxy(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	proxy, err := h.db.GetProxyContract(c.Context(), int64(chainID), address)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch proxy", err.Error())
+	}
+	if proxy == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Address is not a known proxy", nil)
+	}
+
+	history, err := h.db.GetProxyImplementations(c.Context(), int64(chainID), address)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch implementation history", err.Error())
+	}
+
+	cID := int64(chainID)
+	return responses.Success(c, fiber.Map{
+		"proxy":           proxy,
+		"implementations": history,
+	}, &cID)
+}
+
+func (h *ContractHandler) GetABI(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+
+	contractABI, err := h.db.GetContractABI(c.Context(), int64(chainID), common.HexToAddress(address).Hex())
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch abi", err.Error())
+	}
+	if contractABI == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "ABI not found", nil)
+	}
+
+	cID := int64(chainID)
+	return responses.Success(c, contractABI, &cID)
+}
+
+func (h *ContractHandler) UploadABI(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+
+	var req uploadABIRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(req.ABI, &entries); err != nil {
+		return responses.Error(c, 400, "INVALID_ABI", "ABI must be a JSON array", err.Error())
+	}
+
+	contractABI := &models.ContractABI{
+		ChainID: int64(chainID),
+		Address: common.HexToAddress(address).Hex(),
+		Name:    req.Name,
+		ABI:     req.ABI,
+	}
+	if err := h.db.UpsertContractABI(c.Context(), contractABI); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to save abi", err.Error())
+	}
+
+	cID := int64(chainID)
+	return responses.Success(c, contractABI, &cID)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/openapi"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// DocsHandler serves the OpenAPI document and the reference page that
+// renders it. The document describes the routes registered on the app, so
+// it is set once they all are.
+type DocsHandler struct {
+	document []byte
+}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+func (h *DocsHandler) SetDocument(doc *openapi.Document) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	h.document = b
+	return nil
+}
+
+func (h *DocsHandler) GetOpenAPI(c *fiber.Ctx) error {
+	if h.document == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "API document not available", nil)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(h.document)
+}
+
+func (h *DocsHandler) GetDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(openapi.UI)
+}
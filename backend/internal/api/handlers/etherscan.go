@@ -0,0 +1,647 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/verifier"
+	"go.uber.org/zap"
+)
+
+const maxEtherscanResults = 10000
+
+type etherscanResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Result  interface{} `json:"result"`
+}
+
+// EtherscanHandler implements the subset of the Etherscan API used by
+// Hardhat, Foundry and similar tooling, on top of the index.
+type EtherscanHandler struct {
+	db           *database.DB
+	chainManager *blockchain.ChainManager
+	verifier     *verifier.Verifier
+	logger       *zap.SugaredLogger
+}
+
+func NewEtherscanHandler(db *database.DB, chainManager *blockchain.ChainManager, verifier *verifier.Verifier, logger *zap.Logger) *EtherscanHandler {
+	return &EtherscanHandler{
+		db:           db,
+		chainManager: chainManager,
+		verifier:     verifier,
+		logger:       logger.Sugar(),
+	}
+}
+
+func (h *EtherscanHandler) Handle(c *fiber.Ctx) error {
+	module := etherscanParam(c, "module")
+	action := etherscanParam(c, "action")
+
+	switch module + "." + action {
+	case "account.balance":
+		return h.balance(c)
+	case "account.txlist":
+		return h.txList(c)
+	case "account.tokentx":
+		return h.tokenTx(c)
+	case "block.getblockreward":
+		return h.blockReward(c)
+	case "block.getblocknobytime":
+		return h.blockNoByTime(c)
+	case "logs.getLogs":
+		return h.getLogs(c)
+	case "contract.getabi":
+		return h.getABI(c)
+	case "contract.getsourcecode":
+		return h.getSourceCode(c)
+	case "contract.verifysourcecode":
+		return h.verifySourceCode(c)
+	case "contract.checkverifystatus":
+		return h.checkVerifyStatus(c)
+	}
+	return etherscanError(c, "Error! Missing Or invalid Module name or Action name")
+}
+
+func (h *EtherscanHandler) balance(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	address, ok := etherscanAddress(c, "address")
+	if !ok {
+		return etherscanError(c, "Error! Invalid address format")
+	}
+
+	if client, err := h.chainManager.GetClient(chainID); err == nil {
+		if balance, err := client.GetBalance(c.Context(), address, nil); err == nil {
+			return etherscanOK(c, balance.String())
+		}
+	}
+
+	addr, err := h.db.GetAddress(c.Context(), chainID, address)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch balance")
+	}
+	if addr == nil {
+		return etherscanOK(c, "0")
+	}
+	return etherscanOK(c, addr.Balance)
+}
+
+func (h *EtherscanHandler) txList(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	address, ok := etherscanAddress(c, "address")
+	if !ok {
+		return etherscanError(c, "Error! Invalid address format")
+	}
+	startBlock, endBlock := etherscanBlockRange(c)
+	limit, offset, err := etherscanPage(c)
+	if err != nil {
+		return etherscanError(c, err.Error())
+	}
+
+	txs, err := h.db.GetTransactionsByAddressInRange(c.Context(), chainID, address, startBlock, endBlock, etherscanParam(c, "sort") != "desc", limit, offset)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch transactions")
+	}
+	if len(txs) == 0 {
+		return etherscanEmpty(c, "No transactions found")
+	}
+
+	latest := h.latestBlockNumber(c, chainID)
+	result := make([]fiber.Map, len(txs))
+	for i, tx := range txs {
+		result[i] = etherscanTx(tx, latest)
+	}
+	return etherscanOK(c, result)
+}
+
+func (h *EtherscanHandler) tokenTx(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	startBlock, endBlock := etherscanBlockRange(c)
+	limit, offset, err := etherscanPage(c)
+	if err != nil {
+		return etherscanError(c, err.Error())
+	}
+
+	order := database.OrderAsc
+	if etherscanParam(c, "sort") == "desc" {
+		order = database.OrderDesc
+	}
+	filter := &database.TokenTransferFilter{
+		ChainID:    chainID,
+		BlockRange: database.BlockRange{FromBlock: &startBlock, ToBlock: &endBlock},
+		Sort:       database.Sort{Order: order},
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if etherscanParam(c, "address") != "" {
+		address, ok := etherscanAddress(c, "address")
+		if !ok {
+			return etherscanError(c, "Error! Invalid address format")
+		}
+		filter.Address = &address
+	}
+	if etherscanParam(c, "contractaddress") != "" {
+		token, ok := etherscanAddress(c, "contractaddress")
+		if !ok {
+			return etherscanError(c, "Error! Invalid contract address format")
+		}
+		filter.TokenAddress = &token
+	}
+	if filter.Address == nil && filter.TokenAddress == nil {
+		return etherscanError(c, "Error! Missing address or contractaddress")
+	}
+
+	transfers, err := h.db.GetTokenTransfers(c.Context(), filter)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch token transfers")
+	}
+	if len(transfers) == 0 {
+		return etherscanEmpty(c, "No transactions found")
+	}
+
+	hashes := make([]string, 0, len(transfers))
+	for _, t := range transfers {
+		hashes = append(hashes, t.TransactionHash)
+	}
+	txs, err := h.db.GetTransactionsByHashes(c.Context(), chainID, hashes)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch transactions")
+	}
+
+	latest := h.latestBlockNumber(c, chainID)
+	result := make([]fiber.Map, len(transfers))
+	for i, t := range transfers {
+		entry := fiber.Map{
+			"blockNumber":     strconv.FormatInt(t.BlockNumber, 10),
+			"timeStamp":       strconv.FormatInt(t.Timestamp.Unix(), 10),
+			"hash":            t.TransactionHash,
+			"from":            strings.ToLower(t.FromAddress),
+			"to":              strings.ToLower(t.ToAddress),
+			"contractAddress": strings.ToLower(t.TokenAddress),
+			"value":           stringOr(t.Value, "0"),
+			"tokenName":       stringOr(t.TokenName, ""),
+			"tokenSymbol":     stringOr(t.TokenSymbol, ""),
+			"tokenDecimal":    "",
+			"logIndex":        strconv.Itoa(t.LogIndex),
+			"confirmations":   strconv.FormatInt(latest-t.BlockNumber, 10),
+		}
+		if t.TokenDecimals != nil {
+			entry["tokenDecimal"] = strconv.Itoa(*t.TokenDecimals)
+		}
+		if tx, ok := txs[t.TransactionHash]; ok {
+			entry["nonce"] = strconv.FormatInt(tx.Nonce, 10)
+			entry["blockHash"] = tx.BlockHash
+			entry["transactionIndex"] = strconv.Itoa(tx.TransactionIndex)
+			entry["gas"] = strconv.FormatInt(tx.Gas, 10)
+			entry["gasPrice"] = stringOr(tx.GasPrice, "0")
+			entry["gasUsed"] = strconv.FormatInt(int64OrZero(tx.GasUsed), 10)
+			entry["cumulativeGasUsed"] = strconv.FormatInt(int64OrZero(tx.CumulativeGasUsed), 10)
+			entry["input"] = "deprecated"
+		}
+		result[i] = entry
+	}
+	return etherscanOK(c, result)
+}
+
+func (h *EtherscanHandler) blockReward(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	blockNo, err := strconv.ParseInt(etherscanParam(c, "blockno"), 10, 64)
+	if err != nil {
+		return etherscanError(c, "Error! Invalid block number")
+	}
+
+	block, err := h.db.GetBlockByNumber(c.Context(), chainID, blockNo)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch block")
+	}
+	if block == nil {
+		return etherscanError(c, "Error! Block number not found")
+	}
+
+	fees, err := h.db.GetBlockTxFees(c.Context(), chainID, blockNo)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch block")
+	}
+	// Under EIP-1559 the base fee is burnt, so only the tips reach the miner
+	reward, _ := new(big.Int).SetString(fees, 10)
+	if reward == nil {
+		reward = new(big.Int)
+	}
+	if block.BaseFeePerGas != nil {
+		if baseFee, ok := new(big.Int).SetString(*block.BaseFeePerGas, 10); ok {
+			reward.Sub(reward, baseFee.Mul(baseFee, big.NewInt(block.GasUsed)))
+		}
+	}
+
+	return etherscanOK(c, fiber.Map{
+		"blockNumber":          strconv.FormatInt(block.BlockNumber, 10),
+		"timeStamp":            strconv.FormatInt(block.Timestamp.Unix(), 10),
+		"blockMiner":           strings.ToLower(block.Miner),
+		"blockReward":          reward.String(),
+		"uncles":               []interface{}{},
+		"uncleInclusionReward": "0",
+	})
+}
+
+func (h *EtherscanHandler) blockNoByTime(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	ts, err := strconv.ParseInt(etherscanParam(c, "timestamp"), 10, 64)
+	if err != nil {
+		return etherscanError(c, "Error! Invalid timestamp")
+	}
+
+	blockNumber, found, err := h.db.GetBlockNumberByTime(c.Context(), chainID, time.Unix(ts, 0).UTC(), etherscanParam(c, "closest") != "after")
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch block")
+	}
+	if !found {
+		return etherscanError(c, "Error! No closest block found")
+	}
+	return etherscanOK(c, strconv.FormatInt(blockNumber, 10))
+}
+
+func (h *EtherscanHandler) getLogs(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	filter := &database.LogFilter{ChainID: chainID}
+
+	page, _ := strconv.Atoi(etherscanParam(c, "page"))
+	limit, _ := strconv.Atoi(etherscanParam(c, "offset"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 1000 {
+		limit = 1000
+	}
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	if etherscanParam(c, "address") != "" {
+		address, ok := etherscanAddress(c, "address")
+		if !ok {
+			return etherscanError(c, "Error! Invalid address format")
+		}
+		filter.Addresses = []string{address}
+	}
+	for i := range filter.Topics {
+		if topic := etherscanParam(c, fmt.Sprintf("topic%d", i)); topic != "" {
+			filter.Topics[i] = []string{common.HexToHash(topic).Hex()}
+		}
+	}
+	// Only AND-ing topics is supported; LogFilter has no cross-position OR
+	for i := 0; i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			if opr := etherscanParam(c, fmt.Sprintf("topic%d_%d_opr", i, j)); opr != "" && opr != "and" {
+				return etherscanError(c, "Error! Only the 'and' topic operator is supported")
+			}
+		}
+	}
+
+	from, err := etherscanBlockParam(c, "fromBlock")
+	if err != nil {
+		return etherscanError(c, "Error! Invalid fromBlock")
+	}
+	to, err := etherscanBlockParam(c, "toBlock")
+	if err != nil {
+		return etherscanError(c, "Error! Invalid toBlock")
+	}
+	if from == nil || to == nil {
+		latest := h.latestBlockNumber(c, chainID)
+		if from == nil {
+			zero := int64(0)
+			from = &zero
+		}
+		if to == nil {
+			to = &latest
+		}
+	}
+	filter.FromBlock = from
+	filter.ToBlock = to
+
+	logs, err := h.db.GetLogs(c.Context(), filter)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch logs")
+	}
+	if len(logs) == 0 {
+		return etherscanEmpty(c, "No records found")
+	}
+
+	hashes := make([]string, 0, len(logs))
+	for _, l := range logs {
+		hashes = append(hashes, l.TransactionHash)
+	}
+	txs, err := h.db.GetTransactionsByHashes(c.Context(), chainID, hashes)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch transactions")
+	}
+
+	result := make([]fiber.Map, len(logs))
+	for i, l := range logs {
+		entry := rpcLog(l)
+		entry["address"] = strings.ToLower(l.Address)
+		delete(entry, "removed")
+		if tx, ok := txs[l.TransactionHash]; ok {
+			entry["timeStamp"] = encodeUint(uint64(tx.Timestamp.Unix()))
+			entry["gasPrice"] = encodeDecimal(tx.GasPrice)
+			entry["gasUsed"] = encodeUint(uint64(int64OrZero(tx.GasUsed)))
+		}
+		result[i] = fiber.Map(entry)
+	}
+	return etherscanOK(c, result)
+}
+
+func (h *EtherscanHandler) getABI(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	address, ok := etherscanAddress(c, "address")
+	if !ok {
+		return etherscanError(c, "Error! Invalid address format")
+	}
+
+	contractABI, err := h.db.GetContractABI(c.Context(), chainID, address)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch ABI")
+	}
+	if contractABI == nil {
+		return etherscanError(c, "Contract source code not verified")
+	}
+	return etherscanOK(c, string(contractABI.ABI))
+}
+
+func (h *EtherscanHandler) getSourceCode(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	address, ok := etherscanAddress(c, "address")
+	if !ok {
+		return etherscanError(c, "Error! Invalid address format")
+	}
+
+	entry := fiber.Map{
+		"SourceCode":           "",
+		"ABI":                  "Contract source code not verified",
+		"ContractName":         "",
+		"CompilerVersion":      "",
+		"OptimizationUsed":     "",
+		"Runs":                 "",
+		"ConstructorArguments": "",
+		"EVMVersion":           "",
+		"Library":              "",
+		"LicenseType":          "",
+		"Proxy":                "0",
+		"Implementation":       "",
+		"SwarmSource":          "",
+	}
+
+	verified, err := h.db.GetVerifiedContract(c.Context(), chainID, address)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch source code")
+	}
+	if verified != nil {
+		source := verified.SourceCode
+		// Etherscan wraps standard JSON input in an extra pair of braces
+		if verified.CodeFormat == verifier.CodeFormatStandardJSON {
+			source = "{" + source + "}"
+		}
+		optimization := "0"
+		if verified.OptimizationUsed {
+			optimization = "1"
+		}
+		entry["SourceCode"] = source
+		entry["ContractName"] = verified.ContractName
+		entry["CompilerVersion"] = verified.CompilerVersion
+		entry["OptimizationUsed"] = optimization
+		entry["Runs"] = strconv.Itoa(verified.Runs)
+		entry["ConstructorArguments"] = stringOr(verified.ConstructorArguments, "")
+		entry["EVMVersion"] = stringOr(verified.EVMVersion, "Default")
+		entry["LicenseType"] = stringOr(verified.LicenseType, "")
+		if contractABI, err := h.db.GetContractABI(c.Context(), chainID, address); err == nil && contractABI != nil {
+			entry["ABI"] = string(contractABI.ABI)
+		}
+	}
+
+	if proxy, err := h.db.GetProxyContract(c.Context(), chainID, address); err == nil && proxy != nil {
+		entry["Proxy"] = "1"
+		entry["Implementation"] = strings.ToLower(stringOr(proxy.ImplementationAddress, ""))
+	}
+
+	return etherscanOK(c, []fiber.Map{entry})
+}
+
+func (h *EtherscanHandler) verifySourceCode(c *fiber.Ctx) error {
+	chainID := etherscanChainID(c)
+	address, ok := etherscanAddress(c, "contractaddress")
+	if !ok {
+		return etherscanError(c, "Error! Invalid contract address format")
+	}
+
+	existing, err := h.db.GetVerifiedContract(c.Context(), chainID, address)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to check verification status")
+	}
+	if existing != nil {
+		return etherscanError(c, "Contract source code already verified")
+	}
+
+	codeFormat := etherscanParam(c, "codeformat")
+	if codeFormat == "" {
+		codeFormat = verifier.CodeFormatSingleFile
+	}
+	runs, err := strconv.Atoi(etherscanParam(c, "runs"))
+	if err != nil {
+		runs = 200
+	}
+
+	req := &models.ContractVerification{
+		ChainID:          chainID,
+		Address:          address,
+		ContractName:     etherscanParam(c, "contractname"),
+		CompilerVersion:  etherscanParam(c, "compilerversion"),
+		CodeFormat:       codeFormat,
+		SourceCode:       etherscanParam(c, "sourceCode"),
+		OptimizationUsed: etherscanParam(c, "optimizationUsed") == "1",
+		Runs:             runs,
+	}
+	if req.ContractName == "" || req.CompilerVersion == "" || req.SourceCode == "" {
+		return etherscanError(c, "Error! Missing contractname, compilerversion or sourceCode")
+	}
+	// Etherscan's parameter name is misspelt; accept both spellings
+	if args := etherscanParam(c, "constructorArguements"); args != "" {
+		req.ConstructorArguments = &args
+	} else if args := etherscanParam(c, "constructorArguments"); args != "" {
+		req.ConstructorArguments = &args
+	}
+	if evm := etherscanParam(c, "evmversion"); evm != "" {
+		req.EVMVersion = &evm
+	}
+	if license := etherscanParam(c, "licenseType"); license != "" {
+		req.LicenseType = &license
+	}
+
+	if err := h.verifier.Submit(c.Context(), req); err != nil {
+		if errors.Is(err, verifier.ErrBusy) {
+			c.Set(fiber.HeaderRetryAfter, "30")
+		}
+		return etherscanError(c, "Error! "+err.Error())
+	}
+	return etherscanOK(c, req.GUID)
+}
+
+func (h *EtherscanHandler) checkVerifyStatus(c *fiber.Ctx) error {
+	guid := etherscanParam(c, "guid")
+	v, err := h.db.GetContractVerification(c.Context(), guid)
+	if err != nil {
+		return etherscanError(c, "Error! Failed to fetch verification status")
+	}
+	if v == nil {
+		return etherscanError(c, "Fail - Unable to verify. Unknown GUID")
+	}
+
+	switch v.Status {
+	case "pass":
+		return etherscanOK(c, "Pass - Verified")
+	case "fail":
+		return etherscanError(c, stringOr(v.Message, "Fail - Unable to verify"))
+	}
+	return etherscanError(c, "Pending in queue")
+}
+
+func (h *EtherscanHandler) latestBlockNumber(c *fiber.Ctx, chainID int64) int64 {
+	block, err := h.db.GetLatestBlock(c.Context(), chainID)
+	if err != nil || block == nil {
+		return 0
+	}
+	return block.BlockNumber
+}
+
+func etherscanTx(tx *models.Transaction, latest int64) fiber.Map {
+	isError, receiptStatus := "0", ""
+	if tx.Status != nil {
+		receiptStatus = strconv.Itoa(*tx.Status)
+		if *tx.Status == 0 {
+			isError = "1"
+		}
+	}
+	input := stringOr(tx.Input, "0x")
+	methodID := "0x"
+	if len(input) >= 10 {
+		methodID = input[:10]
+	}
+	to := ""
+	if tx.ToAddress != nil {
+		to = strings.ToLower(*tx.ToAddress)
+	}
+	contractAddress := ""
+	if tx.ContractAddress != nil {
+		contractAddress = strings.ToLower(*tx.ContractAddress)
+	}
+
+	return fiber.Map{
+		"blockNumber":       strconv.FormatInt(tx.BlockNumber, 10),
+		"timeStamp":         strconv.FormatInt(tx.Timestamp.Unix(), 10),
+		"hash":              tx.Hash,
+		"nonce":             strconv.FormatInt(tx.Nonce, 10),
+		"blockHash":         tx.BlockHash,
+		"transactionIndex":  strconv.Itoa(tx.TransactionIndex),
+		"from":              strings.ToLower(tx.FromAddress),
+		"to":                to,
+		"value":             tx.Value,
+		"gas":               strconv.FormatInt(tx.Gas, 10),
+		"gasPrice":          stringOr(tx.GasPrice, "0"),
+		"isError":           isError,
+		"txreceipt_status":  receiptStatus,
+		"input":             input,
+		"contractAddress":   contractAddress,
+		"cumulativeGasUsed": strconv.FormatInt(int64OrZero(tx.CumulativeGasUsed), 10),
+		"gasUsed":           strconv.FormatInt(int64OrZero(tx.GasUsed), 10),
+		"confirmations":     strconv.FormatInt(latest-tx.BlockNumber, 10),
+		"methodId":          methodID,
+		"functionName":      "",
+	}
+}
+
+// etherscanParam reads a parameter from the query string or, for POSTed
+// forms such as verifysourcecode, from the body.
+func etherscanParam(c *fiber.Ctx, name string) string {
+	if v := c.Query(name); v != "" {
+		return v
+	}
+	return c.FormValue(name)
+}
+
+func etherscanChainID(c *fiber.Ctx) int64 {
+	for _, name := range []string{"chainid", "chain_id"} {
+		if v, err := strconv.ParseInt(etherscanParam(c, name), 10, 64); err == nil {
+			return v
+		}
+	}
+	return 1337
+}
+
+func etherscanAddress(c *fiber.Ctx, name string) (string, bool) {
+	address := etherscanParam(c, name)
+	if !common.IsHexAddress(address) {
+		return "", false
+	}
+	return common.HexToAddress(address).Hex(), true
+}
+
+// etherscanBlockParam reads a decimal or 0x block number. It returns nil when
+// the parameter is absent or "latest".
+func etherscanBlockParam(c *fiber.Ctx, name string) (*int64, error) {
+	v := etherscanParam(c, name)
+	if v == "" || v == "latest" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 0, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid block number %q", v)
+	}
+	return &n, nil
+}
+
+func etherscanBlockRange(c *fiber.Ctx) (int64, int64) {
+	start, err := strconv.ParseInt(etherscanParam(c, "startblock"), 10, 64)
+	if err != nil {
+		start = 0
+	}
+	end, err := strconv.ParseInt(etherscanParam(c, "endblock"), 10, 64)
+	if err != nil {
+		end = 99999999
+	}
+	return start, end
+}
+
+// etherscanPage maps Etherscan's page/offset (page size) onto limit/offset,
+// enforcing the same 10,000 result window.
+func etherscanPage(c *fiber.Ctx) (int, int, error) {
+	page, _ := strconv.Atoi(etherscanParam(c, "page"))
+	size, _ := strconv.Atoi(etherscanParam(c, "offset"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > maxEtherscanResults {
+		size = maxEtherscanResults
+	}
+	if page*size > maxEtherscanResults {
+		return 0, 0, fmt.Errorf("Result window is too large, PageNo x Offset size must be less than or equal to %d", maxEtherscanResults)
+	}
+	return size, (page - 1) * size, nil
+}
+
+func etherscanOK(c *fiber.Ctx, result interface{}) error {
+	return c.JSON(etherscanResponse{Status: "1", Message: "OK", Result: result})
+}
+
+func etherscanEmpty(c *fiber.Ctx, message string) error {
+	return c.JSON(etherscanResponse{Status: "0", Message: message, Result: []interface{}{}})
+}
+
+func etherscanError(c *fiber.Ctx, result string) error {
+	return c.JSON(etherscanResponse{Status: "0", Message: "NOTOK", Result: result})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// etherscanCall sends a request that is answered before the index is read,
+// so the handler needs no database.
+func etherscanCall(t *testing.T, method, query string, form url.Values) etherscanResponse {
+	app := fiber.New()
+	h := NewEtherscanHandler(nil, nil, nil, zap.NewNop())
+	app.All("/api", h.Handle)
+
+	req := httptest.NewRequest(method, "/api?"+query, nil)
+	if form != nil {
+		req = httptest.NewRequest(method, "/api?"+query, strings.NewReader(form.Encode()))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var body etherscanResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body
+}
+
+func TestEtherscanErrors(t *testing.T) {
+	cases := map[string]struct {
+		query string
+		want  string
+	}{
+		"unknown action":     {"module=account&action=nope", "Error! Missing Or invalid Module name or Action name"},
+		"balance address":    {"module=account&action=balance&address=0x12", "Error! Invalid address format"},
+		"txlist address":     {"module=account&action=txlist&address=beef", "Error! Invalid address format"},
+		"txlist window":      {"module=account&action=txlist&address=0x000000000000000000000000000000000000beef&page=3&offset=5000", "Result window is too large, PageNo x Offset size must be less than or equal to 10000"},
+		"logs address":       {"module=logs&action=getLogs&address=0x12", "Error! Invalid address format"},
+		"logs operator":      {"module=logs&action=getLogs&topic0=0x1&topic1=0x2&topic0_1_opr=or", "Error! Only the 'and' topic operator is supported"},
+		"logs fromBlock":     {"module=logs&action=getLogs&fromBlock=abc", "Error! Invalid fromBlock"},
+		"logs negative from": {"module=logs&action=getLogs&fromBlock=-5", "Error! Invalid fromBlock"},
+		"logs toBlock":       {"module=logs&action=getLogs&fromBlock=1&toBlock=0xzz", "Error! Invalid toBlock"},
+		"verify address":     {"module=contract&action=verifysourcecode&contractaddress=0x12", "Error! Invalid contract address format"},
+	}
+	for name, tc := range cases {
+		body := etherscanCall(t, "GET", tc.query, nil)
+		assert.Equal(t, "0", body.Status, name)
+		assert.Equal(t, "NOTOK", body.Message, name)
+		assert.Equal(t, tc.want, body.Result, name)
+	}
+
+	// verifysourcecode is POSTed as a form
+	body := etherscanCall(t, "POST", "", url.Values{"module": {"contract"}, "action": {"verifysourcecode"}, "contractaddress": {"nope"}})
+	assert.Equal(t, "Error! Invalid contract address format", body.Result)
+}
+
+func TestEtherscanParams(t *testing.T) {
+	app := fiber.New()
+	var chainID int64
+	var from, to *int64
+	var fromErr error
+	var start, end int64
+	var limit, offset int
+	var pageErr error
+	app.Get("/", func(c *fiber.Ctx) error {
+		chainID = etherscanChainID(c)
+		from, fromErr = etherscanBlockParam(c, "fromBlock")
+		to, _ = etherscanBlockParam(c, "toBlock")
+		start, end = etherscanBlockRange(c)
+		limit, offset, pageErr = etherscanPage(c)
+		return nil
+	})
+	call := func(query string) {
+		_, err := app.Test(httptest.NewRequest("GET", "/?"+query, nil))
+		require.NoError(t, err)
+	}
+
+	call("chainid=5&fromBlock=0x10&toBlock=latest&startblock=7&page=2&offset=100")
+	assert.Equal(t, int64(5), chainID)
+	require.NoError(t, fromErr)
+	assert.Equal(t, int64(16), *from)
+	assert.Nil(t, to)
+	assert.Equal(t, int64(7), start)
+	assert.Equal(t, int64(99999999), end)
+	require.NoError(t, pageErr)
+	assert.Equal(t, 100, limit)
+	assert.Equal(t, 100, offset)
+
+	call("chain_id=10&fromBlock=12")
+	assert.Equal(t, int64(10), chainID)
+	assert.Equal(t, int64(12), *from)
+	assert.Equal(t, maxEtherscanResults, limit)
+
+	call("")
+	assert.Equal(t, int64(1337), chainID)
+	assert.Nil(t, from)
+	require.NoError(t, fromErr)
+}
+
+func TestEtherscanTx(t *testing.T) {
+	to := "0x000000000000000000000000000000000000bEEF"
+	input := "0xa9059cbb0000"
+	failed := 0
+	gasUsed := int64(21000)
+	tx := &models.Transaction{
+		Hash:        "0xhash",
+		BlockNumber: 10,
+		FromAddress: "0x000000000000000000000000000000000000CAFE",
+		ToAddress:   &to,
+		Value:       "5",
+		Gas:         30000,
+		Input:       &input,
+		Status:      &failed,
+		GasUsed:     &gasUsed,
+		Timestamp:   time.Unix(1700000000, 0),
+	}
+
+	result := etherscanTx(tx, 15)
+	assert.Equal(t, "10", result["blockNumber"])
+	assert.Equal(t, "1700000000", result["timeStamp"])
+	assert.Equal(t, "0x000000000000000000000000000000000000cafe", result["from"])
+	assert.Equal(t, "0x000000000000000000000000000000000000beef", result["to"])
+	assert.Equal(t, "1", result["isError"])
+	assert.Equal(t, "0", result["txreceipt_status"])
+	assert.Equal(t, "0xa9059cbb", result["methodId"])
+	assert.Equal(t, "21000", result["gasUsed"])
+	assert.Equal(t, "0", result["gasPrice"])
+	assert.Equal(t, "5", result["confirmations"])
+	assert.Equal(t, "", result["contractAddress"])
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/export"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"go.uber.org/zap"
+)
+
+// HeaderExportLimit tells clients how many rows an export stops at.
+const HeaderExportLimit = "X-Export-Limit"
+
+const (
+	// maxExportRows caps an export over the API; the export command has no
+	// cap
+	maxExportRows = 100000
+	// exportTimeout bounds how long an export may hold its cursor open,
+	// however slowly the client reads
+	exportTimeout = 5 * time.Minute
+)
+
+type ExportHandler struct {
+	db     *database.DB
+	logger *zap.SugaredLogger
+}
+
+func NewExportHandler(db *database.DB, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{db: db, logger: logger.Sugar()}
+}
+
+// Export streams a dataset as CSV (default) or NDJSON, optionally for one
+// address and a block or time range, up to maxExportRows rows. Rows are
+// written as they are read from the database, so errors after the first row
+// can only cut the download short; they are logged.
+func (h *ExportHandler) Export(c *fiber.Ctx) error {
+	name := c.Params("dataset")
+	dataset, err := export.ParseDataset(name)
+	if err != nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", err.Error(), name)
+	}
+	format, err := export.ParseFormat(c.Query("format", string(export.FormatCSV)))
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	filter := &database.ExportFilter{ChainID: int64(c.QueryInt("chain_id", 1337)), Dataset: dataset, Limit: maxExportRows}
+	if address := c.Query("address"); address != "" {
+		if !common.IsHexAddress(address) {
+			return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+		}
+		address = common.HexToAddress(address).Hex()
+		filter.Address = &address
+	}
+	if err := parseBlockRange(c, &filter.BlockRange); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	c.Set("Content-Type", format.ContentType())
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.%s"`, name, filter.ChainID, format))
+	c.Set(HeaderExportLimit, strconv.Itoa(maxExportRows))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		n, err := export.Write(ctx, h.db, filter, format, w)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			h.logger.Warnw("Export stopped", "dataset", name, "chain_id", filter.ChainID, "rows", n, "error", err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExportRejects(t *testing.T) {
+	app := fiber.New()
+	app.Get("/export/:dataset", NewExportHandler(nil, zap.NewNop()).Export)
+
+	for target, want := range map[string]int{
+		"/export/receipts":          404,
+		"/export/blocks?format=xml": 400,
+		"/export/logs?address=0x12": 400,
+		"/export/logs?from_block=x": 400,
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.StatusCode, target)
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+)
+
+// parseTransactionFilter reads the transaction listing filters:
+//
+//	address, direction (in|out|self), counterparty
+//	status (success|failed), method (4-byte selector, or 0x for plain transfers)
+//	min_value, max_value (wei)
+//	from_block, to_block, from_time, to_time (unix seconds or RFC 3339)
+//	type (transaction types, comma-separated or repeated)
+//	sort (block|value), order (asc|desc)
+//
+// Listings scoped to an address in the path set filter.Address beforehand,
+// which takes precedence over the address parameter.
+func parseTransactionFilter(c *fiber.Ctx, filter *database.TransactionFilter) error {
+	if err := parseParty(c, &filter.Party); err != nil {
+		return err
+	}
+	if err := parseBlockRange(c, &filter.BlockRange); err != nil {
+		return err
+	}
+	if err := parseValueRange(c, &filter.ValueRange); err != nil {
+		return err
+	}
+
+	switch status := c.Query("status"); status {
+	case "":
+	case "success":
+		s := 1
+		filter.Status = &s
+	case "failed":
+		s := 0
+		filter.Status = &s
+	default:
+		return fmt.Errorf("invalid status: %s", status)
+	}
+
+	if method := strings.ToLower(c.Query("method")); method != "" {
+		if method != "0x" && (len(method) != 10 || !strings.HasPrefix(method, "0x") || !isHex(method[2:])) {
+			return fmt.Errorf("invalid method: %s", method)
+		}
+		filter.MethodID = &method
+	}
+
+	for _, raw := range queryList(c, "type") {
+		t, err := strconv.Atoi(raw)
+		if err != nil || t < 0 {
+			return fmt.Errorf("invalid type: %s", raw)
+		}
+		filter.Types = append(filter.Types, t)
+	}
+
+	sort, err := parseSort(c)
+	if err != nil {
+		return err
+	}
+	filter.Sort = sort
+	return nil
+}
+
+// parseParty reads address, direction and counterparty. A direction needs an
+// address to be relative to.
+func parseParty(c *fiber.Ctx, party *database.Party) error {
+	if party.Address == nil {
+		address, err := parseAddressParam(c, "address")
+		if err != nil {
+			return err
+		}
+		party.Address = address
+	}
+	counterparty, err := parseAddressParam(c, "counterparty")
+	if err != nil {
+		return err
+	}
+	party.Counterparty = counterparty
+
+	switch direction := database.Direction(c.Query("direction")); direction {
+	case database.DirectionAny:
+	case database.DirectionIn, database.DirectionOut, database.DirectionSelf:
+		if party.Address == nil {
+			return fmt.Errorf("direction requires an address")
+		}
+		party.Direction = direction
+	default:
+		return fmt.Errorf("invalid direction: %s", direction)
+	}
+	return nil
+}
+
+func parseAddressParam(c *fiber.Ctx, name string) (*string, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(value) {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	address := common.HexToAddress(value).Hex()
+	return &address, nil
+}
+
+// parseBlockRange reads from_block and to_block along with the time range.
+func parseBlockRange(c *fiber.Ctx, r *database.BlockRange) error {
+	for _, bound := range []struct {
+		name  string
+		value **int64
+	}{{"from_block", &r.FromBlock}, {"to_block", &r.ToBlock}} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 0, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s: %s", bound.name, raw)
+		}
+		*bound.value = &n
+	}
+	if r.FromBlock != nil && r.ToBlock != nil && *r.FromBlock > *r.ToBlock {
+		return fmt.Errorf("from_block must not be greater than to_block")
+	}
+	return parseTimeRange(c, r)
+}
+
+// parseTimeRange reads from_time and to_time as unix seconds or RFC 3339.
+func parseTimeRange(c *fiber.Ctx, r *database.BlockRange) error {
+	var err error
+	if r.FromTime, err = parseTimeParam(c, "from_time"); err != nil {
+		return err
+	}
+	if r.ToTime, err = parseTimeParam(c, "to_time"); err != nil {
+		return err
+	}
+	if r.FromTime != nil && r.ToTime != nil && r.FromTime.After(*r.ToTime) {
+		return fmt.Errorf("from_time must not be after to_time")
+	}
+	return nil
+}
+
+func parseTimeParam(c *fiber.Ctx, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(seconds, 0).UTC()
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseValueRange reads min_value and max_value as decimal amounts in the
+// smallest unit.
+func parseValueRange(c *fiber.Ctx, v *database.ValueRange) error {
+	for _, bound := range []struct {
+		name  string
+		value **string
+	}{{"min_value", &v.MinValue}, {"max_value", &v.MaxValue}} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		n, ok := new(big.Int).SetString(raw, 10)
+		if !ok || n.Sign() < 0 {
+			return fmt.Errorf("invalid %s: %s", bound.name, raw)
+		}
+		s := n.String()
+		*bound.value = &s
+	}
+	return nil
+}
+
+func parseSort(c *fiber.Ctx) (database.Sort, error) {
+	var sort database.Sort
+	switch field := c.Query("sort"); field {
+	case "", "block":
+	case "value":
+		sort.Field = database.SortValue
+	default:
+		return sort, fmt.Errorf("invalid sort: %s", field)
+	}
+	order, err := parseOrder(c)
+	sort.Order = order
+	return sort, err
+}
+
+func parseOrder(c *fiber.Ctx) (database.SortOrder, error) {
+	switch order := database.SortOrder(c.Query("order")); order {
+	case database.OrderDefault, database.OrderAsc, database.OrderDesc:
+		return order, nil
+	default:
+		return "", fmt.Errorf("invalid order: %s", order)
+	}
+}
+
+// offsetOnly switches a listing sorted by value to page/limit mode, since
+// keyset cursors follow chain order. It fails if a cursor was passed.
+func (p *listPage) offsetOnly(sort database.Sort) error {
+	if sort.Field != database.SortValue {
+		return nil
+	}
+	if p.cursor != nil {
+		return fmt.Errorf("cursors cannot be used with sort=value; use page instead")
+	}
+	if p.page == 0 {
+		p.page = 1
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFilter runs parseTransactionFilter against a request with the given
+// query string.
+func parseFilter(t *testing.T, query string) (*database.TransactionFilter, error) {
+	app := fiber.New()
+	filter := &database.TransactionFilter{}
+	var parseErr error
+	app.Get("/", func(c *fiber.Ctx) error {
+		parseErr = parseTransactionFilter(c, filter)
+		return nil
+	})
+	_, err := app.Test(httptest.NewRequest("GET", "/?"+query, nil))
+	require.NoError(t, err)
+	return filter, parseErr
+}
+
+func TestParseTransactionFilter(t *testing.T) {
+	filter, err := parseFilter(t, "address=0x00000000000000000000000000000000000000ab&direction=out"+
+		"&status=failed&method=0xA9059CBB&min_value=1000&from_block=0x10&from_time=1700000000"+
+		"&to_time=2024-01-01T00:00:00Z&type=0,2&sort=value&order=asc")
+	require.NoError(t, err)
+
+	assert.Equal(t, "0x00000000000000000000000000000000000000AB", *filter.Address)
+	assert.Equal(t, database.DirectionOut, filter.Direction)
+	assert.Equal(t, 0, *filter.Status)
+	assert.Equal(t, "0xa9059cbb", *filter.MethodID)
+	assert.Equal(t, "1000", *filter.MinValue)
+	assert.Equal(t, int64(16), *filter.FromBlock)
+	assert.Equal(t, int64(1700000000), filter.FromTime.Unix())
+	assert.Equal(t, 2024, filter.ToTime.Year())
+	assert.Equal(t, []int{0, 2}, filter.Types)
+	assert.Equal(t, database.Sort{Field: database.SortValue, Order: database.OrderAsc}, filter.Sort)
+}
+
+func TestParseTransactionFilterRejects(t *testing.T) {
+	for _, query := range []string{
+		"direction=in",
+		"address=0x1&direction=in",
+		"status=pending",
+		"method=0x1234",
+		"min_value=-1",
+		"from_block=10&to_block=5",
+		"from_time=yesterday",
+		"type=legacy",
+		"sort=gas",
+		"order=up",
+	} {
+		_, err := parseFilter(t, query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestOffsetOnly(t *testing.T) {
+	valueSort := database.Sort{Field: database.SortValue}
+
+	p := &listPage{limit: 20}
+	require.NoError(t, p.offsetOnly(valueSort))
+	assert.True(t, p.legacy())
+
+	p = &listPage{limit: 20, cursor: &database.Cursor{}}
+	assert.Error(t, p.offsetOnly(valueSort))
+	assert.NoError(t, p.offsetOnly(database.Sort{}))
+}
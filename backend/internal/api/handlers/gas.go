@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+const (
+	// gasHistoryBlocks is how many recent blocks estimates are drawn from
+	gasHistoryBlocks = 20
+	// baseFeeTrendPercent is the change over the window below which the base
+	// fee counts as stable
+	baseFeeTrendPercent = 5
+)
+
+// gasPercentiles are the fees paid by the slow, standard and fast tiers
+var gasPercentiles = []float64{25, 50, 75}
+
+type GasHandler struct {
+	db     *database.DB
+	chains *blockchain.ChainManager
+}
+
+func NewGasHandler(db *database.DB, chains *blockchain.ChainManager) *GasHandler {
+	return &GasHandler{db: db, chains: chains}
+}
+
+// GetGas suggests slow, standard and fast fees for the chain's configured
+// oracle. EIP-1559 chains are priced from the node's eth_feeHistory when it
+// answers and from the recently indexed blocks otherwise; legacy chains from
+// the gas prices paid in recently indexed blocks, falling back to the node's
+// suggestion.
+func (h *GasHandler) GetGas(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	config, err := h.chains.GetConfig(chainID)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_CHAIN", "Unknown chain", chainID)
+	}
+	latest, err := h.db.GetLatestBlock(c.Context(), chainID)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch latest block", err.Error())
+	}
+	// Inactive chains have no client and are estimated from indexed data only
+	client, _ := h.chains.GetClient(chainID)
+
+	estimate := &models.GasEstimate{ChainID: chainID, Oracle: config.GasOracle()}
+	if client != nil {
+		if price, err := client.SuggestGasPrice(c.Context()); err == nil {
+			s := price.String()
+			estimate.NodeGasPrice = &s
+		}
+	}
+	if estimate.Oracle == blockchain.GasOracleEIP1559 {
+		err = h.estimateEIP1559(c.Context(), client, latest, estimate)
+	} else {
+		err = h.estimateLegacy(c.Context(), latest, estimate)
+	}
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to estimate gas prices", err.Error())
+	}
+	if estimate.Standard == nil {
+		return responses.Error(c, 503, "GAS_UNAVAILABLE", "No recent gas prices available", nil)
+	}
+	httpcache.For(c, httpcache.HeadTTL)
+	return responses.Success(c, estimate, &chainID)
+}
+
+// GetGasHistory summarises the base fees and gas prices paid per period
+// ("hour" by default, or "day") over from_time..to_time.
+func (h *GasHandler) GetGasHistory(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	period := c.Query("period", models.StatsPeriodHour)
+	defaultBuckets, maxBuckets := defaultHourlyBuckets, maxHourlyBuckets
+	switch period {
+	case models.StatsPeriodHour:
+	case models.StatsPeriodDay:
+		defaultBuckets, maxBuckets = defaultDailyBuckets, maxDailyBuckets
+	default:
+		return responses.Error(c, 400, "INVALID_FILTER", "period must be hour or day", period)
+	}
+	from, err := parseTimeParam(c, "from_time")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	to, err := parseTimeParam(c, "to_time")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	start, end, err := statsWindow(period, from, to, time.Now(), defaultBuckets, maxBuckets)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	history, err := h.db.GetGasPriceHistory(c.Context(), chainID, period, start, end)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch gas price history", err.Error())
+	}
+	httpcache.For(c, httpcache.RollupTTL)
+	return responses.Success(c, fiber.Map{
+		"period":  period,
+		"from":    start,
+		"to":      end,
+		"history": history,
+	}, &chainID)
+}
+
+func (h *GasHandler) estimateLegacy(ctx context.Context, latest *models.Block, e *models.GasEstimate) error {
+	if latest != nil {
+		prices, err := h.db.GetFeePercentiles(ctx, e.ChainID, latest.BlockNumber-gasHistoryBlocks+1, false, fractions(gasPercentiles))
+		if err != nil {
+			return err
+		}
+		if prices != nil {
+			e.Source = models.GasSourceIndexed
+			e.BlockNumber = latest.BlockNumber
+			e.Slow, e.Standard, e.Fast = legacyTiers(parseWei(prices))
+			return nil
+		}
+	}
+	if e.NodeGasPrice != nil {
+		e.Source = models.GasSourceNode
+		if latest != nil {
+			e.BlockNumber = latest.BlockNumber
+		}
+		price, _ := new(big.Int).SetString(*e.NodeGasPrice, 10)
+		e.Slow, e.Standard, e.Fast = legacyTiers([]*big.Int{price, price, price})
+	}
+	return nil
+}
+
+func (h *GasHandler) estimateEIP1559(ctx context.Context, client *blockchain.ChainClient, latest *models.Block, e *models.GasEstimate) error {
+	if client != nil {
+		history, err := client.FeeHistory(ctx, gasHistoryBlocks, nil, gasPercentiles)
+		if err == nil && len(history.BaseFee) >= 2 && len(history.Reward) > 0 {
+			current, next := history.BaseFee[len(history.BaseFee)-2], history.BaseFee[len(history.BaseFee)-1]
+			e.Source = models.GasSourceFeeHistory
+			e.BlockNumber = history.OldestBlock.Int64() + int64(len(history.Reward)) - 1
+			setBaseFees(e, current, next, history.BaseFee[0])
+			e.Slow, e.Standard, e.Fast = eip1559Tiers(next, blockchain.MedianRewards(history))
+			return nil
+		}
+	}
+
+	// Without fee history, or on chains whose blocks carry no base fee,
+	// fall back to the indexed blocks
+	if latest == nil || latest.BaseFeePerGas == nil {
+		e.Oracle = blockchain.GasOracleLegacy
+		return h.estimateLegacy(ctx, latest, e)
+	}
+	current, ok := new(big.Int).SetString(*latest.BaseFeePerGas, 10)
+	if !ok {
+		e.Oracle = blockchain.GasOracleLegacy
+		return h.estimateLegacy(ctx, latest, e)
+	}
+	next := blockchain.NextBaseFee(current, latest.GasUsed, latest.GasLimit)
+	oldest := current
+	oldBlock, err := h.db.GetBlockByNumber(ctx, e.ChainID, latest.BlockNumber-gasHistoryBlocks+1)
+	if err != nil {
+		return err
+	}
+	if oldBlock != nil && oldBlock.BaseFeePerGas != nil {
+		if fee, ok := new(big.Int).SetString(*oldBlock.BaseFeePerGas, 10); ok {
+			oldest = fee
+		}
+	}
+	tips, err := h.db.GetFeePercentiles(ctx, e.ChainID, latest.BlockNumber-gasHistoryBlocks+1, true, fractions(gasPercentiles))
+	if err != nil {
+		return err
+	}
+
+	e.Source = models.GasSourceIndexed
+	e.BlockNumber = latest.BlockNumber
+	setBaseFees(e, current, next, oldest)
+	e.Slow, e.Standard, e.Fast = eip1559Tiers(next, parseWei(tips))
+	return nil
+}
+
+func setBaseFees(e *models.GasEstimate, current, next, oldest *big.Int) {
+	c, n := current.String(), next.String()
+	e.BaseFee = &c
+	e.NextBaseFee = &n
+	e.BaseFeeTrend = baseFeeTrend(oldest, next)
+}
+
+// eip1559Tiers prices each tip on top of the next base fee; missing tips,
+// as in windows without transactions, are zero. The fee cap allows for the
+// base fee doubling before inclusion.
+func eip1559Tiers(nextBaseFee *big.Int, tips []*big.Int) (slow, standard, fast *models.GasTier) {
+	tiers := make([]*models.GasTier, 3)
+	for i := range tiers {
+		tip := new(big.Int)
+		if i < len(tips) && tips[i] != nil && tips[i].Sign() > 0 {
+			tip = tips[i]
+		}
+		price := new(big.Int).Add(nextBaseFee, tip).String()
+		maxFee := new(big.Int).Add(new(big.Int).Mul(nextBaseFee, big.NewInt(2)), tip).String()
+		priority := tip.String()
+		tiers[i] = &models.GasTier{GasPrice: price, MaxPriorityFeePerGas: &priority, MaxFeePerGas: &maxFee}
+	}
+	return tiers[0], tiers[1], tiers[2]
+}
+
+func legacyTiers(prices []*big.Int) (slow, standard, fast *models.GasTier) {
+	tiers := make([]*models.GasTier, 3)
+	for i := range tiers {
+		price := new(big.Int)
+		if i < len(prices) && prices[i] != nil {
+			price = prices[i]
+		}
+		tiers[i] = &models.GasTier{GasPrice: price.String()}
+	}
+	return tiers[0], tiers[1], tiers[2]
+}
+
+// baseFeeTrend compares the next base fee with the one at the start of the
+// window.
+func baseFeeTrend(oldest, next *big.Int) string {
+	if oldest.Sign() == 0 {
+		if next.Sign() > 0 {
+			return models.BaseFeeRising
+		}
+		return models.BaseFeeStable
+	}
+	// change in percent of the oldest base fee
+	change := new(big.Int).Sub(next, oldest)
+	change.Mul(change, big.NewInt(100))
+	change.Quo(change, oldest)
+	switch {
+	case change.Cmp(big.NewInt(baseFeeTrendPercent)) >= 0:
+		return models.BaseFeeRising
+	case change.Cmp(big.NewInt(-baseFeeTrendPercent)) <= 0:
+		return models.BaseFeeFalling
+	}
+	return models.BaseFeeStable
+}
+
+// fractions converts percentiles to the 0..1 fractions Postgres expects.
+func fractions(percentiles []float64) []float64 {
+	out := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		out[i] = p / 100
+	}
+	return out
+}
+
+// parseWei parses decimal amounts, leaving nil for any that aren't integers.
+func parseWei(values []string) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, v := range values {
+		if n, ok := new(big.Int).SetString(v, 10); ok {
+			out[i] = n
+		}
+	}
+	return out
+}
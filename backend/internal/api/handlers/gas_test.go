@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEIP1559Tiers(t *testing.T) {
+	slow, standard, fast := eip1559Tiers(big.NewInt(100), []*big.Int{big.NewInt(1), big.NewInt(-3), big.NewInt(10)})
+
+	assert.Equal(t, "101", slow.GasPrice)
+	assert.Equal(t, "1", *slow.MaxPriorityFeePerGas)
+	assert.Equal(t, "201", *slow.MaxFeePerGas)
+	// Negative tips from underpriced transactions count as zero
+	assert.Equal(t, "0", *standard.MaxPriorityFeePerGas)
+	assert.Equal(t, "210", *fast.MaxFeePerGas)
+
+	// Without tips every tier pays just the base fee
+	_, standard, _ = eip1559Tiers(big.NewInt(100), nil)
+	assert.Equal(t, "100", standard.GasPrice)
+}
+
+func TestLegacyTiers(t *testing.T) {
+	slow, standard, fast := legacyTiers(parseWei([]string{"1000", "2000", "x"}))
+	assert.Equal(t, "1000", slow.GasPrice)
+	assert.Equal(t, "2000", standard.GasPrice)
+	assert.Equal(t, "0", fast.GasPrice)
+	assert.Nil(t, slow.MaxFeePerGas)
+}
+
+func TestBaseFeeTrend(t *testing.T) {
+	assert.Equal(t, models.BaseFeeRising, baseFeeTrend(big.NewInt(100), big.NewInt(110)))
+	assert.Equal(t, models.BaseFeeFalling, baseFeeTrend(big.NewInt(100), big.NewInt(90)))
+	assert.Equal(t, models.BaseFeeStable, baseFeeTrend(big.NewInt(100), big.NewInt(104)))
+	assert.Equal(t, models.BaseFeeStable, baseFeeTrend(big.NewInt(0), big.NewInt(0)))
+	assert.Equal(t, models.BaseFeeRising, baseFeeTrend(big.NewInt(0), big.NewInt(7)))
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/graphql"
+)
+
+type GraphQLHandler struct {
+	service *graphql.Service
+}
+
+func NewGraphQLHandler(service *graphql.Service) *GraphQLHandler {
+	return &GraphQLHandler{service: service}
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query executes a GraphQL request, POSTed as JSON or passed in the query
+// string of a GET. Responses use the GraphQL wire format rather than the
+// REST envelope so standard clients can consume them.
+func (h *GraphQLHandler) Query(c *fiber.Ctx) error {
+	var req graphQLRequest
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return graphQLError(c, "Invalid variables")
+			}
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return graphQLError(c, "Invalid request body")
+	}
+	if req.Query == "" {
+		return graphQLError(c, "Missing query")
+	}
+
+	return c.JSON(h.service.Exec(c.Context(), req.Query, req.OperationName, req.Variables))
+}
+
+func graphQLError(c *fiber.Ctx, message string) error {
+	return c.Status(400).JSON(fiber.Map{
+		"errors": []fiber.Map{{"message": message}},
+	})
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+const maxLabelLength = 64
+
+// LabelHandler manages address labels. Labels appear in cached block and
+// transaction responses, so changing one purges its chain from the cache.
+type LabelHandler struct {
+	db    *database.DB
+	cache *httpcache.Cache
+}
+
+func NewLabelHandler(db *database.DB, cache *httpcache.Cache) *LabelHandler {
+	return &LabelHandler{db: db, cache: cache}
+}
+
+type labelRequest struct {
+	ChainID     *int64  `json:"chain_id"`
+	Address     string  `json:"address"`
+	Label       *string `json:"label"`
+	Description *string `json:"description"`
+}
+
+// parseLabel trims a label and checks its length.
+func parseLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" || len(label) > maxLabelLength {
+		return "", fmt.Errorf("label must be 1 to %d characters", maxLabelLength)
+	}
+	return label, nil
+}
+
+// CreateLabel tags an address. Adding a label the address already has
+// updates its description.
+func (h *LabelHandler) CreateLabel(c *fiber.Ctx) error {
+	var req labelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if !common.IsHexAddress(req.Address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", req.Address)
+	}
+	if req.Label == nil {
+		return responses.Error(c, 400, "INVALID_LABEL", "label is required", nil)
+	}
+	name, err := parseLabel(*req.Label)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_LABEL", err.Error(), nil)
+	}
+	label := &models.AddressLabel{
+		ChainID:     1337,
+		Address:     common.HexToAddress(req.Address).Hex(),
+		Label:       name,
+		Description: req.Description,
+	}
+	if req.ChainID != nil {
+		label.ChainID = *req.ChainID
+	}
+	chain, err := h.db.GetChain(c.Context(), label.ChainID)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chain", err.Error())
+	}
+	if chain == nil {
+		return responses.Error(c, 400, "INVALID_CHAIN", "Unknown chain", label.ChainID)
+	}
+
+	if err := h.db.CreateAddressLabel(c.Context(), label); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to create label", err.Error())
+	}
+	h.purge(c, label.ChainID)
+	c.Status(fiber.StatusCreated)
+	return responses.Success(c, label, &label.ChainID)
+}
+
+// GetLabels lists a chain's labels, optionally for one address or with one
+// label.
+func (h *LabelHandler) GetLabels(c *fiber.Ctx) error {
+	filter := &database.AddressLabelFilter{ChainID: int64(c.QueryInt("chain_id", 1337))}
+	if address := c.Query("address"); address != "" {
+		if !common.IsHexAddress(address) {
+			return responses.Error(c, 400, "INVALID_FILTER", "Invalid address", address)
+		}
+		address = common.HexToAddress(address).Hex()
+		filter.Address = &address
+	}
+	if label := c.Query("label"); label != "" {
+		filter.Label = &label
+	}
+	page, limit := pageParams(c)
+	filter.Limit, filter.Offset = limit, (page-1)*limit
+
+	labels, err := h.db.GetAddressLabels(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch labels", err.Error())
+	}
+	total, _ := h.db.CountAddressLabels(c.Context(), filter)
+
+	return responses.Success(c, fiber.Map{
+		"labels":     labels,
+		"pagination": pageMeta(page, limit, total),
+	}, &filter.ChainID)
+}
+
+func (h *LabelHandler) GetLabel(c *fiber.Ctx) error {
+	label, err := h.lookupLabel(c)
+	if label == nil {
+		return err
+	}
+	return responses.Success(c, label, &label.ChainID)
+}
+
+// UpdateLabel renames a label or changes its description. The address and
+// chain are fixed.
+func (h *LabelHandler) UpdateLabel(c *fiber.Ctx) error {
+	label, err := h.lookupLabel(c)
+	if label == nil {
+		return err
+	}
+	var req labelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if req.Label != nil {
+		if label.Label, err = parseLabel(*req.Label); err != nil {
+			return responses.Error(c, 400, "INVALID_LABEL", err.Error(), nil)
+		}
+	}
+	if req.Description != nil {
+		label.Description = req.Description
+	}
+
+	updated, err := h.db.UpdateAddressLabel(c.Context(), label)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to update label", err.Error())
+	}
+	if updated == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Label not found", nil)
+	}
+	h.purge(c, updated.ChainID)
+	return responses.Success(c, updated, &updated.ChainID)
+}
+
+func (h *LabelHandler) DeleteLabel(c *fiber.Ctx) error {
+	label, err := h.lookupLabel(c)
+	if label == nil {
+		return err
+	}
+	deleted, err := h.db.DeleteAddressLabel(c.Context(), label.ID)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to delete label", err.Error())
+	}
+	if !deleted {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Label not found", nil)
+	}
+	h.purge(c, label.ChainID)
+	return responses.Success(c, fiber.Map{"id": label.ID, "deleted": true}, nil)
+}
+
+// purge drops a chain's cached responses after its labels changed. Clients
+// may still hold copies until they expire.
+func (h *LabelHandler) purge(c *fiber.Ctx, chainID int64) {
+	h.cache.Purge(c.Context(), chainID)
+}
+
+// lookupLabel resolves the label in the path, like lookupWebhook.
+func (h *LabelHandler) lookupLabel(c *fiber.Ctx) (*models.AddressLabel, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return nil, responses.Error(c, 400, "INVALID_ID", "Invalid label id", nil)
+	}
+	label, err := h.db.GetAddressLabel(c.Context(), int64(id))
+	if err != nil {
+		return nil, responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch label", err.Error())
+	}
+	if label == nil {
+		return nil, responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Label not found", nil)
+	}
+	return label, nil
+}
+
+// labelTarget is an address in a response and the field its labels go in.
+type labelTarget struct {
+	address string
+	labels  *[]string
+}
+
+// labelTargets lists the address fields of blocks, transactions, token
+// transfers, addresses, watchlist members and activity, singly or in slices.
+func labelTargets(items ...interface{}) []labelTarget {
+	var targets []labelTarget
+	add := func(address string, labels *[]string) {
+		targets = append(targets, labelTarget{address, labels})
+	}
+	addOptional := func(address *string, labels *[]string) {
+		if address != nil {
+			add(*address, labels)
+		}
+	}
+	var visit func(item interface{})
+	visit = func(item interface{}) {
+		switch v := item.(type) {
+		case *models.Block:
+			if v != nil {
+				add(v.Miner, &v.MinerLabels)
+			}
+		case *models.Transaction:
+			if v != nil {
+				add(v.FromAddress, &v.FromLabels)
+				addOptional(v.ToAddress, &v.ToLabels)
+				addOptional(v.ContractAddress, &v.ContractLabels)
+			}
+		case *models.TokenTransfer:
+			if v != nil {
+				add(v.TokenAddress, &v.TokenLabels)
+				add(v.FromAddress, &v.FromLabels)
+				add(v.ToAddress, &v.ToLabels)
+			}
+		case *models.Address:
+			if v != nil {
+				add(v.Address, &v.Labels)
+			}
+		case *models.WatchlistAddress:
+			if v != nil {
+				add(v.Address, &v.Labels)
+			}
+		case *models.AddressSummary:
+			if v != nil {
+				add(v.Address, &v.Labels)
+				for _, cp := range v.Counterparties {
+					add(cp.Address, &cp.Labels)
+				}
+			}
+		case *models.TopAccount:
+			if v != nil {
+				add(v.Address, &v.Labels)
+			}
+		case *models.TopContract:
+			if v != nil {
+				add(v.Address, &v.Labels)
+			}
+		case *models.WatchlistActivity:
+			if v != nil {
+				visit(v.Transaction)
+				visit(v.TokenTransfer)
+			}
+		case []*models.Block:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.Transaction:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.TokenTransfer:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.WatchlistAddress:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.WatchlistActivity:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.TopAccount:
+			for _, x := range v {
+				visit(x)
+			}
+		case []*models.TopContract:
+			for _, x := range v {
+				visit(x)
+			}
+		}
+	}
+	for _, item := range items {
+		visit(item)
+	}
+	return targets
+}
+
+// attachLabels fills in the labels of the address fields of items with one
+// query and reports whether any were found. Labels are decoration, so a
+// failed lookup leaves them out rather than failing the response.
+func attachLabels(ctx context.Context, db *database.DB, chainID int64, items ...interface{}) bool {
+	targets := labelTargets(items...)
+	if len(targets) == 0 {
+		return false
+	}
+	seen := make(map[string]bool, len(targets))
+	var addresses []string
+	for _, t := range targets {
+		if !seen[t.address] {
+			seen[t.address] = true
+			addresses = append(addresses, t.address)
+		}
+	}
+	labels, err := db.GetLabelsByAddresses(ctx, chainID, addresses)
+	if err != nil {
+		return false
+	}
+	labelled := false
+	for _, t := range targets {
+		*t.labels = labels[t.address]
+		labelled = labelled || len(*t.labels) > 0
+	}
+	return labelled
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelTargets(t *testing.T) {
+	to := "0x0000000000000000000000000000000000000002"
+	tx := &models.Transaction{FromAddress: "0x0000000000000000000000000000000000000001", ToAddress: &to}
+	transfer := &models.TokenTransfer{TokenAddress: "0xT", FromAddress: "0xA", ToAddress: "0xB"}
+	var missing *models.Block
+
+	targets := labelTargets(
+		[]*models.Block{{Miner: "0xM"}},
+		missing,
+		tx,
+		[]*models.WatchlistActivity{{TokenTransfer: transfer}},
+		"not labelled",
+	)
+	var addresses []string
+	for _, target := range targets {
+		addresses = append(addresses, target.address)
+		*target.labels = []string{"seen"}
+	}
+	assert.Equal(t, []string{"0xM", tx.FromAddress, to, "0xT", "0xA", "0xB"}, addresses)
+	assert.Equal(t, []string{"seen"}, tx.ToLabels)
+	assert.Nil(t, tx.ContractLabels)
+	assert.Equal(t, []string{"seen"}, transfer.TokenLabels)
+
+	summary := &models.AddressSummary{
+		Address:        "0xS",
+		Counterparties: []*models.Counterparty{{Address: "0xC"}},
+	}
+	targets = labelTargets(summary, []*models.TopAccount{{Address: "0xR"}})
+	addresses = nil
+	for _, target := range targets {
+		addresses = append(addresses, target.address)
+	}
+	assert.Equal(t, []string{"0xS", "0xC", "0xR"}, addresses)
+}
+
+func TestParseAddresses(t *testing.T) {
+	addresses, err := parseAddresses([]string{
+		"0x000000000000000000000000000000000000beef",
+		"0x000000000000000000000000000000000000BEEF",
+		"0x0000000000000000000000000000000000000001",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"0x000000000000000000000000000000000000bEEF",
+		"0x0000000000000000000000000000000000000001",
+	}, addresses)
+
+	_, err = parseAddresses([]string{"0x12"})
+	assert.Error(t, err)
+
+	_, err = parseLabel("  ")
+	assert.Error(t, err)
+	label, err := parseLabel(" treasury ")
+	assert.NoError(t, err)
+	assert.Equal(t, "treasury", label)
+}
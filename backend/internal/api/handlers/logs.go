@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+type LogHandler struct {
+	db *database.DB
+}
+
+func NewLogHandler(db *database.DB) *LogHandler {
+	return &LogHandler{db: db}
+}
+
+// GetLogs answers eth_getLogs-style queries from the index. address and
+// topic0..topic3 accept comma-separated or repeated values which are OR-ed;
+// omitted positions match anything. from_time/to_time and order work as on
+// the transaction listings.
+func (h *LogHandler) GetLogs(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	p, err := parseListPage(c, 100, 1000)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_CURSOR", "Invalid cursor", nil)
+	}
+
+	filter := &database.LogFilter{
+		ChainID: int64(chainID),
+		After:   p.after(),
+		Before:  p.before(),
+		Limit:   p.limit + 1,
+	}
+	if p.legacy() {
+		filter.Limit = p.limit
+		filter.Offset = p.offset()
+	}
+
+	for _, address := range queryList(c, "address") {
+		if !common.IsHexAddress(address) {
+			return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", address)
+		}
+		filter.Addresses = append(filter.Addresses, common.HexToAddress(address).Hex())
+	}
+
+	for i := range filter.Topics {
+		for _, topic := range queryList(c, fmt.Sprintf("topic%d", i)) {
+			if !isHexHash(topic) {
+				return responses.Error(c, 400, "INVALID_TOPIC", "Topics must be 32-byte hex values", topic)
+			}
+			filter.Topics[i] = append(filter.Topics[i], strings.ToLower(topic))
+		}
+	}
+
+	if blockHash := c.Query("block_hash"); blockHash != "" {
+		if !isHexHash(blockHash) {
+			return responses.Error(c, 400, "INVALID_BLOCK_HASH", "Invalid block hash", nil)
+		}
+		blockHash = strings.ToLower(blockHash)
+		filter.BlockHash = &blockHash
+	} else {
+		if filter.FromBlock, err = h.parseBlockParam(c, "from_block"); err != nil {
+			return responses.Error(c, 400, "INVALID_BLOCK_RANGE", err.Error(), nil)
+		}
+		if filter.ToBlock, err = h.parseBlockParam(c, "to_block"); err != nil {
+			return responses.Error(c, 400, "INVALID_BLOCK_RANGE", err.Error(), nil)
+		}
+		if filter.FromBlock != nil && filter.ToBlock != nil && *filter.FromBlock > *filter.ToBlock {
+			return responses.Error(c, 400, "INVALID_BLOCK_RANGE", "from_block must not be greater than to_block", nil)
+		}
+		if err := parseTimeRange(c, &filter.BlockRange); err != nil {
+			return responses.Error(c, 400, "INVALID_BLOCK_RANGE", err.Error(), nil)
+		}
+	}
+	if filter.Order, err = parseOrder(c); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	logs, err := h.db.GetLogs(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch logs", err.Error())
+	}
+
+	cID := int64(chainID)
+	httpcache.For(c, httpcache.HeadTTL)
+	if p.legacy() {
+		return responses.Success(c, fiber.Map{
+			"logs": logs,
+			"pagination": fiber.Map{
+				"page":     p.page,
+				"limit":    p.limit,
+				"has_more": len(logs) == p.limit,
+			},
+		}, &cID)
+	}
+
+	logs, meta := paginate(p, logs, func(l *models.TransactionLog) database.Position {
+		return database.Position{BlockNumber: l.BlockNumber, Index: l.LogIndex}
+	})
+	return responses.Success(c, fiber.Map{
+		"logs":       logs,
+		"pagination": meta,
+	}, &cID)
+}
+
+// parseBlockParam accepts a decimal or 0x-prefixed block number, or the
+// "earliest" and "latest" tags.
+func (h *LogHandler) parseBlockParam(c *fiber.Ctx, name string) (*int64, error) {
+	value := c.Query(name)
+	switch value {
+	case "":
+		return nil, nil
+	case "earliest":
+		n := int64(0)
+		return &n, nil
+	case "latest":
+		block, err := h.db.GetLatestBlock(c.Context(), int64(c.QueryInt("chain_id", 1337)))
+		if err != nil {
+			return nil, err
+		}
+		n := int64(0)
+		if block != nil {
+			n = block.BlockNumber
+		}
+		return &n, nil
+	}
+
+	n, err := strconv.ParseInt(value, 0, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &n, nil
+}
+
+// queryList collects a query parameter given either repeated or as a
+// comma-separated list.
+func queryList(c *fiber.Ctx, name string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(name) {
+		for _, v := range strings.Split(string(raw), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func isHexHash(s string) bool {
+	return len(s) == 66 && strings.HasPrefix(s, "0x") && isHex(s[2:])
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,1038 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/middleware"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/openapi"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/decoder"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/export"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// ErrorCodes are the codes error responses carry, with what they mean.
+var ErrorCodes = map[string]string{
+	"ABI_NOT_FOUND":   "No ABI is known for the contract",
+	"DATABASE_ERROR":  "The index could not be read or written",
+	"FORBIDDEN":       "The admin API is disabled",
+	"DECODE_FAILED":   "The calldata does not match the contract's ABI",
+	"GAS_UNAVAILABLE": "No recent gas prices are available",
+	"INTERNAL_ERROR":  "An unexpected error, such as an unknown route",
+	// Written by the recovery middleware
+	"INTERNAL_SERVER_ERROR": "A handler panicked",
+	"INVALID_ABI":           "The uploaded ABI is not valid JSON ABI",
+	"INVALID_ADDRESS":       "An address is not a 20-byte hex address",
+	"INVALID_API_KEY":       "The X-API-Key is unknown, revoked or expired",
+	"INVALID_ALERT_RULE":    "An alert rule is incomplete or its params don't suit its type",
+	"INVALID_BLOCK_HASH":    "A block hash is not a 32-byte hex value",
+	"INVALID_BLOCK_RANGE":   "A block bound is malformed or the range is reversed",
+	"INVALID_BODY":          "The request body is not valid JSON",
+	"INVALID_CHAIN":         "The chain is not configured",
+	"INVALID_CURSOR":        "The cursor is malformed or can't be used with the requested sort",
+	"INVALID_EVENT_ID":      "The Last-Event-ID to resume a stream from is malformed",
+	"INVALID_FILTER":        "A query parameter is malformed or out of range",
+	"INVALID_KEY_REQUEST":   "An API key's name, tier or expiry is invalid",
+	"INVALID_ID":            "A numeric id in the path is malformed",
+	"INVALID_LABEL":         "A label is empty, too long or incomplete",
+	"INVALID_QUERY":         "The search query is missing",
+	"INVALID_TOPIC":         "A log topic is not a 32-byte hex value",
+	"INVALID_WATCHLIST":     "A watchlist is incomplete or holds too many addresses",
+	"INVALID_WEBHOOK":       "A webhook's URL or filters are invalid",
+	"NOT_DECODABLE":         "The transaction is not a contract call",
+	"QUOTA_EXCEEDED":        "The API key's daily quota is used up",
+	"RATE_LIMITED":          "The API key or client IP made too many requests; retry after Retry-After seconds",
+	"UNAUTHORIZED":          "The admin token is missing or wrong",
+	"RESOURCE_NOT_FOUND":    "The requested resource does not exist",
+}
+
+// errorResponses name the error responses by status.
+var errorResponses = map[int]struct{ name, description string }{
+	400: {"BadRequest", "Invalid parameters or body"},
+	401: {"Unauthorized", "Invalid API key or admin token"},
+	403: {"Forbidden", "Admin API disabled"},
+	404: {"NotFound", "Resource not found"},
+	422: {"Unprocessable", "The resource can't be processed as asked"},
+	500: {"InternalError", "Index or server failure"},
+	429: {"TooManyRequests", "Rate limit or daily quota exceeded"},
+	503: {"Unavailable", "Data needed to answer is unavailable"},
+}
+
+// rateLimitHeaders are set on every rate limited response.
+var rateLimitHeaders = map[string]*openapi.Header{
+	middleware.HeaderRateLimitLimit:     {Description: "Requests the bucket holds", Schema: openapi.Integer()},
+	middleware.HeaderRateLimitRemaining: {Description: "Requests left in the bucket", Schema: openapi.Integer()},
+	middleware.HeaderRateLimitReset:     {Description: "Seconds until the bucket is full", Schema: openapi.Integer()},
+	middleware.HeaderQuotaLimit:         {Description: "The key's daily quota, for tiers with one", Schema: openapi.Integer()},
+	middleware.HeaderQuotaRemaining:     {Description: "Requests left today", Schema: openapi.Integer()},
+	middleware.HeaderQuotaReset:         {Description: "Seconds until the quota resets at midnight UTC", Schema: openapi.Integer()},
+}
+
+// cacheHeaders are set on the responses of cached routes.
+var cacheHeaders = map[string]*openapi.Header{
+	fiber.HeaderETag:         {Description: "Strong validator of the response data, for If-None-Match", Schema: openapi.String()},
+	fiber.HeaderCacheControl: {Description: "immutable for blocks deeper than any reorg, a max-age of seconds otherwise", Schema: openapi.String()},
+	fiber.HeaderAge:          {Description: "Seconds the response has been cached, on hits", Schema: openapi.Integer()},
+	httpcache.HeaderCache:    {Description: "HIT or MISS", Schema: openapi.Enum("HIT", "MISS")},
+}
+
+var ifNoneMatchParam = openapi.HeaderParam(fiber.HeaderIfNoneMatch, "ETags of copies already held; a match is answered with 304", openapi.String())
+
+// apiDocs documents the routes on a spec with the API's shared parameters
+// and response envelope.
+type apiDocs struct {
+	*openapi.Spec
+	errors      map[int]*openapi.Response
+	notModified *openapi.Response
+}
+
+// adminSecurity marks the routes that take the admin token.
+var adminSecurity = []openapi.SecurityRequirement{{"adminToken": {}}}
+
+// operation documents one route. Data is the data field of the success
+// envelope; routes that answer outside the envelope set content instead.
+type operation struct {
+	id, tag, summary, description string
+	params                        []*openapi.Parameter
+	body                          *openapi.Schema
+	data                          *openapi.Schema
+	content                       map[string]*openapi.Schema
+	errors                        []int
+	security                      []openapi.SecurityRequirement
+	// cached routes carry ETags and Cache-Control, and answer 304
+	cached bool
+}
+
+func (d *apiDocs) add(method, path string, op operation) {
+	success := &openapi.Response{Description: "Success", Headers: rateLimitHeaders, Content: map[string]openapi.MediaType{}}
+	if op.content != nil {
+		for mime, schema := range op.content {
+			success.Content[mime] = openapi.MediaType{Schema: schema}
+		}
+	} else {
+		success.Content[fiber.MIMEApplicationJSON] = openapi.MediaType{Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+			d.Model(responses.Response{}),
+			openapi.Object(map[string]*openapi.Schema{"data": op.data}),
+		}}}
+	}
+	o := &openapi.Operation{
+		OperationID: op.id,
+		Tags:        []string{op.tag},
+		Summary:     op.summary,
+		Description: op.description,
+		Parameters:  op.params,
+		Responses:   map[string]*openapi.Response{"200": success},
+		Security:    op.security,
+	}
+	if op.body != nil {
+		o.RequestBody = openapi.JSONBody(op.body)
+	}
+	if op.cached {
+		success.Headers = map[string]*openapi.Header{}
+		for _, headers := range []map[string]*openapi.Header{rateLimitHeaders, cacheHeaders} {
+			for name, h := range headers {
+				success.Headers[name] = h
+			}
+		}
+		o.Parameters = append(o.Parameters[:len(o.Parameters):len(o.Parameters)], ifNoneMatchParam)
+		o.Responses["304"] = d.notModified
+	}
+	// Any request may carry a bad key or be rate limited
+	for _, status := range append(op.errors, 401, 429, 500) {
+		o.Responses[fmt.Sprint(status)] = d.errors[status]
+	}
+	d.Add(method, path, o)
+}
+
+// page wraps a counted list in data with its pagination.
+func (d *apiDocs) page(key string, item *openapi.Schema, extra map[string]*openapi.Schema) *openapi.Schema {
+	return d.list(d.Model(responses.PaginationMeta{}), key, item, extra)
+}
+
+// cursorPage wraps a keyset-paginated list, which passing page switches to
+// the offset mode. Lists whose offset mode isn't counted describe its
+// pagination in legacy; counted lists pass nil, since PaginationMeta covers
+// both modes.
+func (d *apiDocs) cursorPage(legacy *openapi.Schema, key string, item *openapi.Schema, extra map[string]*openapi.Schema) *openapi.Schema {
+	pagination := d.Model(responses.PaginationMeta{})
+	if legacy != nil {
+		pagination = openapi.OneOf(pagination, legacy)
+	}
+	return d.list(pagination, key, item, extra)
+}
+
+// list wraps a list in data with the given pagination.
+func (d *apiDocs) list(pagination *openapi.Schema, key string, item *openapi.Schema, extra map[string]*openapi.Schema) *openapi.Schema {
+	props := map[string]*openapi.Schema{
+		key:          openapi.ArrayOf(item),
+		"pagination": pagination,
+	}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return openapi.Object(props)
+}
+
+var (
+	chainParam = openapi.QueryParam("chain_id", "Chain ID", openapi.WithDefault(openapi.Integer(), 1337))
+	// anyChainParam is for lists spanning every chain unless one is given
+	anyChainParam  = openapi.QueryParam("chain_id", "Only this chain; every chain when omitted", openapi.Integer())
+	pageParam      = openapi.QueryParam("page", "Page number, from 1", openapi.WithDefault(openapi.Integer(), 1))
+	limitParam     = openapi.QueryParam("limit", "Page size, up to 100", openapi.WithDefault(openapi.Integer(), 20))
+	cursorParam    = openapi.QueryParam("cursor", "Opaque next or prev cursor from the previous page. Passing page instead selects offset pagination with totals", openapi.String())
+	addressPath    = openapi.PathParam("address", "Address, checksummed or not", openapi.String())
+	idPath         = openapi.PathParam("id", "ID", openapi.Integer())
+	orderParam     = openapi.QueryParam("order", "Sort order", openapi.Enum("asc", "desc"))
+	timeParamKinds = "unix seconds or RFC 3339"
+	fromBlockParam = openapi.QueryParam("from_block", "First block (inclusive)", openapi.Integer())
+	toBlockParam   = openapi.QueryParam("to_block", "Last block (inclusive)", openapi.Integer())
+	fromTimeParam  = openapi.QueryParam("from_time", "Start time, "+timeParamKinds+" (inclusive)", openapi.String())
+	toTimeParam    = openapi.QueryParam("to_time", "End time, "+timeParamKinds+" (inclusive)", openapi.String())
+	lastEventParam = []*openapi.Parameter{
+		openapi.HeaderParam("Last-Event-ID", "Resume after this event, replaying every missed event", openapi.String()),
+		openapi.QueryParam("last_event_id", "Last-Event-ID for clients that can't set headers", openapi.String()),
+	}
+)
+
+func params(groups ...[]*openapi.Parameter) []*openapi.Parameter {
+	var out []*openapi.Parameter
+	for _, g := range groups {
+		out = append(out, g...)
+	}
+	return out
+}
+
+func cursorParams(defaultLimit, maxLimit int) []*openapi.Parameter {
+	return []*openapi.Parameter{
+		openapi.QueryParam("limit", fmt.Sprintf("Page size, up to %d", maxLimit), openapi.WithDefault(openapi.Integer(), defaultLimit)),
+		cursorParam,
+		openapi.QueryParam("page", "Page number for offset pagination", openapi.Integer()),
+	}
+}
+
+func blockRangeParams() []*openapi.Parameter {
+	return []*openapi.Parameter{fromBlockParam, toBlockParam, fromTimeParam, toTimeParam}
+}
+
+// partyParams are read by parseParty.
+func partyParams(withAddress bool) []*openapi.Parameter {
+	var out []*openapi.Parameter
+	if withAddress {
+		out = append(out, openapi.QueryParam("address", "Only rows involving this address", openapi.String()))
+	}
+	return append(out,
+		openapi.QueryParam("direction", "Direction relative to the address", openapi.Enum("in", "out", "self")),
+		openapi.QueryParam("counterparty", "Only rows between the address and this one", openapi.String()),
+	)
+}
+
+// valueSortParams are read by parseValueRange and parseSort.
+func valueSortParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		openapi.QueryParam("min_value", "Minimum value in the smallest unit", openapi.String()),
+		openapi.QueryParam("max_value", "Maximum value in the smallest unit", openapi.String()),
+		openapi.QueryParam("sort", "Sort by chain position or value; sorting by value uses offset pagination", openapi.Enum("block", "value")),
+		orderParam,
+	}
+}
+
+// transactionFilterParams are read by parseTransactionFilter.
+func transactionFilterParams(withAddress bool) []*openapi.Parameter {
+	return params(partyParams(withAddress), blockRangeParams(), valueSortParams(), []*openapi.Parameter{
+		openapi.QueryParam("status", "Receipt status", openapi.Enum("success", "failed")),
+		openapi.QueryParam("method", "4-byte selector, or 0x for plain transfers", openapi.String()),
+		openapi.QueryParam("type", "Transaction types, comma separated or repeated", openapi.String()),
+	})
+}
+
+// OpenAPI documents the API's routes. NewServer serves the document built
+// from it for the routes it registers, and tests check every route is
+// documented.
+func OpenAPI() *openapi.Spec {
+	d := &apiDocs{Spec: openapi.NewSpec(), errors: map[int]*openapi.Response{}}
+	codes := make([]string, 0, len(ErrorCodes))
+	for code := range ErrorCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var meanings strings.Builder
+	meanings.WriteString("Error codes:\n")
+	for _, code := range codes {
+		fmt.Fprintf(&meanings, "\n- `%s`: %s", code, ErrorCodes[code])
+	}
+	errorCode := d.Define("ErrorCode", openapi.Describe(openapi.Enum(codes...), meanings.String()))
+	errorEnvelope := d.Define("ErrorResponse", &openapi.Schema{AllOf: []*openapi.Schema{
+		d.Model(responses.Response{}),
+		openapi.Object(map[string]*openapi.Schema{
+			"error": {AllOf: []*openapi.Schema{
+				d.Model(responses.ErrorInfo{}),
+				openapi.Object(map[string]*openapi.Schema{"code": errorCode}),
+			}},
+		}),
+	}})
+	for status, r := range errorResponses {
+		resp := &openapi.Response{
+			Description: r.description,
+			Content:     map[string]openapi.MediaType{fiber.MIMEApplicationJSON: {Schema: errorEnvelope}},
+		}
+		if status == 429 {
+			resp.Headers = map[string]*openapi.Header{fiber.HeaderRetryAfter: {Description: "Seconds to wait before retrying", Schema: openapi.Integer()}}
+			for name, h := range rateLimitHeaders {
+				resp.Headers[name] = h
+			}
+		}
+		d.errors[status] = d.DefineResponse(r.name, resp)
+	}
+	d.notModified = d.DefineResponse("NotModified", &openapi.Response{
+		Description: "The If-None-Match ETag still matches; the held copy is current",
+		Headers:     cacheHeaders,
+	})
+
+	d.SecurityScheme("apiKey", &openapi.SecurityScheme{
+		Type: "apiKey", In: "header", Name: middleware.HeaderAPIKey,
+		Description: "Lifts requests from the per-IP limit to the key's tier",
+	})
+	d.SecurityScheme("apiKeyQuery", &openapi.SecurityScheme{
+		Type: "apiKey", In: "query", Name: "api_key",
+		Description: "The API key, for clients that can't set headers",
+	})
+	d.SecurityScheme("adminToken", &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer",
+		Description: "The API_ADMIN_TOKEN the server was started with",
+	})
+	// Keys are optional
+	d.Security(openapi.SecurityRequirement{}, openapi.SecurityRequirement{"apiKey": {}}, openapi.SecurityRequirement{"apiKeyQuery": {}})
+
+	d.documentChain()
+	d.documentActivity()
+	d.documentAnalytics()
+	d.documentTokens()
+	d.documentRealtime()
+	d.documentCompat()
+	d.documentNotifications()
+	d.documentContracts()
+	d.documentAdmin()
+	return d.Spec
+}
+
+func (d *apiDocs) documentChain() {
+	d.Tag("Chains", "Configured chains and service health")
+	d.add("GET", "/api/v1/health", operation{
+		id: "getHealth", tag: "Chains", summary: "Service and database health",
+		data: openapi.Object(map[string]*openapi.Schema{
+			"status":   openapi.Enum("healthy", "unhealthy"),
+			"database": openapi.Enum("connected", "disconnected"),
+		}),
+	})
+	d.add("GET", "/api/v1/chains", operation{
+		id: "listChains", tag: "Chains", summary: "Configured chains",
+		data: openapi.Object(map[string]*openapi.Schema{"chains": openapi.ArrayOf(d.Model(models.Chain{}))}),
+	})
+
+	d.Tag("Blocks", "Indexed blocks")
+	d.add("GET", "/api/v1/blocks", operation{
+		id: "listBlocks", tag: "Blocks", summary: "Blocks, newest first",
+		params: params([]*openapi.Parameter{chainParam}, cursorParams(20, 100)),
+		data:   d.cursorPage(nil, "blocks", d.Model(models.Block{}), nil),
+		errors: []int{400},
+		cached: true,
+	})
+	d.add("GET", "/api/v1/blocks/:id", operation{
+		id: "getBlock", tag: "Blocks", summary: "A block by number or hash",
+		params: []*openapi.Parameter{openapi.PathParam("id", "Block number or hash", openapi.String()), chainParam},
+		data:   d.Model(models.Block{}),
+		errors: []int{404},
+		cached: true,
+	})
+
+	d.Tag("Transactions", "Indexed transactions")
+	d.add("GET", "/api/v1/transactions", operation{
+		id: "listTransactions", tag: "Transactions", summary: "Transactions matching filters, newest first",
+		params: params([]*openapi.Parameter{chainParam}, transactionFilterParams(true), cursorParams(20, 100)),
+		data:   d.cursorPage(nil, "transactions", d.Model(models.Transaction{}), nil),
+		errors: []int{400},
+		cached: true,
+	})
+	d.add("GET", "/api/v1/transactions/:hash", operation{
+		id: "getTransaction", tag: "Transactions", summary: "A transaction with its receipt",
+		params: []*openapi.Parameter{openapi.PathParam("hash", "Transaction hash", openapi.String()), chainParam},
+		data:   d.Model(models.Transaction{}),
+		errors: []int{404},
+		cached: true,
+	})
+	d.add("GET", "/api/v1/transactions/:hash/decoded", operation{
+		id: "decodeTransaction", tag: "Transactions", summary: "A transaction's decoded calldata",
+		description: "Calls to proxies are decoded with the implementation active at the transaction's block.",
+		params:      []*openapi.Parameter{openapi.PathParam("hash", "Transaction hash", openapi.String()), chainParam},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"transaction_hash": openapi.String(),
+			"abi_address":      openapi.String(),
+			"decoded":          d.Model(decoder.DecodedCall{}),
+		}),
+		errors: []int{404, 422},
+	})
+
+	d.Tag("Logs", "Indexed event logs")
+	d.add("GET", "/api/v1/logs", operation{
+		id: "listLogs", tag: "Logs", summary: "Logs matching an eth_getLogs style filter",
+		description: "address and topic0..topic3 take comma separated or repeated values, OR-ed; omitted topics match anything.",
+		params: params([]*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("address", "Emitting contracts", openapi.String()),
+			openapi.QueryParam("topic0", "Event signatures", openapi.String()),
+			openapi.QueryParam("topic1", "First indexed argument", openapi.String()),
+			openapi.QueryParam("topic2", "Second indexed argument", openapi.String()),
+			openapi.QueryParam("topic3", "Third indexed argument", openapi.String()),
+			openapi.QueryParam("block_hash", "Only this block; excludes the block and time ranges", openapi.String()),
+			openapi.QueryParam("from_block", "First block: a number, earliest or latest", openapi.String()),
+			openapi.QueryParam("to_block", "Last block: a number, earliest or latest", openapi.String()),
+			fromTimeParam, toTimeParam, orderParam,
+		}, cursorParams(100, 1000)),
+		data: d.cursorPage(openapi.Object(map[string]*openapi.Schema{
+			"page":     openapi.Integer(),
+			"limit":    openapi.Integer(),
+			"has_more": openapi.Boolean(),
+		}), "logs", d.Model(models.TransactionLog{}), nil),
+		errors: []int{400},
+		cached: true,
+	})
+
+	d.Tag("Search", "Lookups across blocks, transactions, addresses, tokens and labels")
+	d.add("GET", "/api/v1/search", operation{
+		id: "search", tag: "Search", summary: "Look up a hash, address, block number, name, token or label",
+		params: []*openapi.Parameter{
+			openapi.Required(openapi.QueryParam("q", "Query", openapi.String())),
+			anyChainParam,
+			openapi.QueryParam("limit", "Results, up to 20", openapi.WithDefault(openapi.Integer(), maxSearchResults)),
+		},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"query":   openapi.String(),
+			"results": openapi.ArrayOf(d.Model(models.SearchResult{})),
+		}),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/search/autocomplete", operation{
+		id: "autocomplete", tag: "Search", summary: "Token and label suggestions as a query is typed",
+		params: []*openapi.Parameter{
+			openapi.QueryParam("q", "Query", openapi.String()),
+			anyChainParam,
+			openapi.QueryParam("limit", "Suggestions, up to 10", openapi.WithDefault(openapi.Integer(), maxSuggestions)),
+		},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"query":       openapi.String(),
+			"suggestions": openapi.ArrayOf(d.Model(models.SearchResult{})),
+		}),
+	})
+}
+
+func (d *apiDocs) documentActivity() {
+	d.Tag("Addresses", "Balances, activity and holdings of addresses")
+	d.add("GET", "/api/v1/addresses/:address", operation{
+		id: "getAddress", tag: "Addresses", summary: "An address's indexed balance and activity",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		data:   d.Model(models.Address{}),
+		errors: []int{400, 404},
+	})
+	d.add("GET", "/api/v1/addresses/:address/transactions", operation{
+		id: "listAddressTransactions", tag: "Addresses", summary: "An address's transactions",
+		description: "Takes the filters of the transaction listing, with direction and counterparty relative to the address.",
+		params:      params([]*openapi.Parameter{addressPath, chainParam}, transactionFilterParams(false), cursorParams(20, 100)),
+		data:        d.cursorPage(nil, "transactions", d.Model(models.Transaction{}), map[string]*openapi.Schema{"address": openapi.String()}),
+		errors:      []int{400},
+	})
+	d.add("GET", "/api/v1/addresses/:address/tokens", operation{
+		id: "listAddressTokens", tag: "Addresses", summary: "Tokens an address holds with their balances",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		data:   openapi.Object(map[string]*openapi.Schema{"tokens": openapi.ArrayOf(d.Model(models.TokenHolding{}))}),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/addresses/:address/approvals", operation{
+		id: "listAddressApprovals", tag: "Addresses", summary: "Live allowances and operator approvals granted by an address",
+		params: []*openapi.Parameter{addressPath, chainParam, pageParam, limitParam},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"address":   openapi.String(),
+			"approvals": openapi.ArrayOf(d.Model(models.TokenApproval{})),
+		}),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/addresses/:address/summary", operation{
+		id: "getAddressSummary", tag: "Addresses", summary: "Balances, activity, gas spent and top counterparties of an address",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		data:   d.Model(models.AddressSummary{}),
+		errors: []int{400, 404},
+	})
+	d.add("GET", "/api/v1/portfolio/:address", operation{
+		id: "getPortfolio", tag: "Addresses", summary: "An address's summary on every active chain",
+		params: []*openapi.Parameter{addressPath},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"address":   openapi.String(),
+			"chains":    openapi.ArrayOf(d.Model(models.PortfolioChain{})),
+			"tx_counts": d.Model(models.TxCounts{}),
+		}),
+		errors: []int{400},
+	})
+
+	d.Tag("Export", "Bulk downloads")
+	d.add("GET", "/api/v1/export/:dataset", operation{
+		id: "exportDataset", tag: "Export", summary: "Stream a dataset as CSV or NDJSON, oldest first",
+		description: "Exports stop after the number of rows in X-Export-Limit; narrow the range or use the export command for more. " +
+			"internal-transactions holds calls made by contracts and is only recorded on chains that trace calls.",
+		params: params([]*openapi.Parameter{
+			openapi.PathParam("dataset", "Dataset", openapi.Enum(exportDatasetNames()...)),
+			chainParam,
+			openapi.QueryParam("format", "File format", openapi.WithDefault(openapi.Enum("csv", "ndjson"), "csv")),
+			openapi.QueryParam("address", "Only rows involving this address or contract", openapi.String()),
+		}, blockRangeParams()),
+		content: map[string]*openapi.Schema{
+			"text/csv":             openapi.String(),
+			"application/x-ndjson": openapi.String(),
+		},
+		errors: []int{400, 404},
+	})
+}
+
+func (d *apiDocs) documentAnalytics() {
+	d.Tag("Stats", "Chain statistics and rollups")
+	d.add("GET", "/api/v1/stats", operation{
+		id: "getStats", tag: "Stats", summary: "Network totals and throughput",
+		params: []*openapi.Parameter{chainParam},
+		data:   d.Model(database.NetworkStats{}),
+		cached: true,
+	})
+	rollups := func(period string) *openapi.Schema {
+		return openapi.Object(map[string]*openapi.Schema{
+			"period": openapi.Enum(period),
+			"from":   &openapi.Schema{Type: "string", Format: "date-time"},
+			"to":     &openapi.Schema{Type: "string", Format: "date-time"},
+			"stats":  openapi.ArrayOf(d.Model(models.ChainStats{})),
+		})
+	}
+	d.add("GET", "/api/v1/stats/daily", operation{
+		id: "listDailyStats", tag: "Stats", summary: "Daily rollups, by default the last 30 days",
+		params: []*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("from_date", "First day, YYYY-MM-DD", openapi.String()),
+			openapi.QueryParam("to_date", "Last day, YYYY-MM-DD (inclusive)", openapi.String()),
+		},
+		data:   rollups(models.StatsPeriodDay),
+		errors: []int{400},
+		cached: true,
+	})
+	d.add("GET", "/api/v1/stats/hourly", operation{
+		id: "listHourlyStats", tag: "Stats", summary: "Hourly rollups, by default the last 24 hours",
+		params: []*openapi.Parameter{chainParam, fromTimeParam, toTimeParam},
+		data:   rollups(models.StatsPeriodHour),
+		errors: []int{400},
+		cached: true,
+	})
+
+	d.Tag("Gas", "Fee suggestions and history")
+	d.add("GET", "/api/v1/gas", operation{
+		id: "getGas", tag: "Gas", summary: "Slow, standard and fast fee suggestions",
+		params: []*openapi.Parameter{chainParam},
+		data:   d.Model(models.GasEstimate{}),
+		errors: []int{400, 503},
+		cached: true,
+	})
+	d.add("GET", "/api/v1/gas/history", operation{
+		id: "getGasHistory", tag: "Gas", summary: "Base fees and gas prices paid per hour or day",
+		params: []*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("period", "Bucket size", openapi.WithDefault(openapi.Enum(models.StatsPeriodHour, models.StatsPeriodDay), models.StatsPeriodHour)),
+			fromTimeParam, toTimeParam,
+		},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"period":  openapi.Enum(models.StatsPeriodHour, models.StatsPeriodDay),
+			"from":    &openapi.Schema{Type: "string", Format: "date-time"},
+			"to":      &openapi.Schema{Type: "string", Format: "date-time"},
+			"history": openapi.ArrayOf(d.Model(models.GasPricePoint{})),
+		}),
+		errors: []int{400},
+		cached: true,
+	})
+
+	d.Tag("Rankings", "Top accounts, contracts and tokens, refreshed every 5 minutes")
+	ranking := func(key string, item *openapi.Schema, rankings []database.Ranking) (*openapi.Parameter, *openapi.Schema) {
+		names := make([]string, len(rankings))
+		for i, r := range rankings {
+			names[i] = string(r)
+		}
+		sortParam := openapi.QueryParam("sort", "Ranking", openapi.WithDefault(openapi.Enum(names...), names[0]))
+		return sortParam, d.page(key, item, map[string]*openapi.Schema{
+			"sort":         openapi.Enum(names...),
+			"refreshed_at": &openapi.Schema{Type: "string", Format: "date-time", Nullable: true},
+		})
+	}
+	sortParam, data := ranking("accounts", d.Model(models.TopAccount{}), database.AccountRankings)
+	d.add("GET", "/api/v1/accounts/top", operation{
+		id: "listTopAccounts", tag: "Rankings", summary: "Addresses by native balance or transaction count",
+		params: []*openapi.Parameter{chainParam, sortParam, pageParam, limitParam},
+		data:   data,
+		errors: []int{400},
+		cached: true,
+	})
+	sortParam, data = ranking("contracts", d.Model(models.TopContract{}), database.ContractRankings)
+	data.Properties["window"] = openapi.Enum(database.ContractWindows...)
+	d.add("GET", "/api/v1/contracts/top", operation{
+		id: "listTopContracts", tag: "Rankings", summary: "Contracts by transactions, unique callers or gas used",
+		params: []*openapi.Parameter{
+			chainParam, sortParam,
+			openapi.QueryParam("window", "Activity window", openapi.WithDefault(openapi.Enum(database.ContractWindows...), database.ContractWindows[0])),
+			pageParam, limitParam,
+		},
+		data:   data,
+		errors: []int{400},
+		cached: true,
+	})
+	sortParam, data = ranking("tokens", d.Model(models.TopToken{}), database.TokenRankings)
+	d.add("GET", "/api/v1/tokens/top", operation{
+		id: "listTopTokens", tag: "Rankings", summary: "Tokens by holders or transfers",
+		params: []*openapi.Parameter{chainParam, sortParam, pageParam, limitParam},
+		data:   data,
+		errors: []int{400},
+		cached: true,
+	})
+}
+
+func (d *apiDocs) documentTokens() {
+	d.Tag("Tokens", "ERC-20, ERC-721 and ERC-1155 tokens")
+	tokenPath := openapi.PathParam("address", "Token contract", openapi.String())
+	d.add("GET", "/api/v1/tokens", operation{
+		id: "listTokens", tag: "Tokens", summary: "Indexed tokens",
+		params: []*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("type", "Standards, comma separated: ERC20, ERC721 or ERC1155", openapi.String()),
+			openapi.QueryParam("sort", "Sort by first seen, holders or transfers", openapi.Enum("created", "holders", "transfers")),
+			orderParam, pageParam, limitParam,
+		},
+		data:   d.page("tokens", d.Model(models.Token{}), nil),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/tokens/:address", operation{
+		id: "getToken", tag: "Tokens", summary: "A token's metadata and counts",
+		params: []*openapi.Parameter{tokenPath, chainParam},
+		data:   d.Model(models.Token{}),
+		errors: []int{400, 404},
+	})
+	d.add("GET", "/api/v1/tokens/:address/transfers", operation{
+		id: "listTokenTransfers", tag: "Tokens", summary: "A token's transfers",
+		params: params([]*openapi.Parameter{tokenPath, chainParam}, partyParams(true), blockRangeParams(), valueSortParams(), cursorParams(20, 100)),
+		data:   d.cursorPage(d.Model(responses.PageMeta{}), "transfers", d.Model(models.TokenTransfer{}), map[string]*openapi.Schema{"token": openapi.String()}),
+		errors: []int{400, 404},
+	})
+	d.add("GET", "/api/v1/tokens/:address/holders", operation{
+		id: "listTokenHolders", tag: "Tokens", summary: "A token's holders by balance with their share of the supply",
+		params: []*openapi.Parameter{tokenPath, chainParam, pageParam, limitParam},
+		data:   d.page("holders", d.Model(models.TokenBalance{}), map[string]*openapi.Schema{"token": openapi.String()}),
+		errors: []int{400, 404},
+	})
+}
+
+func (d *apiDocs) documentRealtime() {
+	d.Tag("Streams", "Server-sent events of newly indexed data. Each event's id is its chain position; reorgs arrive as reorg events on every stream")
+	sse := func(description string) map[string]*openapi.Schema {
+		return map[string]*openapi.Schema{"text/event-stream": openapi.Describe(openapi.String(), description)}
+	}
+	d.Model(events.Reorg{})
+	d.add("GET", "/api/v1/stream/blocks", operation{
+		id: "streamBlocks", tag: "Streams", summary: "New blocks",
+		params:  params([]*openapi.Parameter{chainParam}, lastEventParam),
+		content: sse("block events carry block_number, hash, timestamp, tx_count and gas_used; reorg events a Reorg"),
+		errors:  []int{400},
+	})
+	d.add("GET", "/api/v1/stream/transactions", operation{
+		id: "streamTransactions", tag: "Streams", summary: "New transactions",
+		params:  params([]*openapi.Parameter{chainParam, openapi.QueryParam("address", "Only transactions from or to this address", openapi.String())}, lastEventParam),
+		content: sse("transaction events carry a Transaction; reorg events a Reorg"),
+		errors:  []int{400},
+	})
+	d.add("GET", "/api/v1/stream/logs", operation{
+		id: "streamLogs", tag: "Streams", summary: "New logs",
+		params: params([]*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("address", "Emitting contracts", openapi.String()),
+			openapi.QueryParam("topic0", "Event signatures", openapi.String()),
+			openapi.QueryParam("topic1", "First indexed argument", openapi.String()),
+			openapi.QueryParam("topic2", "Second indexed argument", openapi.String()),
+			openapi.QueryParam("topic3", "Third indexed argument", openapi.String()),
+		}, lastEventParam),
+		content: sse("log events carry a TransactionLog; reorg events a Reorg"),
+		errors:  []int{400},
+	})
+	d.add("GET", "/api/v1/stream/token-transfers", operation{
+		id: "streamTokenTransfers", tag: "Streams", summary: "New token transfers",
+		params: params([]*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("token", "Only transfers of this token", openapi.String()),
+			openapi.QueryParam("address", "Only transfers from or to this address", openapi.String()),
+		}, lastEventParam),
+		content: sse("token_transfer events carry a TokenTransfer; reorg events a Reorg"),
+		errors:  []int{400},
+	})
+	d.add("GET", "/ws", operation{
+		id: "websocket", tag: "Streams", summary: "WebSocket subscriptions on any configured chain",
+		description: "Upgrades to a WebSocket. Clients send {id, method, params} requests with method subscribe, unsubscribe or ping, and receive notifications tagged with their subscription id. Plain HTTP requests get 426.",
+		content:     map[string]*openapi.Schema{},
+	})
+}
+
+func (d *apiDocs) documentCompat() {
+	d.Tag("Compatibility", "Ethereum JSON-RPC, GraphQL and Etherscan-compatible APIs, which answer in their own formats")
+	rpc := operation{
+		id: "rpc", tag: "Compatibility", summary: "Ethereum JSON-RPC, answered from the index where it can and forwarded to the node otherwise",
+		params:  []*openapi.Parameter{chainParam},
+		body:    openapi.Describe(d.Model(rpcRequest{}), "A request, or a batch of them"),
+		content: map[string]*openapi.Schema{fiber.MIMEApplicationJSON: d.Model(rpcResponse{})},
+	}
+	d.add("POST", "/api/v1/rpc", rpc)
+	rpc.id = "rpcForChain"
+	rpc.params = []*openapi.Parameter{openapi.PathParam("chain_id", "Chain ID", openapi.Integer())}
+	d.add("POST", "/api/v1/rpc/:chain_id", rpc)
+
+	graphQLResponse := openapi.Object(map[string]*openapi.Schema{
+		"data":   {Description: "Query result"},
+		"errors": openapi.ArrayOf(openapi.Object(map[string]*openapi.Schema{"message": openapi.String()})),
+	})
+	graphQLResponse.Required = nil
+	d.add("GET", "/api/v1/graphql", operation{
+		id: "graphQLQuery", tag: "Compatibility", summary: "Run a GraphQL query passed in the query string",
+		params: []*openapi.Parameter{
+			openapi.Required(openapi.QueryParam("query", "GraphQL document", openapi.String())),
+			openapi.QueryParam("operationName", "Operation to run", openapi.String()),
+			openapi.QueryParam("variables", "Variables as JSON", openapi.String()),
+		},
+		content: map[string]*openapi.Schema{fiber.MIMEApplicationJSON: graphQLResponse},
+	})
+	d.add("POST", "/api/v1/graphql", operation{
+		id: "graphQL", tag: "Compatibility", summary: "Run a GraphQL query",
+		body:    d.Model(graphQLRequest{}),
+		content: map[string]*openapi.Schema{fiber.MIMEApplicationJSON: graphQLResponse},
+	})
+
+	etherscan := operation{
+		tag: "Compatibility", summary: "Etherscan-compatible API, as used by Hardhat and Foundry",
+		description: "Supports account balance, txlist and tokentx; block getblockreward and getblocknobytime; logs getLogs; contract getabi, getsourcecode, verifysourcecode and checkverifystatus. Parameters may also be sent as a form body.",
+		params: []*openapi.Parameter{
+			openapi.Required(openapi.QueryParam("module", "Module", openapi.Enum("account", "block", "logs", "contract"))),
+			openapi.Required(openapi.QueryParam("action", "Action within the module", openapi.String())),
+			openapi.QueryParam("chainid", "Chain ID", openapi.WithDefault(openapi.Integer(), 1337)),
+		},
+		content: map[string]*openapi.Schema{fiber.MIMEApplicationJSON: d.Model(etherscanResponse{})},
+	}
+	etherscan.id = "etherscan"
+	d.add("GET", "/api", etherscan)
+	etherscan.id = "etherscanForm"
+	d.add("POST", "/api", etherscan)
+}
+
+func (d *apiDocs) documentNotifications() {
+	deleted := func(key string) *openapi.Schema {
+		return openapi.Object(map[string]*openapi.Schema{"id": openapi.Integer(), key: openapi.Boolean()})
+	}
+
+	d.Tag("Webhooks", "Signed HTTP callbacks for matching block activity and alerts")
+	webhook := d.Model(models.Webhook{})
+	d.add("POST", "/api/v1/webhooks", operation{
+		id: "createWebhook", tag: "Webhooks", summary: "Register a webhook; the response carries its signing secret",
+		body:     d.Model(createWebhookRequest{}),
+		data:     webhook,
+		errors:   []int{400, 403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/webhooks", operation{
+		id: "listWebhooks", tag: "Webhooks", summary: "Registered webhooks",
+		params:   []*openapi.Parameter{pageParam, limitParam},
+		data:     d.page("webhooks", webhook, nil),
+		errors:   []int{403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/webhooks/:id", operation{
+		id: "getWebhook", tag: "Webhooks", summary: "A webhook",
+		params:   []*openapi.Parameter{idPath},
+		data:     webhook,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("PATCH", "/api/v1/webhooks/:id", operation{
+		id: "updateWebhook", tag: "Webhooks", summary: "Pause or resume a webhook",
+		params:   []*openapi.Parameter{idPath},
+		body:     d.Model(updateWebhookRequest{}),
+		data:     webhook,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/webhooks/:id", operation{
+		id: "deleteWebhook", tag: "Webhooks", summary: "Delete a webhook",
+		params:   []*openapi.Parameter{idPath},
+		data:     deleted("deleted"),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/webhooks/:id/deliveries", operation{
+		id: "listWebhookDeliveries", tag: "Webhooks", summary: "A webhook's delivery log, newest first",
+		params: []*openapi.Parameter{
+			idPath,
+			openapi.QueryParam("status", "Only deliveries in this state", openapi.Enum(models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead, models.WebhookDeliveryCancelled)),
+			pageParam, limitParam,
+		},
+		data:     d.page("deliveries", d.Model(models.WebhookDelivery{}), map[string]*openapi.Schema{"webhook_id": openapi.Integer()}),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/webhooks/:id/dead-letters", operation{
+		id: "listWebhookDeadLetters", tag: "Webhooks", summary: "Deliveries that failed every attempt",
+		params:   []*openapi.Parameter{idPath, pageParam, limitParam},
+		data:     d.list(d.Model(responses.PageMeta{}), "dead_letters", d.Model(models.WebhookDeadLetter{}), map[string]*openapi.Schema{"webhook_id": openapi.Integer()}),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("POST", "/api/v1/webhooks/:id/deliveries/:delivery_id/redeliver", operation{
+		id: "redeliverWebhookDelivery", tag: "Webhooks", summary: "Queue a delivery to be sent again with fresh attempts",
+		params:   []*openapi.Parameter{idPath, openapi.PathParam("delivery_id", "Delivery ID", openapi.Integer())},
+		data:     d.Model(models.WebhookDelivery{}),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+
+	d.Tag("Alerts", "Rules evaluated against the index, notified through webhooks")
+	rule := d.Model(models.AlertRule{})
+	d.add("POST", "/api/v1/alerts/rules", operation{
+		id: "createAlertRule", tag: "Alerts", summary: "Create an alert rule",
+		body:     d.Model(createAlertRuleRequest{}),
+		data:     rule,
+		errors:   []int{400, 403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/alerts/rules", operation{
+		id: "listAlertRules", tag: "Alerts", summary: "Alert rules",
+		params: []*openapi.Parameter{
+			anyChainParam,
+			openapi.QueryParam("state", "Only rules in this state", openapi.Enum(models.AlertStateOK, models.AlertStateFiring)),
+			pageParam, limitParam,
+		},
+		data:   d.page("rules", rule, nil),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/alerts/rules/:id", operation{
+		id: "getAlertRule", tag: "Alerts", summary: "An alert rule",
+		params: []*openapi.Parameter{idPath},
+		data:   rule,
+		errors: []int{400, 404},
+	})
+	d.add("PATCH", "/api/v1/alerts/rules/:id", operation{
+		id: "updateAlertRule", tag: "Alerts", summary: "Enable or disable a rule; disabling clears a firing rule",
+		params:   []*openapi.Parameter{idPath},
+		body:     d.Model(updateAlertRuleRequest{}),
+		data:     rule,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/alerts/rules/:id", operation{
+		id: "deleteAlertRule", tag: "Alerts", summary: "Delete an alert rule",
+		params:   []*openapi.Parameter{idPath},
+		data:     deleted("deleted"),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/alerts/rules/:id/events", operation{
+		id: "listAlertEvents", tag: "Alerts", summary: "A rule's firing and resolution history, newest first",
+		params: []*openapi.Parameter{idPath, pageParam, limitParam},
+		data:   d.list(d.Model(responses.PageMeta{}), "events", d.Model(models.AlertEvent{}), map[string]*openapi.Schema{"rule_id": openapi.Integer()}),
+		errors: []int{400, 404},
+	})
+
+	d.Tag("Labels", "Address labels shown next to addresses across the API")
+	label := d.Model(models.AddressLabel{})
+	d.add("POST", "/api/v1/labels", operation{
+		id: "createLabel", tag: "Labels", summary: "Label an address",
+		body:     d.Model(labelRequest{}),
+		data:     label,
+		errors:   []int{400, 403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/labels", operation{
+		id: "listLabels", tag: "Labels", summary: "A chain's labels",
+		params: []*openapi.Parameter{
+			chainParam,
+			openapi.QueryParam("address", "Only this address's labels", openapi.String()),
+			openapi.QueryParam("label", "Only this label", openapi.String()),
+			pageParam, limitParam,
+		},
+		data:   d.page("labels", label, nil),
+		errors: []int{400},
+	})
+	d.add("GET", "/api/v1/labels/:id", operation{
+		id: "getLabel", tag: "Labels", summary: "A label",
+		params: []*openapi.Parameter{idPath},
+		data:   label,
+		errors: []int{400, 404},
+	})
+	d.add("PATCH", "/api/v1/labels/:id", operation{
+		id: "updateLabel", tag: "Labels", summary: "Rename a label or change its description",
+		params:   []*openapi.Parameter{idPath},
+		body:     d.Model(labelRequest{}),
+		data:     label,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/labels/:id", operation{
+		id: "deleteLabel", tag: "Labels", summary: "Delete a label",
+		params:   []*openapi.Parameter{idPath},
+		data:     deleted("deleted"),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+
+	d.Tag("Watchlists", "Named sets of addresses with a combined activity feed")
+	watchlist := d.Model(models.Watchlist{})
+	d.add("POST", "/api/v1/watchlists", operation{
+		id: "createWatchlist", tag: "Watchlists", summary: "Create a watchlist",
+		body:     d.Model(watchlistRequest{}),
+		data:     watchlist,
+		errors:   []int{400, 403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/watchlists", operation{
+		id: "listWatchlists", tag: "Watchlists", summary: "Watchlists",
+		params: []*openapi.Parameter{anyChainParam, pageParam, limitParam},
+		data:   d.page("watchlists", watchlist, nil),
+	})
+	d.add("GET", "/api/v1/watchlists/:id", operation{
+		id: "getWatchlist", tag: "Watchlists", summary: "A watchlist with its labelled addresses",
+		params: []*openapi.Parameter{idPath},
+		data:   watchlist,
+		errors: []int{400, 404},
+	})
+	d.add("PATCH", "/api/v1/watchlists/:id", operation{
+		id: "updateWatchlist", tag: "Watchlists", summary: "Rename a watchlist or change its description",
+		params:   []*openapi.Parameter{idPath},
+		body:     d.Model(watchlistRequest{}),
+		data:     watchlist,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/watchlists/:id", operation{
+		id: "deleteWatchlist", tag: "Watchlists", summary: "Delete a watchlist",
+		params:   []*openapi.Parameter{idPath},
+		data:     deleted("deleted"),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("POST", "/api/v1/watchlists/:id/addresses", operation{
+		id: "addWatchlistAddresses", tag: "Watchlists", summary: "Add addresses to a watchlist",
+		params:   []*openapi.Parameter{idPath},
+		body:     d.Model(watchlistRequest{}),
+		data:     watchlist,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/watchlists/:id/addresses/:address", operation{
+		id: "removeWatchlistAddress", tag: "Watchlists", summary: "Remove an address from a watchlist",
+		params: []*openapi.Parameter{idPath, addressPath},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"id":      openapi.Integer(),
+			"address": openapi.String(),
+			"removed": openapi.Boolean(),
+		}),
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/watchlists/:id/activity", operation{
+		id: "listWatchlistActivity", tag: "Watchlists", summary: "Transactions and token transfers involving any member, newest first",
+		params: []*openapi.Parameter{idPath, pageParam, limitParam},
+		data:   d.list(d.Model(responses.PageMeta{}), "activity", d.Model(models.WatchlistActivity{}), map[string]*openapi.Schema{"watchlist_id": openapi.Integer()}),
+		errors: []int{400, 404},
+	})
+}
+
+func (d *apiDocs) documentContracts() {
+	d.Tag("Contracts", "Proxies and ABIs")
+	d.add("GET", "/api/v1/contracts/:address/proxy", operation{
+		id: "getProxy", tag: "Contracts", summary: "A proxy and its implementation history",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		data: openapi.Object(map[string]*openapi.Schema{
+			"proxy":           d.Model(models.ProxyContract{}),
+			"implementations": openapi.ArrayOf(d.Model(models.ProxyImplementation{})),
+		}),
+		errors: []int{400, 404},
+	})
+	d.add("GET", "/api/v1/contracts/:address/abi", operation{
+		id: "getABI", tag: "Contracts", summary: "A contract's ABI",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		data:   d.Model(models.ContractABI{}),
+		errors: []int{400, 404},
+	})
+	d.add("POST", "/api/v1/contracts/:address/abi", operation{
+		id: "uploadABI", tag: "Contracts", summary: "Store a contract's ABI for decoding",
+		params: []*openapi.Parameter{addressPath, chainParam},
+		body:   d.Model(uploadABIRequest{}),
+		data:   d.Model(models.ContractABI{}),
+		errors: []int{400},
+	})
+
+	d.Tag("Docs", "This document")
+	d.add("GET", "/api/v1/openapi.json", operation{
+		id: "getOpenAPI", tag: "Docs", summary: "The OpenAPI document",
+		content: map[string]*openapi.Schema{fiber.MIMEApplicationJSON: {Type: "object"}},
+	})
+	d.add("GET", "/api/v1/docs", operation{
+		id: "getDocs", tag: "Docs", summary: "The API reference",
+		content: map[string]*openapi.Schema{fiber.MIMETextHTML: openapi.String()},
+	})
+}
+
+func (d *apiDocs) documentAdmin() {
+	d.Tag("Admin", "API key management, authenticated with the admin token")
+	key := d.Model(models.APIKey{})
+	d.add("GET", "/api/v1/admin/tiers", operation{
+		id: "listAPITiers", tag: "Admin", summary: "Tiers with their rate limits and daily quotas",
+		data:     openapi.Object(map[string]*openapi.Schema{"tiers": openapi.ArrayOf(d.Model(models.APITier{}))}),
+		errors:   []int{403},
+		security: adminSecurity,
+	})
+	d.add("POST", "/api/v1/admin/api-keys", operation{
+		id: "createAPIKey", tag: "Admin", summary: "Issue an API key; the response is the only time the key is shown",
+		body:     d.Model(createAPIKeyRequest{}),
+		data:     key,
+		errors:   []int{400, 403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/admin/api-keys", operation{
+		id: "listAPIKeys", tag: "Admin", summary: "API keys, newest first, revoked ones included",
+		params:   []*openapi.Parameter{pageParam, limitParam},
+		data:     d.page("api_keys", key, nil),
+		errors:   []int{403},
+		security: adminSecurity,
+	})
+	d.add("GET", "/api/v1/admin/api-keys/:id", operation{
+		id: "getAPIKey", tag: "Admin", summary: "An API key",
+		params:   []*openapi.Parameter{idPath},
+		data:     key,
+		errors:   []int{400, 403, 404},
+		security: adminSecurity,
+	})
+	d.add("DELETE", "/api/v1/admin/api-keys/:id", operation{
+		id: "revokeAPIKey", tag: "Admin", summary: "Revoke an API key",
+		description: "Takes effect at once on the server that handles the request and within a minute on others.",
+		params:      []*openapi.Parameter{idPath},
+		data:        key,
+		errors:      []int{400, 403, 404},
+		security:    adminSecurity,
+	})
+}
+
+// exportDatasetNames are the datasets' URL names, sorted.
+func exportDatasetNames() []string {
+	names := make([]string, 0, len(export.Datasets))
+	for name := range export.Datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrorCodesDocumented checks every code the handlers and middleware
+// answer with is in ErrorCodes, which the API document lists.
+func TestErrorCodesDocumented(t *testing.T) {
+	call := regexp.MustCompile(`responses\.Error\(c, [^,]+, "([A-Z_]+)"`)
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	middleware, err := filepath.Glob("../middleware/*.go")
+	require.NoError(t, err)
+	files = append(files, middleware...)
+
+	found := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		require.NoError(t, err)
+		for _, m := range call.FindAllStringSubmatch(string(src), -1) {
+			found++
+			assert.Contains(t, ErrorCodes, m[1], "%s answers with an undocumented code", file)
+		}
+	}
+	assert.NotZero(t, found)
+}
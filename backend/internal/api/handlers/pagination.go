@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// listPage is a parsed list request. Lists are keyset-paginated with the
+// opaque cursors returned in the previous response; passing page instead
+// selects the legacy offset mode.
+type listPage struct {
+	limit  int
+	page   int
+	cursor *database.Cursor
+}
+
+func parseListPage(c *fiber.Ctx, defaultLimit, maxLimit int) (*listPage, error) {
+	p := &listPage{limit: c.QueryInt("limit", defaultLimit)}
+	if p.limit < 1 {
+		p.limit = defaultLimit
+	}
+	if p.limit > maxLimit {
+		p.limit = maxLimit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := database.DecodeCursor(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.cursor = cursor
+	} else if c.Query("page") != "" {
+		p.page = c.QueryInt("page", 1)
+		if p.page < 1 {
+			p.page = 1
+		}
+	}
+	return p, nil
+}
+
+func (p *listPage) legacy() bool {
+	return p.page > 0
+}
+
+func (p *listPage) offset() int {
+	return (p.page - 1) * p.limit
+}
+
+// after and before are the keyset bounds for the query. Queries fetch one row
+// beyond the limit so paginate can tell whether another page exists.
+func (p *listPage) after() *database.Position {
+	if p.cursor == nil || p.cursor.Backward {
+		return nil
+	}
+	return &p.cursor.Position
+}
+
+func (p *listPage) before() *database.Position {
+	if p.cursor == nil || !p.cursor.Backward {
+		return nil
+	}
+	return &p.cursor.Position
+}
+
+// paginate trims the extra row fetched beyond the limit and builds the
+// cursors of the neighbouring pages. rows must be in listing order.
+func paginate[T any](p *listPage, rows []T, position func(T) database.Position) ([]T, responses.PaginationMeta) {
+	meta := responses.PaginationMeta{Limit: p.limit}
+	more := len(rows) > p.limit
+	backward := p.cursor != nil && p.cursor.Backward
+	if more {
+		if backward {
+			// The extra row lies beyond the start of the page
+			rows = rows[len(rows)-p.limit:]
+		} else {
+			rows = rows[:p.limit]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, meta
+	}
+
+	if (backward && more) || (!backward && p.cursor != nil) {
+		prev := database.Cursor{Position: position(rows[0]), Backward: true}.Encode()
+		meta.PrevCursor = &prev
+	}
+	if (!backward && more) || backward {
+		next := database.Cursor{Position: position(rows[len(rows)-1])}.Encode()
+		meta.NextCursor = &next
+	}
+	return rows, meta
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func position(n int) database.Position {
+	return database.Position{BlockNumber: int64(n)}
+}
+
+func decode(t *testing.T, cursor *string) *database.Cursor {
+	require.NotNil(t, cursor)
+	c, err := database.DecodeCursor(*cursor)
+	require.NoError(t, err)
+	return c
+}
+
+func TestPaginateFirstPage(t *testing.T) {
+	rows, meta := paginate(&listPage{limit: 3}, []int{10, 9, 8, 7}, position)
+	assert.Equal(t, []int{10, 9, 8}, rows)
+	assert.Nil(t, meta.PrevCursor)
+	assert.Equal(t, database.Cursor{Position: position(8)}, *decode(t, meta.NextCursor))
+}
+
+func TestPaginateLastPage(t *testing.T) {
+	p := &listPage{limit: 3, cursor: &database.Cursor{Position: position(8)}}
+	rows, meta := paginate(p, []int{7, 6}, position)
+	assert.Equal(t, []int{7, 6}, rows)
+	assert.Nil(t, meta.NextCursor)
+	assert.Equal(t, database.Cursor{Position: position(7), Backward: true}, *decode(t, meta.PrevCursor))
+}
+
+func TestPaginateBackward(t *testing.T) {
+	// Paging back from 6 with rows 10..7 above it; 10 is the look-ahead row
+	p := &listPage{limit: 3, cursor: &database.Cursor{Position: position(6), Backward: true}}
+	rows, meta := paginate(p, []int{10, 9, 8, 7}, position)
+	assert.Equal(t, []int{9, 8, 7}, rows)
+	assert.Equal(t, database.Cursor{Position: position(9), Backward: true}, *decode(t, meta.PrevCursor))
+	assert.Equal(t, database.Cursor{Position: position(7)}, *decode(t, meta.NextCursor))
+
+	// Reaching the newest rows leaves no previous page
+	rows, meta = paginate(p, []int{8, 7}, position)
+	assert.Equal(t, []int{8, 7}, rows)
+	assert.Nil(t, meta.PrevCursor)
+	assert.NotNil(t, meta.NextCursor)
+}
+
+func TestPaginationMetaShapes(t *testing.T) {
+	// Legacy pages always report their totals, even when empty
+	legacy, err := json.Marshal(responses.PaginationMeta{Page: 1, Limit: 20})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"page":1,"limit":20,"total":0,"total_pages":0}`, string(legacy))
+
+	_, meta := paginate(&listPage{limit: 20}, []int{}, position)
+	keyset, err := json.Marshal(meta)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limit":20}`, string(keyset))
+
+	_, meta = paginate(&listPage{limit: 1}, []int{10, 9}, position)
+	keyset, err = json.Marshal(meta)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limit":1,"next_cursor":"`+*meta.NextCursor+`"}`, string(keyset))
+}
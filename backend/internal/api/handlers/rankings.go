@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// RankingHandler serves the top lists. They are read from materialized views
+// the indexer refreshes every few minutes, so they lag live data slightly;
+// refreshed_at says by how much.
+type RankingHandler struct {
+	db *database.DB
+}
+
+func NewRankingHandler(db *database.DB) *RankingHandler {
+	return &RankingHandler{db: db}
+}
+
+// GetTopAccounts ranks addresses by native balance or transaction count.
+func (h *RankingHandler) GetTopAccounts(c *fiber.Ctx) error {
+	filter, err := parseRanking(c, database.AccountRankings)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	ranked, err := h.db.GetTopAccounts(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch top accounts", err.Error())
+	}
+	attachLabels(c.Context(), h.db, filter.ChainID, ranked.Items)
+	return rankingResponse(c, filter, "accounts", ranked.Items, ranked.Total, ranked.RefreshedAt)
+}
+
+// GetTopContracts ranks contracts by transactions, unique callers or gas
+// consumed over the last 24h, 7d or 30d.
+func (h *RankingHandler) GetTopContracts(c *fiber.Ctx) error {
+	filter, err := parseRanking(c, database.ContractRankings)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	filter.Window = c.Query("window", database.ContractWindows[0])
+	if !slices.Contains(database.ContractWindows, filter.Window) {
+		return responses.Error(c, 400, "INVALID_FILTER",
+			"window must be "+strings.Join(database.ContractWindows, ", "), filter.Window)
+	}
+	ranked, err := h.db.GetTopContracts(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch top contracts", err.Error())
+	}
+	attachLabels(c.Context(), h.db, filter.ChainID, ranked.Items)
+	return rankingResponse(c, filter, "contracts", ranked.Items, ranked.Total, ranked.RefreshedAt)
+}
+
+// GetTopTokens ranks tokens by holders or transfers.
+func (h *RankingHandler) GetTopTokens(c *fiber.Ctx) error {
+	filter, err := parseRanking(c, database.TokenRankings)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	ranked, err := h.db.GetTopTokens(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch top tokens", err.Error())
+	}
+	for _, token := range ranked.Items {
+		token.TotalSupplyFormatted = formatUnits(token.TotalSupply, token.Decimals)
+	}
+	return rankingResponse(c, filter, "tokens", ranked.Items, ranked.Total, ranked.RefreshedAt)
+}
+
+// parseRanking reads chain_id, page, limit and sort, which defaults to the
+// first of the supported rankings.
+func parseRanking(c *fiber.Ctx, supported []database.Ranking) (*database.RankingFilter, error) {
+	page, limit := pageParams(c)
+	filter := &database.RankingFilter{
+		ChainID: int64(c.QueryInt("chain_id", 1337)),
+		By:      database.Ranking(c.Query("sort", string(supported[0]))),
+		Limit:   limit,
+		Offset:  (page - 1) * limit,
+	}
+	if !slices.Contains(supported, filter.By) {
+		names := make([]string, len(supported))
+		for i, r := range supported {
+			names[i] = string(r)
+		}
+		return nil, fmt.Errorf("sort must be %s", strings.Join(names, ", "))
+	}
+	return filter, nil
+}
+
+func rankingResponse(c *fiber.Ctx, filter *database.RankingFilter, key string, items interface{}, total int64, refreshedAt *time.Time) error {
+	data := fiber.Map{
+		"sort":         filter.By,
+		key:            items,
+		"refreshed_at": refreshedAt,
+		"pagination":   pageMeta(filter.Offset/filter.Limit+1, filter.Limit, total),
+	}
+	if filter.Window != "" {
+		data["window"] = filter.Window
+	}
+	httpcache.For(c, httpcache.RollupTTL)
+	return responses.Success(c, data, &filter.ChainID)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRanking(t *testing.T) {
+	parse := func(query string, supported []database.Ranking) (*database.RankingFilter, error) {
+		app := fiber.New()
+		var filter *database.RankingFilter
+		var parseErr error
+		app.Get("/", func(c *fiber.Ctx) error {
+			filter, parseErr = parseRanking(c, supported)
+			return nil
+		})
+		_, err := app.Test(httptest.NewRequest("GET", "/?"+query, nil))
+		require.NoError(t, err)
+		return filter, parseErr
+	}
+
+	filter, err := parse("chain_id=5&page=3&limit=10", database.AccountRankings)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), filter.ChainID)
+	assert.Equal(t, database.RankByBalance, filter.By)
+	assert.Equal(t, 20, filter.Offset)
+
+	filter, err = parse("sort=gas_used", database.ContractRankings)
+	require.NoError(t, err)
+	assert.Equal(t, database.RankByGasUsed, filter.By)
+
+	_, err = parse("sort=balance", database.TokenRankings)
+	assert.EqualError(t, err, "sort must be holders, transfers")
+}
@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcLimitExceeded  = -32005
+
+	maxRPCBatchSize = 100
+	maxRPCLogs      = 10000
+)
+
+// proxiedRPCMethods are the read-only methods, plus eth_sendRawTransaction,
+// that may be forwarded to the node. Everything else is rejected, above all
+// the methods that use the node's own accounts (eth_accounts, eth_sign*,
+// eth_sendTransaction), which dev nodes run unlocked.
+var proxiedRPCMethods = map[string]bool{
+	"eth_blockNumber":                         true,
+	"eth_call":                                true,
+	"eth_chainId":                             true,
+	"eth_estimateGas":                         true,
+	"eth_feeHistory":                          true,
+	"eth_gasPrice":                            true,
+	"eth_getBalance":                          true,
+	"eth_getBlockByHash":                      true,
+	"eth_getBlockByNumber":                    true,
+	"eth_getBlockReceipts":                    true,
+	"eth_getBlockTransactionCountByHash":      true,
+	"eth_getBlockTransactionCountByNumber":    true,
+	"eth_getCode":                             true,
+	"eth_getLogs":                             true,
+	"eth_getProof":                            true,
+	"eth_getStorageAt":                        true,
+	"eth_getTransactionByBlockHashAndIndex":   true,
+	"eth_getTransactionByBlockNumberAndIndex": true,
+	"eth_getTransactionByHash":                true,
+	"eth_getTransactionCount":                 true,
+	"eth_getTransactionReceipt":               true,
+	"eth_maxPriorityFeePerGas":                true,
+	"eth_sendRawTransaction":                  true,
+	"eth_syncing":                             true,
+	"net_listening":                           true,
+	"net_version":                             true,
+	"web3_clientVersion":                      true,
+	"web3_sha3":                               true,
+}
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// errNotIndexed signals that the index cannot answer a request and it should
+// be forwarded to the node instead.
+var errNotIndexed = errors.New("not indexed")
+
+type RPCHandler struct {
+	db           *database.DB
+	chainManager *blockchain.ChainManager
+	logger       *zap.SugaredLogger
+}
+
+func NewRPCHandler(db *database.DB, chainManager *blockchain.ChainManager, logger *zap.Logger) *RPCHandler {
+	return &RPCHandler{
+		db:           db,
+		chainManager: chainManager,
+		logger:       logger.Sugar(),
+	}
+}
+
+// Handle serves Ethereum JSON-RPC. Read methods covered by the index are
+// answered from Postgres; everything else, and anything the indexer hasn't
+// reached yet, is forwarded to the chain's RPC endpoint.
+func (h *RPCHandler) Handle(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	if param := c.Params("chain_id"); param != "" {
+		id, err := c.ParamsInt("chain_id")
+		if err != nil {
+			return c.JSON(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcInvalidRequest, Message: "invalid chain id"}})
+		}
+		chainID = int64(id)
+	}
+
+	body := bytes.TrimSpace(c.Body())
+	if len(body) > 0 && body[0] == '[' {
+		var batch []rpcRequest
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return c.JSON(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: "parse error"}})
+		}
+		if len(batch) == 0 || len(batch) > maxRPCBatchSize {
+			return c.JSON(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcInvalidRequest, Message: fmt.Sprintf("batch must contain 1 to %d requests", maxRPCBatchSize)}})
+		}
+		results := make([]rpcResponse, len(batch))
+		for i := range batch {
+			results[i] = h.dispatch(c.Context(), chainID, &batch[i])
+		}
+		return c.JSON(results)
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return c.JSON(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: "parse error"}})
+	}
+	return c.JSON(h.dispatch(c.Context(), chainID, &req))
+}
+
+func (h *RPCHandler) dispatch(ctx context.Context, chainID int64, req *rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+		return resp
+	}
+
+	result, err := h.serveFromIndex(ctx, chainID, req)
+	if errors.Is(err, errNotIndexed) {
+		result, err = h.forward(ctx, chainID, req)
+	}
+	if err != nil {
+		var rerr *rpcError
+		if errors.As(err, &rerr) {
+			resp.Error = rerr
+		} else {
+			h.logger.Warnw("RPC request failed", "chain_id", chainID, "method", req.Method, "error", err)
+			resp.Error = &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func (h *RPCHandler) serveFromIndex(ctx context.Context, chainID int64, req *rpcRequest) (interface{}, error) {
+	switch req.Method {
+	case "eth_chainId":
+		return encodeUint(uint64(chainID)), nil
+
+	case "eth_blockNumber":
+		block, err := h.db.GetLatestBlock(ctx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errNotIndexed
+		}
+		return encodeUint(uint64(block.BlockNumber)), nil
+
+	case "eth_getBlockByNumber":
+		var tag string
+		var fullTx bool
+		if err := decodeParams(req.Params, &tag, &fullTx); err != nil {
+			return nil, err
+		}
+		number, err := h.resolveBlockTag(ctx, chainID, tag)
+		if err != nil {
+			return nil, err
+		}
+		block, err := h.db.GetBlockByNumber(ctx, chainID, number)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errNotIndexed
+		}
+		return h.rpcBlock(ctx, block, fullTx)
+
+	case "eth_getBlockByHash":
+		var hash string
+		var fullTx bool
+		if err := decodeParams(req.Params, &hash, &fullTx); err != nil {
+			return nil, err
+		}
+		block, err := h.db.GetBlockByHash(ctx, chainID, common.HexToHash(hash).Hex())
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errNotIndexed
+		}
+		return h.rpcBlock(ctx, block, fullTx)
+
+	case "eth_getTransactionByHash":
+		var hash string
+		if err := decodeParams(req.Params, &hash); err != nil {
+			return nil, err
+		}
+		tx, err := h.db.GetTransactionByHash(ctx, chainID, common.HexToHash(hash).Hex())
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil {
+			return nil, errNotIndexed
+		}
+		if result := rpcTransaction(tx); result != nil {
+			return result, nil
+		}
+		return nil, errNotIndexed
+
+	case "eth_getTransactionReceipt":
+		var hash string
+		if err := decodeParams(req.Params, &hash); err != nil {
+			return nil, err
+		}
+		tx, err := h.db.GetTransactionByHash(ctx, chainID, common.HexToHash(hash).Hex())
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil || tx.Status == nil {
+			return nil, errNotIndexed
+		}
+		logs, err := h.db.GetLogsByTransaction(ctx, chainID, tx.Hash)
+		if err != nil {
+			return nil, err
+		}
+		return rpcReceipt(tx, logs), nil
+
+	case "eth_getLogs":
+		var criteria rpcFilter
+		if err := decodeParams(req.Params, &criteria); err != nil {
+			return nil, err
+		}
+		return h.getLogs(ctx, chainID, &criteria)
+	}
+	return nil, errNotIndexed
+}
+
+func (h *RPCHandler) forward(ctx context.Context, chainID int64, req *rpcRequest) (interface{}, error) {
+	if !proxiedRPCMethods[req.Method] {
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+	}
+
+	client, err := h.chainManager.GetClient(chainID)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+	}
+	result, err := client.CallRaw(ctx, req.Method, req.Params)
+	if err != nil {
+		var upstream rpc.Error
+		if errors.As(err, &upstream) {
+			rerr := &rpcError{Code: upstream.ErrorCode(), Message: upstream.Error()}
+			var dataErr rpc.DataError
+			if errors.As(err, &dataErr) {
+				rerr.Data = dataErr.ErrorData()
+			}
+			return nil, rerr
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveBlockTag maps a block number or tag onto an indexed block number.
+// "pending" can't be served from the index and is forwarded.
+func (h *RPCHandler) resolveBlockTag(ctx context.Context, chainID int64, tag string) (int64, error) {
+	var head int64
+	switch tag {
+	case "", "latest", "safe", "finalized":
+		var err error
+		if head, err = h.indexedHead(ctx, chainID); err != nil {
+			return 0, err
+		}
+	}
+	return blockTagNumber(tag, head)
+}
+
+// blockTagNumber resolves a block number or tag against the indexed head.
+// Blocks deeper than any reorg the indexer follows count as safe and
+// finalized.
+func blockTagNumber(tag string, head int64) (int64, error) {
+	switch tag {
+	case "earliest":
+		return 0, nil
+	case "pending":
+		return 0, errNotIndexed
+	case "", "latest":
+		return head, nil
+	case "safe", "finalized":
+		return max(head-indexer.MaxReorgDepth, 0), nil
+	}
+	n, err := hexToUint(tag)
+	if err != nil {
+		return 0, &rpcError{Code: rpcInvalidParams, Message: "invalid block number: " + tag}
+	}
+	return int64(n), nil
+}
+
+func (h *RPCHandler) indexedHead(ctx context.Context, chainID int64) (int64, error) {
+	block, err := h.db.GetLatestBlock(ctx, chainID)
+	if err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, errNotIndexed
+	}
+	return block.BlockNumber, nil
+}
+
+func (h *RPCHandler) rpcBlock(ctx context.Context, block *models.Block, fullTx bool) (map[string]interface{}, error) {
+	txs, err := h.db.GetTransactionsByBlock(ctx, block.ChainID, block.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	// The block row exists but its transactions aren't all written yet
+	if len(txs) != block.TxCount {
+		return nil, errNotIndexed
+	}
+
+	bloom, ok := blockBloom(txs)
+	if !ok {
+		return nil, errNotIndexed
+	}
+	transactions := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		if !fullTx {
+			transactions[i] = tx.Hash
+			continue
+		}
+		encoded := rpcTransaction(tx)
+		if encoded == nil {
+			return nil, errNotIndexed
+		}
+		transactions[i] = encoded
+	}
+
+	result := map[string]interface{}{
+		"number":           encodeUint(uint64(block.BlockNumber)),
+		"hash":             block.Hash,
+		"parentHash":       block.ParentHash,
+		"nonce":            stringOr(block.Nonce, "0x0000000000000000"),
+		"sha3Uncles":       stringOr(block.Sha3Uncles, common.Hash{}.Hex()),
+		"miner":            block.Miner,
+		"stateRoot":        stringOr(block.StateRoot, common.Hash{}.Hex()),
+		"transactionsRoot": stringOr(block.TransactionsRoot, common.Hash{}.Hex()),
+		"receiptsRoot":     stringOr(block.ReceiptsRoot, common.Hash{}.Hex()),
+		"difficulty":       encodeDecimal(block.Difficulty),
+		"size":             encodeUint(uint64(int64OrZero(block.Size))),
+		"gasLimit":         encodeUint(uint64(block.GasLimit)),
+		"gasUsed":          encodeUint(uint64(block.GasUsed)),
+		"timestamp":        encodeUint(uint64(block.Timestamp.Unix())),
+		"extraData":        stringOr(block.ExtraData, "0x"),
+		"mixHash":          stringOr(block.MixHash, common.Hash{}.Hex()),
+		"logsBloom":        bloom,
+		"transactions":     transactions,
+		"uncles":           []string{},
+	}
+	if block.TotalDifficulty != nil {
+		result["totalDifficulty"] = encodeDecimal(block.TotalDifficulty)
+	}
+	if block.BaseFeePerGas != nil {
+		result["baseFeePerGas"] = encodeDecimal(block.BaseFeePerGas)
+	}
+	return result, nil
+}
+
+type rpcFilter struct {
+	FromBlock string            `json:"fromBlock"`
+	ToBlock   string            `json:"toBlock"`
+	BlockHash string            `json:"blockHash"`
+	Address   json.RawMessage   `json:"address"`
+	Topics    []json.RawMessage `json:"topics"`
+}
+
+func (h *RPCHandler) getLogs(ctx context.Context, chainID int64, criteria *rpcFilter) (interface{}, error) {
+	filter := &database.LogFilter{ChainID: chainID, Limit: maxRPCLogs + 1}
+
+	addresses, err := decodeStringOrArray(criteria.Address)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid address filter"}
+	}
+	for _, address := range addresses {
+		filter.Addresses = append(filter.Addresses, common.HexToAddress(address).Hex())
+	}
+
+	if len(criteria.Topics) > len(filter.Topics) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "too many topics"}
+	}
+	for i, raw := range criteria.Topics {
+		topics, err := decodeStringOrArray(raw)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid topic filter"}
+		}
+		for _, topic := range topics {
+			filter.Topics[i] = append(filter.Topics[i], common.HexToHash(topic).Hex())
+		}
+	}
+
+	if criteria.BlockHash != "" {
+		hash := common.HexToHash(criteria.BlockHash).Hex()
+		filter.BlockHash = &hash
+	} else {
+		head, err := h.indexedHead(ctx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		from, err := blockTagNumber(criteria.FromBlock, head)
+		if err != nil {
+			return nil, err
+		}
+		to, err := blockTagNumber(criteria.ToBlock, head)
+		if err != nil {
+			return nil, err
+		}
+		// A range the indexer hasn't reached would come back partial
+		if to > head {
+			return nil, errNotIndexed
+		}
+		if criteria.ToBlock == "" || criteria.ToBlock == "latest" {
+			if behind, err := h.indexerBehind(ctx, chainID, head); err != nil || behind {
+				return nil, errNotIndexed
+			}
+		}
+		filter.FromBlock = &from
+		filter.ToBlock = &to
+	}
+
+	logs, err := h.db.GetLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > maxRPCLogs {
+		return nil, &rpcError{Code: rpcLimitExceeded, Message: fmt.Sprintf("query returned more than %d results", maxRPCLogs)}
+	}
+
+	result := make([]map[string]interface{}, len(logs))
+	for i, log := range logs {
+		result[i] = rpcLog(log)
+	}
+	return result, nil
+}
+
+// indexerBehind reports whether the node has blocks past the indexed head.
+func (h *RPCHandler) indexerBehind(ctx context.Context, chainID, head int64) (bool, error) {
+	client, err := h.chainManager.GetClient(chainID)
+	if err != nil {
+		return false, err
+	}
+	latest, err := client.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	return int64(latest) > head, nil
+}
+
+// decodeParams unmarshals positional params into dst; missing trailing
+// params keep their zero value.
+func decodeParams(params []json.RawMessage, dst ...interface{}) error {
+	if len(params) > len(dst) {
+		return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("too many arguments, want at most %d", len(dst))}
+	}
+	for i, p := range params {
+		if err := json.Unmarshal(p, dst[i]); err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid argument %d: %v", i, err)}
+		}
+	}
+	return nil
+}
+
+func decodeStringOrArray(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, err
+	}
+	return many, nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+var emptyBloom = "0x" + strings.Repeat("0", 512)
+
+// rpcTransaction encodes an indexed transaction, or returns nil if it was
+// indexed without its signature.
+func rpcTransaction(tx *models.Transaction) map[string]interface{} {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil
+	}
+	result := map[string]interface{}{
+		"hash":             tx.Hash,
+		"nonce":            encodeUint(uint64(tx.Nonce)),
+		"blockHash":        tx.BlockHash,
+		"blockNumber":      encodeUint(uint64(tx.BlockNumber)),
+		"transactionIndex": encodeUint(uint64(tx.TransactionIndex)),
+		"from":             tx.FromAddress,
+		"to":               tx.ToAddress,
+		"value":            encodeDecimal(&tx.Value),
+		"gas":              encodeUint(uint64(tx.Gas)),
+		"input":            stringOr(tx.Input, "0x"),
+		"type":             encodeUint(uint64(tx.TransactionType)),
+		"chainId":          encodeUint(uint64(tx.ChainID)),
+		"v":                *tx.V,
+		"r":                *tx.R,
+		"s":                *tx.S,
+	}
+	if tx.TransactionType != types.LegacyTxType {
+		result["yParity"] = *tx.V
+	}
+	if tx.GasPrice != nil {
+		result["gasPrice"] = encodeDecimal(tx.GasPrice)
+	}
+	if tx.MaxFeePerGas != nil {
+		result["maxFeePerGas"] = encodeDecimal(tx.MaxFeePerGas)
+	}
+	if tx.MaxPriorityFeePerGas != nil {
+		result["maxPriorityFeePerGas"] = encodeDecimal(tx.MaxPriorityFeePerGas)
+	}
+	return result
+}
+
+// blockBloom merges the receipt blooms of a block's transactions into the
+// block's logs bloom. ok is false if a receipt bloom is missing.
+func blockBloom(txs []*models.Transaction) (string, bool) {
+	var bloom types.Bloom
+	for _, tx := range txs {
+		if tx.LogsBloom == nil {
+			return "", false
+		}
+		b, err := hexutil.Decode(*tx.LogsBloom)
+		if err != nil || len(b) != types.BloomByteLength {
+			return "", false
+		}
+		for i := range bloom {
+			bloom[i] |= b[i]
+		}
+	}
+	return hexutil.Encode(bloom[:]), true
+}
+
+func rpcReceipt(tx *models.Transaction, logs []*models.TransactionLog) map[string]interface{} {
+	rpcLogs := make([]map[string]interface{}, len(logs))
+	for i, log := range logs {
+		rpcLogs[i] = rpcLog(log)
+	}
+
+	result := map[string]interface{}{
+		"transactionHash":   tx.Hash,
+		"transactionIndex":  encodeUint(uint64(tx.TransactionIndex)),
+		"blockHash":         tx.BlockHash,
+		"blockNumber":       encodeUint(uint64(tx.BlockNumber)),
+		"from":              tx.FromAddress,
+		"to":                tx.ToAddress,
+		"cumulativeGasUsed": encodeUint(uint64(int64OrZero(tx.CumulativeGasUsed))),
+		"gasUsed":           encodeUint(uint64(int64OrZero(tx.GasUsed))),
+		"effectiveGasPrice": encodeDecimal(tx.EffectiveGasPrice),
+		"contractAddress":   tx.ContractAddress,
+		"logs":              rpcLogs,
+		"logsBloom":         stringOr(tx.LogsBloom, emptyBloom),
+		"type":              encodeUint(uint64(tx.TransactionType)),
+	}
+	if tx.Status != nil {
+		result["status"] = encodeUint(uint64(*tx.Status))
+	}
+	return result
+}
+
+func rpcLog(log *models.TransactionLog) map[string]interface{} {
+	topics := []string{}
+	for _, topic := range []*string{log.Topic0, log.Topic1, log.Topic2, log.Topic3} {
+		if topic == nil {
+			break
+		}
+		topics = append(topics, *topic)
+	}
+	return map[string]interface{}{
+		"address":          log.Address,
+		"topics":           topics,
+		"data":             stringOr(log.Data, "0x"),
+		"blockNumber":      encodeUint(uint64(log.BlockNumber)),
+		"blockHash":        log.BlockHash,
+		"transactionHash":  log.TransactionHash,
+		"transactionIndex": encodeUint(uint64(log.TransactionIndex)),
+		"logIndex":         encodeUint(uint64(log.LogIndex)),
+		"removed":          log.Removed,
+	}
+}
+
+func encodeUint(n uint64) string {
+	return hexutil.EncodeUint64(n)
+}
+
+// encodeDecimal converts a NUMERIC column scanned as a decimal string into a
+// hex quantity. Missing values encode as zero.
+func encodeDecimal(s *string) string {
+	if s == nil {
+		return "0x0"
+	}
+	n, ok := new(big.Int).SetString(*s, 10)
+	if !ok {
+		return "0x0"
+	}
+	return hexutil.EncodeBig(n)
+}
+
+func hexToUint(s string) (uint64, error) {
+	return hexutil.DecodeUint64(s)
+}
+
+func stringOr(s *string, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	return *s
+}
+
+func int64OrZero(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
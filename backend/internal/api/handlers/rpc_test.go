@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestBlockTagNumber(t *testing.T) {
+	cases := []struct {
+		tag  string
+		head int64
+		want int64
+		err  error
+	}{
+		{"", 500, 500, nil},
+		{"latest", 500, 500, nil},
+		{"earliest", 500, 0, nil},
+		{"safe", 500, 436, nil},
+		{"finalized", 500, 436, nil},
+		{"finalized", 10, 0, nil},
+		{"0x10", 500, 16, nil},
+		{"0x0", 500, 0, nil},
+		{"pending", 500, 0, errNotIndexed},
+	}
+	for _, tc := range cases {
+		got, err := blockTagNumber(tc.tag, tc.head)
+		assert.ErrorIs(t, err, tc.err, tc.tag)
+		assert.Equal(t, tc.want, got, tc.tag)
+	}
+
+	for _, tag := range []string{"16", "0xzz", "head"} {
+		_, err := blockTagNumber(tag, 500)
+		var rerr *rpcError
+		require.True(t, errors.As(err, &rerr), tag)
+		assert.Equal(t, rpcInvalidParams, rerr.Code, tag)
+	}
+}
+
+func TestRPCEncoders(t *testing.T) {
+	assert.Equal(t, "0x0", encodeUint(0))
+	assert.Equal(t, "0x1a", encodeUint(26))
+
+	for in, want := range map[string]string{
+		"0":                      "0x0",
+		"255":                    "0xff",
+		"1000000000000000000000": "0x3635c9adc5dea00000",
+		"nope":                   "0x0",
+	} {
+		assert.Equal(t, want, encodeDecimal(&in), in)
+	}
+	assert.Equal(t, "0x0", encodeDecimal(nil))
+
+	assert.Equal(t, "0x", stringOr(nil, "0x"))
+	assert.Equal(t, "0xab", stringOr(strPtr("0xab"), "0x"))
+}
+
+func TestRPCTransaction(t *testing.T) {
+	to := "0x000000000000000000000000000000000000bEEF"
+	tx := &models.Transaction{
+		ChainID:          1337,
+		Hash:             "0xhash",
+		BlockNumber:      16,
+		BlockHash:        "0xblock",
+		TransactionIndex: 2,
+		FromAddress:      "0xfrom",
+		ToAddress:        &to,
+		Value:            "1000",
+		Gas:              21000,
+		MaxFeePerGas:     strPtr("30"),
+		Nonce:            7,
+		TransactionType:  types.DynamicFeeTxType,
+	}
+	// Rows indexed before signatures were stored are forwarded
+	assert.Nil(t, rpcTransaction(tx))
+
+	tx.V, tx.R, tx.S = strPtr("0x1"), strPtr("0xaa"), strPtr("0xbb")
+	result := rpcTransaction(tx)
+	require.NotNil(t, result)
+	assert.Equal(t, "0x10", result["blockNumber"])
+	assert.Equal(t, "0x2", result["transactionIndex"])
+	assert.Equal(t, "0x3e8", result["value"])
+	assert.Equal(t, "0x5208", result["gas"])
+	assert.Equal(t, "0x1e", result["maxFeePerGas"])
+	assert.Equal(t, "0x7", result["nonce"])
+	assert.Equal(t, "0x2", result["type"])
+	assert.Equal(t, "0x539", result["chainId"])
+	assert.Equal(t, "0x", result["input"])
+	assert.Equal(t, "0x1", result["v"])
+	assert.Equal(t, "0xaa", result["r"])
+	assert.Equal(t, "0xbb", result["s"])
+	assert.Equal(t, "0x1", result["yParity"])
+	assert.NotContains(t, result, "gasPrice")
+
+	tx.TransactionType = types.LegacyTxType
+	assert.NotContains(t, rpcTransaction(tx), "yParity")
+}
+
+func TestRPCReceiptAndLogs(t *testing.T) {
+	status, gasUsed := 1, int64(21000)
+	tx := &models.Transaction{Hash: "0xhash", BlockNumber: 3, Status: &status, GasUsed: &gasUsed}
+	logs := []*models.TransactionLog{{
+		Address:     "0xtoken",
+		Topic0:      strPtr("0xt0"),
+		Topic1:      strPtr("0xt1"),
+		Topic3:      strPtr("0xt3"),
+		BlockNumber: 3,
+		LogIndex:    4,
+	}}
+
+	receipt := rpcReceipt(tx, logs)
+	assert.Equal(t, "0x1", receipt["status"])
+	assert.Equal(t, "0x5208", receipt["gasUsed"])
+	assert.Equal(t, "0x0", receipt["cumulativeGasUsed"])
+	assert.Equal(t, emptyBloom, receipt["logsBloom"])
+
+	encoded := receipt["logs"].([]map[string]interface{})
+	require.Len(t, encoded, 1)
+	// Topics stop at the first gap
+	assert.Equal(t, []string{"0xt0", "0xt1"}, encoded[0]["topics"])
+	assert.Equal(t, "0x", encoded[0]["data"])
+	assert.Equal(t, "0x4", encoded[0]["logIndex"])
+}
+
+func TestBlockBloom(t *testing.T) {
+	bloom, ok := blockBloom(nil)
+	assert.True(t, ok)
+	assert.Equal(t, emptyBloom, bloom)
+
+	var a, b types.Bloom
+	a[0], b[0], b[255] = 0x01, 0x10, 0x80
+	txs := []*models.Transaction{
+		{LogsBloom: strPtr(hexutil.Encode(a[:]))},
+		{LogsBloom: strPtr(hexutil.Encode(b[:]))},
+	}
+	bloom, ok = blockBloom(txs)
+	require.True(t, ok)
+	assert.Equal(t, "0x11"+strings.Repeat("0", 508)+"80", bloom)
+
+	// A receipt that wasn't indexed leaves the block's bloom unknown
+	_, ok = blockBloom(append(txs, &models.Transaction{}))
+	assert.False(t, ok)
+	_, ok = blockBloom([]*models.Transaction{{LogsBloom: strPtr("0x00")}})
+	assert.False(t, ok)
+}
+
+func TestDecodeRPCParams(t *testing.T) {
+	var tag string
+	var full bool
+	require.NoError(t, decodeParams([]json.RawMessage{json.RawMessage(`"0x1"`)}, &tag, &full))
+	assert.Equal(t, "0x1", tag)
+	assert.False(t, full)
+	assert.Error(t, decodeParams([]json.RawMessage{json.RawMessage(`1`), json.RawMessage(`true`), json.RawMessage(`2`)}, &tag, &full))
+	assert.Error(t, decodeParams([]json.RawMessage{json.RawMessage(`1`)}, &tag))
+
+	for raw, want := range map[string][]string{
+		``:              nil,
+		`null`:          nil,
+		`"0xa"`:         {"0xa"},
+		`["0xa","0xb"]`: {"0xa", "0xb"},
+	} {
+		got, err := decodeStringOrArray(json.RawMessage(raw))
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	_, err := decodeStringOrArray(json.RawMessage(`5`))
+	assert.Error(t, err)
+}
+
+func TestRPCHandle(t *testing.T) {
+	app := fiber.New()
+	app.Post("/rpc", NewRPCHandler(nil, nil, zap.NewNop()).Handle)
+	call := func(body string) string {
+		resp, err := app.Test(httptest.NewRequest("POST", "/rpc?chain_id=10", strings.NewReader(body)))
+		require.NoError(t, err)
+		var out json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return string(out)
+	}
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":"0xa"}`, call(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`, call(`{`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32600,"message":"invalid request"}}`, call(`{"id":2,"method":"eth_chainId"}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"the method admin_peers does not exist/is not available"}}`, call(`{"jsonrpc":"2.0","id":3,"method":"admin_peers"}`))
+	assert.JSONEq(t, `[{"jsonrpc":"2.0","id":1,"result":"0xa"},{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method debug_x does not exist/is not available"}}]`,
+		call(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"debug_x"}]`))
+	assert.Contains(t, call(`[]`), "batch must contain 1 to 100 requests")
+
+	// Methods that would use the node's unlocked accounts are never forwarded
+	for _, method := range []string{"eth_sendTransaction", "eth_sign", "eth_signTransaction", "eth_signTypedData_v4", "eth_accounts", "personal_unlockAccount"} {
+		assert.JSONEq(t, `{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"the method `+method+` does not exist/is not available"}}`,
+			call(`{"jsonrpc":"2.0","id":4,"method":"`+method+`"}`), method)
+	}
+}
@@ -1,50 +1,236 @@
 package handlers
 
 import (
+	"context"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofiber/fiber/v2"
-	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+const (
+	maxSearchResults = 20
+	maxSuggestions   = 10
+	// minFuzzyLength is the shortest query matched against tokens and labels
+	minFuzzyLength = 2
+	// scoreLookup ranks exact hash, address, number and name hits above fuzzy
+	// token and label matches, which score up to 4
+	scoreLookup = 10
 )
 
+// ensName matches dotted names such as vitalik.eth, after lowercasing
+var ensName = regexp.MustCompile(`^([a-z0-9-]+\.)+[a-z0-9-]{2,}$`)
+
 type SearchHandler struct {
-	db *database.DB
+	db     *database.DB
+	chains *blockchain.ChainManager
 }
 
-func NewSearchHandler(db *database.DB) *SearchHandler {
-	return &SearchHandler{db: db}
+func NewSearchHandler(db *database.DB, chains *blockchain.ChainManager) *SearchHandler {
+	return &SearchHandler{db: db, chains: chains}
 }
 
+// Search looks q up as a block or transaction hash, address, block number or
+// ENS-style name, and matches it against token names and symbols and address
+// labels. Without chain_id it searches every active chain. Results are
+// ranked, exact lookups first.
 func (h *SearchHandler) Search(c *fiber.Ctx) error {
-	chainID := c.QueryInt("chain_id", 1337)
-	query := c.Query("q")
-
-	if query == "" {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
 		return responses.Error(c, 400, "INVALID_QUERY", "Search query required", nil)
 	}
+	chainIDs, chainID, err := h.searchChains(c)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chains", err.Error())
+	}
+	limit := c.QueryInt("limit", maxSearchResults)
+	if limit < 1 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	var results []*models.SearchResult
+	for _, id := range chainIDs {
+		results = append(results, h.lookup(c.Context(), id, q)...)
+	}
+	fuzzy, err := h.fuzzy(c.Context(), chainIDs, q, limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to search", err.Error())
+	}
+	results = rankResults(append(results, fuzzy...), limit)
+	for _, r := range results {
+		attachLabels(c.Context(), h.db, r.ChainID, r.Result)
+	}
+
+	return responses.Success(c, fiber.Map{
+		"query":   q,
+		"results": results,
+	}, chainID)
+}
+
+// Autocomplete suggests tokens and labels as q is typed. Suggestions carry
+// a title and value but not the matched entity; Search does full lookups.
+func (h *SearchHandler) Autocomplete(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	chainIDs, chainID, err := h.searchChains(c)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chains", err.Error())
+	}
+	limit := c.QueryInt("limit", maxSuggestions)
+	if limit < 1 || limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	suggestions, err := h.fuzzy(c.Context(), chainIDs, q, limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to search", err.Error())
+	}
+	suggestions = rankResults(suggestions, limit)
+	for _, s := range suggestions {
+		s.Result = nil
+	}
+
+	return responses.Success(c, fiber.Map{
+		"query":       q,
+		"suggestions": suggestions,
+	}, chainID)
+}
+
+// searchChains is the chain in chain_id, or every active chain. The chain is
+// returned separately for the response metadata.
+func (h *SearchHandler) searchChains(c *fiber.Ctx) ([]int64, *int64, error) {
+	if c.Query("chain_id") != "" {
+		id := int64(c.QueryInt("chain_id"))
+		return []int64{id}, &id, nil
+	}
+	chains, err := h.db.GetChains(c.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []int64
+	for _, chain := range chains {
+		if chain.IsActive {
+			ids = append(ids, chain.ChainID)
+		}
+	}
+	return ids, nil, nil
+}
+
+// lookup finds the entities q identifies exactly on one chain. Lookups that
+// fail are treated as misses.
+func (h *SearchHandler) lookup(ctx context.Context, chainID int64, q string) []*models.SearchResult {
+	var results []*models.SearchResult
+	add := func(resultType, title, value string, result interface{}) {
+		results = append(results, &models.SearchResult{
+			Type: resultType, ChainID: chainID, Score: scoreLookup, Title: title, Value: value, Result: result,
+		})
+	}
 
-	if matched, _ := regexp.MatchString(`0x[a-fA-F0-9]{64}$`, query); matched {
-		if block, _ := h.db.GetBlockByHash(c.Context(), int64(chainID), query); block != nil {
-			cID := int64(chainID)
-			return responses.Success(c, fiber.Map{"type": "block", "result": block}, &cID)
+	switch {
+	case isHexHash(q):
+		hash := strings.ToLower(q)
+		if block, _ := h.db.GetBlockByHash(ctx, chainID, hash); block != nil {
+			add(models.SearchBlock, "Block #"+strconv.FormatInt(block.BlockNumber, 10), block.Hash, block)
+		}
+		if tx, _ := h.db.GetTransactionByHash(ctx, chainID, hash); tx != nil {
+			add(models.SearchTransaction, tx.Hash, tx.Hash, tx)
+		}
+
+	case strings.HasPrefix(q, "0x") && common.IsHexAddress(q):
+		address := common.HexToAddress(q).Hex()
+		if addr, _ := h.db.GetAddress(ctx, chainID, address); addr != nil {
+			add(models.SearchAddress, address, address, addr)
+		}
+		if token, _ := h.db.GetToken(ctx, chainID, address); token != nil {
+			add(models.SearchToken, tokenTitle(token), address, token)
 		}
-		if tx, _ := h.db.GetTransactionByHash(c.Context(), int64(chainID), query); tx != nil {
-			cID := int64(chainID)
-			return responses.Success(c, fiber.Map{"type": "transaction", "result": tx}, &cID)
+
+	case isBlockNumber(q):
+		num, _ := strconv.ParseInt(q, 10, 64)
+		if block, _ := h.db.GetBlockByNumber(ctx, chainID, num); block != nil {
+			add(models.SearchBlock, "Block #"+q, block.Hash, block)
 		}
-	} else if matched, _ := regexp.MatchString(`^0x[a-fA-F0-9]{40}$`, query); matched {
-		if addr, _ := h.db.GetAddress(c.Context(), int64(chainID), query); addr != nil {
-			cID := int64(chainID)
-			return responses.Success(c, fiber.Map{"type": "address", "result": addr}, &cID)
+
+	case ensName.MatchString(strings.ToLower(q)):
+		client, err := h.chains.GetClient(chainID)
+		if err != nil {
+			break
 		}
-	} else if num, err := strconv.ParseInt(query, 10, 64); err == nil {
-		if block, _ := h.db.GetBlockByNumber(c.Context(), int64(chainID), num); block != nil {
-			cID := int64(chainID)
-			return responses.Success(c, fiber.Map{"type": "block", "result": block}, &cID)
+		if address, _ := client.ResolveName(ctx, q); address != nil {
+			add(models.SearchName, strings.ToLower(q), address.Hex(), fiber.Map{
+				"name":    strings.ToLower(q),
+				"address": address.Hex(),
+			})
 		}
 	}
+	return results
+}
 
-	return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "No results found", nil)
+// fuzzy matches q against token names and symbols and address labels.
+func (h *SearchHandler) fuzzy(ctx context.Context, chainIDs []int64, q string, limit int) ([]*models.SearchResult, error) {
+	if len(q) < minFuzzyLength || len(chainIDs) == 0 {
+		return nil, nil
+	}
+	tokens, err := h.db.SearchTokens(ctx, chainIDs, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		t.Title = tokenTitle(t.Result.(*models.Token))
+	}
+	labels, err := h.db.SearchAddressLabels(ctx, chainIDs, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		l.Title = l.Result.(*models.AddressLabel).Label
+	}
+	return append(tokens, labels...), nil
+}
+
+// rankResults orders results by score, keeping the order of equal scores,
+// and keeps the first limit. It never returns nil so empty results encode
+// as a list.
+func rankResults(results []*models.SearchResult, limit int) []*models.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []*models.SearchResult{}
+	}
+	return results
+}
+
+// tokenTitle is "Name (SYMBOL)", or whichever of the two is known.
+func tokenTitle(token *models.Token) string {
+	switch {
+	case token.Name != nil && token.Symbol != nil:
+		return *token.Name + " (" + *token.Symbol + ")"
+	case token.Name != nil:
+		return *token.Name
+	case token.Symbol != nil:
+		return *token.Symbol
+	}
+	return token.Address
+}
+
+func isBlockNumber(s string) bool {
+	if s == "" || len(s) > 18 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
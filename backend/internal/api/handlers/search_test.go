@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankResults(t *testing.T) {
+	results := rankResults([]*models.SearchResult{
+		{Type: models.SearchLabel, Value: "a", Score: 2.5},
+		{Type: models.SearchToken, Value: "b", Score: 3.9},
+		{Type: models.SearchBlock, Value: "c", Score: scoreLookup},
+		{Type: models.SearchToken, Value: "d", Score: 2.5},
+	}, 3)
+
+	var values []string
+	for _, r := range results {
+		values = append(values, r.Value)
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, values)
+	assert.NotNil(t, rankResults(nil, 10))
+}
+
+func TestSearchQueryKinds(t *testing.T) {
+	assert.True(t, isBlockNumber("123"))
+	assert.False(t, isBlockNumber("12a"))
+	assert.False(t, isBlockNumber("9999999999999999999"))
+
+	assert.True(t, ensName.MatchString("vitalik.eth"))
+	assert.True(t, ensName.MatchString("pay.treasury.eth"))
+	assert.False(t, ensName.MatchString("treasury"))
+	assert.False(t, ensName.MatchString("a.b"))
+
+	// The hash check is anchored, so longer strings ending in a hash don't match
+	assert.False(t, isHexHash("zz0x"+"00000000000000000000000000000000000000000000000000000000000000aa"))
+
+	name, symbol := "Wrapped Ether", "WETH"
+	assert.Equal(t, "Wrapped Ether (WETH)", tokenTitle(&models.Token{Name: &name, Symbol: &symbol}))
+	assert.Equal(t, "WETH", tokenTitle(&models.Token{Symbol: &symbol}))
+	assert.Equal(t, "0xabc", tokenTitle(&models.Token{Address: "0xabc"}))
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/pulkyeet/eth-devstack/backend/internal/stats"
 )
 
 type StatsHandler struct {
@@ -23,5 +29,96 @@ func (h *StatsHandler) GetStats(c *fiber.Ctx) error {
 	}
 
 	cID := int64(chainID)
+	httpcache.For(c, httpcache.HeadTTL)
 	return responses.Success(c, stats, &cID)
-}
\ No newline at end of file
+}
+
+const (
+	defaultDailyBuckets  = 30
+	maxDailyBuckets      = 366
+	defaultHourlyBuckets = 24
+	maxHourlyBuckets     = 31 * 24
+)
+
+// GetDailyStats lists a chain's daily rollups for from_date..to_date
+// (YYYY-MM-DD, inclusive), by default the last 30 days.
+func (h *StatsHandler) GetDailyStats(c *fiber.Ctx) error {
+	from, err := parseDateParam(c, "from_date")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	to, err := parseDateParam(c, "to_date")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	return h.chainStats(c, models.StatsPeriodDay, from, to, defaultDailyBuckets, maxDailyBuckets)
+}
+
+// GetHourlyStats lists a chain's hourly rollups for the hours containing
+// from_time..to_time (unix seconds or RFC3339), by default the last 24 hours.
+func (h *StatsHandler) GetHourlyStats(c *fiber.Ctx) error {
+	from, err := parseTimeParam(c, "from_time")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	to, err := parseTimeParam(c, "to_time")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	return h.chainStats(c, models.StatsPeriodHour, from, to, defaultHourlyBuckets, maxHourlyBuckets)
+}
+
+func (h *StatsHandler) chainStats(c *fiber.Ctx, period string, from, to *time.Time, defaultBuckets, maxBuckets int) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	start, end, err := statsWindow(period, from, to, time.Now(), defaultBuckets, maxBuckets)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	rollups, err := h.db.GetChainStats(c.Context(), chainID, period, start, end)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch stats", err.Error())
+	}
+	httpcache.For(c, httpcache.RollupTTL)
+	return responses.Success(c, fiber.Map{
+		"period": period,
+		"from":   start,
+		"to":     end,
+		"stats":  rollups,
+	}, &chainID)
+}
+
+// statsWindow resolves the requested bounds to the [start, end) range of
+// whole buckets covering them. A missing to means now and a missing from
+// means defaultBuckets before to.
+func statsWindow(period string, from, to *time.Time, now time.Time, defaultBuckets, maxBuckets int) (time.Time, time.Time, error) {
+	length := stats.Length(period)
+	end := stats.BucketStart(now, period).Add(length)
+	if to != nil {
+		end = stats.BucketStart(*to, period).Add(length)
+	}
+	start := end.Add(-time.Duration(defaultBuckets) * length)
+	if from != nil {
+		start = stats.BucketStart(*from, period)
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("from must not be after to")
+	}
+	if end.Sub(start) > time.Duration(maxBuckets)*length {
+		return start, end, fmt.Errorf("range may span at most %d %ss", maxBuckets, period)
+	}
+	return start, end, nil
+}
+
+// parseDateParam reads a YYYY-MM-DD date as midnight UTC.
+func parseDateParam(c *fiber.Ctx, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &t, nil
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsWindow(t *testing.T) {
+	now := time.Date(2024, 5, 20, 13, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	// Defaults end with the current bucket
+	start, end, err := statsWindow(models.StatsPeriodDay, nil, nil, now, 30, 366)
+	require.NoError(t, err)
+	assert.Equal(t, day(21), end)
+	assert.Equal(t, day(21).AddDate(0, 0, -30), start)
+
+	// to_date is inclusive
+	from, to := day(1), day(3)
+	start, end, err = statsWindow(models.StatsPeriodDay, &from, &to, now, 30, 366)
+	require.NoError(t, err)
+	assert.Equal(t, day(1), start)
+	assert.Equal(t, day(4), end)
+
+	// Hourly bounds widen to whole hours
+	fromTime := time.Date(2024, 5, 20, 9, 15, 0, 0, time.UTC)
+	start, end, err = statsWindow(models.StatsPeriodHour, &fromTime, nil, now, 24, 744)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC), end)
+
+	_, _, err = statsWindow(models.StatsPeriodDay, &to, &from, now, 30, 366)
+	assert.Error(t, err)
+	from = day(1).AddDate(-2, 0, 0)
+	_, _, err = statsWindow(models.StatsPeriodDay, &from, nil, now, 30, 366)
+	assert.Error(t, err)
+}
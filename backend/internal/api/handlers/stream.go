@@ -5,27 +5,246 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofiber/fiber/v2"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
 	"go.uber.org/zap"
 )
 
+const (
+	// streamBuffer is the number of events a client may fall behind before
+	// it is disconnected to resume with Last-Event-ID
+	streamBuffer = 64
+	// maxStreamReplay is the page size events are replayed in on resume
+	maxStreamReplay   = 1000
+	heartbeatInterval = 15 * time.Second
+)
+
+// StreamHandler serves server-sent event streams of newly indexed data. All
+// clients share one event bus; each event's id is its chain position, and a
+// client reconnecting with Last-Event-ID is first replayed what it missed
+// from the index. Reorgs are announced on every stream as reorg events.
 type StreamHandler struct {
 	db     *database.DB
+	bus    *events.Bus
 	logger *zap.SugaredLogger
 }
 
-func NewStreamHandler(db *database.DB, logger *zap.Logger) *StreamHandler {
+func NewStreamHandler(db *database.DB, bus *events.Bus, logger *zap.Logger) *StreamHandler {
 	return &StreamHandler{
 		db:     db,
+		bus:    bus,
 		logger: logger.Sugar(),
 	}
 }
 
+// streamItem is one event and the chain position that identifies it.
+type streamItem struct {
+	pos  database.Position
+	data interface{}
+}
+
+// stream describes one SSE endpoint: its event name, how to replay missed
+// events from the index and which parts of an indexed block it delivers.
+type stream struct {
+	event  string
+	replay func(ctx context.Context, after database.Position) ([]streamItem, error)
+	match  func(e *events.BlockIndexed) []streamItem
+}
+
 func (h *StreamHandler) StreamBlocks(c *fiber.Ctx) error {
-	chainID := c.QueryInt("chain_id", 1337)
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	item := func(block *models.Block) streamItem {
+		return streamItem{pos: database.Position{BlockNumber: block.BlockNumber}, data: blockSummary(block)}
+	}
+
+	return h.serve(c, chainID, stream{
+		event: "block",
+		replay: func(ctx context.Context, after database.Position) ([]streamItem, error) {
+			blocks, err := h.db.GetBlocksPage(ctx, chainID, nil, &after.BlockNumber, maxStreamReplay)
+			if err != nil {
+				return nil, err
+			}
+			slices.Reverse(blocks)
+			items := make([]streamItem, len(blocks))
+			for i, block := range blocks {
+				items[i] = item(block)
+			}
+			return items, nil
+		},
+		match: func(e *events.BlockIndexed) []streamItem {
+			return []streamItem{item(e.Block)}
+		},
+	})
+}
+
+// StreamTransactions streams new transactions, optionally only those sent
+// from or to address.
+func (h *StreamHandler) StreamTransactions(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	address, err := parseAddressParam(c, "address")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_ADDRESS", err.Error(), nil)
+	}
+	matches := func(tx *models.Transaction) bool {
+		return address == nil || tx.FromAddress == *address || (tx.ToAddress != nil && *tx.ToAddress == *address)
+	}
+
+	return h.serve(c, chainID, stream{
+		event: "transaction",
+		replay: func(ctx context.Context, after database.Position) ([]streamItem, error) {
+			txs, err := h.db.GetTransactionsByFilter(ctx, &database.TransactionFilter{
+				ChainID: chainID,
+				Party:   database.Party{Address: address},
+				Sort:    database.Sort{Order: database.OrderAsc},
+				After:   &after,
+				Limit:   maxStreamReplay,
+			})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]streamItem, len(txs))
+			for i, tx := range txs {
+				items[i] = streamItem{pos: transactionPosition(tx), data: tx}
+			}
+			return items, nil
+		},
+		match: func(e *events.BlockIndexed) []streamItem {
+			var items []streamItem
+			for _, tx := range e.Transactions {
+				if matches(tx) {
+					items = append(items, streamItem{pos: transactionPosition(tx), data: tx})
+				}
+			}
+			return items
+		},
+	})
+}
+
+// StreamLogs streams new logs matching address and topic0..topic3, which are
+// OR sets as on GET /logs.
+func (h *StreamHandler) StreamLogs(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	filter := &database.LogFilter{ChainID: chainID, Limit: maxStreamReplay}
+	for _, address := range queryList(c, "address") {
+		if !common.IsHexAddress(address) {
+			return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", address)
+		}
+		filter.Addresses = append(filter.Addresses, common.HexToAddress(address).Hex())
+	}
+	for i := range filter.Topics {
+		for _, topic := range queryList(c, fmt.Sprintf("topic%d", i)) {
+			if !isHexHash(topic) {
+				return responses.Error(c, 400, "INVALID_TOPIC", "Topics must be 32-byte hex values", topic)
+			}
+			filter.Topics[i] = append(filter.Topics[i], strings.ToLower(topic))
+		}
+	}
+	item := func(l *models.TransactionLog) streamItem {
+		return streamItem{pos: database.Position{BlockNumber: l.BlockNumber, Index: l.LogIndex}, data: l}
+	}
+
+	return h.serve(c, chainID, stream{
+		event: "log",
+		replay: func(ctx context.Context, after database.Position) ([]streamItem, error) {
+			f := *filter
+			f.After = &after
+			logs, err := h.db.GetLogs(ctx, &f)
+			if err != nil {
+				return nil, err
+			}
+			items := make([]streamItem, len(logs))
+			for i, l := range logs {
+				items[i] = item(l)
+			}
+			return items, nil
+		},
+		match: func(e *events.BlockIndexed) []streamItem {
+			var items []streamItem
+			for _, l := range e.Logs {
+				if logMatches(filter, l) {
+					items = append(items, item(l))
+				}
+			}
+			return items
+		},
+	})
+}
+
+// StreamTokenTransfers streams new token transfers, optionally only those of
+// token and those sent from or to address.
+func (h *StreamHandler) StreamTokenTransfers(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	address, err := parseAddressParam(c, "address")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_ADDRESS", err.Error(), nil)
+	}
+	token, err := parseAddressParam(c, "token")
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_ADDRESS", err.Error(), nil)
+	}
+	matches := func(t *models.TokenTransfer) bool {
+		return (token == nil || t.TokenAddress == *token) &&
+			(address == nil || t.FromAddress == *address || t.ToAddress == *address)
+	}
+	item := func(t *models.TokenTransfer) streamItem {
+		return streamItem{pos: database.Position{BlockNumber: t.BlockNumber, Index: t.LogIndex}, data: t}
+	}
+
+	return h.serve(c, chainID, stream{
+		event: "token_transfer",
+		replay: func(ctx context.Context, after database.Position) ([]streamItem, error) {
+			transfers, err := h.db.GetTokenTransfers(ctx, &database.TokenTransferFilter{
+				ChainID:      chainID,
+				Party:        database.Party{Address: address},
+				TokenAddress: token,
+				Sort:         database.Sort{Order: database.OrderAsc},
+				After:        &after,
+				Limit:        maxStreamReplay,
+			})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]streamItem, len(transfers))
+			for i, t := range transfers {
+				items[i] = item(t)
+			}
+			return items, nil
+		},
+		match: func(e *events.BlockIndexed) []streamItem {
+			var items []streamItem
+			for _, t := range e.TokenTransfers {
+				if matches(t) {
+					items = append(items, item(t))
+				}
+			}
+			return items
+		},
+	})
+}
+
+// serve runs an SSE stream. The resume position comes from the Last-Event-ID
+// header, or the last_event_id parameter for clients that cannot set it.
+func (h *StreamHandler) serve(c *fiber.Ctx, chainID int64, s stream) error {
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var resume *database.Position
+	if lastEventID != "" {
+		pos, err := parseEventID(lastEventID)
+		if err != nil {
+			return responses.Error(c, 400, "INVALID_EVENT_ID", "Invalid Last-Event-ID", nil)
+		}
+		resume = pos
+	}
 
 	c.Set("Content-Type", "text/event-stream")
 	c.Set("Cache-Control", "no-cache")
@@ -33,65 +252,150 @@ func (h *StreamHandler) StreamBlocks(c *fiber.Ctx) error {
 	c.Set("Transfer-Encoding", "chunked")
 	c.Set("X-Accel-Buffering", "no")
 
-	ctx := context.Background()
-	lastBlockNum := int64(0)
+	// Subscribe before replaying so nothing indexed in between is lost
+	sub := h.bus.Subscribe(chainID, streamBuffer)
 
 	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
+		defer sub.Close()
+		ctx := context.Background()
 
-		// Send initial connection message
 		fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"streaming\"}\n\n")
 		if err := w.Flush(); err != nil {
-			h.logger.Warnw("Failed to flush initial message", "error", err)
 			return
 		}
 
+		// Live events already covered by the replay are skipped
+		var replayed *database.Position
+		send := func(item streamItem) bool {
+			if replayed != nil && !positionAfter(item.pos, *replayed) {
+				return true
+			}
+			data, err := json.Marshal(item.data)
+			if err != nil {
+				h.logger.Warnw("Failed to marshal stream event", "event", s.event, "error", err)
+				return true
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", eventID(item.pos), s.event, data); err != nil {
+				return false
+			}
+			return w.Flush() == nil
+		}
+
+		if resume != nil {
+			last, ok := h.replay(ctx, s, *resume, send)
+			if !ok {
+				return
+			}
+			replayed = &last
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
 		for {
 			select {
-			case <-ticker.C:
-				block, err := h.db.GetLatestBlock(ctx, int64(chainID))
-				if err != nil {
-					h.logger.Warnw("Failed to get latest block", "error", err)
-					continue
+			case e, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind; the client resumes from its
+					// last event id
+					fmt.Fprintf(w, "event: overflow\ndata: {}\n\n")
+					w.Flush()
+					return
 				}
-
-				if block == nil {
-					continue
-				}
-
-				// Only send if new block
-				if block.BlockNumber > lastBlockNum {
-					lastBlockNum = block.BlockNumber
-					
-					data := map[string]interface{}{
-						"block_number": block.BlockNumber,
-						"hash":         block.Hash,
-						"timestamp":    block.Timestamp.Format(time.RFC3339),
-						"tx_count":     block.TxCount,
-						"gas_used":     block.GasUsed,
+				if e.Reorg != nil {
+					// Replacement blocks may reuse replayed positions
+					if replayed != nil && e.Reorg.FromBlock <= replayed.BlockNumber {
+						replayed = nil
 					}
-
-					jsonData, err := json.Marshal(data)
-					if err != nil {
-						h.logger.Warnw("Failed to marshal block data", "error", err)
-						continue
-					}
-
-					_, writeErr := fmt.Fprintf(w, "event: block\ndata: %s\n\n", string(jsonData))
-					if writeErr != nil {
-						h.logger.Info("Client disconnected")
+					data, _ := json.Marshal(e.Reorg)
+					if _, err := fmt.Fprintf(w, "event: reorg\ndata: %s\n\n", data); err != nil || w.Flush() != nil {
 						return
 					}
-
-					if err := w.Flush(); err != nil {
-						h.logger.Info("Client disconnected during flush")
+					continue
+				}
+				for _, item := range s.match(e.Block) {
+					if !send(item) {
 						return
 					}
 				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprintf(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
 			}
 		}
 	})
 
 	return nil
-}
\ No newline at end of file
+}
+
+// replay sends the events after a resume position page by page until it
+// catches up with the index, and returns the last position sent. It reports
+// false once the client has gone.
+func (h *StreamHandler) replay(ctx context.Context, s stream, after database.Position, send func(streamItem) bool) (database.Position, bool) {
+	for {
+		items, err := s.replay(ctx, after)
+		if err != nil {
+			h.logger.Warnw("Failed to replay stream", "event", s.event, "error", err)
+			return after, true
+		}
+		for _, item := range items {
+			if !send(item) {
+				return after, false
+			}
+			after = item.pos
+		}
+		if len(items) < maxStreamReplay {
+			return after, true
+		}
+	}
+}
+
+// blockSummary is the payload of a block event.
+func blockSummary(block *models.Block) map[string]interface{} {
+	return map[string]interface{}{
+		"block_number": block.BlockNumber,
+		"hash":         block.Hash,
+		"timestamp":    block.Timestamp.Format(time.RFC3339),
+		"tx_count":     block.TxCount,
+		"gas_used":     block.GasUsed,
+	}
+}
+
+func eventID(pos database.Position) string {
+	return fmt.Sprintf("%d-%d", pos.BlockNumber, pos.Index)
+}
+
+func parseEventID(id string) (*database.Position, error) {
+	var pos database.Position
+	if _, err := fmt.Sscanf(id, "%d-%d", &pos.BlockNumber, &pos.Index); err != nil {
+		return nil, err
+	}
+	if eventID(pos) != id {
+		return nil, fmt.Errorf("invalid event id: %s", id)
+	}
+	return &pos, nil
+}
+
+func positionAfter(a, b database.Position) bool {
+	return a.BlockNumber > b.BlockNumber || (a.BlockNumber == b.BlockNumber && a.Index > b.Index)
+}
+
+// logMatches applies a log filter's address and topic sets to one log.
+func logMatches(filter *database.LogFilter, l *models.TransactionLog) bool {
+	if len(filter.Addresses) > 0 && !slices.Contains(filter.Addresses, l.Address) {
+		return false
+	}
+	topics := [4]*string{l.Topic0, l.Topic1, l.Topic2, l.Topic3}
+	for i, set := range filter.Topics {
+		if len(set) == 0 {
+			continue
+		}
+		if topics[i] == nil || !slices.Contains(set, *topics[i]) {
+			return false
+		}
+	}
+	return true
+}
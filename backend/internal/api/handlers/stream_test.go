@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEventIDRoundTrip(t *testing.T) {
+	pos := database.Position{BlockNumber: 1234, Index: 5}
+	parsed, err := parseEventID(eventID(pos))
+	require.NoError(t, err)
+	assert.Equal(t, pos, *parsed)
+
+	for _, invalid := range []string{"", "12", "a-b", "1-2-3", "01-2"} {
+		_, err := parseEventID(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPositionAfter(t *testing.T) {
+	assert.True(t, positionAfter(database.Position{BlockNumber: 2}, database.Position{BlockNumber: 1, Index: 9}))
+	assert.True(t, positionAfter(database.Position{BlockNumber: 1, Index: 3}, database.Position{BlockNumber: 1, Index: 2}))
+	assert.False(t, positionAfter(database.Position{BlockNumber: 1, Index: 2}, database.Position{BlockNumber: 1, Index: 2}))
+}
+
+func TestLogMatches(t *testing.T) {
+	transfer := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	log := &models.TransactionLog{Address: "0xToken", Topic0: &transfer}
+
+	assert.True(t, logMatches(&database.LogFilter{}, log))
+	assert.True(t, logMatches(&database.LogFilter{Addresses: []string{"0xOther", "0xToken"}}, log))
+	assert.False(t, logMatches(&database.LogFilter{Addresses: []string{"0xOther"}}, log))
+	assert.True(t, logMatches(&database.LogFilter{Topics: [4][]string{{transfer}}}, log))
+	assert.False(t, logMatches(&database.LogFilter{Topics: [4][]string{nil, {transfer}}}, log))
+}
+
+func TestReplayPagesToHead(t *testing.T) {
+	// 2500 indexed events after the resume position, served a page at a time
+	const indexed = 2500
+	s := stream{
+		event: "block",
+		replay: func(_ context.Context, after database.Position) ([]streamItem, error) {
+			var items []streamItem
+			for n := after.BlockNumber + 1; n <= indexed && len(items) < maxStreamReplay; n++ {
+				items = append(items, streamItem{pos: database.Position{BlockNumber: n}})
+			}
+			return items, nil
+		},
+	}
+	h := &StreamHandler{logger: zap.NewNop().Sugar()}
+
+	var sent []int64
+	last, ok := h.replay(context.Background(), s, database.Position{}, func(item streamItem) bool {
+		sent = append(sent, item.pos.BlockNumber)
+		return true
+	})
+	require.True(t, ok)
+	require.Len(t, sent, indexed)
+	assert.Equal(t, int64(1), sent[0])
+	assert.Equal(t, int64(indexed), sent[indexed-1])
+	assert.Equal(t, database.Position{BlockNumber: indexed}, last)
+
+	// A client that goes away stops the replay
+	count := 0
+	_, ok = h.replay(context.Background(), s, database.Position{}, func(streamItem) bool {
+		count++
+		return count < 10
+	})
+	assert.False(t, ok)
+	assert.Equal(t, 10, count)
+}
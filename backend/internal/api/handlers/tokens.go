@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+type TokenHandler struct {
+	db *database.DB
+}
+
+func NewTokenHandler(db *database.DB) *TokenHandler {
+	return &TokenHandler{db: db}
+}
+
+// GetTokens lists indexed tokens. type filters by standard (comma-separated)
+// and sort orders by holders or transfers instead of first seen.
+func (h *TokenHandler) GetTokens(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := &database.TokenListFilter{ChainID: chainID, Limit: limit, Offset: (page - 1) * limit}
+	for _, t := range queryList(c, "type") {
+		t = strings.ToUpper(t)
+		if t != "ERC20" && t != "ERC721" && t != "ERC1155" {
+			return responses.Error(c, 400, "INVALID_FILTER", "type must be ERC20, ERC721 or ERC1155", t)
+		}
+		filter.Types = append(filter.Types, t)
+	}
+	switch sort := c.Query("sort"); sort {
+	case "", "created":
+	case "holders":
+		filter.Sort = database.TokenSortHolders
+	case "transfers":
+		filter.Sort = database.TokenSortTransfers
+	default:
+		return responses.Error(c, 400, "INVALID_FILTER", "sort must be created, holders or transfers", sort)
+	}
+	var err error
+	if filter.Order, err = parseOrder(c); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	tokens, err := h.db.GetTokens(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch tokens", err.Error())
+	}
+	for _, token := range tokens {
+		token.TotalSupplyFormatted = formatUnits(token.TotalSupply, token.Decimals)
+	}
+
+	total, _ := h.db.CountTokens(c.Context(), filter)
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+
+	return responses.Success(c, fiber.Map{
+		"tokens": tokens,
+		"pagination": responses.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}, &chainID)
+}
+
+func (h *TokenHandler) GetToken(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	token, err := h.lookupToken(c, chainID)
+	if token == nil {
+		return err
+	}
+
+	token.TotalSupplyFormatted = formatUnits(token.TotalSupply, token.Decimals)
+	return responses.Success(c, token, &chainID)
+}
+
+// GetTokenTransfers lists a token's transfers, newest first. It accepts the
+// party, range and sort filters of the transaction listings.
+func (h *TokenHandler) GetTokenTransfers(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	token, err := h.lookupToken(c, chainID)
+	if token == nil {
+		return err
+	}
+
+	filter := &database.TokenTransferFilter{ChainID: chainID, TokenAddress: &token.Address}
+	if err := parseParty(c, &filter.Party); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	if err := parseBlockRange(c, &filter.BlockRange); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	if err := parseValueRange(c, &filter.ValueRange); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+	if filter.Sort, err = parseSort(c); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
+	}
+
+	p, err := parseListPage(c, 20, 100)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_CURSOR", "Invalid cursor", nil)
+	}
+	if err := p.offsetOnly(filter.Sort); err != nil {
+		return responses.Error(c, 400, "INVALID_CURSOR", err.Error(), nil)
+	}
+	if p.legacy() {
+		filter.Limit = p.limit
+		filter.Offset = p.offset()
+	} else {
+		filter.After = p.after()
+		filter.Before = p.before()
+		filter.Limit = p.limit + 1
+	}
+
+	transfers, err := h.db.GetTokenTransfers(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch token transfers", err.Error())
+	}
+	var meta interface{} = responses.PageMeta{Page: p.page, Limit: p.limit}
+	if !p.legacy() {
+		transfers, meta = paginate(p, transfers, func(t *models.TokenTransfer) database.Position {
+			return database.Position{BlockNumber: t.BlockNumber, Index: t.LogIndex}
+		})
+	}
+	for _, transfer := range transfers {
+		transfer.ValueFormatted = formatUnits(transfer.Value, transfer.TokenDecimals)
+	}
+	attachLabels(c.Context(), h.db, chainID, transfers)
+
+	return responses.Success(c, fiber.Map{
+		"token":      token.Address,
+		"transfers":  transfers,
+		"pagination": meta,
+	}, &chainID)
+}
+
+// GetTokenHolders lists a token's holders by balance with their share of the
+// supply. Tokens without a known total supply are measured against the sum
+// of indexed balances.
+func (h *TokenHandler) GetTokenHolders(c *fiber.Ctx) error {
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	token, err := h.lookupToken(c, chainID)
+	if token == nil {
+		return err
+	}
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	holders, err := h.db.GetTokenHolders(c.Context(), chainID, token.Address, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch token holders", err.Error())
+	}
+
+	supply := token.TotalSupply
+	if supply == nil || *supply == "0" {
+		sum, err := h.db.SumTokenBalances(c.Context(), chainID, token.Address)
+		if err != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch token supply", err.Error())
+		}
+		supply = &sum
+	}
+	for _, holder := range holders {
+		holder.BalanceFormatted = formatUnits(&holder.Balance, token.Decimals)
+		holder.Percentage = percentage(holder.Balance, *supply)
+	}
+
+	return responses.Success(c, fiber.Map{
+		"token":   token.Address,
+		"holders": holders,
+		"pagination": responses.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      token.HolderCount,
+			TotalPages: int((token.HolderCount + int64(limit) - 1) / int64(limit)),
+		},
+	}, &chainID)
+}
+
+// lookupToken resolves the token in the path. A nil token means the error
+// response has been written; the returned error is the handler's result.
+func (h *TokenHandler) lookupToken(c *fiber.Ctx, chainID int64) (*models.Token, error) {
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return nil, responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	token, err := h.db.GetToken(c.Context(), chainID, common.HexToAddress(address).Hex())
+	if err != nil {
+		return nil, responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch token", err.Error())
+	}
+	if token == nil {
+		return nil, responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Token not found", nil)
+	}
+	return token, nil
+}
+
+// formatUnits renders an integer amount in whole token units, e.g.
+// 1500000000000000000 with 18 decimals as "1.5". It returns nil when either
+// the amount or the decimals are unknown.
+func formatUnits(value *string, decimals *int) *string {
+	if value == nil || decimals == nil || *decimals < 0 {
+		return nil
+	}
+	n, ok := new(big.Int).SetString(*value, 10)
+	if !ok {
+		return nil
+	}
+	if *decimals == 0 {
+		s := n.String()
+		return &s
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(n, unit, new(big.Int))
+	s := sign + whole.String()
+	if frac.Sign() != 0 {
+		digits := frac.String()
+		digits = strings.Repeat("0", *decimals-len(digits)) + digits
+		s += "." + strings.TrimRight(digits, "0")
+	}
+	return &s
+}
+
+// percentage returns part as a percentage of whole, or nil if whole is zero
+// or either is not an integer.
+func percentage(part, whole string) *float64 {
+	p, ok := new(big.Rat).SetString(part)
+	if !ok {
+		return nil
+	}
+	w, ok := new(big.Rat).SetString(whole)
+	if !ok || w.Sign() == 0 {
+		return nil
+	}
+	pct, _ := new(big.Rat).Mul(new(big.Rat).Quo(p, w), big.NewRat(100, 1)).Float64()
+	return &pct
+}
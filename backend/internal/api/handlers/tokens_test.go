@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatUnits(t *testing.T) {
+	decimals := func(n int) *int { return &n }
+	cases := []struct {
+		value    string
+		decimals *int
+		want     string
+	}{
+		{"1500000000000000000", decimals(18), "1.5"},
+		{"1000000", decimals(6), "1"},
+		{"1", decimals(6), "0.000001"},
+		{"123456789", decimals(0), "123456789"},
+		{"-2500", decimals(3), "-2.5"},
+	}
+	for _, tc := range cases {
+		got := formatUnits(&tc.value, tc.decimals)
+		require.NotNil(t, got, tc.value)
+		assert.Equal(t, tc.want, *got)
+	}
+
+	value := "100"
+	assert.Nil(t, formatUnits(&value, nil))
+	assert.Nil(t, formatUnits(nil, decimals(18)))
+}
+
+func TestFormatUnitsOnIndexedToken(t *testing.T) {
+	node := blockchaintest.NewNode(t)
+	supply, _ := new(big.Int).SetString("1000500000", 10)
+	node.SetContract("0x000000000000000000000000000000000000700C", (&blockchaintest.Token{
+		Name: "USD Coin", Symbol: "USDC", Decimals: 6, TotalSupply: supply,
+	}).Contract())
+
+	// The token as the indexer stores it on first sight
+	token := indexer.DescribeToken(context.Background(), node.Client(t), blockchaintest.ChainID, "0x000000000000000000000000000000000000700C", big.NewInt(1))
+	require.NotNil(t, token.Decimals)
+	assert.Equal(t, "USDC", *token.Symbol)
+
+	formatted := formatUnits(token.TotalSupply, token.Decimals)
+	require.NotNil(t, formatted)
+	assert.Equal(t, "1000.5", *formatted)
+	value := "2500000"
+	assert.Equal(t, "2.5", *formatUnits(&value, token.Decimals))
+}
+
+func TestPercentage(t *testing.T) {
+	pct := percentage("250", "1000")
+	require.NotNil(t, pct)
+	assert.InDelta(t, 25.0, *pct, 1e-9)
+
+	assert.Nil(t, percentage("1", "0"))
+	assert.Nil(t, percentage("x", "10"))
+}
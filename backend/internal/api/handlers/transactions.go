@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
 	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/decoder"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
 type TransactionHandler struct {
@@ -14,37 +17,75 @@ func NewTransactionHandler(db *database.DB) *TransactionHandler {
 	return &TransactionHandler{db: db}
 }
 
+// GetTransactions lists transactions matching the filters read by
+// parseTransactionFilter.
 func (h *TransactionHandler) GetTransactions(c *fiber.Ctx) error {
-	chainID := c.QueryInt("chain_id", 1337)
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 20)
-
-	if limit > 100 {
-		limit = 100
+	chainID := int64(c.QueryInt("chain_id", 1337))
+	filter := &database.TransactionFilter{ChainID: chainID}
+	if err := parseTransactionFilter(c, filter); err != nil {
+		return responses.Error(c, 400, "INVALID_FILTER", err.Error(), nil)
 	}
-	offset := (page - 1) * limit
+	return listTransactions(c, h.db, filter, nil)
+}
 
-	txs, err := h.db.GetTransactions(c.Context(), int64(chainID), limit, offset)
+// listTransactions serves a filtered transaction listing in cursor mode, or in
+// the legacy page/limit mode with totals. extra is merged into the response.
+func listTransactions(c *fiber.Ctx, db *database.DB, filter *database.TransactionFilter, extra fiber.Map) error {
+	p, err := parseListPage(c, 20, 100)
 	if err != nil {
-		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch transactions", err.Error())
+		return responses.Error(c, 400, "INVALID_CURSOR", "Invalid cursor", nil)
+	}
+	if err := p.offsetOnly(filter.Sort); err != nil {
+		return responses.Error(c, 400, "INVALID_CURSOR", err.Error(), nil)
 	}
 
-	total, _ := h.db.CountTransactions(c.Context(), int64(chainID))
-	totalPages := int(total) / limit
-	if int(total)%limit != 0 {
-		totalPages++
+	data := fiber.Map{}
+	for k, v := range extra {
+		data[k] = v
 	}
+	if p.legacy() {
+		filter.Limit = p.limit
+		filter.Offset = p.offset()
+		txs, err := db.GetTransactionsByFilter(c.Context(), filter)
+		if err != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch transactions", err.Error())
+		}
 
-	cID := int64(chainID)
-	return responses.Success(c, fiber.Map{
-		"transactions": txs,
-		"pagination": responses.PaginationMeta{
-			Page:       page,
-			Limit:      limit,
+		total, _ := db.CountTransactionsByFilter(c.Context(), filter)
+		totalPages := int(total) / p.limit
+		if int(total)%p.limit != 0 {
+			totalPages++
+		}
+		attachLabels(c.Context(), db, filter.ChainID, txs)
+		data["transactions"] = txs
+		data["pagination"] = responses.PaginationMeta{
+			Page:       p.page,
+			Limit:      p.limit,
 			Total:      total,
 			TotalPages: totalPages,
-		},
-	}, &cID)
+		}
+		httpcache.For(c, httpcache.HeadTTL)
+		return responses.Success(c, data, &filter.ChainID)
+	}
+
+	filter.After = p.after()
+	filter.Before = p.before()
+	filter.Limit = p.limit + 1
+	txs, err := db.GetTransactionsByFilter(c.Context(), filter)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch transactions", err.Error())
+	}
+	txs, meta := paginate(p, txs, transactionPosition)
+	attachLabels(c.Context(), db, filter.ChainID, txs)
+
+	data["transactions"] = txs
+	data["pagination"] = meta
+	httpcache.For(c, httpcache.HeadTTL)
+	return responses.Success(c, data, &filter.ChainID)
+}
+
+func transactionPosition(tx *models.Transaction) database.Position {
+	return database.Position{BlockNumber: tx.BlockNumber, Index: tx.TransactionIndex}
 }
 
 func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
@@ -60,5 +101,49 @@ func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
 	}
 
 	cID := int64(chainID)
+	// Labels can change, so labelled responses are never immutable
+	if attachLabels(c.Context(), h.db, cID, tx) {
+		httpcache.For(c, httpcache.HeadTTL)
+	} else {
+		httpcache.Block(c, tx.BlockNumber)
+	}
 	return responses.Success(c, tx, &cID)
-}
\ No newline at end of file
+}
+
+// GetDecodedInput decodes the calldata of a transaction. Calls to proxies are
+// decoded with the ABI of the implementation active at the transaction's block.
+func (h *TransactionHandler) GetDecodedInput(c *fiber.Ctx) error {
+	chainID := c.QueryInt("chain_id", 1337)
+	hash := c.Params("hash")
+
+	tx, err := h.db.GetTransactionByHash(c.Context(), int64(chainID), hash)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch transaction", err.Error())
+	}
+	if tx == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Transaction not found", nil)
+	}
+	if tx.ToAddress == nil || tx.Input == nil {
+		return responses.Error(c, 422, "NOT_DECODABLE", "Transaction is not a contract call", nil)
+	}
+
+	contractABI, err := h.db.GetDecodingABI(c.Context(), int64(chainID), *tx.ToAddress, tx.BlockNumber)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch abi", err.Error())
+	}
+	if contractABI == nil {
+		return responses.Error(c, 404, "ABI_NOT_FOUND", "No ABI available for the called contract", nil)
+	}
+
+	call, err := decoder.DecodeInput(contractABI.ABI, *tx.Input)
+	if err != nil {
+		return responses.Error(c, 422, "DECODE_FAILED", "Failed to decode input", err.Error())
+	}
+
+	cID := int64(chainID)
+	return responses.Success(c, fiber.Map{
+		"transaction_hash": tx.Hash,
+		"abi_address":      contractABI.Address,
+		"decoded":          call,
+	}, &cID)
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// maxWatchlistAddresses bounds a watchlist so its activity feed stays a
+// handful of index scans
+const maxWatchlistAddresses = 500
+
+type WatchlistHandler struct {
+	db *database.DB
+}
+
+func NewWatchlistHandler(db *database.DB) *WatchlistHandler {
+	return &WatchlistHandler{db: db}
+}
+
+type watchlistRequest struct {
+	ChainID     *int64   `json:"chain_id"`
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Addresses   []string `json:"addresses"`
+}
+
+// parseAddresses checksums and deduplicates addresses.
+func parseAddresses(addresses []string) ([]string, error) {
+	var parsed []string
+	seen := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address: %s", address)
+		}
+		address = common.HexToAddress(address).Hex()
+		if !seen[address] {
+			seen[address] = true
+			parsed = append(parsed, address)
+		}
+	}
+	return parsed, nil
+}
+
+func (h *WatchlistHandler) CreateWatchlist(c *fiber.Ctx) error {
+	var req watchlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", "name is required", nil)
+	}
+	addresses, err := parseAddresses(req.Addresses)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", err.Error(), nil)
+	}
+	if len(addresses) > maxWatchlistAddresses {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", fmt.Sprintf("a watchlist may hold %d addresses", maxWatchlistAddresses), nil)
+	}
+	list := &models.Watchlist{
+		ChainID:     1337,
+		Name:        strings.TrimSpace(*req.Name),
+		Description: req.Description,
+		Addresses:   []*models.WatchlistAddress{},
+	}
+	if req.ChainID != nil {
+		list.ChainID = *req.ChainID
+	}
+	for _, address := range addresses {
+		list.Addresses = append(list.Addresses, &models.WatchlistAddress{Address: address})
+	}
+	chain, err := h.db.GetChain(c.Context(), list.ChainID)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chain", err.Error())
+	}
+	if chain == nil {
+		return responses.Error(c, 400, "INVALID_CHAIN", "Unknown chain", list.ChainID)
+	}
+
+	if err := h.db.CreateWatchlist(c.Context(), list); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to create watchlist", err.Error())
+	}
+	attachLabels(c.Context(), h.db, list.ChainID, list.Addresses)
+	c.Status(fiber.StatusCreated)
+	return responses.Success(c, list, &list.ChainID)
+}
+
+func (h *WatchlistHandler) GetWatchlists(c *fiber.Ctx) error {
+	var chainID *int64
+	if c.Query("chain_id") != "" {
+		id := int64(c.QueryInt("chain_id"))
+		chainID = &id
+	}
+	page, limit := pageParams(c)
+
+	lists, err := h.db.GetWatchlists(c.Context(), chainID, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch watchlists", err.Error())
+	}
+	total, _ := h.db.CountWatchlists(c.Context(), chainID)
+
+	return responses.Success(c, fiber.Map{
+		"watchlists": lists,
+		"pagination": pageMeta(page, limit, total),
+	}, chainID)
+}
+
+// GetWatchlist returns a watchlist with its addresses and their labels.
+func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
+	list, err := h.lookupWatchlist(c)
+	if list == nil {
+		return err
+	}
+	attachLabels(c.Context(), h.db, list.ChainID, list.Addresses)
+	return responses.Success(c, list, &list.ChainID)
+}
+
+// UpdateWatchlist renames a watchlist or changes its description.
+func (h *WatchlistHandler) UpdateWatchlist(c *fiber.Ctx) error {
+	list, err := h.lookupWatchlist(c)
+	if list == nil {
+		return err
+	}
+	var req watchlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return responses.Error(c, 400, "INVALID_WATCHLIST", "name must not be empty", nil)
+		}
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		list.Description = req.Description
+	}
+	if err := h.db.UpdateWatchlist(c.Context(), list); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to update watchlist", err.Error())
+	}
+	attachLabels(c.Context(), h.db, list.ChainID, list.Addresses)
+	return responses.Success(c, list, &list.ChainID)
+}
+
+func (h *WatchlistHandler) DeleteWatchlist(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid watchlist id", nil)
+	}
+	deleted, err := h.db.DeleteWatchlist(c.Context(), int64(id))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to delete watchlist", err.Error())
+	}
+	if !deleted {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Watchlist not found", nil)
+	}
+	return responses.Success(c, fiber.Map{"id": id, "deleted": true}, nil)
+}
+
+// AddAddresses adds addresses to a watchlist. Members are left as they are.
+func (h *WatchlistHandler) AddAddresses(c *fiber.Ctx) error {
+	list, err := h.lookupWatchlist(c)
+	if list == nil {
+		return err
+	}
+	var req watchlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	addresses, err := parseAddresses(req.Addresses)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", err.Error(), nil)
+	}
+	if len(addresses) == 0 {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", "addresses is required", nil)
+	}
+	members := make(map[string]bool, len(list.Addresses))
+	for _, member := range list.Addresses {
+		members[member.Address] = true
+	}
+	added := 0
+	for _, address := range addresses {
+		if !members[address] {
+			added++
+		}
+	}
+	if len(list.Addresses)+added > maxWatchlistAddresses {
+		return responses.Error(c, 400, "INVALID_WATCHLIST", fmt.Sprintf("a watchlist may hold %d addresses", maxWatchlistAddresses), nil)
+	}
+
+	if err := h.db.AddWatchlistAddresses(c.Context(), list.ID, addresses); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to add addresses", err.Error())
+	}
+	return h.GetWatchlist(c)
+}
+
+func (h *WatchlistHandler) RemoveAddress(c *fiber.Ctx) error {
+	list, err := h.lookupWatchlist(c)
+	if list == nil {
+		return err
+	}
+	address := c.Params("address")
+	if !common.IsHexAddress(address) {
+		return responses.Error(c, 400, "INVALID_ADDRESS", "Invalid address", nil)
+	}
+	address = common.HexToAddress(address).Hex()
+
+	removed, err := h.db.RemoveWatchlistAddress(c.Context(), list.ID, address)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to remove address", err.Error())
+	}
+	if !removed {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Address is not on the watchlist", nil)
+	}
+	return responses.Success(c, fiber.Map{"id": list.ID, "address": address, "removed": true}, &list.ChainID)
+}
+
+// GetActivity is the watchlist's feed: transactions and token transfers
+// involving any member, newest first, with a transaction ahead of the
+// transfers it made.
+func (h *WatchlistHandler) GetActivity(c *fiber.Ctx) error {
+	list, err := h.lookupWatchlist(c)
+	if list == nil {
+		return err
+	}
+	addresses := make([]string, 0, len(list.Addresses))
+	for _, member := range list.Addresses {
+		addresses = append(addresses, member.Address)
+	}
+	page, limit := pageParams(c)
+
+	activity, err := h.db.GetWatchlistActivity(c.Context(), list.ChainID, addresses, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch watchlist activity", err.Error())
+	}
+	if activity == nil {
+		activity = []*models.WatchlistActivity{}
+	}
+	for _, a := range activity {
+		if a.TokenTransfer != nil {
+			a.TokenTransfer.ValueFormatted = formatUnits(a.TokenTransfer.Value, a.TokenTransfer.TokenDecimals)
+		}
+	}
+	attachLabels(c.Context(), h.db, list.ChainID, activity)
+
+	return responses.Success(c, fiber.Map{
+		"watchlist_id": list.ID,
+		"activity":     activity,
+		"pagination":   responses.PageMeta{Page: page, Limit: limit},
+	}, &list.ChainID)
+}
+
+// lookupWatchlist resolves the watchlist in the path, like lookupWebhook.
+func (h *WatchlistHandler) lookupWatchlist(c *fiber.Ctx) (*models.Watchlist, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return nil, responses.Error(c, 400, "INVALID_ID", "Invalid watchlist id", nil)
+	}
+	list, err := h.db.GetWatchlist(c.Context(), int64(id))
+	if err != nil {
+		return nil, responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch watchlist", err.Error())
+	}
+	if list == nil {
+		return nil, responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Watchlist not found", nil)
+	}
+	return list, nil
+}
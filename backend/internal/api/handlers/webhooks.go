@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/pulkyeet/eth-devstack/backend/internal/webhooks"
+)
+
+type WebhookHandler struct {
+	db                  *database.DB
+	allowPrivateTargets bool
+}
+
+func NewWebhookHandler(db *database.DB, cfg config.WebhookConfig) *WebhookHandler {
+	return &WebhookHandler{db: db, allowPrivateTargets: cfg.AllowPrivateTargets}
+}
+
+type createWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description *string  `json:"description"`
+	ChainID     *int64   `json:"chain_id"`
+	Addresses   []string `json:"addresses"`
+	Tokens      []string `json:"tokens"`
+	Topics      []string `json:"topics"`
+	MinValue    *string  `json:"min_value"`
+	EventTypes  []string `json:"event_types"`
+}
+
+type updateWebhookRequest struct {
+	Active *bool `json:"active"`
+}
+
+// webhook validates a registration into a webhook, normalising addresses
+// and topics the way the index stores them. A webhook without filters matches
+// no block activity and only receives the alerts routed to it.
+func (r *createWebhookRequest) webhook(allowPrivateTargets bool) (*models.Webhook, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if err := webhooks.CheckTarget(u.Hostname(), allowPrivateTargets); err != nil {
+		return nil, err
+	}
+	hook := &models.Webhook{
+		URL:         r.URL,
+		Description: r.Description,
+		ChainID:     r.ChainID,
+		Addresses:   []string{},
+		Tokens:      []string{},
+		Topics:      []string{},
+		EventTypes:  []string{},
+		Active:      true,
+	}
+	for _, address := range r.Addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address: %s", address)
+		}
+		hook.Addresses = append(hook.Addresses, common.HexToAddress(address).Hex())
+	}
+	for _, token := range r.Tokens {
+		if !common.IsHexAddress(token) {
+			return nil, fmt.Errorf("invalid token: %s", token)
+		}
+		hook.Tokens = append(hook.Tokens, common.HexToAddress(token).Hex())
+	}
+	for _, topic := range r.Topics {
+		if !isHexHash(topic) {
+			return nil, fmt.Errorf("topics must be 32-byte hex values: %s", topic)
+		}
+		hook.Topics = append(hook.Topics, strings.ToLower(topic))
+	}
+	if r.MinValue != nil {
+		v, ok := new(big.Int).SetString(*r.MinValue, 10)
+		if !ok || v.Sign() < 0 {
+			return nil, fmt.Errorf("min_value must be a non-negative integer in wei or token base units")
+		}
+		s := v.String()
+		hook.MinValue = &s
+	}
+	types := []string{models.WebhookEventTransaction, models.WebhookEventTokenTransfer, models.WebhookEventLog}
+	for _, t := range r.EventTypes {
+		if !slices.Contains(types, t) {
+			return nil, fmt.Errorf("event_types must be transaction, token_transfer or log")
+		}
+		if !slices.Contains(hook.EventTypes, t) {
+			hook.EventTypes = append(hook.EventTypes, t)
+		}
+	}
+	return hook, nil
+}
+
+// CreateWebhook registers a webhook. The response carries the signing secret,
+// which is not shown again.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var req createWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	hook, err := req.webhook(h.allowPrivateTargets)
+	if err != nil {
+		return responses.Error(c, 400, "INVALID_WEBHOOK", err.Error(), nil)
+	}
+	if hook.ChainID != nil {
+		chain, err := h.db.GetChain(c.Context(), *hook.ChainID)
+		if err != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch chain", err.Error())
+		}
+		if chain == nil {
+			return responses.Error(c, 400, "INVALID_CHAIN", "Unknown chain", *hook.ChainID)
+		}
+	}
+	if hook.Secret, err = webhooks.NewSecret(); err != nil {
+		return responses.Error(c, 500, "INTERNAL_ERROR", "Failed to generate secret", err.Error())
+	}
+
+	if err := h.db.CreateWebhook(c.Context(), hook); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to create webhook", err.Error())
+	}
+	c.Status(fiber.StatusCreated)
+	return responses.Success(c, hook, hook.ChainID)
+}
+
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+	hooks, err := h.db.GetWebhooks(c.Context(), limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch webhooks", err.Error())
+	}
+	for _, hook := range hooks {
+		hook.Secret = ""
+	}
+	total, _ := h.db.CountWebhooks(c.Context())
+
+	return responses.Success(c, fiber.Map{
+		"webhooks":   hooks,
+		"pagination": pageMeta(page, limit, total),
+	}, nil)
+}
+
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	hook, err := h.lookupWebhook(c)
+	if hook == nil {
+		return err
+	}
+	hook.Secret = ""
+	return responses.Success(c, hook, hook.ChainID)
+}
+
+// UpdateWebhook pauses or resumes a webhook. Deliveries queued while it is
+// paused are sent once it resumes.
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	hook, err := h.lookupWebhook(c)
+	if hook == nil {
+		return err
+	}
+	var req updateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, 400, "INVALID_BODY", "Invalid request body", err.Error())
+	}
+	if req.Active == nil {
+		return responses.Error(c, 400, "INVALID_WEBHOOK", "active is required", nil)
+	}
+	if _, err := h.db.SetWebhookActive(c.Context(), hook.ID, *req.Active); err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to update webhook", err.Error())
+	}
+	hook.Active = *req.Active
+	hook.Secret = ""
+	return responses.Success(c, hook, hook.ChainID)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid webhook id", nil)
+	}
+	deleted, err := h.db.DeleteWebhook(c.Context(), int64(id))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to delete webhook", err.Error())
+	}
+	if !deleted {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Webhook not found", nil)
+	}
+	return responses.Success(c, fiber.Map{"id": id, "deleted": true}, nil)
+}
+
+// GetDeliveries is the delivery log of a webhook, newest first, optionally
+// filtered by status.
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	hook, err := h.lookupWebhook(c)
+	if hook == nil {
+		return err
+	}
+	var status *string
+	if s := c.Query("status"); s != "" {
+		if s != models.WebhookDeliveryPending && s != models.WebhookDeliveryDelivered && s != models.WebhookDeliveryDead && s != models.WebhookDeliveryCancelled {
+			return responses.Error(c, 400, "INVALID_FILTER", "status must be pending, delivered, dead or cancelled", s)
+		}
+		status = &s
+	}
+	page, limit := pageParams(c)
+
+	deliveries, err := h.db.GetWebhookDeliveries(c.Context(), hook.ID, status, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch deliveries", err.Error())
+	}
+	total, _ := h.db.CountWebhookDeliveries(c.Context(), hook.ID, status)
+
+	return responses.Success(c, fiber.Map{
+		"webhook_id": hook.ID,
+		"deliveries": deliveries,
+		"pagination": pageMeta(page, limit, total),
+	}, hook.ChainID)
+}
+
+func (h *WebhookHandler) GetDeadLetters(c *fiber.Ctx) error {
+	hook, err := h.lookupWebhook(c)
+	if hook == nil {
+		return err
+	}
+	page, limit := pageParams(c)
+
+	letters, err := h.db.GetWebhookDeadLetters(c.Context(), hook.ID, limit, (page-1)*limit)
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch dead letters", err.Error())
+	}
+	return responses.Success(c, fiber.Map{
+		"webhook_id":   hook.ID,
+		"dead_letters": letters,
+		"pagination":   responses.PageMeta{Page: page, Limit: limit},
+	}, hook.ChainID)
+}
+
+// Redeliver queues a delivery to be sent again with a fresh set of attempts,
+// e.g. once a dead-lettered receiver is fixed.
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	hook, err := h.lookupWebhook(c)
+	if hook == nil {
+		return err
+	}
+	deliveryID, err := c.ParamsInt("delivery_id")
+	if err != nil || deliveryID < 1 {
+		return responses.Error(c, 400, "INVALID_ID", "Invalid delivery id", nil)
+	}
+	delivery, err := h.db.RedeliverWebhookDelivery(c.Context(), hook.ID, int64(deliveryID))
+	if err != nil {
+		return responses.Error(c, 500, "DATABASE_ERROR", "Failed to requeue delivery", err.Error())
+	}
+	if delivery == nil {
+		return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Delivery not found", nil)
+	}
+	return responses.Success(c, delivery, hook.ChainID)
+}
+
+// lookupWebhook resolves the webhook in the path. A nil webhook means the
+// error response has been written; the returned error is the handler's
+// result.
+func (h *WebhookHandler) lookupWebhook(c *fiber.Ctx) (*models.Webhook, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return nil, responses.Error(c, 400, "INVALID_ID", "Invalid webhook id", nil)
+	}
+	hook, err := h.db.GetWebhook(c.Context(), int64(id))
+	if err != nil {
+		return nil, responses.Error(c, 500, "DATABASE_ERROR", "Failed to fetch webhook", err.Error())
+	}
+	if hook == nil {
+		return nil, responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Webhook not found", nil)
+	}
+	return hook, nil
+}
+
+// pageParams reads page and limit for the page/limit lists.
+func pageParams(c *fiber.Ctx) (page, limit int) {
+	page = c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit = c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+func pageMeta(page, limit int, total int64) responses.PaginationMeta {
+	return responses.PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookRequest(t *testing.T) {
+	minValue := "1000000000000000000"
+	req := &createWebhookRequest{
+		URL:        "https://hooks.example.com:9000/hook",
+		Addresses:  []string{"0x000000000000000000000000000000000000beef"},
+		Topics:     []string{"0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"},
+		MinValue:   &minValue,
+		EventTypes: []string{"log", "log"},
+	}
+	hook, err := req.webhook(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x000000000000000000000000000000000000bEEF"}, hook.Addresses)
+	assert.Equal(t, []string{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"}, hook.Topics)
+	assert.Equal(t, []string{"log"}, hook.EventTypes)
+	assert.Empty(t, hook.Tokens)
+	assert.True(t, hook.Active)
+
+	// Alert-only webhooks have no filters
+	_, err = (&createWebhookRequest{URL: req.URL}).webhook(false)
+	assert.NoError(t, err)
+
+	negative := "-1"
+	for name, invalid := range map[string]*createWebhookRequest{
+		"relative url":  {URL: "/hook", Addresses: req.Addresses},
+		"ftp url":       {URL: "ftp://example.com", Addresses: req.Addresses},
+		"localhost":     {URL: "http://localhost:9000/hook", Addresses: req.Addresses},
+		"loopback":      {URL: "http://127.0.0.1/hook", Addresses: req.Addresses},
+		"private":       {URL: "http://10.0.0.5/hook", Addresses: req.Addresses},
+		"link-local":    {URL: "http://169.254.169.254/latest/meta-data", Addresses: req.Addresses},
+		"ipv6 loopback": {URL: "http://[::1]:8080/hook", Addresses: req.Addresses},
+		"address":       {URL: req.URL, Addresses: []string{"0x12"}},
+		"token":         {URL: req.URL, Tokens: []string{"beef"}},
+		"topic":         {URL: req.URL, Topics: []string{"0x12"}},
+		"min value":     {URL: req.URL, Addresses: req.Addresses, MinValue: &negative},
+		"event type":    {URL: req.URL, Addresses: req.Addresses, EventTypes: []string{"block"}},
+	} {
+		_, err := invalid.webhook(false)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCreateWebhookRequestPrivateTargets(t *testing.T) {
+	req := &createWebhookRequest{URL: "http://127.0.0.1:9000/hook"}
+	_, err := req.webhook(false)
+	assert.EqualError(t, err, "webhook host 127.0.0.1 is not public")
+
+	// e.g. a receiver next to a development node
+	hook, err := req.webhook(true)
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:9000/hook", hook.URL)
+}
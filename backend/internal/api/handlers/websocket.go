@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"go.uber.org/zap"
+)
+
+const (
+	maxWSSubscriptions = 20
+	maxWSMessageSize   = 16 * 1024
+	// wsOutbox is the number of messages queued for a slow client before its
+	// subscriptions start falling behind
+	wsOutbox       = 256
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is how long a client may stay silent, pongs included,
+	// before the connection is closed
+	wsPongWait  = 2 * wsPingInterval
+	wsWriteWait = 10 * time.Second
+)
+
+// Subscription types accepted by the WebSocket API
+const (
+	wsBlocks              = "blocks"
+	wsPendingTransactions = "pending_transactions"
+	wsAddress             = "address"
+	wsLogs                = "logs"
+	wsReorgs              = "reorgs"
+)
+
+// WebSocketHandler serves the bidirectional subscription API on /ws. A client
+// holds one connection and adds or removes subscriptions to any configured
+// chain with JSON requests; events arrive as notifications tagged with the
+// subscription id. Unlike the SSE streams, nothing is replayed on reconnect.
+type WebSocketHandler struct {
+	bus     *events.Bus
+	pending *events.PendingFeed
+	// checkChain reports why a chain cannot be subscribed to, if it can't
+	checkChain func(chainID int64) error
+	logger     *zap.SugaredLogger
+}
+
+func NewWebSocketHandler(bus *events.Bus, pending *events.PendingFeed, chainManager *blockchain.ChainManager, logger *zap.Logger) *WebSocketHandler {
+	return &WebSocketHandler{
+		bus:     bus,
+		pending: pending,
+		checkChain: func(chainID int64) error {
+			_, err := chainManager.GetConfig(chainID)
+			return err
+		},
+		logger: logger.Sugar(),
+	}
+}
+
+// Upgrade rejects plain HTTP requests to the WebSocket endpoint.
+func (h *WebSocketHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+	return c.Next()
+}
+
+// wsRequest is a client message. id is echoed back in the response.
+type wsRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type wsSubscribeParams struct {
+	Type    string `json:"type"`
+	ChainID *int64 `json:"chain_id"`
+	// Address is the account watched by an address subscription
+	Address string `json:"address"`
+	// Addresses and Topics filter a logs subscription as on GET /logs:
+	// each topic position is an OR set and a missing one matches anything
+	Addresses []string   `json:"addresses"`
+	Topics    [][]string `json:"topics"`
+}
+
+type wsUnsubscribeParams struct {
+	Subscription string `json:"subscription"`
+}
+
+type wsResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  *wsError        `json:"error,omitempty"`
+}
+
+type wsError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// wsNotification delivers one event of a subscription.
+type wsNotification struct {
+	Subscription string      `json:"subscription"`
+	ChainID      int64       `json:"chain_id"`
+	Type         string      `json:"type"`
+	Data         interface{} `json:"data"`
+}
+
+// wsEvent is one notification's type and payload.
+type wsEvent struct {
+	Type string
+	Data interface{}
+}
+
+// wsTopic is a validated subscription request. Bus subscriptions select
+// their events from each published chain event with match.
+type wsTopic struct {
+	kind    string
+	chainID int64
+	match   func(e *events.Event) []wsEvent
+}
+
+// parseWSTopic validates subscribe params into a topic.
+func parseWSTopic(raw json.RawMessage) (*wsTopic, error) {
+	var params wsSubscribeParams
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("params are required")
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	topic := &wsTopic{kind: params.Type, chainID: 1337}
+	if params.ChainID != nil {
+		topic.chainID = *params.ChainID
+	}
+
+	switch params.Type {
+	case wsBlocks:
+		topic.match = func(e *events.Event) []wsEvent {
+			if e.Block == nil {
+				return nil
+			}
+			return []wsEvent{{Type: "block", Data: blockSummary(e.Block.Block)}}
+		}
+	case wsPendingTransactions:
+	case wsAddress:
+		if !common.IsHexAddress(params.Address) {
+			return nil, fmt.Errorf("address subscriptions require a valid address")
+		}
+		address := common.HexToAddress(params.Address).Hex()
+		topic.match = func(e *events.Event) []wsEvent {
+			if e.Block == nil {
+				return nil
+			}
+			var matched []wsEvent
+			for _, tx := range e.Block.Transactions {
+				if tx.FromAddress == address || (tx.ToAddress != nil && *tx.ToAddress == address) ||
+					(tx.ContractAddress != nil && *tx.ContractAddress == address) {
+					matched = append(matched, wsEvent{Type: "transaction", Data: tx})
+				}
+			}
+			for _, t := range e.Block.TokenTransfers {
+				if t.FromAddress == address || t.ToAddress == address {
+					matched = append(matched, wsEvent{Type: "token_transfer", Data: t})
+				}
+			}
+			return matched
+		}
+	case wsLogs:
+		filter := &database.LogFilter{}
+		for _, address := range params.Addresses {
+			if !common.IsHexAddress(address) {
+				return nil, fmt.Errorf("invalid address: %s", address)
+			}
+			filter.Addresses = append(filter.Addresses, common.HexToAddress(address).Hex())
+		}
+		if len(params.Topics) > len(filter.Topics) {
+			return nil, fmt.Errorf("at most %d topic positions may be given", len(filter.Topics))
+		}
+		for i, set := range params.Topics {
+			for _, t := range set {
+				if !isHexHash(t) {
+					return nil, fmt.Errorf("topics must be 32-byte hex values: %s", t)
+				}
+				filter.Topics[i] = append(filter.Topics[i], strings.ToLower(t))
+			}
+		}
+		topic.match = func(e *events.Event) []wsEvent {
+			if e.Block == nil {
+				return nil
+			}
+			var matched []wsEvent
+			for _, l := range e.Block.Logs {
+				if logMatches(filter, l) {
+					matched = append(matched, wsEvent{Type: "log", Data: l})
+				}
+			}
+			return matched
+		}
+	case wsReorgs:
+		topic.match = func(e *events.Event) []wsEvent {
+			if e.Reorg == nil {
+				return nil
+			}
+			return []wsEvent{{Type: "reorg", Data: e.Reorg}}
+		}
+	case "":
+		return nil, fmt.Errorf("type is required")
+	default:
+		return nil, fmt.Errorf("unknown subscription type: %s", params.Type)
+	}
+	return topic, nil
+}
+
+// Handle runs one connection. Reads happen here; a single writer goroutine
+// owns all writes, including the heartbeat pings.
+func (h *WebSocketHandler) Handle(conn *websocket.Conn) {
+	s := newWSSession(h)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.write(conn)
+	}()
+	// The connection is released when Handle returns, so the writer must be
+	// gone by then
+	defer func() {
+		s.close()
+		wg.Wait()
+	}()
+
+	conn.SetReadLimit(maxWSMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.Debugw("WebSocket read failed", "error", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		s.handle(data)
+	}
+}
+
+// wsSession is the subscription state of one connection.
+type wsSession struct {
+	h    *WebSocketHandler
+	out  chan interface{}
+	done chan struct{}
+
+	mu     sync.Mutex
+	subs   map[string]func()
+	nextID int
+	closed bool
+}
+
+func newWSSession(h *WebSocketHandler) *wsSession {
+	return &wsSession{
+		h:    h,
+		out:  make(chan interface{}, wsOutbox),
+		done: make(chan struct{}),
+		subs: make(map[string]func()),
+	}
+}
+
+// write sends queued messages and pings until the session ends or a write
+// fails. Closing the connection on failure unblocks the reader.
+func (s *wsSession) write(conn *websocket.Conn) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-s.done:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+			return
+		case msg := <-s.out:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// send queues a message, waiting for room unless the session has ended.
+func (s *wsSession) send(msg interface{}) bool {
+	select {
+	case s.out <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *wsSession) reply(id json.RawMessage, result interface{}) {
+	s.send(&wsResponse{ID: id, Result: result})
+}
+
+func (s *wsSession) fail(id json.RawMessage, code, message string) {
+	s.send(&wsResponse{ID: id, Error: &wsError{Code: code, Message: message}})
+}
+
+// handle answers one client message.
+func (s *wsSession) handle(data []byte) {
+	var req wsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		s.fail(nil, "PARSE_ERROR", "Message must be a JSON object")
+		return
+	}
+
+	switch req.Method {
+	case "subscribe":
+		topic, err := parseWSTopic(req.Params)
+		if err != nil {
+			s.fail(req.ID, "INVALID_PARAMS", err.Error())
+			return
+		}
+		if err := s.h.checkChain(topic.chainID); err != nil {
+			s.fail(req.ID, "INVALID_CHAIN", err.Error())
+			return
+		}
+		id, err := s.subscribe(topic)
+		if err != nil {
+			s.fail(req.ID, "SUBSCRIPTION_FAILED", err.Error())
+			return
+		}
+		s.reply(req.ID, fiber.Map{"subscription": id})
+	case "unsubscribe":
+		var params wsUnsubscribeParams
+		if len(req.Params) == 0 || json.Unmarshal(req.Params, &params) != nil || params.Subscription == "" {
+			s.fail(req.ID, "INVALID_PARAMS", "subscription is required")
+			return
+		}
+		if !s.unsubscribe(params.Subscription) {
+			s.fail(req.ID, "SUBSCRIPTION_NOT_FOUND", "Unknown subscription")
+			return
+		}
+		s.reply(req.ID, true)
+	case "ping":
+		// Application-level heartbeat for clients that can't see pings
+		s.reply(req.ID, "pong")
+	default:
+		s.fail(req.ID, "METHOD_NOT_FOUND", fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// subscribe starts delivering a topic and returns its subscription id.
+func (s *wsSession) subscribe(topic *wsTopic) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.subs) >= maxWSSubscriptions {
+		return "", fmt.Errorf("at most %d subscriptions per connection", maxWSSubscriptions)
+	}
+	s.nextID++
+	id := fmt.Sprintf("%d", s.nextID)
+
+	if topic.kind == wsPendingTransactions {
+		sub, err := s.h.pending.Subscribe(topic.chainID, streamBuffer)
+		if err != nil {
+			return "", err
+		}
+		s.subs[id] = sub.Close
+		go forward(s, id, topic.chainID, sub.C, func(hash string) []wsEvent {
+			return []wsEvent{{Type: "pending_transaction", Data: fiber.Map{"hash": hash}}}
+		})
+		return id, nil
+	}
+
+	sub := s.h.bus.Subscribe(topic.chainID, streamBuffer)
+	s.subs[id] = sub.Close
+	go forward(s, id, topic.chainID, sub.C, topic.match)
+	return id, nil
+}
+
+// forward turns a subscription's channel into notifications until it is
+// closed. A channel closed while the subscription is still registered was
+// dropped for falling behind, which the client is told about.
+func forward[T any](s *wsSession, id string, chainID int64, c <-chan T, match func(T) []wsEvent) {
+	for item := range c {
+		for _, e := range match(item) {
+			if !s.send(&wsNotification{Subscription: id, ChainID: chainID, Type: e.Type, Data: e.Data}) {
+				return
+			}
+		}
+	}
+	s.mu.Lock()
+	_, active := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+	if active {
+		s.send(&wsNotification{Subscription: id, ChainID: chainID, Type: "overflow", Data: fiber.Map{}})
+	}
+}
+
+func (s *wsSession) unsubscribe(id string) bool {
+	s.mu.Lock()
+	cancel, ok := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// close ends the session and all its subscriptions.
+func (s *wsSession) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	subs := s.subs
+	s.subs = make(map[string]func())
+	s.mu.Unlock()
+	for _, cancel := range subs {
+		cancel()
+	}
+}
+
+// subscriptions returns the number of active subscriptions.
+func (s *wsSession) subscriptions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	fasthttpws "github.com/fasthttp/websocket"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseWSTopic(t *testing.T) {
+	topic, err := parseWSTopic(json.RawMessage(`{"type":"blocks"}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1337), topic.chainID)
+
+	topic, err = parseWSTopic(json.RawMessage(`{"type":"reorgs","chain_id":11155111}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11155111), topic.chainID)
+
+	for _, invalid := range []string{
+		``,
+		`[]`,
+		`{}`,
+		`{"type":"unknown"}`,
+		`{"type":"address"}`,
+		`{"type":"address","address":"0x12"}`,
+		`{"type":"logs","addresses":["0x12"]}`,
+		`{"type":"logs","topics":[["0x12"]]}`,
+		`{"type":"logs","topics":[[],[],[],[],[]]}`,
+	} {
+		_, err := parseWSTopic(json.RawMessage(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWSTopicMatches(t *testing.T) {
+	to := "0x000000000000000000000000000000000000bEEF"
+	transfer := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	event := &events.Event{ChainID: 1337, Block: &events.BlockIndexed{
+		Block: &models.Block{BlockNumber: 7},
+		Transactions: []*models.Transaction{
+			{FromAddress: "0x0000000000000000000000000000000000000001", ToAddress: &to},
+			{FromAddress: "0x0000000000000000000000000000000000000002"},
+		},
+		Logs: []*models.TransactionLog{{Address: to, Topic0: &transfer}},
+	}}
+
+	address, err := parseWSTopic(json.RawMessage(`{"type":"address","address":"0x000000000000000000000000000000000000beef"}`))
+	require.NoError(t, err)
+	matched := address.match(event)
+	require.Len(t, matched, 1)
+	assert.Equal(t, "transaction", matched[0].Type)
+
+	logs, err := parseWSTopic(json.RawMessage(`{"type":"logs","topics":[["` + transfer + `"]]}`))
+	require.NoError(t, err)
+	assert.Len(t, logs.match(event), 1)
+
+	reorgs, err := parseWSTopic(json.RawMessage(`{"type":"reorgs"}`))
+	require.NoError(t, err)
+	assert.Empty(t, reorgs.match(event))
+	assert.Len(t, reorgs.match(&events.Event{ChainID: 1337, Reorg: &events.Reorg{FromBlock: 5}}), 1)
+}
+
+func newTestWSSession() *wsSession {
+	return newWSSession(&WebSocketHandler{
+		bus: events.NewBus(),
+		checkChain: func(chainID int64) error {
+			if chainID != 1337 {
+				return fmt.Errorf("chain %d not configured", chainID)
+			}
+			return nil
+		},
+		logger: zap.NewNop().Sugar(),
+	})
+}
+
+func nextMessage(t *testing.T, s *wsSession) interface{} {
+	t.Helper()
+	select {
+	case msg := <-s.out:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message sent")
+		return nil
+	}
+}
+
+func TestWSSessionSubscribe(t *testing.T) {
+	s := newTestWSSession()
+	defer s.close()
+
+	s.handle([]byte(`{"id":1,"method":"subscribe","params":{"type":"blocks"}}`))
+	resp := nextMessage(t, s).(*wsResponse)
+	require.Nil(t, resp.Error)
+	assert.JSONEq(t, `1`, string(resp.ID))
+	id := resp.Result.(fiber.Map)["subscription"].(string)
+
+	s.h.bus.Publish(&events.Event{ChainID: 1337, Block: &events.BlockIndexed{Block: &models.Block{BlockNumber: 9}}})
+	note := nextMessage(t, s).(*wsNotification)
+	assert.Equal(t, id, note.Subscription)
+	assert.Equal(t, "block", note.Type)
+	assert.Equal(t, int64(9), note.Data.(map[string]interface{})["block_number"])
+
+	s.handle([]byte(`{"id":2,"method":"unsubscribe","params":{"subscription":"` + id + `"}}`))
+	assert.Equal(t, true, nextMessage(t, s).(*wsResponse).Result)
+	assert.Equal(t, 0, s.subscriptions())
+
+	s.handle([]byte(`{"id":3,"method":"unsubscribe","params":{"subscription":"` + id + `"}}`))
+	assert.Equal(t, "SUBSCRIPTION_NOT_FOUND", nextMessage(t, s).(*wsResponse).Error.Code)
+}
+
+func TestWSSessionErrors(t *testing.T) {
+	s := newTestWSSession()
+	defer s.close()
+
+	cases := map[string]string{
+		`not json`:                          "PARSE_ERROR",
+		`{"id":1,"method":"eth_subscribe"}`: "METHOD_NOT_FOUND",
+		`{"id":1,"method":"subscribe","params":{"type":"nope"}}`:                "INVALID_PARAMS",
+		`{"id":1,"method":"subscribe","params":{"type":"blocks","chain_id":1}}`: "INVALID_CHAIN",
+		`{"id":1,"method":"unsubscribe"}`:                                       "INVALID_PARAMS",
+	}
+	for msg, code := range cases {
+		s.handle([]byte(msg))
+		resp := nextMessage(t, s).(*wsResponse)
+		require.NotNil(t, resp.Error, msg)
+		assert.Equal(t, code, resp.Error.Code, msg)
+	}
+
+	s.handle([]byte(`{"id":"hb","method":"ping"}`))
+	assert.Equal(t, "pong", nextMessage(t, s).(*wsResponse).Result)
+}
+
+func TestWSSessionSubscriptionLimit(t *testing.T) {
+	s := newTestWSSession()
+	defer s.close()
+
+	for i := 0; i < maxWSSubscriptions; i++ {
+		s.handle([]byte(`{"method":"subscribe","params":{"type":"reorgs"}}`))
+		require.Nil(t, nextMessage(t, s).(*wsResponse).Error)
+	}
+	s.handle([]byte(`{"method":"subscribe","params":{"type":"reorgs"}}`))
+	resp := nextMessage(t, s).(*wsResponse)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "SUBSCRIPTION_FAILED", resp.Error.Code)
+	assert.Equal(t, maxWSSubscriptions, s.subscriptions())
+}
+
+func TestWSSessionClose(t *testing.T) {
+	s := newTestWSSession()
+	s.handle([]byte(`{"method":"subscribe","params":{"type":"blocks"}}`))
+	nextMessage(t, s)
+	require.Equal(t, 1, s.h.bus.Subscribers())
+
+	s.close()
+	s.close()
+	assert.Equal(t, 0, s.h.bus.Subscribers())
+}
+
+func TestWebSocketEndToEnd(t *testing.T) {
+	s := newTestWSSession()
+	h := s.h
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use("/ws", h.Upgrade)
+	app.Get("/ws", websocket.New(h.Handle))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"id": 1, "method": "subscribe", "params": map[string]interface{}{"type": "reorgs"},
+	}))
+	var resp map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&resp))
+	assert.Equal(t, map[string]interface{}{"subscription": "1"}, resp["result"])
+
+	require.Eventually(t, func() bool { return h.bus.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+	h.bus.Publish(&events.Event{ChainID: 1337, Reorg: &events.Reorg{FromBlock: 3}})
+	var note map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&note))
+	assert.Equal(t, "reorg", note["type"])
+	assert.Equal(t, float64(3), note["data"].(map[string]interface{})["from_block"])
+
+	conn.Close()
+	require.Eventually(t, func() bool { return h.bus.Subscribers() == 0 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package httpcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// headRefresh is how long a head is trusted without a block event before
+// it is read again.
+const headRefresh = 30 * time.Second
+
+// HeadSource reads the latest indexed block of a chain. It returns nil if
+// nothing is indexed yet.
+type HeadSource interface {
+	GetLatestBlock(ctx context.Context, chainID int64) (*models.Block, error)
+}
+
+// isFinal reports whether a block is deeper below head than any reorg the
+// indexer follows.
+func isFinal(head, blockNumber int64) bool {
+	return blockNumber <= head-indexer.MaxReorgDepth
+}
+
+// heads tracks the indexed head of each chain.
+type heads struct {
+	source HeadSource
+	now    func() time.Time
+
+	mu     sync.Mutex
+	latest map[int64]head
+}
+
+type head struct {
+	number  int64
+	checked time.Time
+}
+
+func newHeads(source HeadSource) *heads {
+	return &heads{source: source, now: time.Now, latest: make(map[int64]head)}
+}
+
+// get returns a chain's head; ok is false when nothing is indexed.
+func (h *heads) get(ctx context.Context, chainID int64) (number int64, ok bool, err error) {
+	h.mu.Lock()
+	cur, known := h.latest[chainID]
+	h.mu.Unlock()
+	if known && h.now().Sub(cur.checked) < headRefresh {
+		return cur.number, true, nil
+	}
+
+	block, err := h.source.GetLatestBlock(ctx, chainID)
+	if err != nil {
+		return 0, false, err
+	}
+	if block == nil {
+		return 0, false, nil
+	}
+	h.advance(chainID, block.BlockNumber)
+	return block.BlockNumber, true, nil
+}
+
+// advance records a newly indexed block. A stale head is replaced even if
+// it was higher, since a reorg may have been missed.
+func (h *heads) advance(chainID, number int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	cur, known := h.latest[chainID]
+	if known && cur.number > number && now.Sub(cur.checked) < headRefresh {
+		return
+	}
+	h.latest[chainID] = head{number: number, checked: now}
+}
+
+// rewind moves the head back to the last block a reorg kept.
+func (h *heads) rewind(chainID, number int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest[chainID] = head{number: number, checked: h.now()}
+}
+
+func (h *heads) forget(chainID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.latest, chainID)
+}
@@ -0,0 +1,322 @@
+// Package httpcache caches GET responses and tells clients how long they may
+// reuse them. Handlers opt in by setting a policy: data about a block that is
+// deeper than any reorg the indexer follows never changes, so it is served
+// as immutable; data relative to the chain head gets a short TTL. Each
+// response carries a strong ETag, and a matching If-None-Match is answered
+// with 304 Not Modified. A reorg drops everything cached for its chain.
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/cache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeadTTL is how long data relative to the chain head may be reused.
+	HeadTTL = 5 * time.Second
+	// RollupTTL is for aggregates the indexer recomputes periodically.
+	RollupTTL = time.Minute
+	// ImmutableTTL is how long the server keeps immutable responses. Clients
+	// may keep them for a year.
+	ImmutableTTL = time.Hour
+
+	// HeaderCache reports whether a response came from the cache.
+	HeaderCache = "X-Cache"
+
+	immutableControl = "public, max-age=31536000, immutable"
+	policyKey        = "httpcache_policy"
+	watchBuffer      = 256
+)
+
+type policy struct {
+	ttl   time.Duration
+	block *int64
+}
+
+// For makes a successful response cacheable for ttl.
+func For(c *fiber.Ctx, ttl time.Duration) {
+	c.Locals(policyKey, &policy{ttl: ttl})
+}
+
+// Block makes a successful response about the given block cacheable. It is
+// immutable once the block is final and gets HeadTTL until then.
+func Block(c *fiber.Ctx, blockNumber int64) {
+	c.Locals(policyKey, &policy{ttl: HeadTTL, block: &blockNumber})
+}
+
+// Cache is the response cache shared by the cached routes. A nil *Cache
+// caches nothing.
+type Cache struct {
+	store  cache.Store
+	heads  *heads
+	known  func(chainID int64) bool
+	bus    *events.Bus
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	watched map[int64]*events.Subscription
+	closed  bool
+}
+
+// New creates a cache over store. Heads are read from source and kept
+// current from bus, whose reorgs also invalidate the cache; bus may be nil.
+// Only chains for which known returns true are cached and followed; requests
+// for any other chain pass through.
+func New(store cache.Store, source HeadSource, known func(chainID int64) bool, bus *events.Bus, logger *zap.Logger) *Cache {
+	return &Cache{
+		store:   store,
+		heads:   newHeads(source),
+		known:   known,
+		bus:     bus,
+		logger:  logger,
+		now:     time.Now,
+		watched: make(map[int64]*events.Subscription),
+	}
+}
+
+// Handler serves cached responses and stores the responses of the handlers
+// after it that set a policy.
+func (h *Cache) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if h == nil || c.Method() != fiber.MethodGet {
+			return c.Next()
+		}
+		ctx := c.Context()
+		chainID := int64(c.QueryInt("chain_id", 1337))
+		if !h.known(chainID) {
+			return c.Next()
+		}
+		h.watch(chainID)
+		key := requestKey(chainID, c)
+
+		if e := h.load(ctx, key); e != nil {
+			return h.write(c, e, "HIT")
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		p, _ := c.Locals(policyKey).(*policy)
+		if p == nil || c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		e := &entry{
+			ContentType:  string(c.Response().Header.ContentType()),
+			CacheControl: fmt.Sprintf("public, max-age=%d", int(p.ttl.Seconds())),
+			ETag:         etag(body),
+			StoredAt:     h.now().UTC(),
+			body:         body,
+		}
+		ttl := p.ttl
+		if p.block != nil && h.final(ctx, chainID, *p.block) {
+			e.CacheControl = immutableControl
+			ttl = ImmutableTTL
+		}
+		if err := h.store.Set(ctx, key, e.encode(), ttl); err != nil {
+			h.logger.Sugar().Warnw("Failed to cache response", "key", key, "error", err)
+		}
+		return h.write(c, e, "MISS")
+	}
+}
+
+// Purge drops every response cached for a chain. Failures are logged; the
+// entries then expire with their TTL.
+func (h *Cache) Purge(ctx context.Context, chainID int64) {
+	if h == nil {
+		return
+	}
+	if err := h.store.DeletePrefix(ctx, chainPrefix(chainID)); err != nil {
+		h.logger.Sugar().Errorw("Failed to purge cached responses", "chain_id", chainID, "error", err)
+	}
+}
+
+// Close stops following the bus.
+func (h *Cache) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.closed = true
+	subs := h.watched
+	h.watched = make(map[int64]*events.Subscription)
+	h.mu.Unlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+func (h *Cache) load(ctx context.Context, key string) *entry {
+	data, err := h.store.Get(ctx, key)
+	if err != nil {
+		h.logger.Sugar().Warnw("Failed to read cached response", "key", key, "error", err)
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	e, err := decodeEntry(data)
+	if err != nil {
+		h.logger.Sugar().Warnw("Dropping unreadable cached response", "key", key, "error", err)
+		return nil
+	}
+	return e
+}
+
+func (h *Cache) write(c *fiber.Ctx, e *entry, status string) error {
+	c.Set(fiber.HeaderETag, e.ETag)
+	c.Set(fiber.HeaderCacheControl, e.CacheControl)
+	c.Set(HeaderCache, status)
+	if status == "HIT" {
+		c.Set(fiber.HeaderAge, strconv.Itoa(int(h.now().Sub(e.StoredAt).Seconds())))
+	}
+	if matches(c.Get(fiber.HeaderIfNoneMatch), e.ETag) {
+		c.Response().ResetBody()
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+	c.Set(fiber.HeaderContentType, e.ContentType)
+	c.Status(fiber.StatusOK)
+	return c.Send(e.body)
+}
+
+// final reports whether a block is deeper than any reorg can reach. When
+// the head can't be read the block is treated as not final.
+func (h *Cache) final(ctx context.Context, chainID, blockNumber int64) bool {
+	head, ok, err := h.heads.get(ctx, chainID)
+	if err != nil {
+		h.logger.Sugar().Warnw("Failed to read chain head", "chain_id", chainID, "error", err)
+		return false
+	}
+	return ok && isFinal(head, blockNumber)
+}
+
+// watch follows a chain's events, once per chain, to track its head and
+// purge its responses on reorgs.
+func (h *Cache) watch(chainID int64) {
+	if h.bus == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || h.watched[chainID] != nil {
+		return
+	}
+	sub := h.bus.Subscribe(chainID, watchBuffer)
+	h.watched[chainID] = sub
+	go h.follow(chainID, sub)
+}
+
+func (h *Cache) follow(chainID int64, sub *events.Subscription) {
+	ctx := context.Background()
+	for event := range sub.C {
+		switch {
+		case event.Block != nil:
+			h.heads.advance(chainID, event.Block.Block.BlockNumber)
+		case event.Reorg != nil:
+			h.heads.rewind(chainID, event.Reorg.FromBlock-1)
+			h.Purge(ctx, chainID)
+		}
+	}
+
+	// The bus dropped us for falling behind, so a reorg may have been
+	// missed. Start over; the next cached request subscribes again.
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	if h.watched[chainID] == sub {
+		delete(h.watched, chainID)
+	}
+	h.mu.Unlock()
+	h.heads.forget(chainID)
+	h.Purge(ctx, chainID)
+}
+
+// entry is a stored response: a JSON header line followed by the body.
+type entry struct {
+	ContentType  string    `json:"content_type"`
+	CacheControl string    `json:"cache_control"`
+	ETag         string    `json:"etag"`
+	StoredAt     time.Time `json:"stored_at"`
+
+	body []byte
+}
+
+func (e *entry) encode() []byte {
+	header, _ := json.Marshal(e)
+	return append(append(header, '\n'), e.body...)
+}
+
+func decodeEntry(data []byte) (*entry, error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return nil, fmt.Errorf("missing entry header")
+	}
+	var e entry
+	if err := json.Unmarshal(data[:i], &e); err != nil {
+		return nil, fmt.Errorf("failed to decode entry header: %w", err)
+	}
+	e.body = data[i+1:]
+	return &e, nil
+}
+
+// etag hashes the data of a response envelope, leaving out its meta, whose
+// timestamp changes on every render. Other bodies are hashed whole.
+func etag(body []byte) string {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	hashed := body
+	if json.Unmarshal(body, &envelope) == nil && len(envelope.Data) > 0 {
+		hashed = envelope.Data
+	}
+	sum := sha256.Sum256(hashed)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matches implements If-None-Match's weak comparison.
+func matches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func chainPrefix(chainID int64) string {
+	return strconv.FormatInt(chainID, 10) + ":"
+}
+
+// requestKey identifies a response by chain, path and query. The chain is
+// the key's prefix so a chain's entries can be purged together; API keys
+// don't change a response and are left out.
+func requestKey(chainID int64, c *fiber.Ctx) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Del("chain_id")
+	query.Del("api_key")
+	key := chainPrefix(chainID) + c.Path()
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
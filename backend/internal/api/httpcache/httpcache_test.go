@@ -0,0 +1,246 @@
+package httpcache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/cache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fixedHead int64
+
+func (h fixedHead) GetLatestBlock(ctx context.Context, chainID int64) (*models.Block, error) {
+	return &models.Block{ChainID: chainID, BlockNumber: int64(h)}, nil
+}
+
+func knownChain(chainID int64) bool {
+	return chainID == 1 || chainID == 1337
+}
+
+// newCachedApp serves blocks and stats for chains 1 and 1337 through a cache
+// whose head is 100. calls counts the requests that reached a handler.
+func newCachedApp(bus *events.Bus) (*fiber.App, *cache.LRU, *int) {
+	store := cache.NewLRU(1 << 20)
+	h := New(store, fixedHead(100), knownChain, bus, zap.NewNop())
+	calls := new(int)
+
+	app := fiber.New()
+	app.Get("/blocks/:n", h.Handler(), func(c *fiber.Ctx) error {
+		*calls++
+		n, _ := c.ParamsInt("n")
+		Block(c, int64(n))
+		if n > 100 {
+			return responses.Error(c, 404, "RESOURCE_NOT_FOUND", "Block not found", nil)
+		}
+		chainID := int64(c.QueryInt("chain_id", 1337))
+		return responses.Success(c, fiber.Map{"number": n}, &chainID)
+	})
+	app.Get("/stats", h.Handler(), func(c *fiber.Ctx) error {
+		*calls++
+		For(c, HeadTTL)
+		return responses.Success(c, fiber.Map{"calls": *calls}, nil)
+	})
+	app.Get("/uncached", h.Handler(), func(c *fiber.Ctx) error {
+		*calls++
+		return responses.Success(c, nil, nil)
+	})
+	return app, store, calls
+}
+
+func get(t *testing.T, app *fiber.App, target string, header http.Header) *http.Response {
+	req := httptest.NewRequest("GET", target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestFinalBlockIsImmutable(t *testing.T) {
+	app, _, calls := newCachedApp(nil)
+
+	resp := get(t, app, "/blocks/10", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "public, max-age=31536000, immutable", resp.Header.Get(fiber.HeaderCacheControl))
+	assert.Equal(t, "MISS", resp.Header.Get(HeaderCache))
+	etag := resp.Header.Get(fiber.HeaderETag)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	body, _ := io.ReadAll(resp.Body)
+
+	resp = get(t, app, "/blocks/10", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "HIT", resp.Header.Get(HeaderCache))
+	assert.Equal(t, etag, resp.Header.Get(fiber.HeaderETag))
+	assert.Equal(t, "0", resp.Header.Get(fiber.HeaderAge))
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+	cached, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, body, cached)
+	assert.Equal(t, 1, *calls)
+
+	// Blocks a reorg could still replace get a short TTL
+	resp = get(t, app, "/blocks/90", nil)
+	assert.Equal(t, "public, max-age=5", resp.Header.Get(fiber.HeaderCacheControl))
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
+}
+
+func TestNotModified(t *testing.T) {
+	app, _, _ := newCachedApp(nil)
+	etag := get(t, app, "/blocks/10", nil).Header.Get(fiber.HeaderETag)
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		resp := get(t, app, "/blocks/10", http.Header{"If-None-Match": {ifNoneMatch}})
+		assert.Equal(t, 304, resp.StatusCode, ifNoneMatch)
+		assert.Equal(t, etag, resp.Header.Get(fiber.HeaderETag))
+		body, _ := io.ReadAll(resp.Body)
+		assert.Empty(t, body)
+	}
+
+	resp := get(t, app, "/blocks/10", http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestCacheKeys(t *testing.T) {
+	app, _, calls := newCachedApp(nil)
+
+	get(t, app, "/stats?b=2&a=1", nil)
+	// Parameter order, the default chain and API keys don't matter
+	resp := get(t, app, "/stats?a=1&b=2&chain_id=1337&api_key=eds_test", nil)
+	assert.Equal(t, "HIT", resp.Header.Get(HeaderCache))
+	assert.Equal(t, 1, *calls)
+
+	resp = get(t, app, "/stats?a=1&b=2&chain_id=1", nil)
+	assert.Equal(t, "MISS", resp.Header.Get(HeaderCache))
+	assert.Equal(t, 2, *calls)
+}
+
+func TestWatchesKnownChainsOnly(t *testing.T) {
+	h := New(cache.NewLRU(1<<20), fixedHead(100), knownChain, events.NewBus(), zap.NewNop())
+	defer h.Close()
+	app := fiber.New()
+	app.Get("/watched", h.Handler(), func(c *fiber.Ctx) error { return nil })
+
+	for _, chainID := range []int{1, 1337, 5, 6, 7} {
+		get(t, app, fmt.Sprintf("/watched?chain_id=%d", chainID), nil)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	assert.Len(t, h.watched, 2)
+	assert.Contains(t, h.watched, int64(1))
+	assert.Contains(t, h.watched, int64(1337))
+}
+
+func TestUncachedResponses(t *testing.T) {
+	app, store, calls := newCachedApp(nil)
+
+	// Errors aren't stored even when the handler set a policy
+	for i := 0; i < 2; i++ {
+		resp := get(t, app, "/blocks/200", nil)
+		assert.Equal(t, 404, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+	}
+	// Nor are responses without a policy
+	for i := 0; i < 2; i++ {
+		resp := get(t, app, "/uncached", nil)
+		assert.Empty(t, resp.Header.Get(fiber.HeaderCacheControl))
+	}
+	// Nor are responses for chains that aren't configured
+	for i := 0; i < 2; i++ {
+		resp := get(t, app, "/blocks/10?chain_id=99", nil)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(HeaderCache))
+	}
+	assert.Equal(t, 6, *calls)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestNilCachePassesThrough(t *testing.T) {
+	var h *Cache
+	app := fiber.New()
+	app.Get("/", h.Handler(), func(c *fiber.Ctx) error {
+		For(c, HeadTTL)
+		return c.SendString("ok")
+	})
+	resp := get(t, app, "/", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+	h.Purge(context.Background(), 1337)
+	h.Close()
+}
+
+func TestReorgPurgesChain(t *testing.T) {
+	bus := events.NewBus()
+	app, store, _ := newCachedApp(bus)
+
+	get(t, app, "/blocks/10", nil)
+	get(t, app, "/blocks/10?chain_id=1", nil)
+	require.Equal(t, 2, store.Len())
+
+	bus.Publish(&events.Event{ChainID: 1337, Reorg: &events.Reorg{FromBlock: 70}})
+	require.Eventually(t, func() bool { return store.Len() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "HIT", get(t, app, "/blocks/10?chain_id=1", nil).Header.Get(HeaderCache))
+
+	// The reorg moved the head back to 69, so block 10 is no longer final
+	resp := get(t, app, "/blocks/10", nil)
+	assert.Equal(t, "MISS", resp.Header.Get(HeaderCache))
+	assert.Equal(t, "public, max-age=5", resp.Header.Get(fiber.HeaderCacheControl))
+
+	// New blocks move it forward again
+	bus.Publish(&events.Event{ChainID: 1337, Block: &events.BlockIndexed{Block: &models.Block{BlockNumber: 200}}})
+	attempt := 0
+	require.Eventually(t, func() bool {
+		attempt++
+		resp := get(t, app, fmt.Sprintf("/blocks/20?attempt=%d", attempt), nil)
+		return resp.Header.Get(fiber.HeaderCacheControl) == immutableControl
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHeads(t *testing.T) {
+	h := newHeads(fixedHead(100))
+	now := time.Now()
+	h.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	head, ok, err := h.get(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), head)
+
+	// Late block events don't move a fresh head back
+	h.advance(1, 99)
+	head, _, _ = h.get(ctx, 1)
+	assert.Equal(t, int64(100), head)
+
+	h.rewind(1, 80)
+	head, _, _ = h.get(ctx, 1)
+	assert.Equal(t, int64(80), head)
+
+	// Without events the head is read again
+	now = now.Add(headRefresh)
+	head, _, _ = h.get(ctx, 1)
+	assert.Equal(t, int64(100), head)
+
+	assert.True(t, isFinal(100, 36))
+	assert.False(t, isFinal(100, 37))
+}
+
+func TestETagIgnoresMeta(t *testing.T) {
+	a := etag([]byte(`{"success":true,"data":{"n":1},"meta":{"timestamp":"2024-01-01T00:00:00Z"}}`))
+	b := etag([]byte(`{"success":true,"data":{"n":1},"meta":{"timestamp":"2024-01-02T00:00:00Z"}}`))
+	c := etag([]byte(`{"success":true,"data":{"n":2},"meta":{"timestamp":"2024-01-01T00:00:00Z"}}`))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.NotEqual(t, etag([]byte("plain")), etag([]byte("other")))
+}
@@ -13,13 +13,17 @@ func Logger(logger *zap.Logger) fiber.Handler {
 		err := c.Next()
 		duration := time.Since(start)
 
-		sugar.Infow("HTTP request",
+		fields := []interface{}{
 			"method", c.Method(),
 			"path", c.Path(),
 			"status", c.Response().StatusCode(),
 			"duration_ms", duration.Milliseconds(),
 			"ip", c.IP(),
-		)
+		}
+		if key := RequestAPIKey(c); key != nil {
+			fields = append(fields, "api_key", key.Prefix)
+		}
+		sugar.Infow("HTTP request", fields...)
 		return err
 	}
 }
\ No newline at end of file
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/apikeys"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/ratelimit"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+)
+
+// Headers read and set by RateLimit
+const (
+	HeaderAPIKey             = "X-API-Key"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderQuotaLimit         = "X-Quota-Limit"
+	HeaderQuotaRemaining     = "X-Quota-Remaining"
+	HeaderQuotaReset         = "X-Quota-Reset"
+)
+
+// LocalAPIKey holds the *models.APIKey of an authenticated request.
+const LocalAPIKey = "api_key"
+
+// RateLimit limits requests with a token bucket per API key, or per client
+// IP for requests without one. Keys are read from the X-API-Key header or
+// the api_key query parameter, for clients such as EventSource that can't
+// set headers, and take their limits and daily quota from their tier.
+// Requests with an unusable key get 401 and count against the IP's bucket,
+// so keys can't be guessed faster than anonymous requests are allowed.
+//
+// Every limited response carries X-RateLimit-Limit (the burst),
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is
+// full); keys with a quota also get the X-Quota-* equivalents. Quotas are
+// counted in counter, so they are shared by every API process. Preflight
+// requests and the exempt paths aren't limited.
+func RateLimit(keys *apikeys.Store, counter ratelimit.Counter, anonymous ratelimit.Limit, exempt ...string) fiber.Handler {
+	limiter := ratelimit.NewLimiter()
+	quota := ratelimit.NewQuota(counter)
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodOptions || skip[c.Path()] {
+			return c.Next()
+		}
+		presented := c.Get(HeaderAPIKey)
+		if presented == "" {
+			presented = c.Query("api_key")
+		}
+		if presented == "" {
+			return limited(c, limiter.Allow("ip:"+c.IP(), anonymous))
+		}
+
+		key, err := keys.Get(c.Context(), presented)
+		if err != nil {
+			return responses.Error(c, 500, "DATABASE_ERROR", "Failed to check API key", err.Error())
+		}
+		if key == nil {
+			if result := limiter.Allow("ip:"+c.IP(), anonymous); !result.Allowed {
+				return limited(c, result)
+			}
+			return responses.Error(c, 401, "INVALID_API_KEY", "Unknown, revoked or expired API key", nil)
+		}
+		c.Locals(LocalAPIKey, key)
+
+		limit := ratelimit.Limit{PerMinute: key.Tier.RequestsPerMinute, Burst: key.Tier.Burst}
+		result := limiter.Allow("key:"+strconv.FormatInt(key.ID, 10), limit)
+		setRateLimitHeaders(c, result)
+		if !result.Allowed {
+			return limited(c, result)
+		}
+		if key.Tier.DailyQuota != nil {
+			ok, remaining, reset, err := quota.Take(c.Context(), key.ID, *key.Tier.DailyQuota)
+			if err != nil {
+				return responses.Error(c, 500, "DATABASE_ERROR", "Failed to check daily quota", err.Error())
+			}
+			c.Set(HeaderQuotaLimit, strconv.FormatInt(*key.Tier.DailyQuota, 10))
+			c.Set(HeaderQuotaRemaining, strconv.FormatInt(remaining, 10))
+			c.Set(HeaderQuotaReset, ceilSeconds(reset))
+			if !ok {
+				c.Set(fiber.HeaderRetryAfter, ceilSeconds(reset))
+				return responses.Error(c, 429, "QUOTA_EXCEEDED", "Daily request quota used up", fiber.Map{
+					"tier":        key.Tier.Name,
+					"daily_quota": *key.Tier.DailyQuota,
+				})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// limited answers 429 if the request was refused and continues otherwise.
+func limited(c *fiber.Ctx, result ratelimit.Result) error {
+	setRateLimitHeaders(c, result)
+	if result.Allowed {
+		return c.Next()
+	}
+	c.Set(fiber.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+	return responses.Error(c, 429, "RATE_LIMITED", "Too many requests", fiber.Map{
+		"limit":       result.Limit,
+		"retry_after": math.Ceil(result.RetryAfter.Seconds()),
+	})
+}
+
+func setRateLimitHeaders(c *fiber.Ctx, result ratelimit.Result) {
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	c.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+}
+
+func ceilSeconds(d time.Duration) string {
+	return fmt.Sprint(int64(math.Ceil(d.Seconds())))
+}
+
+// RequestAPIKey returns the key a request authenticated with, if any.
+func RequestAPIKey(c *fiber.Ctx) *models.APIKey {
+	key, _ := c.Locals(LocalAPIKey).(*models.APIKey)
+	return key
+}
+
+// AdminAuth guards the admin endpoints with a bearer token. Without a
+// configured token they are disabled.
+func AdminAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return responses.Error(c, 403, "FORBIDDEN", "Admin API is disabled; set API_ADMIN_TOKEN to enable it", nil)
+		}
+		presented, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return responses.Error(c, 401, "UNAUTHORIZED", "Missing or invalid admin token", nil)
+		}
+		return c.Next()
+	}
+}
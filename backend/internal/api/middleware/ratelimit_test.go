@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pulkyeet/eth-devstack/backend/internal/apikeys"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/ratelimit"
+	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyLookup map[string]*models.APIKey
+
+func (l keyLookup) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return l[hash], nil
+}
+
+func (l keyLookup) TouchAPIKey(ctx context.Context, id int64) error {
+	return nil
+}
+
+// requestCounter counts quota requests per key, ignoring the day.
+type requestCounter map[int64]int64
+
+func (r requestCounter) TakeAPIKeyRequest(ctx context.Context, key int64, day time.Time, limit int64) (int64, bool, error) {
+	if r[key] >= limit {
+		return r[key], false, nil
+	}
+	r[key]++
+	return r[key], true, nil
+}
+
+func newRateLimitedApp(t *testing.T, anonymous ratelimit.Limit) (*fiber.App, *models.APIKey) {
+	quota := int64(3)
+	key := &models.APIKey{ID: 7, Prefix: "eds_test", Tier: &models.APITier{Name: "free", RequestsPerMinute: 60, Burst: 5, DailyQuota: &quota}}
+	require.NoError(t, apikeys.New(key))
+
+	app := fiber.New()
+	app.Use(RateLimit(apikeys.NewStore(keyLookup{key.KeyHash: key}, time.Minute), requestCounter{}, anonymous, "/health"))
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/", func(c *fiber.Ctx) error {
+		if k := RequestAPIKey(c); k != nil {
+			return c.SendString(k.Prefix)
+		}
+		return c.SendString("anonymous")
+	})
+	return app, key
+}
+
+func get(t *testing.T, app *fiber.App, target string, header http.Header) *http.Response {
+	req := httptest.NewRequest("GET", target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func errorCode(t *testing.T, resp *http.Response) string {
+	var body responses.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotNil(t, body.Error)
+	return body.Error.Code
+}
+
+func TestRateLimitAnonymous(t *testing.T) {
+	app, _ := newRateLimitedApp(t, ratelimit.Limit{PerMinute: 60, Burst: 2})
+
+	resp := get(t, app, "/", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get(HeaderRateLimitLimit))
+	assert.Equal(t, "1", resp.Header.Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "1", resp.Header.Get(HeaderRateLimitReset))
+
+	assert.Equal(t, 200, get(t, app, "/", nil).StatusCode)
+	resp = get(t, app, "/", nil)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, "RATE_LIMITED", errorCode(t, resp))
+
+	// Exempt paths aren't limited
+	resp = get(t, app, "/health", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(HeaderRateLimitLimit))
+}
+
+func TestRateLimitAPIKey(t *testing.T) {
+	app, key := newRateLimitedApp(t, ratelimit.Limit{PerMinute: 60, Burst: 1})
+
+	// Keys have their own bucket, on their tier's limits
+	for i := 0; i < 3; i++ {
+		resp := get(t, app, "/", http.Header{HeaderAPIKey: {key.Key}})
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "5", resp.Header.Get(HeaderRateLimitLimit))
+		assert.Equal(t, "3", resp.Header.Get(HeaderQuotaLimit))
+	}
+	assert.Equal(t, 200, get(t, app, "/", nil).StatusCode)
+
+	// The query parameter works too, and the quota of 3 is used up
+	resp := get(t, app, "/?api_key="+key.Key, nil)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get(HeaderQuotaRemaining))
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, "QUOTA_EXCEEDED", errorCode(t, resp))
+}
+
+func TestRateLimitInvalidKey(t *testing.T) {
+	app, _ := newRateLimitedApp(t, ratelimit.Limit{PerMinute: 60, Burst: 1})
+
+	resp := get(t, app, "/", http.Header{HeaderAPIKey: {apikeys.Prefix + "wrong"}})
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, "INVALID_API_KEY", errorCode(t, resp))
+
+	// Failed attempts use the IP's bucket
+	resp = get(t, app, "/", http.Header{HeaderAPIKey: {apikeys.Prefix + "wrong"}})
+	assert.Equal(t, 429, resp.StatusCode)
+}
+
+func TestAdminAuth(t *testing.T) {
+	newApp := func(token string) *fiber.App {
+		app := fiber.New()
+		app.Use(AdminAuth(token))
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+		return app
+	}
+
+	resp := get(t, newApp(""), "/", http.Header{fiber.HeaderAuthorization: {"Bearer "}})
+	assert.Equal(t, 403, resp.StatusCode)
+
+	app := newApp("s3cret")
+	resp = get(t, app, "/", nil)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Header.Get(fiber.HeaderWWWAuthenticate))
+	assert.Equal(t, "UNAUTHORIZED", errorCode(t, resp))
+	assert.Equal(t, 401, get(t, app, "/", http.Header{fiber.HeaderAuthorization: {"Bearer wrong"}}).StatusCode)
+	assert.Equal(t, 200, get(t, app, "/", http.Header{fiber.HeaderAuthorization: {"Bearer s3cret"}}).StatusCode)
+}
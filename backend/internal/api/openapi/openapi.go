@@ -0,0 +1,296 @@
+// Package openapi builds an OpenAPI 3 document for the API. Paths come from
+// the routes registered on the Fiber app and are matched to the operations
+// documented for them, so a route can't be served without being documented
+// or documented without being served. Schemas for models are derived from
+// their Go types and JSON tags.
+package openapi
+
+import (
+	_ "embed"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Version is the OpenAPI version documents are written in.
+const Version = "3.0.3"
+
+// UI is a self-contained page that renders the document served next to it
+// at openapi.json and can send requests to the documented operations.
+//
+//go:embed ui/index.html
+var UI []byte
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations on a path.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]*Header   `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how a request authenticates: an API key in a
+// header or query parameter, or an HTTP scheme such as bearer.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+}
+
+// SecurityRequirement names the schemes a request may authenticate with;
+// an empty requirement makes authentication optional.
+type SecurityRequirement map[string][]string
+
+// Schema is the subset of JSON Schema used by the API's documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Route is one documented method and path, with the path in Fiber's
+// :param form.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Spec collects the documented operations and the schemas they refer to.
+type Spec struct {
+	tags            []Tag
+	operations      map[Route]*Operation
+	schemas         *schemas
+	responses       map[string]*Response
+	securitySchemes map[string]*SecurityScheme
+	security        []SecurityRequirement
+}
+
+func NewSpec() *Spec {
+	return &Spec{
+		operations: map[Route]*Operation{},
+		schemas:    newSchemas(),
+		responses:  map[string]*Response{},
+
+		securitySchemes: map[string]*SecurityScheme{},
+	}
+}
+
+// Tag adds a tag, listed in the order tags are added.
+func (s *Spec) Tag(name, description string) {
+	s.tags = append(s.tags, Tag{Name: name, Description: description})
+}
+
+// Add documents the operation served on method and path.
+func (s *Spec) Add(method, path string, op *Operation) {
+	s.operations[Route{Method: method, Path: path}] = op
+}
+
+// Model returns a reference to the schema of v's type, deriving it and the
+// schemas of the types it contains on first use.
+func (s *Spec) Model(v interface{}) *Schema {
+	return s.schemas.model(v)
+}
+
+// Define adds a named schema and returns a reference to it.
+func (s *Spec) Define(name string, schema *Schema) *Schema {
+	s.schemas.defined[name] = schema
+	return Ref(name)
+}
+
+// DefineResponse adds a named response and returns a reference to it.
+func (s *Spec) DefineResponse(name string, response *Response) *Response {
+	s.responses[name] = response
+	return &Response{Ref: "#/components/responses/" + name}
+}
+
+// SecurityScheme adds a way of authenticating requests.
+func (s *Spec) SecurityScheme(name string, scheme *SecurityScheme) {
+	s.securitySchemes[name] = scheme
+}
+
+// Security sets the authentication operations accept unless they say
+// otherwise.
+func (s *Spec) Security(requirements ...SecurityRequirement) {
+	s.security = requirements
+}
+
+// Build documents the routes registered on an app. HEAD routes, which Fiber
+// adds for every GET, and undocumented routes are left out; Missing lists
+// the latter.
+func (s *Spec) Build(info Info, routes []fiber.Route) *Document {
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     info,
+		Tags:     s.tags,
+		Security: s.security,
+		Paths:    map[string]PathItem{},
+		Components: Components{
+			Schemas:         s.schemas.defined,
+			Responses:       s.responses,
+			SecuritySchemes: s.securitySchemes,
+		},
+	}
+	for _, route := range routes {
+		op := s.operations[Route{Method: route.Method, Path: route.Path}]
+		if op == nil {
+			continue
+		}
+		path := PathTemplate(route.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = withPathParams(op, route.Params)
+	}
+	return doc
+}
+
+// Missing lists the routes without a documented operation, as
+// "METHOD path".
+func (s *Spec) Missing(routes []fiber.Route) []string {
+	var missing []string
+	seen := map[Route]bool{}
+	for _, route := range routes {
+		r := Route{Method: route.Method, Path: route.Path}
+		if r.Method == fiber.MethodHead || seen[r] || s.operations[r] != nil {
+			continue
+		}
+		seen[r] = true
+		missing = append(missing, r.Method+" "+r.Path)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Unrouted lists the documented operations no route serves, as
+// "METHOD path".
+func (s *Spec) Unrouted(routes []fiber.Route) []string {
+	served := map[Route]bool{}
+	for _, route := range routes {
+		served[Route{Method: route.Method, Path: route.Path}] = true
+	}
+	var unrouted []string
+	for r := range s.operations {
+		if !served[r] {
+			unrouted = append(unrouted, r.Method+" "+r.Path)
+		}
+	}
+	sort.Strings(unrouted)
+	return unrouted
+}
+
+// PathTemplate converts a Fiber path to an OpenAPI path template, e.g.
+// /blocks/:id to /blocks/{id}.
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(segment[1:], "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// withPathParams adds the route's path parameters the operation doesn't
+// document itself, as strings.
+func withPathParams(op *Operation, params []string) *Operation {
+	documented := map[string]bool{}
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			documented[p.Name] = true
+		}
+	}
+	out := *op
+	out.Parameters = nil
+	for _, name := range params {
+		if !documented[name] {
+			out.Parameters = append(out.Parameters, PathParam(name, "", String()))
+		}
+	}
+	out.Parameters = append(out.Parameters, op.Parameters...)
+	return &out
+}
@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type inner struct {
+	Name string `json:"name"`
+}
+
+type base struct {
+	ID int64 `json:"id"`
+}
+
+type sample struct {
+	base
+	Hash      string            `json:"hash"`
+	Value     *string           `json:"value"`
+	Tags      []string          `json:"tags"`
+	Extra     map[string]int    `json:"extra,omitempty"`
+	Raw       json.RawMessage   `json:"raw"`
+	CreatedAt time.Time         `json:"created_at"`
+	Inner     *inner            `json:"inner"`
+	Inners    []inner           `json:"inners"`
+	Anonymous struct{ N int32 } `json:"anonymous"`
+	Skipped   string            `json:"-"`
+	hidden    string
+}
+
+func TestModel(t *testing.T) {
+	spec := NewSpec()
+	assert.Equal(t, Ref("Sample"), spec.Model(sample{}))
+
+	s := spec.schemas.defined["Sample"]
+	require.NotNil(t, s)
+	assert.ElementsMatch(t, []string{"id", "hash", "tags", "raw", "created_at", "inners", "anonymous"}, s.Required)
+	assert.NotContains(t, s.Properties, "Skipped")
+	assert.NotContains(t, s.Properties, "hidden")
+
+	assert.Equal(t, "int64", s.Properties["id"].Format)
+	assert.True(t, s.Properties["value"].Nullable)
+	assert.True(t, s.Properties["tags"].Nullable)
+	assert.Equal(t, "integer", s.Properties["extra"].AdditionalProperties.Type)
+	assert.Empty(t, s.Properties["raw"].Type)
+	assert.Equal(t, "date-time", s.Properties["created_at"].Format)
+	assert.Equal(t, []*Schema{Ref("Inner")}, s.Properties["inner"].AllOf)
+	assert.True(t, s.Properties["inner"].Nullable)
+	assert.Equal(t, Ref("Inner"), s.Properties["inners"].Items)
+	assert.Equal(t, "int32", s.Properties["anonymous"].Properties["N"].Format)
+	assert.Contains(t, spec.schemas.defined, "Inner")
+}
+
+func TestBuild(t *testing.T) {
+	spec := NewSpec()
+	spec.Add("GET", "/blocks/:id", &Operation{OperationID: "getBlock", Responses: map[string]*Response{}})
+	spec.Add("GET", "/gone", &Operation{OperationID: "gone", Responses: map[string]*Response{}})
+
+	routes := []fiber.Route{
+		{Method: "GET", Path: "/blocks/:id", Params: []string{"id"}},
+		{Method: "HEAD", Path: "/blocks/:id", Params: []string{"id"}},
+		{Method: "POST", Path: "/blocks"},
+	}
+	doc := spec.Build(Info{Title: "test", Version: "1"}, routes)
+	require.Contains(t, doc.Paths, "/blocks/{id}")
+	op := doc.Paths["/blocks/{id}"]["get"]
+	require.Len(t, op.Parameters, 1)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.NotContains(t, doc.Paths["/blocks/{id}"], "head")
+
+	assert.Equal(t, []string{"POST /blocks"}, spec.Missing(routes))
+	assert.Equal(t, []string{"GET /gone"}, spec.Unrouted(routes))
+}
+
+func TestPathTemplate(t *testing.T) {
+	assert.Equal(t, "/api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+		PathTemplate("/api/v1/webhooks/:id/deliveries/:delivery_id/redeliver"))
+	assert.Equal(t, "/rpc/{chain_id}", PathTemplate("/rpc/:chain_id?"))
+	assert.Equal(t, "/health", PathTemplate("/health"))
+}
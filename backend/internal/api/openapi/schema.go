@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas holds the named component schemas. Types are named after
+// themselves, prefixed with their package when two packages share a name.
+type schemas struct {
+	defined map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{defined: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+func (s *schemas) model(v interface{}) *Schema {
+	return s.schema(reflect.TypeOf(v))
+}
+
+// schema returns the schema of t, with named structs as references.
+func (s *schemas) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(s.schema(t.Elem()))
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(s.schema(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return Ref(s.name(t))
+	}
+	// Interfaces hold any value
+	return &Schema{}
+}
+
+// name registers a named struct, deriving its schema the first time.
+func (s *schemas) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	// Unexported request types are named as if exported
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := s.defined[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.names[t] = name
+	// Reserve the name before recursing, so self-referencing types resolve
+	s.defined[name] = &Schema{}
+	*s.defined[name] = *s.object(t)
+	return name
+}
+
+// object derives a struct's schema following encoding/json: fields are
+// named by their json tag, "-" fields are skipped and embedded structs are
+// inlined. Fields without omitempty are required, except pointers, which
+// request bodies may leave out; pointers, slices, maps and interfaces may
+// be null.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(t, schema, false)
+	return schema
+}
+
+func (s *schemas) fields(t reflect.Type, schema *Schema, optional bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, schema, optional || field.Type.Kind() == reflect.Ptr)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.schema(field.Type)
+		switch field.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface:
+			if field.Type != rawMessageType {
+				prop = nullable(prop)
+			}
+		}
+		schema.Properties[name] = prop
+		if !optional && !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// nullable marks a schema as accepting null. References can't carry
+// siblings in OpenAPI 3.0, so they are wrapped.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Nullable: true}
+	}
+	if s.Type == "" {
+		return s
+	}
+	out := *s
+	out.Nullable = true
+	return &out
+}
+
+// Ref refers to a component schema.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func Integer() *Schema {
+	return &Schema{Type: "integer", Format: "int64"}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+// Enum is a string taking one of values.
+func Enum(values ...string) *Schema {
+	schema := String()
+	for _, v := range values {
+		schema.Enum = append(schema.Enum, v)
+	}
+	return schema
+}
+
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// OneOf is a value matching exactly one of schemas.
+func OneOf(schemas ...*Schema) *Schema {
+	return &Schema{OneOf: schemas}
+}
+
+// Object is an object with the given properties, all of them present.
+func Object(properties map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: properties}
+	for name := range properties {
+		schema.Required = append(schema.Required, name)
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// Describe returns a copy of s with a description.
+func Describe(s *Schema, description string) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Description: description}
+	}
+	out := *s
+	out.Description = description
+	return &out
+}
+
+// WithDefault returns a copy of s with a default value.
+func WithDefault(s *Schema, value interface{}) *Schema {
+	out := *s
+	out.Default = value
+	return &out
+}
+
+// Range returns a copy of an integer schema bounded to min..max.
+func Range(s *Schema, min, max float64) *Schema {
+	out := *s
+	out.Minimum, out.Maximum = &min, &max
+	return &out
+}
+
+func QueryParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func PathParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+func HeaderParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "header", Description: description, Schema: schema}
+}
+
+// Required returns a copy of p that must be given.
+func Required(p *Parameter) *Parameter {
+	out := *p
+	out.Required = true
+	return &out
+}
+
+// JSONBody is a required JSON request body.
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: schema}}}
+}
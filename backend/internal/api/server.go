@@ -3,22 +3,36 @@ package api
 import (
 	"fmt"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/pulkyeet/eth-devstack/backend/internal/api/handlers"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/httpcache"
 	"github.com/pulkyeet/eth-devstack/backend/internal/api/middleware"
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/openapi"
+	"github.com/pulkyeet/eth-devstack/backend/internal/apikeys"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/cache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/graphql"
+	"github.com/pulkyeet/eth-devstack/backend/internal/ratelimit"
 	"github.com/pulkyeet/eth-devstack/backend/internal/responses"
+	"github.com/pulkyeet/eth-devstack/backend/internal/verifier"
 	"go.uber.org/zap"
 )
 
 type Server struct {
 	app *fiber.App
 	db *database.DB
+	cache *httpcache.Cache
 	logger *zap.Logger
 	port string
 }
 
-func NewServer(db *database.DB, logger *zap.Logger, port string) *Server {
+// NewServer sets up the routes. A nil responseCache turns response caching
+// off.
+func NewServer(db *database.DB, chainManager *blockchain.ChainManager, verifier *verifier.Verifier, bus *events.Bus, auth config.AuthConfig, webhookCfg config.WebhookConfig, responseCache cache.Store, logger *zap.Logger, port string) *Server {
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -34,39 +48,175 @@ func NewServer(db *database.DB, logger *zap.Logger, port string) *Server {
 	app.Use(middleware.Logger(logger))
 	app.Use(middleware.CORS())
 
+	keys := apikeys.NewStore(db, apikeys.DefaultCacheTTL)
+	if auth.RateLimitEnabled {
+		anonymous := ratelimit.Limit{PerMinute: auth.AnonymousPerMinute, Burst: auth.AnonymousBurst}
+		app.Use(middleware.RateLimit(keys, db, anonymous, "/api/v1/health"))
+	}
+
+	var httpCache *httpcache.Cache
+	if responseCache != nil {
+		configured := func(chainID int64) bool {
+			_, err := chainManager.GetConfig(chainID)
+			return err == nil
+		}
+		httpCache = httpcache.New(responseCache, db, configured, bus, logger)
+	}
+	cached := httpCache.Handler()
+
 	blockHandler := handlers.NewBlockHandler(db)
 	txHandler := handlers.NewTransactionHandler(db)
-	addrHandler := handlers.NewAddressHandler(db)
+	addrHandler := handlers.NewAddressHandler(db, chainManager)
 	chainHandler := handlers.NewChainHandler(db)
-	searchHandler := handlers.NewSearchHandler(db)
-	streamHandler := handlers.NewStreamHandler(db, logger)
+	searchHandler := handlers.NewSearchHandler(db, chainManager)
+	streamHandler := handlers.NewStreamHandler(db, bus, logger)
+	wsHandler := handlers.NewWebSocketHandler(bus, events.NewPendingFeed(chainManager, logger), chainManager, logger)
 	statsHandler := handlers.NewStatsHandler(db)
+	gasHandler := handlers.NewGasHandler(db, chainManager)
+	contractHandler := handlers.NewContractHandler(db)
+	logHandler := handlers.NewLogHandler(db)
+	tokenHandler := handlers.NewTokenHandler(db)
+	rankingHandler := handlers.NewRankingHandler(db)
+	exportHandler := handlers.NewExportHandler(db, logger)
+	webhookHandler := handlers.NewWebhookHandler(db, webhookCfg)
+	alertHandler := handlers.NewAlertHandler(db)
+	labelHandler := handlers.NewLabelHandler(db, httpCache)
+	watchlistHandler := handlers.NewWatchlistHandler(db)
+	rpcHandler := handlers.NewRPCHandler(db, chainManager, logger)
+	etherscanHandler := handlers.NewEtherscanHandler(db, chainManager, verifier, logger)
+	graphQLHandler := handlers.NewGraphQLHandler(graphql.NewService(db))
+	docsHandler := handlers.NewDocsHandler()
+	apiKeyHandler := handlers.NewAPIKeyHandler(db, keys)
+
+	// Etherscan-compatible API, mounted where Hardhat and Foundry expect it
+	app.Get("/api", etherscanHandler.Handle)
+	app.Post("/api", etherscanHandler.Handle)
+
+	app.Use("/ws", wsHandler.Upgrade)
+	app.Get("/ws", websocket.New(wsHandler.Handle))
 
 	api := app.Group("/api/v1")
 
+	api.Get("/openapi.json", docsHandler.GetOpenAPI)
+	api.Get("/docs", docsHandler.GetDocs)
+
 	api.Get("/health", chainHandler.GetHealth)
 	api.Get("/chains", chainHandler.GetChains)
 
-	api.Get("/blocks", blockHandler.GetBlocks)
-	api.Get("/blocks/:id", blockHandler.GetBlock)
+	api.Get("/blocks", cached, blockHandler.GetBlocks)
+	api.Get("/blocks/:id", cached, blockHandler.GetBlock)
 
-	api.Get("/transactions", txHandler.GetTransactions)
-	api.Get("/transactions/:hash", txHandler.GetTransaction)
+	api.Get("/transactions", cached, txHandler.GetTransactions)
+	api.Get("/transactions/:hash", cached, txHandler.GetTransaction)
+	api.Get("/transactions/:hash/decoded", txHandler.GetDecodedInput)
 
 	api.Get("/addresses/:address", addrHandler.GetAddress)
-	api.Get("/addresses/:address/:/transactions", addrHandler.GetAddressTransactions)
+	api.Get("/addresses/:address/transactions", addrHandler.GetAddressTransactions)
+
+	api.Get("/logs", cached, logHandler.GetLogs)
 
 	api.Get("/search", searchHandler.Search)
+	api.Get("/search/autocomplete", searchHandler.Autocomplete)
 
 	api.Get("/stream/blocks", streamHandler.StreamBlocks)
+	api.Get("/stream/transactions", streamHandler.StreamTransactions)
+	api.Get("/stream/logs", streamHandler.StreamLogs)
+	api.Get("/stream/token-transfers", streamHandler.StreamTokenTransfers)
 
-	api.Get("/stats", statsHandler.GetStats)
+	api.Post("/rpc", rpcHandler.Handle)
+	api.Post("/rpc/:chain_id", rpcHandler.Handle)
+
+	api.Get("/graphql", graphQLHandler.Query)
+	api.Post("/graphql", graphQLHandler.Query)
+
+	api.Get("/stats", cached, statsHandler.GetStats)
+	api.Get("/stats/daily", cached, statsHandler.GetDailyStats)
+	api.Get("/stats/hourly", cached, statsHandler.GetHourlyStats)
+
+	api.Get("/gas", cached, gasHandler.GetGas)
+	api.Get("/gas/history", cached, gasHandler.GetGasHistory)
 
 	api.Get("/addresses/:address/tokens", addrHandler.GetAddressTokens)
+	api.Get("/addresses/:address/approvals", addrHandler.GetAddressApprovals)
+	api.Get("/addresses/:address/summary", addrHandler.GetAddressSummary)
+	api.Get("/portfolio/:address", addrHandler.GetPortfolio)
+
+	api.Get("/export/:dataset", exportHandler.Export)
+
+	api.Get("/accounts/top", cached, rankingHandler.GetTopAccounts)
+	api.Get("/contracts/top", cached, rankingHandler.GetTopContracts)
+	api.Get("/tokens/top", cached, rankingHandler.GetTopTokens)
+
+	api.Get("/tokens", tokenHandler.GetTokens)
+	api.Get("/tokens/:address", tokenHandler.GetToken)
+	api.Get("/tokens/:address/transfers", tokenHandler.GetTokenTransfers)
+	api.Get("/tokens/:address/holders", tokenHandler.GetTokenHolders)
+
+	// Webhooks reach out to arbitrary URLs and carry signing secrets, and
+	// rules, labels and watchlists are shared by every client, so changing
+	// them takes the admin token
+	adminOnly := middleware.AdminAuth(auth.AdminToken)
+
+	webhookRoutes := api.Group("/webhooks", adminOnly)
+	webhookRoutes.Post("", webhookHandler.CreateWebhook)
+	webhookRoutes.Get("", webhookHandler.GetWebhooks)
+	webhookRoutes.Get("/:id", webhookHandler.GetWebhook)
+	webhookRoutes.Patch("/:id", webhookHandler.UpdateWebhook)
+	webhookRoutes.Delete("/:id", webhookHandler.DeleteWebhook)
+	webhookRoutes.Get("/:id/deliveries", webhookHandler.GetDeliveries)
+	webhookRoutes.Get("/:id/dead-letters", webhookHandler.GetDeadLetters)
+	webhookRoutes.Post("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+	api.Post("/alerts/rules", adminOnly, alertHandler.CreateAlertRule)
+	api.Get("/alerts/rules", alertHandler.GetAlertRules)
+	api.Get("/alerts/rules/:id", alertHandler.GetAlertRule)
+	api.Patch("/alerts/rules/:id", adminOnly, alertHandler.UpdateAlertRule)
+	api.Delete("/alerts/rules/:id", adminOnly, alertHandler.DeleteAlertRule)
+	api.Get("/alerts/rules/:id/events", alertHandler.GetAlertEvents)
+
+	api.Post("/labels", adminOnly, labelHandler.CreateLabel)
+	api.Get("/labels", labelHandler.GetLabels)
+	api.Get("/labels/:id", labelHandler.GetLabel)
+	api.Patch("/labels/:id", adminOnly, labelHandler.UpdateLabel)
+	api.Delete("/labels/:id", adminOnly, labelHandler.DeleteLabel)
+
+	api.Post("/watchlists", adminOnly, watchlistHandler.CreateWatchlist)
+	api.Get("/watchlists", watchlistHandler.GetWatchlists)
+	api.Get("/watchlists/:id", watchlistHandler.GetWatchlist)
+	api.Patch("/watchlists/:id", adminOnly, watchlistHandler.UpdateWatchlist)
+	api.Delete("/watchlists/:id", adminOnly, watchlistHandler.DeleteWatchlist)
+	api.Post("/watchlists/:id/addresses", adminOnly, watchlistHandler.AddAddresses)
+	api.Delete("/watchlists/:id/addresses/:address", adminOnly, watchlistHandler.RemoveAddress)
+	api.Get("/watchlists/:id/activity", watchlistHandler.GetActivity)
+
+	api.Get("/contracts/:address/proxy", contractHandler.GetProxy)
+	api.Get("/contracts/:address/abi", contractHandler.GetABI)
+	api.Post("/contracts/:address/abi", contractHandler.UploadABI)
+
+	admin := api.Group("/admin", adminOnly)
+	admin.Get("/tiers", apiKeyHandler.GetTiers)
+	admin.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+	admin.Get("/api-keys", apiKeyHandler.GetAPIKeys)
+	admin.Get("/api-keys/:id", apiKeyHandler.GetAPIKey)
+	admin.Delete("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+	spec := handlers.OpenAPI()
+	if missing := spec.Missing(app.GetRoutes(true)); len(missing) > 0 {
+		logger.Sugar().Warnw("Routes missing from the API document", "routes", missing)
+	}
+	doc := spec.Build(openapi.Info{
+		Title:       "eth-devstack API",
+		Description: "Indexed blocks, transactions, tokens and logs of the configured chains. Successful responses wrap their data in the Response envelope; errors carry an ErrorCode.",
+		Version:     "1.0.0",
+	}, app.GetRoutes(true))
+	if err := docsHandler.SetDocument(doc); err != nil {
+		logger.Sugar().Errorw("Failed to encode API document", "error", err)
+	}
 
 	return &Server{
 		app: app,
 		db: db,
+		cache: httpCache,
 		logger: logger,
 		port: port,
 	}
@@ -78,5 +228,6 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Shutdown() error {
+	s.cache.Close()
 	return s.app.Shutdown()
 }
\ No newline at end of file
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/api/handlers"
+	"github.com/pulkyeet/eth-devstack/backend/internal/cache"
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, config.AuthConfig{}, config.WebhookConfig{}, nil, zap.NewNop(), "0")
+	routes := s.app.GetRoutes(true)
+	spec := handlers.OpenAPI()
+
+	assert.Empty(t, spec.Missing(routes), "routes without an operation in handlers.OpenAPI")
+	assert.Empty(t, spec.Unrouted(routes), "operations in handlers.OpenAPI no route serves")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, config.AuthConfig{}, config.WebhookConfig{}, cache.NewLRU(0), zap.NewNop(), "0")
+	defer s.Shutdown()
+
+	resp, err := s.app.Test(httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	paths := doc["paths"].(map[string]interface{})
+	assert.Contains(t, paths, "/api/v1/blocks/{id}")
+	assert.Contains(t, paths, "/api")
+	ids := map[string]bool{}
+	for path, item := range paths {
+		for method, op := range item.(map[string]interface{}) {
+			id, _ := op.(map[string]interface{})["operationId"].(string)
+			assert.NotEmpty(t, id, "%s %s has no operationId", method, path)
+			assert.False(t, ids[id], "duplicate operationId %s", id)
+			ids[id] = true
+		}
+	}
+	getBlock := paths["/api/v1/blocks/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	assert.Contains(t, getBlock["responses"], "304")
+
+	components := doc["components"].(map[string]interface{})
+	var refs []string
+	collectRefs(doc, &refs)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+		require.Len(t, parts, 2, ref)
+		defined, _ := components[parts[0]].(map[string]interface{})
+		assert.Contains(t, defined, parts[1], "unresolved %s", ref)
+	}
+
+	resp, err = s.app.Test(httptest.NewRequest("GET", "/api/v1/docs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+}
+
+func collectRefs(v interface{}, refs *[]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if ref, ok := child.(string); ok && k == "$ref" {
+				*refs = append(*refs, ref)
+				continue
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func TestMutationsTakeAdminToken(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, config.AuthConfig{AdminToken: "secret"}, config.WebhookConfig{}, nil, zap.NewNop(), "0")
+
+	for _, route := range []string{
+		"POST /api/v1/webhooks",
+		"GET /api/v1/webhooks",
+		"DELETE /api/v1/webhooks/1",
+		"POST /api/v1/webhooks/1/deliveries/2/redeliver",
+		"POST /api/v1/alerts/rules",
+		"PATCH /api/v1/alerts/rules/1",
+		"DELETE /api/v1/alerts/rules/1",
+		"POST /api/v1/labels",
+		"PATCH /api/v1/labels/1",
+		"DELETE /api/v1/labels/1",
+		"POST /api/v1/watchlists",
+		"PATCH /api/v1/watchlists/1",
+		"DELETE /api/v1/watchlists/1",
+		"POST /api/v1/watchlists/1/addresses",
+		"DELETE /api/v1/watchlists/1/addresses/0x0000000000000000000000000000000000000001",
+	} {
+		method, target, _ := strings.Cut(route, " ")
+		resp, err := s.app.Test(httptest.NewRequest(method, target, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode, route)
+
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		resp, err = s.app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode, route)
+	}
+}
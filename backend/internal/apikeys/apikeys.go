@@ -0,0 +1,126 @@
+// Package apikeys generates API keys and resolves the keys clients present.
+// Keys are random, so a plain SHA-256 hash is enough to store them by.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// Prefix starts every key, so leaked keys are easy to recognise.
+const Prefix = "eds_"
+
+// prefixLength is how much of a key is kept in the clear to identify it.
+const prefixLength = len(Prefix) + 8
+
+// DefaultCacheTTL is how long a lookup is reused. Revoking a key takes
+// effect immediately in the process that revoked it and within this long
+// in others.
+const DefaultCacheTTL = time.Minute
+
+// New generates a key for k, setting its Key, Prefix and KeyHash.
+func New(k *models.APIKey) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate api key: %w", err)
+	}
+	k.Key = Prefix + hex.EncodeToString(b)
+	k.Prefix = k.Key[:prefixLength]
+	k.KeyHash = Hash(k.Key)
+	return nil
+}
+
+// Hash is the hex SHA-256 a key is stored by.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup finds stored keys; *database.DB implements it.
+type Lookup interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+}
+
+type entry struct {
+	key     *models.APIKey
+	expires time.Time
+}
+
+// Store resolves presented keys, caching lookups (unknown keys included) so
+// authenticated requests don't each cost a query. A key's last_used_at is
+// updated when its lookup is refreshed, so it is accurate to the TTL.
+type Store struct {
+	db  Lookup
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+func NewStore(db Lookup, ttl time.Duration) *Store {
+	return &Store{db: db, ttl: ttl, now: time.Now, entries: make(map[string]entry)}
+}
+
+// Get returns the usable key matching a presented key, or nil if it is
+// unknown, revoked or expired.
+func (s *Store) Get(ctx context.Context, presented string) (*models.APIKey, error) {
+	if !strings.HasPrefix(presented, Prefix) {
+		return nil, nil
+	}
+	hash := Hash(presented)
+	now := s.now()
+
+	s.mu.Lock()
+	e, ok := s.entries[hash]
+	s.mu.Unlock()
+	if !ok || now.After(e.expires) {
+		key, err := s.db.GetAPIKeyByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		e = entry{key: key, expires: now.Add(s.ttl)}
+		s.mu.Lock()
+		s.sweep(now)
+		s.entries[hash] = e
+		s.mu.Unlock()
+		if key != nil && key.Usable(now) {
+			// Best effort; a missed update only makes last_used_at stale
+			_ = s.db.TouchAPIKey(ctx, key.ID)
+		}
+	}
+	if e.key == nil || !e.key.Usable(now) {
+		return nil, nil
+	}
+	return e.key, nil
+}
+
+// Invalidate drops the cached lookup of a key, such as one just revoked.
+func (s *Store) Invalidate(hash string) {
+	s.mu.Lock()
+	delete(s.entries, hash)
+	s.mu.Unlock()
+}
+
+// sweep drops expired entries, at most once a TTL. Callers hold mu.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for hash, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, hash)
+		}
+	}
+}
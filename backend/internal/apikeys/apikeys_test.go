@@ -0,0 +1,97 @@
+package apikeys
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLookup struct {
+	keys    map[string]*models.APIKey
+	lookups int
+	touched []int64
+}
+
+func (f *fakeLookup) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	f.lookups++
+	// Copied, as a fresh row would be
+	if k := f.keys[hash]; k != nil {
+		copied := *k
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeLookup) TouchAPIKey(ctx context.Context, id int64) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	var a, b models.APIKey
+	require.NoError(t, New(&a))
+	require.NoError(t, New(&b))
+
+	assert.True(t, strings.HasPrefix(a.Key, Prefix))
+	assert.Len(t, a.Key, len(Prefix)+64)
+	assert.Equal(t, a.Key[:12], a.Prefix)
+	assert.Equal(t, Hash(a.Key), a.KeyHash)
+	assert.Len(t, a.KeyHash, 64)
+	assert.NotEqual(t, a.Key, b.Key)
+}
+
+func TestStore(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var active, revoked, expired models.APIKey
+	for _, k := range []*models.APIKey{&active, &revoked, &expired} {
+		require.NoError(t, New(k))
+	}
+	active.ID = 1
+	revokedAt, expiresAt := now.Add(-time.Hour), now.Add(-time.Minute)
+	revoked.RevokedAt = &revokedAt
+	expired.ExpiresAt = &expiresAt
+
+	db := &fakeLookup{keys: map[string]*models.APIKey{
+		active.KeyHash:  &active,
+		revoked.KeyHash: &revoked,
+		expired.KeyHash: &expired,
+	}}
+	store := NewStore(db, time.Minute)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	key, err := store.Get(ctx, active.Key)
+	require.NoError(t, err)
+	assert.Equal(t, &active, key)
+	key, _ = store.Get(ctx, active.Key)
+	assert.Equal(t, int64(1), key.ID)
+	assert.Equal(t, 1, db.lookups, "lookups are cached")
+	assert.Equal(t, []int64{1}, db.touched)
+
+	for _, presented := range []string{revoked.Key, expired.Key, Prefix + "unknown", "no-prefix"} {
+		key, err := store.Get(ctx, presented)
+		require.NoError(t, err)
+		assert.Nil(t, key, presented)
+	}
+	assert.Equal(t, 4, db.lookups, "keys without the prefix aren't looked up")
+
+	// Revoking elsewhere is seen once the lookup expires
+	active.RevokedAt = &now
+	key, _ = store.Get(ctx, active.Key)
+	assert.NotNil(t, key)
+	now = now.Add(time.Minute + time.Second)
+	key, _ = store.Get(ctx, active.Key)
+	assert.Nil(t, key)
+
+	active.RevokedAt = nil
+	key, _ = store.Get(ctx, active.Key)
+	assert.Nil(t, key, "still cached")
+	store.Invalidate(active.KeyHash)
+	key, _ = store.Get(ctx, active.Key)
+	assert.NotNil(t, key)
+}
@@ -0,0 +1,314 @@
+// Package blockchaintest provides a fake JSON-RPC node for tests that read
+// balances, storage and contract state through a blockchain.ChainClient.
+package blockchaintest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"go.uber.org/zap"
+)
+
+// ChainID is the chain the fake node serves.
+const ChainID = 1337
+
+// ErrReverted is what a Contract returns to revert a call.
+var ErrReverted = errors.New("execution reverted")
+
+// Contract answers the calldata of an eth_call to one address.
+type Contract func(data []byte) ([]byte, error)
+
+// Call is one eth_call the node answered.
+type Call struct {
+	To    common.Address
+	Data  []byte
+	Block string
+}
+
+// Node answers eth_call, eth_getBalance, eth_getStorageAt, eth_getCode,
+// eth_chainId and debug_traceTransaction from state set by the test. Addresses without a contract
+// behave as accounts: calls to them return no data.
+type Node struct {
+	URL string
+
+	mu        sync.Mutex
+	balances  map[common.Address]*big.Int
+	contracts map[common.Address]Contract
+	storage   map[common.Address]map[common.Hash]common.Hash
+	traces    map[common.Hash]*blockchain.CallFrame
+	calls     []Call
+}
+
+// NewNode starts a node that is stopped when the test ends.
+func NewNode(t *testing.T) *Node {
+	n := &Node{
+		balances:  make(map[common.Address]*big.Int),
+		contracts: make(map[common.Address]Contract),
+		storage:   make(map[common.Address]map[common.Hash]common.Hash),
+		traces:    make(map[common.Hash]*blockchain.CallFrame),
+	}
+	server := httptest.NewServer(http.HandlerFunc(n.serve))
+	t.Cleanup(server.Close)
+	n.URL = server.URL
+	return n
+}
+
+// Client connects a chain client to the node.
+func (n *Node) Client(t *testing.T) *blockchain.ChainClient {
+	client, err := blockchain.NewChainClient(n.config(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to connect to fake node: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// Chains is a chain manager whose only active chain is served by the node.
+func (n *Node) Chains(t *testing.T) *blockchain.ChainManager {
+	data, err := json.Marshal(blockchain.ChainsFile{Chains: []blockchain.ChainConfig{*n.config()}, DefaultChainID: ChainID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chains.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	chains, err := blockchain.NewChainManager(path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to load fake chain: %v", err)
+	}
+	t.Cleanup(chains.Close)
+	return chains
+}
+
+func (n *Node) config() *blockchain.ChainConfig {
+	return &blockchain.ChainConfig{ChainID: ChainID, Name: "Fake", RPCEndpoint: n.URL, IsActive: true}
+}
+
+func (n *Node) SetBalance(address string, wei *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[common.HexToAddress(address)] = wei
+}
+
+func (n *Node) SetContract(address string, contract Contract) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.contracts[common.HexToAddress(address)] = contract
+}
+
+func (n *Node) SetStorage(address string, slot, value common.Hash) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	addr := common.HexToAddress(address)
+	if n.storage[addr] == nil {
+		n.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	n.storage[addr][slot] = value
+}
+
+// SetTrace sets the call tree debug_traceTransaction returns for a
+// transaction.
+func (n *Node) SetTrace(txHash string, frame *blockchain.CallFrame) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.traces[common.HexToHash(txHash)] = frame
+}
+
+// Calls returns the eth_calls answered so far.
+func (n *Node) Calls() []Call {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Call(nil), n.calls...)
+}
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type callArgs struct {
+	To    common.Address `json:"to"`
+	Input hexutil.Bytes  `json:"input"`
+	Data  hexutil.Bytes  `json:"data"`
+}
+
+func (n *Node) serve(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := n.answer(&req)
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if err != nil {
+		resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+	} else {
+		resp["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (n *Node) answer(req *request) (interface{}, error) {
+	param := func(i int, v interface{}) error {
+		if i >= len(req.Params) {
+			return fmt.Errorf("missing param %d", i)
+		}
+		return json.Unmarshal(req.Params[i], v)
+	}
+	block := func(i int) string {
+		var tag string
+		if i < len(req.Params) {
+			json.Unmarshal(req.Params[i], &tag)
+		}
+		return tag
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch req.Method {
+	case "eth_chainId":
+		return hexutil.EncodeUint64(ChainID), nil
+	case "eth_getBalance":
+		var address common.Address
+		if err := param(0, &address); err != nil {
+			return nil, err
+		}
+		balance := n.balances[address]
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		return hexutil.EncodeBig(balance), nil
+	case "eth_getStorageAt":
+		var address common.Address
+		var slot common.Hash
+		if err := param(0, &address); err != nil {
+			return nil, err
+		}
+		if err := param(1, &slot); err != nil {
+			return nil, err
+		}
+		return n.storage[address][slot].Hex(), nil
+	case "eth_getCode":
+		var address common.Address
+		if err := param(0, &address); err != nil {
+			return nil, err
+		}
+		if n.contracts[address] == nil && n.storage[address] == nil {
+			return "0x", nil
+		}
+		return "0x6080", nil
+	case "eth_call":
+		var args callArgs
+		if err := param(0, &args); err != nil {
+			return nil, err
+		}
+		data := args.Input
+		if len(data) == 0 {
+			data = args.Data
+		}
+		n.calls = append(n.calls, Call{To: args.To, Data: data, Block: block(1)})
+		contract := n.contracts[args.To]
+		if contract == nil {
+			return "0x", nil
+		}
+		out, err := contract(data)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Encode(out), nil
+	case "debug_traceTransaction":
+		var hash common.Hash
+		if err := param(0, &hash); err != nil {
+			return nil, err
+		}
+		frame := n.traces[hash]
+		if frame == nil {
+			return nil, fmt.Errorf("transaction %s not found", hash.Hex())
+		}
+		return frame, nil
+	}
+	return nil, fmt.Errorf("the method %s does not exist/is not available", req.Method)
+}
+
+// Token is an ERC20 token. Balances and allowances are keyed by address,
+// allowances as "owner/spender".
+type Token struct {
+	Name        string
+	Symbol      string
+	Decimals    int
+	TotalSupply *big.Int
+	Balances    map[string]*big.Int
+	Allowances  map[string]*big.Int
+}
+
+// Contract answers the token's name, symbol, decimals, totalSupply,
+// balanceOf and allowance calls.
+func (tk *Token) Contract() Contract {
+	return func(data []byte) ([]byte, error) {
+		if len(data) < 4 {
+			return nil, ErrReverted
+		}
+		arg := func(i int) string {
+			start := 4 + 32*i
+			if len(data) < start+32 {
+				return ""
+			}
+			return common.BytesToAddress(data[start : start+32]).Hex()
+		}
+		switch hexutil.Encode(data[:4]) {
+		case "0x06fdde03":
+			return encodeString(tk.Name), nil
+		case "0x95d89b41":
+			return encodeString(tk.Symbol), nil
+		case "0x313ce567":
+			return encodeUint(big.NewInt(int64(tk.Decimals))), nil
+		case "0x18160ddd":
+			return encodeUint(tk.TotalSupply), nil
+		case "0x70a08231":
+			return encodeUint(lookup(tk.Balances, arg(0))), nil
+		case "0xdd62ed3e":
+			return encodeUint(lookup(tk.Allowances, arg(0)+"/"+arg(1))), nil
+		}
+		return nil, ErrReverted
+	}
+}
+
+// lookup finds an amount by address key, ignoring checksum case.
+func lookup(amounts map[string]*big.Int, key string) *big.Int {
+	for k, v := range amounts {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return new(big.Int)
+}
+
+func encodeUint(v *big.Int) []byte {
+	if v == nil {
+		v = new(big.Int)
+	}
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+func encodeString(s string) []byte {
+	out := encodeUint(big.NewInt(32))
+	out = append(out, encodeUint(big.NewInt(int64(len(s))))...)
+	padded := make([]byte, (len(s)+31)/32*32)
+	copy(padded, s)
+	return append(out, padded...)
+}
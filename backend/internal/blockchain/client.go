@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"time"
@@ -73,6 +74,14 @@ func (c *ChainClient) GetCode(ctx context.Context, address string, blockNumber *
 	return c.rpcClient.CodeAt(ctx, common.HexToAddress(address), blockNumber)
 }
 
+func (c *ChainClient) GetStorageAt(ctx context.Context, address string, slot common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return c.rpcClient.StorageAt(ctx, common.HexToAddress(address), slot, blockNumber)
+}
+
+func (c *ChainClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.rpcClient.CallContract(ctx, msg, blockNumber)
+}
+
 func (c *ChainClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
 	return c.rpcClient.EstimateGas(ctx, msg)
 }
@@ -81,6 +90,20 @@ func (c *ChainClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return c.rpcClient.SuggestGasPrice(ctx)
 }
 
+// CallRaw forwards an arbitrary JSON-RPC call to the node and returns the raw
+// result, for callers that proxy requests they don't handle themselves.
+func (c *ChainClient) CallRaw(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+	args := make([]interface{}, len(params))
+	for i, p := range params {
+		args[i] = p
+	}
+	var result json.RawMessage
+	if err := c.rpcClient.Client().CallContext(ctx, &result, method, args...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *ChainClient) ChainID() int64 {
 	return c.config.ChainID
 }
@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// resolver(bytes32 node) on the ENS registry
+	ensResolverSelector = common.FromHex("0x0178b8bf")
+	// addr(bytes32 node) on a public resolver
+	ensAddrSelector = common.FromHex("0x3b3b57de")
+)
+
+// Namehash is the EIP-137 hash of a dotted name. The name is expected to be
+// normalised already; callers only lowercase it, which covers ASCII names.
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256([]byte(labels[i]))
+		node = common.BytesToHash(crypto.Keccak256(node[:], label))
+	}
+	return node
+}
+
+// ResolveName looks up the address an ENS-style name points to through the
+// chain's configured registry. It returns nil if the chain has no registry or
+// the name has no resolver or address.
+func (c *ChainClient) ResolveName(ctx context.Context, name string) (*common.Address, error) {
+	if c.config.ENSRegistry == "" {
+		return nil, nil
+	}
+	node := Namehash(strings.ToLower(name))
+
+	resolver, err := c.callForAddress(ctx, common.HexToAddress(c.config.ENSRegistry), ensResolverSelector, node)
+	if err != nil || resolver == nil {
+		return nil, err
+	}
+	return c.callForAddress(ctx, *resolver, ensAddrSelector, node)
+}
+
+// callForAddress calls a method taking a node and returning an address. The
+// zero address and empty results read as unset.
+func (c *ChainClient) callForAddress(ctx context.Context, to common.Address, selector []byte, node common.Hash) (*common.Address, error) {
+	data := append(append([]byte{}, selector...), node[:]...)
+	result, err := c.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32 {
+		return nil, nil
+	}
+	address := common.BytesToAddress(result[12:32])
+	if address == (common.Address{}) {
+		return nil, nil
+	}
+	return &address, nil
+}
@@ -0,0 +1,14 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamehash(t *testing.T) {
+	// Vectors from EIP-137
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", Namehash("").Hex())
+	assert.Equal(t, "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", Namehash("eth").Hex())
+	assert.Equal(t, "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", Namehash("foo.eth").Hex())
+}
@@ -0,0 +1,128 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// allowance(address owner, address spender) on an ERC20 token
+	allowanceSelector = common.FromHex("0xdd62ed3e")
+	// balanceOf(address holder)
+	balanceOfSelector = common.FromHex("0x70a08231")
+	// name(), symbol(), decimals() and totalSupply()
+	nameSelector        = common.FromHex("0x06fdde03")
+	symbolSelector      = common.FromHex("0x95d89b41")
+	decimalsSelector    = common.FromHex("0x313ce567")
+	totalSupplySelector = common.FromHex("0x18160ddd")
+)
+
+// maxTokenDecimals is the most decimals a token is believed to have; larger
+// values are garbage from contracts that don't implement decimals()
+const maxTokenDecimals = 255
+
+// TokenMetadata is what an ERC20 token reports about itself. Methods the
+// token doesn't implement, or answers with garbage, are left nil.
+type TokenMetadata struct {
+	Name        *string
+	Symbol      *string
+	Decimals    *int
+	TotalSupply *big.Int
+}
+
+// TokenMetadata reads a token's name, symbol, decimals and total supply at
+// blockNumber, or at the head if it is nil.
+func (c *ChainClient) TokenMetadata(ctx context.Context, token string, blockNumber *big.Int) *TokenMetadata {
+	meta := &TokenMetadata{}
+	if out, err := c.callToken(ctx, token, nameSelector, blockNumber); err == nil {
+		meta.Name = decodeTokenString(out)
+	}
+	if out, err := c.callToken(ctx, token, symbolSelector, blockNumber); err == nil {
+		meta.Symbol = decodeTokenString(out)
+	}
+	if out, err := c.callToken(ctx, token, decimalsSelector, blockNumber); err == nil && len(out) >= 32 {
+		if d := new(big.Int).SetBytes(out[:32]); d.IsInt64() && d.Int64() <= maxTokenDecimals {
+			decimals := int(d.Int64())
+			meta.Decimals = &decimals
+		}
+	}
+	if supply, err := c.TotalSupply(ctx, token, blockNumber); err == nil {
+		meta.TotalSupply = supply
+	}
+	return meta
+}
+
+// TotalSupply reads a token's total supply at blockNumber.
+func (c *ChainClient) TotalSupply(ctx context.Context, token string, blockNumber *big.Int) (*big.Int, error) {
+	return c.callTokenUint(ctx, token, totalSupplySelector, blockNumber)
+}
+
+// BalanceOf reads holder's balance of a token at blockNumber.
+func (c *ChainClient) BalanceOf(ctx context.Context, token, holder string, blockNumber *big.Int) (*big.Int, error) {
+	return c.callTokenUint(ctx, token, tokenCallData(balanceOfSelector, holder), blockNumber)
+}
+
+// Allowance reads how much of owner's token spender may still transfer.
+func (c *ChainClient) Allowance(ctx context.Context, token, owner, spender string) (*big.Int, error) {
+	return c.callTokenUint(ctx, token, tokenCallData(allowanceSelector, owner, spender), nil)
+}
+
+// tokenCallData is a call to selector with address arguments.
+func tokenCallData(selector []byte, addresses ...string) []byte {
+	data := append([]byte{}, selector...)
+	for _, address := range addresses {
+		data = append(data, common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)...)
+	}
+	return data
+}
+
+func (c *ChainClient) callToken(ctx context.Context, token string, data []byte, blockNumber *big.Int) ([]byte, error) {
+	to := common.HexToAddress(token)
+	return c.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, blockNumber)
+}
+
+func (c *ChainClient) callTokenUint(ctx context.Context, token string, data []byte, blockNumber *big.Int) (*big.Int, error) {
+	out, err := c.callToken(ctx, token, data, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("token %s returned no value", token)
+	}
+	return new(big.Int).SetBytes(out[:32]), nil
+}
+
+// decodeTokenString decodes a name() or symbol() result, which is an ABI
+// string or, for older tokens, a zero-padded bytes32.
+func decodeTokenString(out []byte) *string {
+	var raw []byte
+	switch {
+	case len(out) == 32:
+		raw = bytes.TrimRight(out, "\x00")
+	case len(out) >= 64:
+		offset := new(big.Int).SetBytes(out[:32])
+		if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+			return nil
+		}
+		start := offset.Int64() + 32
+		length := new(big.Int).SetBytes(out[start-32 : start])
+		if !length.IsInt64() || start+length.Int64() > int64(len(out)) {
+			return nil
+		}
+		raw = out[start : start+length.Int64()]
+	default:
+		return nil
+	}
+	s := strings.TrimSpace(string(raw))
+	if s == "" || !utf8.ValidString(s) || strings.ContainsRune(s, 0) {
+		return nil
+	}
+	return &s
+}
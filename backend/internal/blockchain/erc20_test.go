@@ -0,0 +1,86 @@
+package blockchain_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	token   = "0x000000000000000000000000000000000000700C"
+	owner   = "0x000000000000000000000000000000000000CAFE"
+	spender = "0x000000000000000000000000000000000000bEEF"
+)
+
+func TestTokenCalls(t *testing.T) {
+	node := blockchaintest.NewNode(t)
+	node.SetContract(token, (&blockchaintest.Token{
+		Name:        "Test Token",
+		Symbol:      "TST",
+		Decimals:    6,
+		TotalSupply: big.NewInt(5000000),
+		Balances:    map[string]*big.Int{owner: big.NewInt(1500000)},
+		Allowances:  map[string]*big.Int{owner + "/" + spender: big.NewInt(1000)},
+	}).Contract())
+	client := node.Client(t)
+	ctx := context.Background()
+
+	allowance, err := client.Allowance(ctx, token, owner, spender)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), allowance.Int64())
+
+	balance, err := client.BalanceOf(ctx, token, owner, big.NewInt(12))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500000), balance.Int64())
+
+	calls := node.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, common.HexToAddress(token), calls[0].To)
+	assert.Equal(t, "0xdd62ed3e"+
+		"000000000000000000000000000000000000000000000000000000000000cafe"+
+		"000000000000000000000000000000000000000000000000000000000000beef", hexutil.Encode(calls[0].Data))
+	// Balances are read at the block being indexed
+	assert.Equal(t, "0xc", calls[1].Block)
+
+	meta := client.TokenMetadata(ctx, token, nil)
+	require.NotNil(t, meta.Name)
+	assert.Equal(t, "Test Token", *meta.Name)
+	assert.Equal(t, "TST", *meta.Symbol)
+	assert.Equal(t, 6, *meta.Decimals)
+	assert.Equal(t, int64(5000000), meta.TotalSupply.Int64())
+}
+
+func TestTokenMetadataFallbacks(t *testing.T) {
+	node := blockchaintest.NewNode(t)
+	// Older tokens return bytes32 names and have no decimals()
+	node.SetContract(token, func(data []byte) ([]byte, error) {
+		switch hexutil.Encode(data[:4]) {
+		case "0x06fdde03":
+			return common.RightPadBytes([]byte("Maker"), 32), nil
+		case "0x95d89b41":
+			return common.RightPadBytes([]byte("MKR"), 32), nil
+		}
+		return nil, blockchaintest.ErrReverted
+	})
+	client := node.Client(t)
+
+	meta := client.TokenMetadata(context.Background(), token, nil)
+	require.NotNil(t, meta.Name)
+	assert.Equal(t, "Maker", *meta.Name)
+	assert.Equal(t, "MKR", *meta.Symbol)
+	assert.Nil(t, meta.Decimals)
+	assert.Nil(t, meta.TotalSupply)
+
+	// An account answers nothing
+	meta = client.TokenMetadata(context.Background(), owner, nil)
+	assert.Nil(t, meta.Name)
+	assert.Nil(t, meta.Decimals)
+	_, err := client.BalanceOf(context.Background(), owner, spender, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// Gas price oracles a chain can be configured with
+const (
+	// GasOracleLegacy prices transactions with a single gas price
+	GasOracleLegacy = "legacy"
+	// GasOracleEIP1559 prices transactions with a base fee and priority fee
+	GasOracleEIP1559 = "eip1559"
+)
+
+// baseFeeChangeDenominator bounds the change of the base fee from one block
+// to the next to 1/8
+const baseFeeChangeDenominator = 8
+
+// GasOracle returns the oracle the chain is configured with, falling back to
+// what the chain supports when none is set.
+func (c *ChainConfig) GasOracle() string {
+	switch c.GasPriceOracle {
+	case GasOracleLegacy, GasOracleEIP1559:
+		return c.GasPriceOracle
+	}
+	if c.SupportsEIP1559 {
+		return GasOracleEIP1559
+	}
+	return GasOracleLegacy
+}
+
+// FeeHistory calls eth_feeHistory for the blockCount blocks up to lastBlock
+// (nil for the latest), with the priority fees paid at each of percentiles.
+func (c *ChainClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, percentiles []float64) (*ethereum.FeeHistory, error) {
+	return c.rpcClient.FeeHistory(ctx, blockCount, lastBlock, percentiles)
+}
+
+// NextBaseFee returns the base fee of the block after one with the given base
+// fee and gas usage, per EIP-1559. The gas target is half the limit.
+func NextBaseFee(baseFee *big.Int, gasUsed, gasLimit int64) *big.Int {
+	target := gasLimit / 2
+	if target <= 0 || gasUsed == target {
+		return new(big.Int).Set(baseFee)
+	}
+	if gasUsed > target {
+		delta := new(big.Int).Mul(baseFee, big.NewInt(gasUsed-target))
+		delta.Div(delta, big.NewInt(target))
+		delta.Div(delta, big.NewInt(baseFeeChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		return delta.Add(delta, baseFee)
+	}
+	delta := new(big.Int).Mul(baseFee, big.NewInt(target-gasUsed))
+	delta.Div(delta, big.NewInt(target))
+	delta.Div(delta, big.NewInt(baseFeeChangeDenominator))
+	return delta.Sub(baseFee, delta)
+}
+
+// MedianRewards reduces a fee history's per-block rewards to one priority fee
+// per requested percentile, the median across blocks. Empty blocks, which
+// report zero rewards, are skipped. It returns nil if no block had
+// transactions.
+func MedianRewards(history *ethereum.FeeHistory) []*big.Int {
+	var columns [][]*big.Int
+	for i, rewards := range history.Reward {
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		for j, reward := range rewards {
+			if j >= len(columns) {
+				columns = append(columns, nil)
+			}
+			columns[j] = append(columns[j], reward)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	medians := make([]*big.Int, len(columns))
+	for j, column := range columns {
+		sort.Slice(column, func(a, b int) bool { return column[a].Cmp(column[b]) < 0 })
+		medians[j] = column[len(column)/2]
+	}
+	return medians
+}
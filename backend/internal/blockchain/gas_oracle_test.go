@@ -0,0 +1,43 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasOracle(t *testing.T) {
+	assert.Equal(t, GasOracleLegacy, (&ChainConfig{GasPriceOracle: "legacy", SupportsEIP1559: true}).GasOracle())
+	assert.Equal(t, GasOracleEIP1559, (&ChainConfig{GasPriceOracle: "eip1559"}).GasOracle())
+	assert.Equal(t, GasOracleEIP1559, (&ChainConfig{SupportsEIP1559: true}).GasOracle())
+	assert.Equal(t, GasOracleLegacy, (&ChainConfig{}).GasOracle())
+}
+
+func TestNextBaseFee(t *testing.T) {
+	base := big.NewInt(1_000_000_000)
+
+	assert.Equal(t, base, NextBaseFee(base, 15_000_000, 30_000_000))
+	// Full blocks raise the base fee by 1/8, empty ones lower it by 1/8
+	assert.Equal(t, big.NewInt(1_125_000_000), NextBaseFee(base, 30_000_000, 30_000_000))
+	assert.Equal(t, big.NewInt(875_000_000), NextBaseFee(base, 0, 30_000_000))
+	// Any usage above target raises it by at least 1 wei
+	assert.Equal(t, big.NewInt(8), NextBaseFee(big.NewInt(7), 15_000_001, 30_000_000))
+}
+
+func TestMedianRewards(t *testing.T) {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1_000_000_000)) }
+	history := &ethereum.FeeHistory{
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(5)},
+			{gwei(0), gwei(0), gwei(0)},
+			{gwei(3), gwei(4), gwei(6)},
+			{gwei(2), gwei(3), gwei(9)},
+		},
+		GasUsedRatio: []float64{0.4, 0, 0.7, 0.5},
+	}
+	assert.Equal(t, []*big.Int{gwei(2), gwei(3), gwei(6)}, MedianRewards(history))
+
+	assert.Nil(t, MedianRewards(&ethereum.FeeHistory{Reward: [][]*big.Int{{gwei(0)}}, GasUsedRatio: []float64{0}}))
+}
@@ -22,6 +22,13 @@ type ChainConfig struct {
 	SupportsEIP1559    bool     `json:"supports_eip1559"`
 	GasPriceOracle     string   `json:"gas_price_oracle"`
 	BackupRPCEndpoints []string `json:"backup_rpc_endpoints"`
+	// ENSRegistry is the address of an ENS-compatible registry used to
+	// resolve names in search, if the chain has one
+	ENSRegistry string `json:"ens_registry,omitempty"`
+	// TraceCalls has the indexer trace every transaction with
+	// debug_traceTransaction to record internal transactions; the node must
+	// expose the debug namespace
+	TraceCalls bool `json:"trace_calls,omitempty"`
 }
 
 type ChainsFile struct {
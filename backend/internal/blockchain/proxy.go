@@ -0,0 +1,134 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ProxyTypeTransparent = "TRANSPARENT"
+	ProxyTypeUUPS        = "UUPS"
+	ProxyTypeBeacon      = "BEACON"
+	ProxyTypeEIP1822     = "EIP1822"
+	ProxyTypeZeppelinOS  = "ZEPPELINOS"
+)
+
+// Well-known proxy storage slots and event topics.
+var (
+	// bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1)
+	EIP1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// bytes32(uint256(keccak256('eip1967.proxy.admin')) - 1)
+	EIP1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// bytes32(uint256(keccak256('eip1967.proxy.beacon')) - 1)
+	EIP1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// keccak256('PROXIABLE')
+	EIP1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+	// keccak256('org.zeppelinos.proxy.implementation')
+	ZeppelinOSImplementationSlot = common.HexToHash("0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3")
+
+	// Upgraded(address indexed implementation)
+	UpgradedEventTopic = common.HexToHash("0xbc7cd75a20ee27fd9adebab32041f755214dbc6bffa90cc0225b39da2e5c2d3b")
+	// BeaconUpgraded(address indexed beacon)
+	BeaconUpgradedEventTopic = common.HexToHash("0x1cf3b03a6cf19fa2baba4df148e9dcabedea7f8a5c07840e207e5c089be95d3e")
+	// AdminChanged(address previousAdmin, address newAdmin)
+	AdminChangedEventTopic = common.HexToHash("0x7e644d79422f17c01e4894b5f4f588d331ebfa28653d42ae832dc59e38c9798f")
+
+	// implementation() selector exposed by UpgradeableBeacon
+	beaconImplementationSelector = common.FromHex("0x5c60da1b")
+)
+
+type ProxyInfo struct {
+	Type           string
+	Implementation *common.Address
+	Admin          *common.Address
+	Beacon         *common.Address
+}
+
+// DetectProxy reads the standard proxy storage slots of address and reports
+// which pattern it follows. It returns nil if the contract is not a proxy.
+func (c *ChainClient) DetectProxy(ctx context.Context, address string, blockNumber *big.Int) (*ProxyInfo, error) {
+	beacon, err := c.readAddressSlot(ctx, address, EIP1967BeaconSlot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != nil {
+		impl, err := c.GetBeaconImplementation(ctx, beacon.Hex(), blockNumber)
+		if err != nil {
+			c.logger.Warnw("Failed to read beacon implementation", "beacon", beacon.Hex(), "error", err)
+		}
+		return &ProxyInfo{Type: ProxyTypeBeacon, Implementation: impl, Beacon: beacon}, nil
+	}
+
+	impl, err := c.readAddressSlot(ctx, address, EIP1967ImplementationSlot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		admin, err := c.readAddressSlot(ctx, address, EIP1967AdminSlot, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		// Transparent proxies keep their admin in the proxy itself, UUPS
+		// proxies delegate upgrade authorisation to the implementation.
+		if admin != nil {
+			return &ProxyInfo{Type: ProxyTypeTransparent, Implementation: impl, Admin: admin}, nil
+		}
+		return &ProxyInfo{Type: ProxyTypeUUPS, Implementation: impl}, nil
+	}
+
+	impl, err = c.readAddressSlot(ctx, address, EIP1822ProxiableSlot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		return &ProxyInfo{Type: ProxyTypeEIP1822, Implementation: impl}, nil
+	}
+
+	impl, err = c.readAddressSlot(ctx, address, ZeppelinOSImplementationSlot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		return &ProxyInfo{Type: ProxyTypeZeppelinOS, Implementation: impl}, nil
+	}
+
+	return nil, nil
+}
+
+// GetBeaconImplementation calls implementation() on an UpgradeableBeacon.
+func (c *ChainClient) GetBeaconImplementation(ctx context.Context, beacon string, blockNumber *big.Int) (*common.Address, error) {
+	to := common.HexToAddress(beacon)
+	out, err := c.CallContract(ctx, ethereum.CallMsg{To: &to, Data: beaconImplementationSelector}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call beacon implementation: %w", err)
+	}
+	return SlotToAddress(out), nil
+}
+
+func (c *ChainClient) readAddressSlot(ctx context.Context, address string, slot common.Hash, blockNumber *big.Int) (*common.Address, error) {
+	value, err := c.GetStorageAt(ctx, address, slot, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage slot %s: %w", slot.Hex(), err)
+	}
+	return SlotToAddress(value), nil
+}
+
+// SlotToAddress extracts the right-aligned address from a 32-byte storage
+// word or ABI-encoded return value. Empty or zero words yield nil.
+func SlotToAddress(word []byte) *common.Address {
+	if len(word) < common.AddressLength {
+		return nil
+	}
+	if len(word) > common.HashLength {
+		word = word[:common.HashLength]
+	}
+	addr := common.BytesToAddress(word)
+	if addr == (common.Address{}) {
+		return nil
+	}
+	return &addr
+}
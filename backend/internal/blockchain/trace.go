@@ -0,0 +1,35 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// CallFrame is one call of a transaction's call tree as reported by the
+// callTracer. The outermost frame is the transaction itself.
+type CallFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []CallFrame     `json:"calls,omitempty"`
+}
+
+// TraceCalls replays a transaction with debug_traceTransaction's callTracer
+// and returns its outermost call frame. Nodes that don't expose the debug
+// namespace return an error.
+func (c *ChainClient) TraceCalls(ctx context.Context, txHash string) (*CallFrame, error) {
+	var frame CallFrame
+	err := c.rpcClient.Client().CallContext(ctx, &frame, "debug_traceTransaction",
+		common.HexToHash(txHash), map[string]string{"tracer": "callTracer"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace %s: %w", txHash, err)
+	}
+	return &frame, nil
+}
@@ -0,0 +1,18 @@
+// Package cache stores API responses for reuse: in process in an LRU, or in
+// Redis when several API processes should share one cache.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds byte values under string keys until they expire or are
+// evicted.
+type Store interface {
+	// Get returns nil if key isn't stored.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// DeletePrefix drops every key starting with prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore checks the behaviour every Store shares. expire moves the
+// store's clock forward.
+func testStore(t *testing.T, store Store, expire func(time.Duration)) {
+	ctx := context.Background()
+
+	value, err := store.Get(ctx, "1:/blocks")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, store.Set(ctx, "1:/blocks", []byte("blocks"), time.Minute))
+	require.NoError(t, store.Set(ctx, "1:/blocks/1", []byte("block"), time.Hour))
+	require.NoError(t, store.Set(ctx, "10:/blocks", []byte("other chain"), time.Hour))
+	require.NoError(t, store.Set(ctx, "1:*", []byte("glob"), time.Hour))
+
+	value, err = store.Get(ctx, "1:/blocks")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blocks"), value)
+
+	expire(time.Minute + time.Second)
+	value, _ = store.Get(ctx, "1:/blocks")
+	assert.Nil(t, value)
+	value, _ = store.Get(ctx, "1:/blocks/1")
+	assert.Equal(t, []byte("block"), value)
+
+	// Prefixes are literal
+	require.NoError(t, store.DeletePrefix(ctx, "1:*"))
+	value, _ = store.Get(ctx, "1:/blocks/1")
+	assert.NotNil(t, value)
+
+	require.NoError(t, store.DeletePrefix(ctx, "1:"))
+	for _, key := range []string{"1:/blocks/1", "1:*"} {
+		value, _ = store.Get(ctx, key)
+		assert.Nil(t, value, key)
+	}
+	value, _ = store.Get(ctx, "10:/blocks")
+	assert.Equal(t, []byte("other chain"), value)
+}
+
+func TestLRU(t *testing.T) {
+	lru := NewLRU(1 << 20)
+	now := time.Now()
+	lru.now = func() time.Time { return now }
+	testStore(t, lru, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(30)
+
+	require.NoError(t, lru.Set(ctx, "a", make([]byte, 9), time.Hour))
+	require.NoError(t, lru.Set(ctx, "b", make([]byte, 9), time.Hour))
+	require.NoError(t, lru.Set(ctx, "c", make([]byte, 9), time.Hour))
+	assert.Equal(t, 3, lru.Len())
+
+	// Reading a makes b the least recently used
+	value, _ := lru.Get(ctx, "a")
+	assert.NotNil(t, value)
+	require.NoError(t, lru.Set(ctx, "d", make([]byte, 9), time.Hour))
+	value, _ = lru.Get(ctx, "b")
+	assert.Nil(t, value)
+	for _, key := range []string{"a", "c", "d"} {
+		value, _ = lru.Get(ctx, key)
+		assert.NotNil(t, value, key)
+	}
+
+	// Replacing a value frees the old one
+	require.NoError(t, lru.Set(ctx, "a", make([]byte, 4), time.Hour))
+	assert.Equal(t, 25, lru.size)
+
+	// Values too large for the cache aren't stored
+	require.NoError(t, lru.Set(ctx, "huge", make([]byte, 31), time.Hour))
+	value, _ = lru.Get(ctx, "huge")
+	assert.Nil(t, value)
+	assert.Equal(t, 3, lru.Len())
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := NewRedis(context.Background(), "redis://"+server.Addr(), "test:")
+	require.NoError(t, err)
+	defer store.Close()
+
+	testStore(t, store, server.FastForward)
+
+	// Keys are namespaced
+	require.NoError(t, store.Set(context.Background(), "k", []byte("v"), time.Hour))
+	assert.True(t, server.Exists("test:k"))
+}
+
+func TestNewRedisUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+	_, err := NewRedis(context.Background(), "redis://"+addr, "test:")
+	assert.Error(t, err)
+}
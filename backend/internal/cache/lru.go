@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBytes bounds the in-process cache when no size is configured.
+const DefaultMaxBytes = 64 << 20
+
+// LRU is an in-process Store bounded by the total size of its keys and
+// values. When full, the least recently used entries are evicted first.
+type LRU struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *lruEntry) size() int {
+	return len(e.key) + len(e.value)
+}
+
+func NewLRU(maxBytes int) *LRU {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &LRU{maxBytes: maxBytes, order: list.New(), entries: make(map[string]*list.Element), now: time.Now}
+}
+
+func (l *LRU) Get(ctx context.Context, key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := el.Value.(*lruEntry)
+	if !l.now().Before(entry.expires) {
+		l.remove(el)
+		return nil, nil
+	}
+	l.order.MoveToFront(el)
+	return entry.value, nil
+}
+
+// Set stores a value. Values larger than the whole cache aren't stored.
+func (l *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.entries[key]; ok {
+		l.remove(el)
+	}
+	entry := &lruEntry{key: key, value: value, expires: l.now().Add(ttl)}
+	if entry.size() > l.maxBytes {
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(entry)
+	l.size += entry.size()
+	for l.size > l.maxBytes {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+func (l *LRU) DeletePrefix(ctx context.Context, prefix string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, el := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included until they are
+// next read or evicted.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// remove drops an entry. Callers hold mu.
+func (l *LRU) remove(el *list.Element) {
+	entry := l.order.Remove(el).(*lruEntry)
+	delete(l.entries, entry.key)
+	l.size -= entry.size()
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount is how many keys each SCAN step of DeletePrefix asks for.
+const scanCount = 500
+
+// Redis is a Store shared by every API process using the same server.
+// Keys are namespaced under a prefix, so one server can hold other data.
+type Redis struct {
+	client    *redis.Client
+	namespace string
+}
+
+// NewRedis connects to the server at url, e.g. redis://localhost:6379/0,
+// and checks it answers.
+func NewRedis(ctx context.Context, url, namespace string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &Redis{client: client, namespace: namespace}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, r.namespace+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	return value, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.namespace+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeletePrefix scans for the matching keys, so its cost grows with the
+// size of the whole keyspace rather than the number of keys dropped.
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := r.client.Scan(ctx, 0, escapeGlob(r.namespace+prefix)+"*", scanCount).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanCount {
+			if err := r.client.Unlink(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to delete cache entries: %w", err)
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan cache entries: %w", err)
+	}
+	if len(keys) > 0 {
+		if err := r.client.Unlink(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to delete cache entries: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// escapeGlob escapes the characters SCAN's MATCH treats as patterns.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -12,6 +12,10 @@ type Config struct {
 	Database DatabaseConfig
 	Chains   ChainsConfig
 	Logging  LoggingConfig
+	Verifier VerifierConfig
+	Auth     AuthConfig
+	Cache    CacheConfig
+	Webhooks WebhookConfig
 }
 
 type ServerConfig struct {
@@ -37,6 +41,35 @@ type LoggingConfig struct {
 	Output string
 }
 
+type VerifierConfig struct {
+	SolcPath string
+	SolcDir  string
+}
+
+// AuthConfig sets up API keys and rate limiting. Requests without a key are
+// limited per IP; the admin endpoints are disabled without an AdminToken.
+type AuthConfig struct {
+	AdminToken         string
+	RateLimitEnabled   bool
+	AnonymousPerMinute int
+	AnonymousBurst     int
+}
+
+// CacheConfig sets up response caching. Responses are kept in process,
+// up to MaxMB, unless a RedisURL is given for a cache shared between
+// API processes.
+type CacheConfig struct {
+	Enabled  bool
+	MaxMB    int
+	RedisURL string
+}
+
+// WebhookConfig sets where webhooks may deliver. Private targets, such as a
+// receiver on localhost, are allowed by default in development only.
+type WebhookConfig struct {
+	AllowPrivateTargets bool
+}
+
 type ChainsConfig struct {
 	DefaultChainID int64
 	ConfigPath     string
@@ -58,10 +91,17 @@ func Load() (*Config, error) {
 	viper.SetDefault("LOG_OUTPUT", "stdout")
 	viper.SetDefault("CHAINS_CONFIG_PATH", "internal/config/chains.json")
 	viper.SetDefault("DEFAULT_CHAIND_ID", 1337)
+	viper.SetDefault("SOLC_PATH", "solc")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_ANON_PER_MINUTE", 60)
+	viper.SetDefault("RATE_LIMIT_ANON_BURST", 30)
+	viper.SetDefault("CACHE_ENABLED", true)
+	viper.SetDefault("CACHE_MAX_MB", 64)
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Warning: .env file not found. using defaults and environment variables")
 	}
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE_TARGETS", viper.GetString("ENVIRONMENT") == "development")
 
 	config := &Config{
 		Server: ServerConfig{
@@ -88,6 +128,24 @@ func Load() (*Config, error) {
 			DefaultChainID: viper.GetInt64("DEFAULT_CHAIN_ID"),
 			ConfigPath:     viper.GetString("CHAINS_CONFIG_PATH"),
 		},
+		Verifier: VerifierConfig{
+			SolcPath: viper.GetString("SOLC_PATH"),
+			SolcDir:  viper.GetString("SOLC_DIR"),
+		},
+		Auth: AuthConfig{
+			AdminToken:         viper.GetString("API_ADMIN_TOKEN"),
+			RateLimitEnabled:   viper.GetBool("RATE_LIMIT_ENABLED"),
+			AnonymousPerMinute: viper.GetInt("RATE_LIMIT_ANON_PER_MINUTE"),
+			AnonymousBurst:     viper.GetInt("RATE_LIMIT_ANON_BURST"),
+		},
+		Cache: CacheConfig{
+			Enabled:  viper.GetBool("CACHE_ENABLED"),
+			MaxMB:    viper.GetInt("CACHE_MAX_MB"),
+			RedisURL: viper.GetString("REDIS_URL"),
+		},
+		Webhooks: WebhookConfig{
+			AllowPrivateTargets: viper.GetBool("WEBHOOK_ALLOW_PRIVATE_TARGETS"),
+		},
 	}
 	return config, nil
 }
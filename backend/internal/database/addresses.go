@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
+	"github.com/lib/pq"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
@@ -31,15 +33,17 @@ func (db *DB) GetAddress(ctx context.Context, chainID int64, address string) (*m
 	return addr, nil
 }
 
+// UpsertAddress records activity of an address. An empty Balance keeps the
+// balance already stored.
 func (db *DB) UpsertAddress(ctx context.Context, addr *models.Address) error {
 	query := `
 		INSERT INTO addresses (
 			chain_id, address, balance, nonce, is_contract,
-			contract_creator, creation_tx_hash, code_hash,
+			contract_creator, creation_tx_hash, code_hash, tx_count,
 			first_seen_block, last_seen_block, first_seen_at, last_seen_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		) VALUES ($1, $2, COALESCE($3, 0), $4, $5, $6, $7, $8, 1, $9, $10, $11, $12)
 		ON CONFLICT (chain_id, address) DO UPDATE SET
-			balance = EXCLUDED.balance,
+			balance = COALESCE($3, addresses.balance),
 			nonce = EXCLUDED.nonce,
 			last_seen_block = EXCLUDED.last_seen_block,
 			last_seen_at = EXCLUDED.last_seen_at,
@@ -48,13 +52,26 @@ func (db *DB) UpsertAddress(ctx context.Context, addr *models.Address) error {
 		RETURNING id
 	`
 	err := db.conn.QueryRowContext(ctx, query,
-		addr.ChainID, addr.Address, addr.Balance, addr.Nonce, addr.IsContract,
+		addr.ChainID, addr.Address, sql.NullString{String: addr.Balance, Valid: addr.Balance != ""}, addr.Nonce, addr.IsContract,
 		addr.ContractCreator, addr.CreationTxHash, addr.CodeHash,
 		addr.FirstSeenBlock, addr.LastSeenBlock, addr.FirstSeenAt, addr.LastSeenAt,
 	).Scan(&addr.ID)
 	return err
 }
 
+// UpdateAddressBalance sets the native balance of an indexed address.
+func (db *DB) UpdateAddressBalance(ctx context.Context, chainID int64, address, balance string) error {
+	query := `
+		UPDATE addresses
+		SET balance = $3, updated_at = NOW()
+		WHERE chain_id = $1 AND address = $2
+	`
+	if _, err := db.conn.ExecContext(ctx, query, chainID, address, balance); err != nil {
+		return fmt.Errorf("failed to update address balance: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) IncrementAddressTxCount(ctx context.Context, chainID int64, address string) error {
 	query := `
 		UPDATE addresses
@@ -63,4 +80,111 @@ func (db *DB) IncrementAddressTxCount(ctx context.Context, chainID int64, addres
 	`
 	_, err := db.conn.ExecContext(ctx, query, chainID, address)
 	return err
-}
\ No newline at end of file
+}
+
+// GetAddresses batch-loads indexed addresses keyed by address. Addresses that
+// have not been indexed are absent from the result.
+func (db *DB) GetAddresses(ctx context.Context, chainID int64, addresses []string) (map[string]*models.Address, error) {
+	result := make(map[string]*models.Address, len(addresses))
+	if len(addresses) == 0 {
+		return result, nil
+	}
+	query := `SELECT id, chain_id, address, balance, nonce, is_contract, contract_creator,
+			   creation_tx_hash, code_hash, tx_count, first_seen_block, last_seen_block,
+			   first_seen_at, last_seen_at, created_at, updated_at
+			   FROM addresses WHERE chain_id = $1 AND address = ANY($2)`
+
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		addr := &models.Address{}
+		err := rows.Scan(
+			&addr.ID, &addr.ChainID, &addr.Address, &addr.Balance, &addr.Nonce,
+			&addr.IsContract, &addr.ContractCreator, &addr.CreationTxHash,
+			&addr.CodeHash, &addr.TxCount, &addr.FirstSeenBlock, &addr.LastSeenBlock,
+			&addr.FirstSeenAt, &addr.LastSeenAt, &addr.CreatedAt, &addr.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		result[addr.Address] = addr
+	}
+	return result, nil
+}
+
+// rollbackAddresses deletes the transactions from height up. Addresses first
+// seen there are removed; the rest get their transaction count and last
+// activity back from the transactions that remain, and are returned, as only
+// the node knows their balance before height.
+func rollbackAddresses(ctx context.Context, tx *sql.Tx, chainID, height int64) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM transactions WHERE chain_id = $1 AND block_number >= $2
+		RETURNING from_address, to_address
+	`, chainID, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete transactions: %w", err)
+	}
+	seen := make(map[string]bool)
+	var addresses []string
+	for rows.Next() {
+		var from string
+		var to sql.NullString
+		if err := rows.Scan(&from, &to); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		for _, address := range []string{from, to.String} {
+			if address != "" && !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	rows.Close()
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		DELETE FROM addresses WHERE chain_id = $1 AND address = ANY($2) AND first_seen_block >= $3
+		RETURNING address
+	`, chainID, pq.Array(addresses), height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete addresses: %w", err)
+	}
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		delete(seen, address)
+	}
+	rows.Close()
+	addresses = slices.DeleteFunc(addresses, func(address string) bool { return !seen[address] })
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE addresses a SET
+			tx_count = activity.tx_count,
+			last_seen_block = activity.last_block,
+			last_seen_at = activity.last_at,
+			updated_at = NOW()
+		FROM (
+			SELECT r.address, COUNT(t.hash) AS tx_count, MAX(t.block_number) AS last_block, MAX(t.timestamp) AS last_at
+			FROM unnest($2::text[]) AS r(address)
+			LEFT JOIN transactions t ON t.chain_id = $1 AND (t.from_address = r.address OR t.to_address = r.address)
+			GROUP BY r.address
+		) activity
+		WHERE a.chain_id = $1 AND a.address = activity.address
+	`, chainID, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute address activity: %w", err)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+const alertRuleColumns = `id, name, description, chain_id, rule_type, params, webhook_id, enabled, state,
+	state_since, last_evaluated_at, last_error, created_at, updated_at`
+
+func scanAlertRule(row rowScanner) (*models.AlertRule, error) {
+	rule := &models.AlertRule{}
+	var params []byte
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.ChainID, &rule.RuleType, &params, &rule.WebhookID,
+		&rule.Enabled, &rule.State, &rule.StateSince, &rule.LastEvaluatedAt, &rule.LastError,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	)
+	rule.Params = params
+	return rule, err
+}
+
+func (db *DB) CreateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (name, description, chain_id, rule_type, params, webhook_id, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, state, state_since, created_at, updated_at
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		rule.Name, rule.Description, rule.ChainID, rule.RuleType, []byte(rule.Params), rule.WebhookID, rule.Enabled,
+	).Scan(&rule.ID, &rule.State, &rule.StateSince, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetAlertRule(ctx context.Context, id int64) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1`
+	rule, err := scanAlertRule(db.conn.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// AlertRuleFilter selects alert rules. Nil fields match everything.
+type AlertRuleFilter struct {
+	ChainID *int64
+	State   *string
+	Enabled *bool
+	Limit   int
+	Offset  int
+}
+
+func (f *AlertRuleFilter) where() (string, []interface{}) {
+	return `WHERE ($1::bigint IS NULL OR chain_id = $1) AND ($2::text IS NULL OR state = $2)
+		AND ($3::boolean IS NULL OR enabled = $3)`, []interface{}{f.ChainID, f.State, f.Enabled}
+}
+
+func (db *DB) GetAlertRules(ctx context.Context, filter *AlertRuleFilter) ([]*models.AlertRule, error) {
+	where, args := filter.where()
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules ` + where + ` ORDER BY id`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	}
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (db *DB) CountAlertRules(ctx context.Context, filter *AlertRuleFilter) (int64, error) {
+	where, args := filter.where()
+	var count int64
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM alert_rules `+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count alert rules: %w", err)
+	}
+	return count, nil
+}
+
+// SetAlertRuleEnabled enables or disables a rule. A disabled rule goes back
+// to ok without a resolution, so re-enabling it starts afresh.
+func (db *DB) SetAlertRuleEnabled(ctx context.Context, id int64, enabled bool) (*models.AlertRule, error) {
+	query := `
+		UPDATE alert_rules SET enabled = $2,
+			state = CASE WHEN $2 THEN state ELSE 'ok' END,
+			state_since = CASE WHEN $2 OR state = 'ok' THEN state_since ELSE NOW() END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + alertRuleColumns
+	rule, err := scanAlertRule(db.conn.QueryRowContext(ctx, query, id, enabled))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteAlertRule removes a rule with its history. It reports whether the
+// rule existed.
+func (db *DB) DeleteAlertRule(ctx context.Context, id int64) (bool, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// MarkAlertRuleEvaluated records an evaluation and its error, if any.
+func (db *DB) MarkAlertRuleEvaluated(ctx context.Context, id int64, evalErr *string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE alert_rules SET last_evaluated_at = NOW(), last_error = $2 WHERE id = $1`, id, evalErr)
+	if err != nil {
+		return fmt.Errorf("failed to mark alert rule evaluated: %w", err)
+	}
+	return nil
+}
+
+// TransitionAlertRule moves a rule from its current state to state, records
+// the alert event and, if the rule has a webhook, queues the notification
+// built by payload. The transition only happens if the rule is still in
+// rule.State, so concurrent evaluators notify once; it returns nil if another
+// got there first.
+func (db *DB) TransitionAlertRule(ctx context.Context, rule *models.AlertRule, state string, details json.RawMessage,
+	payload func(webhookID int64, event *models.AlertEvent) (json.RawMessage, error)) (*models.AlertEvent, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var webhookID *int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE alert_rules SET state = $3, state_since = NOW()
+		WHERE id = $1 AND state = $2 AND enabled
+		RETURNING webhook_id
+	`, rule.ID, rule.State, state).Scan(&webhookID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert state: %w", err)
+	}
+
+	event := &models.AlertEvent{RuleID: rule.ID, ChainID: rule.ChainID, State: models.AlertStateResolved, Details: details}
+	if state == models.AlertStateFiring {
+		event.State = models.AlertStateFiring
+	}
+	var detailsArg interface{}
+	if len(details) > 0 {
+		detailsArg = []byte(details)
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO alert_events (rule_id, chain_id, state, details) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, event.RuleID, event.ChainID, event.State, detailsArg).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record alert event: %w", err)
+	}
+
+	if webhookID != nil {
+		body, err := payload(*webhookID, event)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (webhook_id, chain_id, alert_event_id, payload) VALUES ($1, $2, $3, $4)
+		`, *webhookID, rule.ChainID, event.ID, []byte(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue alert notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	rule.State = state
+	return event, nil
+}
+
+func (db *DB) GetAlertEvents(ctx context.Context, ruleID int64, limit, offset int) ([]*models.AlertEvent, error) {
+	query := `
+		SELECT id, rule_id, chain_id, state, details, created_at
+		FROM alert_events WHERE rule_id = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3
+	`
+	rows, err := db.conn.QueryContext(ctx, query, ruleID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.AlertEvent
+	for rows.Next() {
+		e := &models.AlertEvent{}
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.ChainID, &e.State, &details, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert event: %w", err)
+		}
+		e.Details = details
+		events = append(events, e)
+	}
+	return events, nil
+}
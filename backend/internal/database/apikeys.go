@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+const apiKeyColumns = `k.id, k.name, k.prefix, k.key_hash, k.created_at, k.expires_at, k.revoked_at, k.last_used_at,
+	t.name, t.description, t.requests_per_minute, t.burst, t.daily_quota`
+
+const apiKeyFrom = ` FROM api_keys k JOIN api_tiers t ON t.name = k.tier`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	k := &models.APIKey{Tier: &models.APITier{}}
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, &k.CreatedAt, &k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt,
+		&k.Tier.Name, &k.Tier.Description, &k.Tier.RequestsPerMinute, &k.Tier.Burst, &k.Tier.DailyQuota)
+	return k, err
+}
+
+func (db *DB) GetAPITiers(ctx context.Context) ([]*models.APITier, error) {
+	query := `
+		SELECT name, description, requests_per_minute, burst, daily_quota
+		FROM api_tiers
+		ORDER BY requests_per_minute, name
+	`
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api tiers: %w", err)
+	}
+	defer rows.Close()
+
+	var tiers []*models.APITier
+	for rows.Next() {
+		t := &models.APITier{}
+		if err := rows.Scan(&t.Name, &t.Description, &t.RequestsPerMinute, &t.Burst, &t.DailyQuota); err != nil {
+			return nil, fmt.Errorf("failed to scan api tier: %w", err)
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, nil
+}
+
+// CreateAPIKey stores a key by its hash on the tier named in key.Tier,
+// filling in the tier's limits.
+func (db *DB) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	query := `
+		WITH inserted AS (
+			INSERT INTO api_keys (name, prefix, key_hash, tier, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at, tier
+		)
+		SELECT i.id, i.created_at, t.description, t.requests_per_minute, t.burst, t.daily_quota
+		FROM inserted i JOIN api_tiers t ON t.name = i.tier
+	`
+	err := db.conn.QueryRowContext(ctx, query, key.Name, key.Prefix, key.KeyHash, key.Tier.Name, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt, &key.Tier.Description, &key.Tier.RequestsPerMinute, &key.Tier.Burst, &key.Tier.DailyQuota)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	return db.getAPIKey(ctx, `k.id = $1`, id)
+}
+
+// GetAPIKeyByHash finds a key by the hash of its secret, whether or not it
+// is still usable.
+func (db *DB) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return db.getAPIKey(ctx, `k.key_hash = $1`, hash)
+}
+
+func (db *DB) getAPIKey(ctx context.Context, cond string, arg interface{}) (*models.APIKey, error) {
+	key, err := scanAPIKey(db.conn.QueryRowContext(ctx, `SELECT `+apiKeyColumns+apiKeyFrom+` WHERE `+cond, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetAPIKeys lists keys newest first, revoked ones included.
+func (db *DB) GetAPIKeys(ctx context.Context, limit, offset int) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + apiKeyFrom + ` ORDER BY k.created_at DESC, k.id DESC LIMIT $1 OFFSET $2`
+	rows, err := db.conn.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (db *DB) CountAPIKeys(ctx context.Context) (int64, error) {
+	var count int64
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM api_keys`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	return count, nil
+}
+
+// RevokeAPIKey stops a key authenticating requests. Revoking a revoked key
+// keeps its original revocation time. It returns nil if the key doesn't
+// exist.
+func (db *DB) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT ` + apiKeyColumns + ` FROM revoked k JOIN api_tiers t ON t.name = k.tier
+	`
+	key, err := scanAPIKey(db.conn.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that a key was used.
+func (db *DB) TouchAPIKey(ctx context.Context, id int64) error {
+	if _, err := db.conn.ExecContext(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+	return nil
+}
+
+// TakeAPIKeyRequest counts a request against a key's quota of limit requests
+// on day, unless it is used up. It returns the key's requests that day and
+// whether this one was counted.
+func (db *DB) TakeAPIKeyRequest(ctx context.Context, id int64, day time.Time, limit int64) (int64, bool, error) {
+	if limit < 1 {
+		return 0, false, nil
+	}
+	query := `
+		INSERT INTO api_key_usage (api_key_id, day, requests) VALUES ($1, $2, 1)
+		ON CONFLICT (api_key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+		WHERE api_key_usage.requests < $3
+		RETURNING requests
+	`
+	var requests int64
+	err := db.conn.QueryRowContext(ctx, query, id, day.Format(time.DateOnly), limit).Scan(&requests)
+	if err == sql.ErrNoRows {
+		return limit, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to count api key request: %w", err)
+	}
+	return requests, true, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// UpsertTokenApproval records the latest approval for an owner/spender/token
+// triple. Events older than the stored one are ignored so re-indexing a range
+// never rolls an allowance back.
+func (db *DB) UpsertTokenApproval(ctx context.Context, approval *models.TokenApproval) error {
+	query := `
+		INSERT INTO token_approvals (
+			chain_id, token_address, owner_address, spender_address, approval_type,
+			value, approved, transaction_hash, log_index, block_number, timestamp
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (chain_id, token_address, owner_address, spender_address) DO UPDATE SET
+			approval_type = EXCLUDED.approval_type,
+			value = EXCLUDED.value,
+			approved = EXCLUDED.approved,
+			transaction_hash = EXCLUDED.transaction_hash,
+			log_index = EXCLUDED.log_index,
+			block_number = EXCLUDED.block_number,
+			timestamp = EXCLUDED.timestamp,
+			updated_at = NOW()
+		WHERE (token_approvals.block_number, token_approvals.log_index) <= (EXCLUDED.block_number, EXCLUDED.log_index)
+	`
+	_, err := db.conn.ExecContext(ctx, query,
+		approval.ChainID, approval.TokenAddress, approval.OwnerAddress, approval.SpenderAddress,
+		approval.ApprovalType, approval.Value, approval.Approved, approval.TransactionHash,
+		approval.LogIndex, approval.BlockNumber, approval.Timestamp,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert token approval: %w", err)
+	}
+	return nil
+}
+
+// GetLiveApprovalsByOwner returns the allowances and operator approvals an
+// owner currently has outstanding.
+func (db *DB) GetLiveApprovalsByOwner(ctx context.Context, chainID int64, owner string, limit, offset int) ([]*models.TokenApproval, error) {
+	query := `
+		SELECT id, chain_id, token_address, owner_address, spender_address, approval_type,
+			   value, approved, transaction_hash, log_index, block_number, timestamp, updated_at
+		FROM token_approvals
+		WHERE chain_id = $1 AND owner_address = $2
+		  AND ((approval_type = 'ALLOWANCE' AND value > 0) OR (approval_type = 'APPROVAL_FOR_ALL' AND approved))
+		ORDER BY block_number DESC, log_index DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, owner, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []*models.TokenApproval
+	for rows.Next() {
+		approval := &models.TokenApproval{}
+		err := rows.Scan(
+			&approval.ID, &approval.ChainID, &approval.TokenAddress, &approval.OwnerAddress,
+			&approval.SpenderAddress, &approval.ApprovalType, &approval.Value, &approval.Approved,
+			&approval.TransactionHash, &approval.LogIndex, &approval.BlockNumber,
+			&approval.Timestamp, &approval.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
@@ -1,10 +1,14 @@
 package database
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
@@ -184,8 +188,200 @@ func (db *DB) DeleteBlocksFromHeight(ctx context.Context, chainID, blockNumber i
 	return nil
 }
 
+// Rollback is what RollbackFromHeight removed: the dropped blocks, oldest
+// first, and the addresses and token holdings the dropped blocks touched that
+// still have earlier activity. Their balances are stale until read again from
+// the node.
+type Rollback struct {
+	Blocks    []*models.Block
+	Addresses []string
+	Holdings  []*models.TokenBalance
+}
+
+// RollbackFromHeight removes a chain's blocks from height up, along with their
+// transactions, internal transactions, logs, token transfers, approvals, proxy
+// upgrades and the stats buckets they fall in, and records the reorg with the
+// hash that replaced the block at height. Activity counts of the addresses and
+// tokens involved are recomputed from what remains and pending webhook
+// deliveries for the dropped blocks are cancelled.
+func (db *DB) RollbackFromHeight(ctx context.Context, chainID, height int64, newHash string) (*Rollback, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin rollback: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM blocks WHERE chain_id = $1 AND block_number >= $2
+		RETURNING `+blockColumns, chainID, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete blocks: %w", err)
+	}
+	result := &Rollback{}
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		result.Blocks = append(result.Blocks, block)
+	}
+	rows.Close()
+	if len(result.Blocks) == 0 {
+		return result, nil
+	}
+	slices.SortFunc(result.Blocks, func(a, b *models.Block) int { return cmp.Compare(a.BlockNumber, b.BlockNumber) })
+	fork := result.Blocks[0]
+	hashes := make([]string, 0, len(result.Blocks))
+	for _, block := range result.Blocks {
+		hashes = append(hashes, block.Hash)
+	}
+
+	for _, table := range []string{"transaction_logs", "token_approvals", "internal_transactions"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE chain_id = $1 AND block_number >= $2`, chainID, height); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+	if result.Addresses, err = rollbackAddresses(ctx, tx, chainID, height); err != nil {
+		return nil, err
+	}
+	if result.Holdings, err = rollbackTokenTransfers(ctx, tx, chainID, height); err != nil {
+		return nil, err
+	}
+	if err := rollbackProxies(ctx, tx, chainID, height); err != nil {
+		return nil, err
+	}
+	if err := cancelWebhookDeliveries(ctx, tx, chainID, hashes); err != nil {
+		return nil, err
+	}
+	if err := rollbackChainStats(ctx, tx, chainID, fork.Timestamp); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO reorgs (chain_id, old_block_number, old_block_hash, new_block_hash, depth)
+		VALUES ($1, $2, $3, $4, $5)
+	`, chainID, fork.BlockNumber, fork.Hash, newHash, len(result.Blocks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reorg: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+	return result, nil
+}
+
 func (db *DB) CountBlocks(ctx context.Context, chainID int64) (int64, error) {
 	var count int64
 	err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM blocks WHERE chain_id = $1`, chainID).Scan(&count)
 	return count, err
-}
\ No newline at end of file
+}
+
+// GetBlockNumberByTime returns the last block mined at or before ts, or the
+// first block mined at or after it when before is false.
+func (db *DB) GetBlockNumberByTime(ctx context.Context, chainID int64, ts time.Time, before bool) (int64, bool, error) {
+	query := `
+		SELECT block_number FROM blocks
+		WHERE chain_id = $1 AND timestamp <= $2
+		ORDER BY timestamp DESC, block_number DESC
+		LIMIT 1
+	`
+	if !before {
+		query = `
+			SELECT block_number FROM blocks
+			WHERE chain_id = $1 AND timestamp >= $2
+			ORDER BY timestamp ASC, block_number ASC
+			LIMIT 1
+		`
+	}
+	var blockNumber int64
+	err := db.conn.QueryRowContext(ctx, query, chainID, ts).Scan(&blockNumber)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get block by time: %w", err)
+	}
+	return blockNumber, true, nil
+}
+
+const blockColumns = `id, chain_id, block_number, hash, parent_hash, nonce, sha3_uncles,
+	miner, state_root, transactions_root, receipts_root,
+	difficulty, total_difficulty, size, gas_limit, gas_used,
+	timestamp, extra_data, mix_hash, base_fee_per_gas, tx_count, created_at`
+
+func scanBlock(row rowScanner) (*models.Block, error) {
+	block := &models.Block{}
+	err := row.Scan(
+		&block.ID, &block.ChainID, &block.BlockNumber, &block.Hash,
+		&block.ParentHash, &block.Nonce, &block.Sha3Uncles, &block.Miner,
+		&block.StateRoot, &block.TransactionsRoot, &block.ReceiptsRoot,
+		&block.Difficulty, &block.TotalDifficulty, &block.Size,
+		&block.GasLimit, &block.GasUsed, &block.Timestamp, &block.ExtraData,
+		&block.MixHash, &block.BaseFeePerGas, &block.TxCount, &block.CreatedAt,
+	)
+	return block, err
+}
+
+// GetBlocksPage lists up to limit blocks newest first. after continues below
+// a block number and before pages back above one; the result is in listing
+// order either way.
+func (db *DB) GetBlocksPage(ctx context.Context, chainID int64, after, before *int64, limit int) ([]*models.Block, error) {
+	condition, order := "($2::bigint IS NULL OR block_number < $2)", "DESC"
+	bound := after
+	if before != nil {
+		condition, order = "block_number > $2", "ASC"
+		bound = before
+	}
+	query := `
+		SELECT ` + blockColumns + `
+		FROM blocks
+		WHERE chain_id = $1 AND ` + condition + `
+		ORDER BY block_number ` + order + `
+		LIMIT $3
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, bound, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []*models.Block
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocks = append(blocks, block)
+	}
+	if before != nil {
+		slices.Reverse(blocks)
+	}
+	return blocks, nil
+}
+
+// GetBlocksByNumbers batch-loads blocks keyed by number.
+func (db *DB) GetBlocksByNumbers(ctx context.Context, chainID int64, numbers []int64) (map[int64]*models.Block, error) {
+	blocks := make(map[int64]*models.Block, len(numbers))
+	if len(numbers) == 0 {
+		return blocks, nil
+	}
+	query := `
+		SELECT ` + blockColumns + `
+		FROM blocks
+		WHERE chain_id = $1 AND block_number = ANY($2)
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(numbers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		blocks[block.BlockNumber] = block
+	}
+	return blocks, nil
+}
@@ -35,6 +35,10 @@ func setupTestDB(t *testing.T) *DB {
 	return db
 }
 
+// SetupTestDB shares the test database with the external tests, which can
+// populate it through the indexer.
+var SetupTestDB = setupTestDB
+
 func TestInsertAndGetBlock(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -119,4 +123,146 @@ func TestCountBlocks(t *testing.T) {
 	count, err := db.CountBlocks(ctx, 1337)
 	require.NoError(t, err)
 	assert.Equal(t, int64(3), count)
-}
\ No newline at end of file
+}
+func TestRollbackFromHeightTokens(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	token, alice, bob := "0xtoken", "0xalice", "0xbob"
+
+	require.NoError(t, db.UpsertToken(ctx, &models.Token{ChainID: 1337, Address: token, Type: "ERC20"}))
+	transfer := func(block int64, from, to, value, fromBalance string) {
+		require.NoError(t, db.InsertBlock(ctx, &models.Block{ChainID: 1337, BlockNumber: block, Hash: fmt.Sprintf("0xb%d", block), ParentHash: "0x0", Miner: "0xminer", Timestamp: now}))
+		require.NoError(t, db.InsertTokenTransfer(ctx, &models.TokenTransfer{ChainID: 1337, TransactionHash: fmt.Sprintf("0xt%d", block), TokenAddress: token, FromAddress: from, ToAddress: to, Value: &value, BlockNumber: block, Timestamp: now}))
+		if from != "0x0000000000000000000000000000000000000000" {
+			require.NoError(t, db.UpsertTokenBalance(ctx, &models.TokenBalance{ChainID: 1337, TokenAddress: token, HolderAddress: from, Balance: fromBalance}))
+		}
+		require.NoError(t, db.UpsertTokenBalance(ctx, &models.TokenBalance{ChainID: 1337, TokenAddress: token, HolderAddress: to, Balance: value}))
+		require.NoError(t, db.UpdateTokenCounts(ctx, 1337, token, 1))
+	}
+	transfer(1, "0x0000000000000000000000000000000000000000", alice, "100", "")
+	transfer(2, alice, bob, "40", "60")
+	value := "5"
+	require.NoError(t, db.UpsertTokenApproval(ctx, &models.TokenApproval{ChainID: 1337, TokenAddress: token, OwnerAddress: alice, SpenderAddress: bob, ApprovalType: "ALLOWANCE", Value: &value, Approved: true, TransactionHash: "0xt2", BlockNumber: 2, Timestamp: now}))
+
+	rollback, err := db.RollbackFromHeight(ctx, 1337, 2, "0xb2'")
+	require.NoError(t, err)
+	require.Len(t, rollback.Blocks, 1)
+
+	// Bob never held any; Alice's balance is left to be read again
+	balance, err := db.GetTokenBalance(ctx, 1337, token, bob)
+	require.NoError(t, err)
+	assert.Nil(t, balance)
+	require.Len(t, rollback.Holdings, 1)
+	assert.Equal(t, token, rollback.Holdings[0].TokenAddress)
+	assert.Equal(t, alice, rollback.Holdings[0].HolderAddress)
+
+	stored, err := db.GetToken(ctx, 1337, token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.TransferCount)
+	assert.Equal(t, int64(1), stored.HolderCount)
+
+	approvals, err := db.GetLiveApprovalsByOwner(ctx, 1337, alice, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+}
+
+func TestRollbackFromHeightActivity(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	start := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+	alice, bob, carol := "0xalice", "0xbob", "0xcarol"
+
+	send := func(block int64, from, to string) {
+		at := start.Add(time.Duration(block) * time.Hour)
+		hash := fmt.Sprintf("0xa%d", block)
+		require.NoError(t, db.InsertBlock(ctx, &models.Block{ChainID: 1337, BlockNumber: block, Hash: hash, ParentHash: "0x0", Miner: "0xminer", Timestamp: at}))
+		require.NoError(t, db.InsertTransaction(ctx, &models.Transaction{ChainID: 1337, Hash: fmt.Sprintf("0xtx%d", block), BlockNumber: block, BlockHash: hash, FromAddress: from, ToAddress: &to, Value: "1", Gas: 21000, Timestamp: at}))
+		require.NoError(t, db.InsertInternalTransactions(ctx, []*models.InternalTransaction{{ChainID: 1337, TransactionHash: fmt.Sprintf("0xtx%d", block), TraceAddress: "0", CallType: "CALL", FromAddress: to, ToAddress: &from, Value: "1", BlockNumber: block, Timestamp: at}}))
+		for _, address := range []string{from, to} {
+			require.NoError(t, db.UpsertAddress(ctx, &models.Address{ChainID: 1337, Address: address, FirstSeenBlock: &block, LastSeenBlock: &block, FirstSeenAt: &at, LastSeenAt: &at}))
+		}
+	}
+	send(1, alice, bob)
+	send(2, alice, carol)
+	for _, period := range []string{"hour", "day"} {
+		require.NoError(t, db.RollupChainStats(ctx, 1337, period, start, start.Add(48*time.Hour)))
+	}
+
+	hook := &models.Webhook{URL: "https://example.com/hook", Secret: "s", Active: true}
+	require.NoError(t, db.CreateWebhook(ctx, hook))
+	for block := int64(1); block <= 2; block++ {
+		hash := fmt.Sprintf("0xa%d", block)
+		require.NoError(t, db.CreateWebhookDeliveries(ctx, []*models.WebhookDelivery{{WebhookID: hook.ID, ChainID: 1337, BlockNumber: &block, BlockHash: &hash, Payload: []byte(`{}`)}}))
+	}
+
+	_, err := db.RollbackFromHeight(ctx, 1337, 2, "0xa2'")
+	require.NoError(t, err)
+
+	// Alice is back to one transaction in block 1 and Carol was never seen
+	addresses, err := db.GetAddresses(ctx, 1337, []string{alice, carol})
+	require.NoError(t, err)
+	require.Contains(t, addresses, alice)
+	assert.Equal(t, int64(1), addresses[alice].TxCount)
+	assert.Equal(t, int64(1), *addresses[alice].LastSeenBlock)
+	assert.True(t, addresses[alice].LastSeenAt.Equal(start.Add(time.Hour)))
+	assert.NotContains(t, addresses, carol)
+
+	var internalTxs int
+	require.NoError(t, db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM internal_transactions WHERE chain_id = 1337`).Scan(&internalTxs))
+	assert.Equal(t, 1, internalTxs)
+
+	var oldHash, newHash string
+	var depth int
+	require.NoError(t, db.conn.QueryRowContext(ctx, `SELECT old_block_hash, new_block_hash, depth FROM reorgs WHERE chain_id = 1337`).Scan(&oldHash, &newHash, &depth))
+	assert.Equal(t, "0xa2", oldHash)
+	assert.Equal(t, "0xa2'", newHash)
+	assert.Equal(t, 1, depth)
+
+	deliveries, err := db.GetWebhookDeliveries(ctx, hook.ID, nil, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.WebhookDeliveryCancelled, deliveries[0].Status)
+	assert.Equal(t, models.WebhookDeliveryPending, deliveries[1].Status)
+
+	// Block 2's hour is gone; block 1's hour stays
+	hours, err := db.GetChainStats(ctx, 1337, "hour", start, start.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.True(t, hours[0].BucketStart.Equal(start.Add(time.Hour)))
+}
+
+func TestRollbackFromHeightProxies(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	for n := int64(1); n <= 3; n++ {
+		require.NoError(t, db.InsertBlock(ctx, &models.Block{ChainID: 1337, BlockNumber: n, Hash: fmt.Sprintf("0xp%d", n), ParentHash: "0x0", Miner: "0xminer", Timestamp: time.Now().UTC()}))
+	}
+	upgrade := func(proxy, impl string, block int64) {
+		last := block
+		require.NoError(t, db.UpsertProxyContract(ctx, &models.ProxyContract{ChainID: 1337, ProxyAddress: proxy, ProxyType: "UUPS", ImplementationAddress: &impl, DetectedAtBlock: block, LastUpgradedBlock: &last}))
+		require.NoError(t, db.InsertProxyImplementation(ctx, &models.ProxyImplementation{ChainID: 1337, ProxyAddress: proxy, ImplementationAddress: impl, BlockNumber: block, Source: "event"}))
+	}
+	upgrade("0xproxy", "0ximplv1", 1)
+	upgrade("0xproxy", "0ximplv2", 3)
+	upgrade("0xlate", "0ximpl", 3)
+
+	_, err := db.RollbackFromHeight(ctx, 1337, 2, "0xp2'")
+	require.NoError(t, err)
+
+	proxy, err := db.GetProxyContract(ctx, 1337, "0xproxy")
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "0ximplv1", *proxy.ImplementationAddress)
+	assert.Equal(t, int64(1), *proxy.LastUpgradedBlock)
+	impls, err := db.GetProxyImplementations(ctx, 1337, "0xproxy")
+	require.NoError(t, err)
+	assert.Len(t, impls, 1)
+
+	late, err := db.GetProxyContract(ctx, 1337, "0xlate")
+	require.NoError(t, err)
+	assert.Nil(t, late)
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// ChainEventsChannel is the NOTIFY channel chain events are announced on.
+const ChainEventsChannel = "chain_events"
+
+// PublishChainEvent stores an event and announces it on ChainEventsChannel in
+// the same statement, so listeners never see a pointer to a missing row.
+func (db *DB) PublishChainEvent(ctx context.Context, event *models.ChainEvent) error {
+	query := `
+		WITH e AS (
+			INSERT INTO chain_events (chain_id, event_type, block_number, block_hash, payload)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, chain_id, event_type, block_number, block_hash, created_at
+		)
+		SELECT e.id, e.created_at, pg_notify($6, json_build_object(
+			'id', e.id, 'chain_id', e.chain_id, 'type', e.event_type,
+			'block_number', e.block_number, 'block_hash', e.block_hash
+		)::text)
+		FROM e
+	`
+	var payload interface{}
+	if len(event.Payload) > 0 {
+		payload = []byte(event.Payload)
+	}
+	var notified string
+	err := db.conn.QueryRowContext(ctx, query,
+		event.ChainID, event.EventType, event.BlockNumber, event.BlockHash, payload, ChainEventsChannel,
+	).Scan(&event.ID, &event.CreatedAt, &notified)
+	if err != nil {
+		return fmt.Errorf("failed to publish chain event: %w", err)
+	}
+	return nil
+}
+
+const chainEventColumns = `id, chain_id, event_type, block_number, block_hash, payload, created_at`
+
+func scanChainEvent(row rowScanner) (*models.ChainEvent, error) {
+	event := &models.ChainEvent{}
+	var payload []byte
+	err := row.Scan(
+		&event.ID, &event.ChainID, &event.EventType, &event.BlockNumber,
+		&event.BlockHash, &payload, &event.CreatedAt,
+	)
+	event.Payload = payload
+	return event, err
+}
+
+func (db *DB) GetChainEvent(ctx context.Context, id int64) (*models.ChainEvent, error) {
+	query := `SELECT ` + chainEventColumns + ` FROM chain_events WHERE id = $1`
+	event, err := scanChainEvent(db.conn.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain event: %w", err)
+	}
+	return event, nil
+}
+
+// GetChainEventsAfter lists events published after id, oldest first. Listeners
+// use it to catch up on notifications missed while disconnected.
+func (db *DB) GetChainEventsAfter(ctx context.Context, id int64, limit int) ([]*models.ChainEvent, error) {
+	query := `SELECT ` + chainEventColumns + ` FROM chain_events WHERE id > $1 ORDER BY id ASC LIMIT $2`
+	rows, err := db.conn.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.ChainEvent
+	for rows.Next() {
+		event, err := scanChainEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chain event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// GetLatestChainEventID returns the id of the newest event, or 0 if there are
+// none.
+func (db *DB) GetLatestChainEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := db.conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM chain_events`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest chain event: %w", err)
+	}
+	return id, nil
+}
+
+// PruneChainEvents deletes events older than keepHours. Events are only
+// needed until every listener has caught up.
+func (db *DB) PruneChainEvents(ctx context.Context, keepHours int) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM chain_events WHERE created_at < NOW() - make_interval(hours => $1)`, keepHours)
+	if err != nil {
+		return fmt.Errorf("failed to prune chain events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// RollupChainStats recomputes the period buckets of a chain starting in
+// [from, to) from the indexed blocks, transactions and token transfers.
+// Buckets without blocks are left alone.
+func (db *DB) RollupChainStats(ctx context.Context, chainID int64, period string, from, to time.Time) error {
+	query := `
+		INSERT INTO chain_stats (
+			chain_id, period, bucket_start, block_count, tx_count, failed_tx_count,
+			active_addresses, unique_senders, unique_receivers, new_contracts, gas_used, total_value,
+			avg_gas_price, avg_block_time, token_transfer_count, tokens_transferred, updated_at
+		)
+		SELECT $1, $2, b.bucket, b.block_count, COALESCE(t.tx_count, 0), COALESCE(t.failed, 0),
+			COALESCE(a.active, 0), COALESCE(t.senders, 0), COALESCE(t.receivers, 0), COALESCE(t.contracts, 0),
+			b.gas_used, COALESCE(t.total_value, 0), t.avg_gas_price, b.avg_block_time,
+			COALESCE(tt.transfers, 0), COALESCE(tt.tokens, 0), NOW()
+		FROM (
+			SELECT date_trunc($2::text, timestamp) AS bucket, COUNT(*) AS block_count, SUM(gas_used) AS gas_used,
+				EXTRACT(EPOCH FROM MAX(timestamp) - MIN(timestamp)) / NULLIF(COUNT(*) - 1, 0) AS avg_block_time
+			FROM blocks
+			WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+			GROUP BY 1
+		) b
+		LEFT JOIN (
+			SELECT date_trunc($2::text, timestamp) AS bucket, COUNT(*) AS tx_count,
+				COUNT(*) FILTER (WHERE status = 0) AS failed,
+				COUNT(DISTINCT from_address) AS senders, COUNT(DISTINCT to_address) AS receivers,
+				COUNT(contract_address) AS contracts, SUM(value) AS total_value,
+				ROUND(AVG(COALESCE(effective_gas_price, gas_price))) AS avg_gas_price
+			FROM transactions
+			WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+			GROUP BY 1
+		) t ON t.bucket = b.bucket
+		LEFT JOIN (
+			SELECT bucket, COUNT(DISTINCT address) AS active
+			FROM (
+				SELECT date_trunc($2::text, timestamp) AS bucket, from_address AS address
+				FROM transactions
+				WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+				UNION ALL
+				SELECT date_trunc($2::text, timestamp), to_address
+				FROM transactions
+				WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4 AND to_address IS NOT NULL
+			) parties
+			GROUP BY bucket
+		) a ON a.bucket = b.bucket
+		LEFT JOIN (
+			SELECT date_trunc($2::text, timestamp) AS bucket, COUNT(*) AS transfers,
+				COUNT(DISTINCT token_address) AS tokens
+			FROM token_transfers
+			WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+			GROUP BY 1
+		) tt ON tt.bucket = b.bucket
+		ON CONFLICT (chain_id, period, bucket_start) DO UPDATE SET
+			block_count = EXCLUDED.block_count,
+			tx_count = EXCLUDED.tx_count,
+			failed_tx_count = EXCLUDED.failed_tx_count,
+			active_addresses = EXCLUDED.active_addresses,
+			unique_senders = EXCLUDED.unique_senders,
+			unique_receivers = EXCLUDED.unique_receivers,
+			new_contracts = EXCLUDED.new_contracts,
+			gas_used = EXCLUDED.gas_used,
+			total_value = EXCLUDED.total_value,
+			avg_gas_price = EXCLUDED.avg_gas_price,
+			avg_block_time = EXCLUDED.avg_block_time,
+			token_transfer_count = EXCLUDED.token_transfer_count,
+			tokens_transferred = EXCLUDED.tokens_transferred,
+			updated_at = NOW()
+	`
+	if _, err := db.conn.ExecContext(ctx, query, chainID, period, from, to); err != nil {
+		return fmt.Errorf("failed to roll up chain stats: %w", err)
+	}
+	return nil
+}
+
+// GetLatestStatsBucket returns the start of the newest rolled up bucket, or
+// nil if the chain has none for period.
+func (db *DB) GetLatestStatsBucket(ctx context.Context, chainID int64, period string) (*time.Time, error) {
+	var latest sql.NullTime
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT MAX(bucket_start) FROM chain_stats WHERE chain_id = $1 AND period = $2`, chainID, period).Scan(&latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest stats bucket: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	return &latest.Time, nil
+}
+
+// GetFirstBlockTimeSince returns the timestamp of the first indexed block at
+// or after since, or nil if there is none.
+func (db *DB) GetFirstBlockTimeSince(ctx context.Context, chainID int64, since time.Time) (*time.Time, error) {
+	var first sql.NullTime
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT MIN(timestamp) FROM blocks WHERE chain_id = $1 AND timestamp >= $2`, chainID, since).Scan(&first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get first block time: %w", err)
+	}
+	if !first.Valid {
+		return nil, nil
+	}
+	return &first.Time, nil
+}
+
+// GetChainStats lists a chain's period buckets starting in [from, to),
+// oldest first.
+func (db *DB) GetChainStats(ctx context.Context, chainID int64, period string, from, to time.Time) ([]*models.ChainStats, error) {
+	query := `
+		SELECT chain_id, period, bucket_start, block_count, tx_count, failed_tx_count,
+			active_addresses, unique_senders, unique_receivers, new_contracts, gas_used, total_value,
+			avg_gas_price, avg_block_time, token_transfer_count, tokens_transferred, updated_at
+		FROM chain_stats
+		WHERE chain_id = $1 AND period = $2 AND bucket_start >= $3 AND bucket_start < $4
+		ORDER BY bucket_start
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*models.ChainStats{}
+	for rows.Next() {
+		s := &models.ChainStats{}
+		err := rows.Scan(
+			&s.ChainID, &s.Period, &s.BucketStart, &s.BlockCount, &s.TxCount, &s.FailedTxCount,
+			&s.ActiveAddresses, &s.UniqueSenders, &s.UniqueReceivers, &s.NewContracts, &s.GasUsed, &s.TotalValue,
+			&s.AvgGasPrice, &s.AvgBlockTime, &s.TokenTransferCount, &s.TokensTransferred, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chain stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// rollbackChainStats drops the buckets from the one since falls in, so the
+// aggregator rolls them up again from the blocks that remain.
+func rollbackChainStats(ctx context.Context, tx *sql.Tx, chainID int64, since time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM chain_stats WHERE chain_id = $1 AND bucket_start >= date_trunc(period, $2::timestamp)
+	`, chainID, since)
+	if err != nil {
+		return fmt.Errorf("failed to delete chain stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+func (db *DB) UpsertContractABI(ctx context.Context, contractABI *models.ContractABI) error {
+	query := `
+		INSERT INTO contract_abis (chain_id, address, name, abi)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_id, address) DO UPDATE SET
+			name = COALESCE(EXCLUDED.name, contract_abis.name),
+			abi = EXCLUDED.abi,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		contractABI.ChainID, contractABI.Address, contractABI.Name, []byte(contractABI.ABI),
+	).Scan(&contractABI.ID, &contractABI.CreatedAt, &contractABI.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert contract abi: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetContractABI(ctx context.Context, chainID int64, address string) (*models.ContractABI, error) {
+	query := `
+		SELECT id, chain_id, address, name, abi, created_at, updated_at
+		FROM contract_abis
+		WHERE chain_id = $1 AND address = $2
+	`
+	contractABI := &models.ContractABI{}
+	err := db.conn.QueryRowContext(ctx, query, chainID, address).Scan(
+		&contractABI.ID, &contractABI.ChainID, &contractABI.Address, &contractABI.Name,
+		&contractABI.ABI, &contractABI.CreatedAt, &contractABI.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contract abi: %w", err)
+	}
+	return contractABI, nil
+}
+
+// GetDecodingABI returns the ABI that should be used to decode calls made to
+// address at blockNumber. For proxies this is the ABI of the implementation
+// that was active at that block, falling back to the proxy's own ABI.
+func (db *DB) GetDecodingABI(ctx context.Context, chainID int64, address string, blockNumber int64) (*models.ContractABI, error) {
+	implementation, err := db.GetImplementationAt(ctx, chainID, address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if implementation != "" {
+		contractABI, err := db.GetContractABI(ctx, chainID, implementation)
+		if err != nil {
+			return nil, err
+		}
+		if contractABI != nil {
+			return contractABI, nil
+		}
+	}
+	return db.GetContractABI(ctx, chainID, address)
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ExportDataset names a table that can be exported.
+type ExportDataset string
+
+const (
+	ExportBlocks         ExportDataset = "blocks"
+	ExportTransactions   ExportDataset = "transactions"
+	ExportTokenTransfers ExportDataset = "token_transfers"
+	ExportLogs           ExportDataset = "logs"
+	// ExportInternalTransactions are recorded only on chains that trace calls
+	ExportInternalTransactions ExportDataset = "internal_transactions"
+)
+
+// ExportKind is how an exported value is typed in formats that type values.
+// Wei amounts are strings, since they overflow JSON numbers.
+type ExportKind int
+
+const (
+	ExportString ExportKind = iota
+	ExportInteger
+	ExportBoolean
+	// ExportDecimal is a whole number of up to 38 digits, which holds any
+	// native wei amount. Token amounts are uint256 and stay strings.
+	ExportDecimal
+	// ExportTimestamp is an RFC3339 UTC time
+	ExportTimestamp
+)
+
+// ExportColumn is one column of an exported dataset.
+type ExportColumn struct {
+	Name string
+	Kind ExportKind
+	expr string
+}
+
+// exportBatch is how many rows are fetched from the cursor at a time
+const exportBatch = 1000
+
+// exportTimestamp renders a timestamp column as RFC3339 UTC
+const exportTimestamp = `to_char(%s, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+
+type exportSpec struct {
+	from    string
+	columns []ExportColumn
+	// blockCol and timeCol are passed to BlockRange.apply
+	blockCol, timeCol string
+	// addressCols are the columns an address filter matches any of
+	addressCols []string
+	order       string
+}
+
+var exportSpecs = map[ExportDataset]*exportSpec{
+	ExportBlocks: {
+		from: "blocks",
+		columns: []ExportColumn{
+			{"block_number", ExportInteger, "block_number"},
+			{"hash", ExportString, "hash"},
+			{"parent_hash", ExportString, "parent_hash"},
+			{"timestamp", ExportTimestamp, fmt.Sprintf(exportTimestamp, "timestamp")},
+			{"miner", ExportString, "miner"},
+			{"nonce", ExportString, "nonce"},
+			{"difficulty", ExportDecimal, "difficulty"},
+			{"total_difficulty", ExportDecimal, "total_difficulty"},
+			{"size", ExportInteger, "size"},
+			{"gas_limit", ExportInteger, "gas_limit"},
+			{"gas_used", ExportInteger, "gas_used"},
+			{"base_fee_per_gas", ExportDecimal, "base_fee_per_gas"},
+			{"tx_count", ExportInteger, "tx_count"},
+			{"state_root", ExportString, "state_root"},
+			{"transactions_root", ExportString, "transactions_root"},
+			{"receipts_root", ExportString, "receipts_root"},
+			{"extra_data", ExportString, "extra_data"},
+		},
+		blockCol:    "block_number",
+		timeCol:     "timestamp",
+		addressCols: []string{"miner"},
+		order:       "block_number",
+	},
+	ExportTransactions: {
+		from: "transactions",
+		columns: []ExportColumn{
+			{"hash", ExportString, "hash"},
+			{"block_number", ExportInteger, "block_number"},
+			{"block_hash", ExportString, "block_hash"},
+			{"transaction_index", ExportInteger, "transaction_index"},
+			{"timestamp", ExportTimestamp, fmt.Sprintf(exportTimestamp, "timestamp")},
+			{"from_address", ExportString, "from_address"},
+			{"to_address", ExportString, "to_address"},
+			{"contract_address", ExportString, "contract_address"},
+			{"value", ExportDecimal, "value"},
+			{"method_id", ExportString, "CASE WHEN length(input) >= 10 THEN substring(input, 1, 10) END"},
+			{"nonce", ExportInteger, "nonce"},
+			{"transaction_type", ExportInteger, "transaction_type"},
+			{"status", ExportInteger, "status"},
+			{"gas", ExportInteger, "gas"},
+			{"gas_used", ExportInteger, "gas_used"},
+			{"gas_price", ExportDecimal, "gas_price"},
+			{"max_fee_per_gas", ExportDecimal, "max_fee_per_gas"},
+			{"max_priority_fee_per_gas", ExportDecimal, "max_priority_fee_per_gas"},
+			{"effective_gas_price", ExportDecimal, "effective_gas_price"},
+			{"fee", ExportDecimal, "gas_used * COALESCE(effective_gas_price, gas_price)"},
+		},
+		blockCol:    "block_number",
+		timeCol:     "timestamp",
+		addressCols: []string{"from_address", "to_address", "contract_address"},
+		order:       "block_number, transaction_index",
+	},
+	ExportTokenTransfers: {
+		from: "token_transfers tt LEFT JOIN tokens t ON t.chain_id = tt.chain_id AND t.address = tt.token_address",
+		columns: []ExportColumn{
+			{"transaction_hash", ExportString, "tt.transaction_hash"},
+			{"log_index", ExportInteger, "tt.log_index"},
+			{"block_number", ExportInteger, "tt.block_number"},
+			{"timestamp", ExportTimestamp, fmt.Sprintf(exportTimestamp, "tt.timestamp")},
+			{"token_address", ExportString, "tt.token_address"},
+			{"token_type", ExportString, "t.type"},
+			{"token_symbol", ExportString, "t.symbol"},
+			{"token_decimals", ExportInteger, "t.decimals"},
+			{"from_address", ExportString, "tt.from_address"},
+			{"to_address", ExportString, "tt.to_address"},
+			{"value", ExportString, "tt.value"},
+			{"token_id", ExportString, "tt.token_id"},
+		},
+		blockCol:    "tt.block_number",
+		timeCol:     "tt.timestamp",
+		addressCols: []string{"tt.from_address", "tt.to_address", "tt.token_address"},
+		order:       "tt.block_number, tt.log_index",
+	},
+	ExportLogs: {
+		from: "transaction_logs",
+		columns: []ExportColumn{
+			{"transaction_hash", ExportString, "transaction_hash"},
+			{"log_index", ExportInteger, "log_index"},
+			{"block_number", ExportInteger, "block_number"},
+			{"block_hash", ExportString, "block_hash"},
+			{"transaction_index", ExportInteger, "transaction_index"},
+			{"address", ExportString, "address"},
+			{"topic0", ExportString, "topic0"},
+			{"topic1", ExportString, "topic1"},
+			{"topic2", ExportString, "topic2"},
+			{"topic3", ExportString, "topic3"},
+			{"data", ExportString, "data"},
+			{"removed", ExportBoolean, "removed"},
+		},
+		blockCol:    "block_number",
+		addressCols: []string{"address"},
+		order:       "block_number, log_index",
+	},
+	ExportInternalTransactions: {
+		from: "internal_transactions",
+		columns: []ExportColumn{
+			{"transaction_hash", ExportString, "transaction_hash"},
+			{"trace_index", ExportInteger, "trace_index"},
+			{"trace_address", ExportString, "trace_address"},
+			{"block_number", ExportInteger, "block_number"},
+			{"transaction_index", ExportInteger, "transaction_index"},
+			{"timestamp", ExportTimestamp, fmt.Sprintf(exportTimestamp, "timestamp")},
+			{"call_type", ExportString, "call_type"},
+			{"from_address", ExportString, "from_address"},
+			{"to_address", ExportString, "to_address"},
+			{"value", ExportDecimal, "value"},
+			{"gas", ExportInteger, "gas"},
+			{"gas_used", ExportInteger, "gas_used"},
+			{"error", ExportString, "error"},
+		},
+		blockCol:    "block_number",
+		timeCol:     "timestamp",
+		addressCols: []string{"from_address", "to_address"},
+		order:       "block_number, transaction_index, trace_index",
+	},
+}
+
+// ExportColumns returns the columns of dataset, or nil if it can't be
+// exported.
+func ExportColumns(dataset ExportDataset) []ExportColumn {
+	if spec, ok := exportSpecs[dataset]; ok {
+		return spec.columns
+	}
+	return nil
+}
+
+// ExportFilter selects the rows to export. Address matches the sender,
+// recipient or created contract of transactions, the sender, recipient or
+// token of transfers, the caller or callee of internal transactions, and the
+// emitting contract of logs. A Limit of zero
+// exports every row.
+type ExportFilter struct {
+	ChainID int64
+	Dataset ExportDataset
+	Address *string
+	BlockRange
+	Limit int64
+}
+
+// Export streams the rows selected by f in block order, calling fn with each
+// row's values in ExportColumns order; NULLs are invalid. Rows are fetched
+// through a server-side cursor in batches, so exports of any size run in
+// constant memory. An error from fn stops the export and is returned.
+func (db *DB) Export(ctx context.Context, f *ExportFilter, fn func(values []sql.NullString) error) error {
+	query, args, err := buildExportQuery(f)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin export: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	columns := len(exportSpecs[f.Dataset].columns)
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportBatch)
+	for {
+		n, err := exportBatchRows(ctx, tx, fetch, columns, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportBatch {
+			return nil
+		}
+	}
+}
+
+// exportBatchRows fetches one batch from the cursor and returns its size.
+func exportBatchRows(ctx context.Context, tx *sql.Tx, fetch string, columns int, fn func([]sql.NullString) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch export rows: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, columns)
+	dest := make([]interface{}, columns)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, fmt.Errorf("failed to scan export row: %w", err)
+		}
+		if err := fn(values); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+func buildExportQuery(f *ExportFilter) (string, []interface{}, error) {
+	spec, ok := exportSpecs[f.Dataset]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown export dataset: %s", f.Dataset)
+	}
+	chainCol := "chain_id"
+	if f.Dataset == ExportTokenTransfers {
+		chainCol = "tt.chain_id"
+	}
+	w := newWhere(chainCol, f.ChainID)
+	if f.Address != nil {
+		p := w.arg(*f.Address)
+		conditions := make([]string, len(spec.addressCols))
+		for i, col := range spec.addressCols {
+			conditions[i] = col + " = " + p
+		}
+		w.add("(" + strings.Join(conditions, " OR ") + ")")
+	}
+	f.BlockRange.apply(w, spec.blockCol, spec.timeCol, f.ChainID)
+
+	exprs := make([]string, len(spec.columns))
+	for i, col := range spec.columns {
+		exprs[i] = "(" + col.expr + ")::text"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s",
+		strings.Join(exprs, ", "), spec.from, w, spec.order)
+	if f.Limit > 0 {
+		query += " LIMIT " + w.arg(f.Limit)
+	}
+	return query, w.args, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildExportQuery(t *testing.T) {
+	address := "0xA"
+	from := int64(100)
+	query, args, err := buildExportQuery(&ExportFilter{
+		ChainID:    1337,
+		Dataset:    ExportTokenTransfers,
+		Address:    &address,
+		BlockRange: BlockRange{FromBlock: &from},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, query, "tt.chain_id = $1")
+	assert.Contains(t, query, "(tt.from_address = $2 OR tt.to_address = $2 OR tt.token_address = $2)")
+	assert.Contains(t, query, "tt.block_number >= $3")
+	assert.Contains(t, query, "ORDER BY tt.block_number, tt.log_index")
+	assert.NotContains(t, query, "LIMIT")
+	assert.Len(t, args, 3)
+
+	query, args, err = buildExportQuery(&ExportFilter{ChainID: 1337, Dataset: ExportBlocks, Limit: 500})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(query, "ORDER BY block_number LIMIT $2"), query)
+	assert.Equal(t, []interface{}{int64(1337), int64(500)}, args)
+
+	// Logs have no timestamp, so time bounds go through blocks
+	since := time.Unix(1700000000, 0)
+	query, _, err = buildExportQuery(&ExportFilter{ChainID: 1337, Dataset: ExportLogs, BlockRange: BlockRange{FromTime: &since}})
+	require.NoError(t, err)
+	assert.Contains(t, query, "FROM blocks WHERE chain_id = $2 AND timestamp >= $3")
+
+	query, _, err = buildExportQuery(&ExportFilter{ChainID: 1337, Dataset: ExportInternalTransactions, Address: &address})
+	require.NoError(t, err)
+	assert.Contains(t, query, "(from_address = $2 OR to_address = $2)")
+	assert.Contains(t, query, "ORDER BY block_number, transaction_index, trace_index")
+
+	_, _, err = buildExportQuery(&ExportFilter{ChainID: 1337, Dataset: "receipts"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// GetFeePercentiles returns the fees paid at each of percentiles (0..1) by
+// the transactions of blocks fromBlock and later. With priority set it
+// measures the priority fee above each block's base fee, skipping blocks
+// without one; otherwise the full gas price. It returns nil if there are no
+// such transactions.
+func (db *DB) GetFeePercentiles(ctx context.Context, chainID, fromBlock int64, priority bool, percentiles []float64) ([]string, error) {
+	fee := "COALESCE(t.effective_gas_price, t.gas_price)"
+	join := ""
+	if priority {
+		fee += " - b.base_fee_per_gas"
+		join = `JOIN blocks b ON b.chain_id = t.chain_id AND b.block_number = t.block_number
+			AND b.base_fee_per_gas IS NOT NULL`
+	}
+	query := fmt.Sprintf(`
+		SELECT percentile_disc($3::float8[]) WITHIN GROUP (ORDER BY %s)
+		FROM transactions t
+		%s
+		WHERE t.chain_id = $1 AND t.block_number >= $2
+			AND COALESCE(t.effective_gas_price, t.gas_price) IS NOT NULL
+	`, fee, join)
+
+	var fees pq.StringArray
+	if err := db.conn.QueryRowContext(ctx, query, chainID, fromBlock, pq.Array(percentiles)).Scan(&fees); err != nil {
+		return nil, fmt.Errorf("failed to get fee percentiles: %w", err)
+	}
+	if len(fees) == 0 {
+		return nil, nil
+	}
+	return fees, nil
+}
+
+// GetGasPriceHistory summarises the base fees and gas prices of a chain per
+// period bucket starting in [from, to), oldest first. Buckets without blocks
+// are omitted.
+func (db *DB) GetGasPriceHistory(ctx context.Context, chainID int64, period string, from, to time.Time) ([]*models.GasPricePoint, error) {
+	query := `
+		SELECT b.bucket, b.block_count, COALESCE(t.tx_count, 0), b.avg_base_fee,
+			t.min_price, t.median_price, t.avg_price, t.max_price
+		FROM (
+			SELECT date_trunc($2::text, timestamp) AS bucket, COUNT(*) AS block_count,
+				ROUND(AVG(base_fee_per_gas)) AS avg_base_fee
+			FROM blocks
+			WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+			GROUP BY 1
+		) b
+		LEFT JOIN (
+			SELECT date_trunc($2::text, timestamp) AS bucket, COUNT(price) AS tx_count,
+				MIN(price) AS min_price,
+				percentile_disc(0.5) WITHIN GROUP (ORDER BY price) AS median_price,
+				ROUND(AVG(price)) AS avg_price,
+				MAX(price) AS max_price
+			FROM (
+				SELECT timestamp, COALESCE(effective_gas_price, gas_price) AS price
+				FROM transactions
+				WHERE chain_id = $1 AND timestamp >= $3 AND timestamp < $4
+			) prices
+			GROUP BY 1
+		) t ON t.bucket = b.bucket
+		ORDER BY b.bucket
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price history: %w", err)
+	}
+	defer rows.Close()
+
+	points := []*models.GasPricePoint{}
+	for rows.Next() {
+		p := &models.GasPricePoint{}
+		err := rows.Scan(&p.BucketStart, &p.BlockCount, &p.TxCount, &p.AvgBaseFee,
+			&p.MinGasPrice, &p.MedianGasPrice, &p.AvgGasPrice, &p.MaxGasPrice)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan gas price history: %w", err)
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
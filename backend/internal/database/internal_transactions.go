@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// InsertInternalTransactions stores the internal transactions of one
+// transaction together, so a trace is either recorded whole or not at all.
+// Calls already stored are skipped.
+func (db *DB) InsertInternalTransactions(ctx context.Context, calls []*models.InternalTransaction) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin internal transactions: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO internal_transactions (
+			chain_id, transaction_hash, trace_index, trace_address, call_type,
+			from_address, to_address, value, gas, gas_used, error,
+			block_number, transaction_index, timestamp
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (chain_id, transaction_hash, trace_index) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare internal transaction insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, call := range calls {
+		_, err := stmt.ExecContext(ctx,
+			call.ChainID, call.TransactionHash, call.TraceIndex, call.TraceAddress, call.CallType,
+			call.FromAddress, call.ToAddress, call.Value, call.Gas, call.GasUsed, call.Error,
+			call.BlockNumber, call.TransactionIndex, call.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert internal transaction: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit internal transactions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+const addressLabelColumns = `id, chain_id, address, label, description, created_at, updated_at`
+
+func scanAddressLabel(row rowScanner) (*models.AddressLabel, error) {
+	l := &models.AddressLabel{}
+	err := row.Scan(&l.ID, &l.ChainID, &l.Address, &l.Label, &l.Description, &l.CreatedAt, &l.UpdatedAt)
+	return l, err
+}
+
+// CreateAddressLabel tags an address. Tagging an address with a label it
+// already has updates the description instead.
+func (db *DB) CreateAddressLabel(ctx context.Context, label *models.AddressLabel) error {
+	query := `
+		INSERT INTO address_labels (chain_id, address, label, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_id, address, label) DO UPDATE SET
+			description = EXCLUDED.description,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	err := db.conn.QueryRowContext(ctx, query, label.ChainID, label.Address, label.Label, label.Description).
+		Scan(&label.ID, &label.CreatedAt, &label.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create address label: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetAddressLabel(ctx context.Context, id int64) (*models.AddressLabel, error) {
+	query := `SELECT ` + addressLabelColumns + ` FROM address_labels WHERE id = $1`
+	label, err := scanAddressLabel(db.conn.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address label: %w", err)
+	}
+	return label, nil
+}
+
+// AddressLabelFilter selects labels on a chain; nil fields are not filtered
+// on. Label matches case-insensitively.
+type AddressLabelFilter struct {
+	ChainID int64
+	Address *string
+	Label   *string
+	Limit   int
+	Offset  int
+}
+
+func (f *AddressLabelFilter) where() *where {
+	w := newWhere("chain_id", f.ChainID)
+	if f.Address != nil {
+		w.add("address = " + w.arg(*f.Address))
+	}
+	if f.Label != nil {
+		w.add("LOWER(label) = LOWER(" + w.arg(*f.Label) + ")")
+	}
+	return w
+}
+
+func (db *DB) GetAddressLabels(ctx context.Context, filter *AddressLabelFilter) ([]*models.AddressLabel, error) {
+	w := filter.where()
+	query := `SELECT ` + addressLabelColumns + ` FROM address_labels WHERE ` + w.String() + `
+		ORDER BY address, label
+		LIMIT ` + w.arg(filter.Limit) + ` OFFSET ` + w.arg(filter.Offset)
+	return db.queryAddressLabels(ctx, query, w.args...)
+}
+
+func (db *DB) CountAddressLabels(ctx context.Context, filter *AddressLabelFilter) (int64, error) {
+	w := filter.where()
+	var count int64
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM address_labels WHERE `+w.String(), w.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count address labels: %w", err)
+	}
+	return count, nil
+}
+
+func (db *DB) queryAddressLabels(ctx context.Context, query string, args ...interface{}) ([]*models.AddressLabel, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address labels: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []*models.AddressLabel
+	for rows.Next() {
+		label, err := scanAddressLabel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// UpdateAddressLabel renames a label or changes its description. It returns
+// nil if the label doesn't exist.
+func (db *DB) UpdateAddressLabel(ctx context.Context, label *models.AddressLabel) (*models.AddressLabel, error) {
+	query := `
+		UPDATE address_labels SET label = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + addressLabelColumns
+	updated, err := scanAddressLabel(db.conn.QueryRowContext(ctx, query, label.ID, label.Label, label.Description))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update address label: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteAddressLabel removes a label. It reports whether the label existed.
+func (db *DB) DeleteAddressLabel(ctx context.Context, id int64) (bool, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM address_labels WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete address label: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetLabelsByAddresses batch-loads the labels of several addresses, keyed by
+// address and sorted by label. Unlabelled addresses are absent.
+func (db *DB) GetLabelsByAddresses(ctx context.Context, chainID int64, addresses []string) (map[string][]string, error) {
+	labels := make(map[string][]string)
+	if len(addresses) == 0 {
+		return labels, nil
+	}
+	query := `
+		SELECT address, label FROM address_labels
+		WHERE chain_id = $1 AND address = ANY($2)
+		ORDER BY label
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address labels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address, label string
+		if err := rows.Scan(&address, &label); err != nil {
+			return nil, fmt.Errorf("failed to scan address label: %w", err)
+		}
+		labels[address] = append(labels[address], label)
+	}
+	return labels, nil
+}
@@ -3,9 +3,29 @@ package database
 import (
 	"context"
 	"fmt"
+	"slices"
+
+	"github.com/lib/pq"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
+// LogFilter mirrors the eth_getLogs filter object. Addresses and each topic
+// position are OR sets; an empty set matches anything. BlockHash, when set,
+// replaces the block range. After continues past the last log of the previous
+// page and Before pages back from the first log of the next one.
+type LogFilter struct {
+	ChainID   int64
+	Addresses []string
+	Topics    [4][]string
+	BlockRange
+	BlockHash *string
+	Order     SortOrder
+	After     *Position
+	Before    *Position
+	Limit     int
+	Offset    int
+}
+
 func (db *DB) InsertLog(ctx context.Context, log *models.TransactionLog) error {
 	query := `
 		INSERT INTO transaction_logs (
@@ -60,3 +80,98 @@ func (db *DB) GetLogsByTransaction(ctx context.Context, chainID int64, txHash st
 	return logs, nil
 }
 
+// GetLogs returns logs matching filter in chain order.
+func (db *DB) GetLogs(ctx context.Context, filter *LogFilter) ([]*models.TransactionLog, error) {
+	query, args := buildLogsQuery(filter)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*models.TransactionLog
+	for rows.Next() {
+		log := &models.TransactionLog{}
+		err := rows.Scan(
+			&log.ID, &log.ChainID, &log.TransactionHash, &log.LogIndex,
+			&log.Address, &log.Data, &log.Topic0, &log.Topic1, &log.Topic2,
+			&log.Topic3, &log.BlockNumber, &log.BlockHash, &log.TransactionIndex,
+			&log.Removed, &log.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs = append(logs, log)
+	}
+	if filter.Before != nil {
+		slices.Reverse(logs)
+	}
+	return logs, nil
+}
+
+func buildLogsQuery(filter *LogFilter) (string, []interface{}) {
+	w := newWhere("chain_id", filter.ChainID)
+	if len(filter.Addresses) > 0 {
+		w.add("address = ANY(" + w.arg(pq.Array(filter.Addresses)) + ")")
+	}
+	for i, topics := range filter.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		w.add(fmt.Sprintf("topic%d = ANY(%s)", i, w.arg(pq.Array(topics))))
+	}
+	if filter.BlockHash != nil {
+		w.add("block_hash = " + w.arg(*filter.BlockHash))
+	} else {
+		filter.BlockRange.apply(w, "block_number", "", filter.ChainID)
+	}
+	order := w.orderBy(Sort{Order: filter.Order}, true, "block_number", "log_index", "", filter.After, filter.Before)
+
+	query := `
+		SELECT id, chain_id, transaction_hash, log_index, address, data,
+			   topic0, topic1, topic2, topic3, block_number, block_hash,
+			   transaction_index, removed, created_at
+		FROM transaction_logs
+		WHERE ` + w.String() + `
+		ORDER BY ` + order + `
+		LIMIT ` + w.arg(filter.Limit) + ` OFFSET ` + w.arg(filter.Offset)
+
+	return query, w.args
+}
+
+// GetLogsByTransactions batch-loads the logs emitted by several transactions,
+// keyed by transaction hash and in log index order.
+func (db *DB) GetLogsByTransactions(ctx context.Context, chainID int64, txHashes []string) (map[string][]*models.TransactionLog, error) {
+	logs := make(map[string][]*models.TransactionLog, len(txHashes))
+	if len(txHashes) == 0 {
+		return logs, nil
+	}
+	query := `
+		SELECT id, chain_id, transaction_hash, log_index, address, data,
+			   topic0, topic1, topic2, topic3, block_number, block_hash,
+			   transaction_index, removed, created_at
+		FROM transaction_logs
+		WHERE chain_id = $1 AND transaction_hash = ANY($2)
+		ORDER BY log_index ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(txHashes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log := &models.TransactionLog{}
+		err := rows.Scan(
+			&log.ID, &log.ChainID, &log.TransactionHash, &log.LogIndex,
+			&log.Address, &log.Data, &log.Topic0, &log.Topic1, &log.Topic2,
+			&log.Topic3, &log.BlockNumber, &log.BlockHash, &log.TransactionIndex,
+			&log.Removed, &log.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs[log.TransactionHash] = append(logs[log.TransactionHash], log)
+	}
+	return logs, nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLogsQuery(t *testing.T) {
+	from := int64(10)
+	to := int64(20)
+	filter := &LogFilter{
+		ChainID:    1337,
+		Addresses:  []string{"0xA", "0xB"},
+		Topics:     [4][]string{{"0xtopic0"}, nil, {"0xt2a", "0xt2b"}, nil},
+		BlockRange: BlockRange{FromBlock: &from, ToBlock: &to},
+		Limit:      50,
+		Offset:     100,
+	}
+
+	query, args := buildLogsQuery(filter)
+	assert.Contains(t, query, "address = ANY($2)")
+	assert.Contains(t, query, "topic0 = ANY($3)")
+	assert.Contains(t, query, "topic2 = ANY($4)")
+	assert.NotContains(t, query, "topic1 = ANY")
+	assert.Contains(t, query, "block_number >= $5")
+	assert.Contains(t, query, "block_number <= $6")
+	assert.Contains(t, query, "LIMIT $7 OFFSET $8")
+	assert.Len(t, args, 8)
+}
+
+func TestBuildLogsQueryBlockHashOverridesRange(t *testing.T) {
+	from := int64(10)
+	hash := "0xblock"
+	query, args := buildLogsQuery(&LogFilter{ChainID: 1337, BlockRange: BlockRange{FromBlock: &from}, BlockHash: &hash, Limit: 10})
+	assert.Contains(t, query, "block_hash = $2")
+	assert.NotContains(t, query, "block_number >=")
+	assert.Len(t, args, 4)
+}
+
+func TestGetLogsEmptyTimeWindow(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	mined := time.Now().UTC().Add(-time.Hour)
+
+	require.NoError(t, db.InsertBlock(ctx, &models.Block{ChainID: 1337, BlockNumber: 1, Hash: "0xwindow", ParentHash: "0x0", Miner: "0xminer", Timestamp: mined}))
+	require.NoError(t, db.InsertTransaction(ctx, &models.Transaction{ChainID: 1337, Hash: "0xwindowtx", BlockNumber: 1, BlockHash: "0xwindow", FromAddress: "0xfrom", Value: "0", Timestamp: mined}))
+	require.NoError(t, db.InsertLog(ctx, &models.TransactionLog{ChainID: 1337, TransactionHash: "0xwindowtx", Address: "0xA", BlockNumber: 1, BlockHash: "0xwindow"}))
+
+	since := mined.Add(-time.Minute)
+	logs, err := db.GetLogs(ctx, &LogFilter{ChainID: 1337, BlockRange: BlockRange{FromTime: &since}, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+
+	// No block is at or after a future from_time, so nothing matches
+	future := time.Now().UTC().Add(time.Hour)
+	logs, err = db.GetLogs(ctx, &LogFilter{ChainID: 1337, BlockRange: BlockRange{FromTime: &future}, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	before := mined.Add(-time.Minute)
+	logs, err = db.GetLogs(ctx, &LogFilter{ChainID: 1337, BlockRange: BlockRange{ToTime: &before}, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS proxy_implementations;
+DROP TABLE IF EXISTS proxy_contracts;
+DROP TABLE IF EXISTS contract_abis;
\ No newline at end of file
@@ -0,0 +1,62 @@
+-- ============================================================================
+-- CONTRACT ABIS & PROXY CONTRACTS
+-- ============================================================================
+
+CREATE TABLE contract_abis (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    name VARCHAR(255),
+    abi JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, address)
+);
+
+-- ============================================================================
+
+CREATE TABLE proxy_contracts (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    proxy_address VARCHAR(42) NOT NULL,
+    proxy_type VARCHAR(20) NOT NULL,
+    implementation_address VARCHAR(42),
+    admin_address VARCHAR(42),
+    beacon_address VARCHAR(42),
+    detected_at_block BIGINT NOT NULL,
+    last_upgraded_block BIGINT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, proxy_address),
+    CHECK (proxy_type IN ('TRANSPARENT', 'UUPS', 'BEACON', 'EIP1822', 'ZEPPELINOS'))
+);
+
+CREATE INDEX idx_proxy_contracts_chain_impl ON proxy_contracts(chain_id, implementation_address);
+CREATE INDEX idx_proxy_contracts_chain_beacon ON proxy_contracts(chain_id, beacon_address) WHERE beacon_address IS NOT NULL;
+
+-- ============================================================================
+
+CREATE TABLE proxy_implementations (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    proxy_address VARCHAR(42) NOT NULL,
+    implementation_address VARCHAR(42) NOT NULL,
+    block_number BIGINT NOT NULL,
+    transaction_hash VARCHAR(66),
+    log_index INT,
+    source VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, proxy_address, block_number, implementation_address),
+    CHECK (source IN ('event', 'storage'))
+);
+
+CREATE INDEX idx_proxy_impls_chain_proxy ON proxy_implementations(chain_id, proxy_address, block_number DESC);
+
+CREATE TRIGGER update_contract_abis_updated_at BEFORE UPDATE ON contract_abis
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_proxy_contracts_updated_at BEFORE UPDATE ON proxy_contracts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
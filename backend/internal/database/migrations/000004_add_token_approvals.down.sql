@@ -0,0 +1 @@
+DROP TABLE IF EXISTS token_approvals;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- ============================================================================
+-- TOKEN APPROVALS (ERC20 Approval / ERC721 & ERC1155 ApprovalForAll)
+-- ============================================================================
+
+CREATE TABLE token_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    token_address VARCHAR(42) NOT NULL,
+    owner_address VARCHAR(42) NOT NULL,
+    spender_address VARCHAR(42) NOT NULL,
+    approval_type VARCHAR(20) NOT NULL,
+    value NUMERIC(78, 0),
+    approved BOOLEAN NOT NULL DEFAULT true,
+    transaction_hash VARCHAR(66) NOT NULL,
+    log_index INT NOT NULL,
+    block_number BIGINT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, token_address, owner_address, spender_address),
+    CHECK (approval_type IN ('ALLOWANCE', 'APPROVAL_FOR_ALL'))
+);
+
+CREATE INDEX idx_token_approvals_chain_owner ON token_approvals(chain_id, owner_address, block_number DESC);
+CREATE INDEX idx_token_approvals_chain_spender ON token_approvals(chain_id, spender_address);
+CREATE INDEX idx_token_approvals_block ON token_approvals(chain_id, block_number DESC);
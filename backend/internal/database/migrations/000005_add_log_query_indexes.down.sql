@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_logs_chain_topic3;
+DROP INDEX IF EXISTS idx_logs_chain_topic2;
+DROP INDEX IF EXISTS idx_logs_chain_topic1;
+DROP INDEX IF EXISTS idx_logs_chain_address_block;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Indexes backing the eth_getLogs-style query API
+CREATE INDEX idx_logs_chain_address_block ON transaction_logs(chain_id, address, block_number, log_index);
+CREATE INDEX idx_logs_chain_topic1 ON transaction_logs(chain_id, topic1) WHERE topic1 IS NOT NULL;
+CREATE INDEX idx_logs_chain_topic2 ON transaction_logs(chain_id, topic2) WHERE topic2 IS NOT NULL;
+CREATE INDEX idx_logs_chain_topic3 ON transaction_logs(chain_id, topic3) WHERE topic3 IS NOT NULL;
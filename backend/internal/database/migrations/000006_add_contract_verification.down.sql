@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS verified_contracts;
+DROP TABLE IF EXISTS contract_verifications;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- ============================================================================
+-- SOURCE CODE VERIFICATION (Etherscan-compatible)
+-- ============================================================================
+
+CREATE TABLE contract_verifications (
+    id BIGSERIAL PRIMARY KEY,
+    guid UUID NOT NULL DEFAULT uuid_generate_v4() UNIQUE,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    contract_name VARCHAR(255) NOT NULL,
+    compiler_version VARCHAR(100) NOT NULL,
+    code_format VARCHAR(50) NOT NULL,
+    source_code TEXT NOT NULL,
+    optimization_used BOOLEAN DEFAULT false,
+    runs INT DEFAULT 200,
+    evm_version VARCHAR(50),
+    constructor_arguments TEXT,
+    license_type VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CHECK (status IN ('pending', 'pass', 'fail'))
+);
+
+CREATE INDEX idx_contract_verifications_chain_address ON contract_verifications(chain_id, address);
+
+-- ============================================================================
+
+CREATE TABLE verified_contracts (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    contract_name VARCHAR(255) NOT NULL,
+    compiler_version VARCHAR(100) NOT NULL,
+    code_format VARCHAR(50) NOT NULL,
+    source_code TEXT NOT NULL,
+    optimization_used BOOLEAN DEFAULT false,
+    runs INT DEFAULT 200,
+    evm_version VARCHAR(50),
+    constructor_arguments TEXT,
+    license_type VARCHAR(50),
+    verified_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, address)
+);
+
+CREATE TRIGGER update_contract_verifications_updated_at BEFORE UPDATE ON contract_verifications
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
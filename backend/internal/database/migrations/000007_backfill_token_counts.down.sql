@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_token_transfers_chain_token_block;
+DROP INDEX IF EXISTS idx_tokens_chain_transfers;
+DROP INDEX IF EXISTS idx_tokens_chain_holders;
@@ -0,0 +1,14 @@
+-- holder_count and transfer_count are maintained by the indexer from here on
+UPDATE tokens t SET
+    holder_count = (
+        SELECT COUNT(*) FROM token_balances tb
+        WHERE tb.chain_id = t.chain_id AND tb.token_address = t.address AND tb.balance > 0
+    ),
+    transfer_count = (
+        SELECT COUNT(*) FROM token_transfers tt
+        WHERE tt.chain_id = t.chain_id AND tt.token_address = t.address
+    );
+
+CREATE INDEX idx_tokens_chain_holders ON tokens(chain_id, holder_count DESC);
+CREATE INDEX idx_tokens_chain_transfers ON tokens(chain_id, transfer_count DESC);
+CREATE INDEX idx_token_transfers_chain_token_block ON token_transfers(chain_id, token_address, block_number, log_index);
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- CHAIN EVENTS
+-- Published by the indexer and announced on the chain_events NOTIFY channel.
+-- Notifications only carry a pointer to the row; listeners read the rest
+-- here and from the index tables.
+-- ============================================================================
+
+CREATE TABLE chain_events (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL,
+    block_number BIGINT NOT NULL,
+    block_hash VARCHAR(66),
+    payload JSONB,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CHECK (event_type IN ('block', 'reorg'))
+);
+
+CREATE INDEX idx_chain_events_created ON chain_events(created_at);
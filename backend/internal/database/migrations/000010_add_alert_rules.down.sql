@@ -0,0 +1,11 @@
+DELETE FROM webhook_dead_letters WHERE block_number IS NULL;
+DELETE FROM webhook_deliveries WHERE block_number IS NULL OR block_hash IS NULL;
+ALTER TABLE webhook_dead_letters ALTER COLUMN block_number SET NOT NULL;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS alert_event_id;
+ALTER TABLE webhook_deliveries ALTER COLUMN block_hash SET NOT NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN block_number SET NOT NULL;
+
+DROP TABLE IF EXISTS alert_events;
+DROP TABLE IF EXISTS alert_rules;
+
+ALTER TABLE sync_status DROP COLUMN IF EXISTS latest_block_at;
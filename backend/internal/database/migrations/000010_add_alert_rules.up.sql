@@ -0,0 +1,54 @@
+-- ============================================================================
+-- ALERT RULES
+-- Declarative conditions evaluated by the alerts service. A rule is either
+-- ok or firing; every transition is recorded in alert_events and, when the
+-- rule names a webhook, queued as a webhook delivery.
+-- ============================================================================
+
+-- When the chain head last advanced, for "no new block" alerts
+ALTER TABLE sync_status ADD COLUMN latest_block_at TIMESTAMP;
+
+CREATE TABLE alert_rules (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    rule_type VARCHAR(30) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    webhook_id BIGINT REFERENCES webhooks(id) ON DELETE SET NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    state VARCHAR(20) NOT NULL DEFAULT 'ok',
+    state_since TIMESTAMP DEFAULT NOW(),
+    last_evaluated_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CHECK (rule_type IN ('balance_below', 'event_emitted', 'failed_tx_rate', 'no_new_block')),
+    CHECK (state IN ('ok', 'firing'))
+);
+
+CREATE INDEX idx_alert_rules_chain ON alert_rules(chain_id) WHERE enabled;
+
+-- ============================================================================
+
+CREATE TABLE alert_events (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id BIGINT NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CHECK (state IN ('firing', 'resolved'))
+);
+
+CREATE INDEX idx_alert_events_rule ON alert_events(rule_id, id DESC);
+
+-- ============================================================================
+-- Alert notifications share the webhook delivery queue but belong to no block
+
+ALTER TABLE webhook_deliveries ALTER COLUMN block_number DROP NOT NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN block_hash DROP NOT NULL;
+ALTER TABLE webhook_deliveries ADD COLUMN alert_event_id BIGINT REFERENCES alert_events(id) ON DELETE SET NULL;
+ALTER TABLE webhook_dead_letters ALTER COLUMN block_number DROP NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS watchlist_addresses;
+DROP TABLE IF EXISTS watchlists;
+DROP TABLE IF EXISTS address_labels;
@@ -0,0 +1,39 @@
+-- ============================================================================
+-- ADDRESS LABELS AND WATCHLISTS
+-- Labels are free-form tags ("treasury", "deployer", "bridge") shown next to
+-- addresses in API responses. Watchlists group addresses for a combined
+-- activity feed.
+-- ============================================================================
+
+CREATE TABLE address_labels (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    label VARCHAR(64) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, address, label)
+);
+
+CREATE INDEX idx_address_labels_label ON address_labels(chain_id, LOWER(label));
+
+-- ============================================================================
+
+CREATE TABLE watchlists (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE watchlist_addresses (
+    watchlist_id BIGINT NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL,
+    added_at TIMESTAMP DEFAULT NOW(),
+
+    PRIMARY KEY (watchlist_id, address)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_address_labels_label_trgm;
+DROP INDEX IF EXISTS idx_tokens_name_trgm;
+DROP INDEX IF EXISTS idx_tokens_symbol_trgm;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- SEARCH INDEXES
+-- Trigram indexes behind fuzzy and prefix matching of token names and
+-- symbols and address labels.
+-- ============================================================================
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_tokens_symbol_trgm ON tokens USING GIN (LOWER(symbol) gin_trgm_ops);
+CREATE INDEX idx_tokens_name_trgm ON tokens USING GIN (LOWER(name) gin_trgm_ops);
+CREATE INDEX idx_address_labels_label_trgm ON address_labels USING GIN (LOWER(label) gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_token_transfers_chain_timestamp;
+DROP TABLE IF EXISTS chain_stats;
@@ -0,0 +1,31 @@
+-- ============================================================================
+-- CHAIN STATS ROLLUPS
+-- Hourly and daily aggregates maintained by the stats aggregator in the
+-- indexer process. Buckets start on UTC hour and day boundaries.
+-- ============================================================================
+
+CREATE TABLE chain_stats (
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    period VARCHAR(10) NOT NULL,
+    bucket_start TIMESTAMP NOT NULL,
+    block_count BIGINT NOT NULL DEFAULT 0,
+    tx_count BIGINT NOT NULL DEFAULT 0,
+    failed_tx_count BIGINT NOT NULL DEFAULT 0,
+    active_addresses BIGINT NOT NULL DEFAULT 0,
+    unique_senders BIGINT NOT NULL DEFAULT 0,
+    unique_receivers BIGINT NOT NULL DEFAULT 0,
+    new_contracts BIGINT NOT NULL DEFAULT 0,
+    gas_used NUMERIC(38, 0) NOT NULL DEFAULT 0,
+    total_value NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    avg_gas_price NUMERIC(78, 0),
+    avg_block_time DOUBLE PRECISION,
+    token_transfer_count BIGINT NOT NULL DEFAULT 0,
+    tokens_transferred BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    PRIMARY KEY (chain_id, period, bucket_start),
+    CHECK (period IN ('hour', 'day'))
+);
+
+-- Bucketing token transfers by time
+CREATE INDEX idx_token_transfers_chain_timestamp ON token_transfers(chain_id, timestamp DESC);
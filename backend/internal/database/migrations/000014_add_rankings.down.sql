@@ -0,0 +1,3 @@
+DROP MATERIALIZED VIEW IF EXISTS top_tokens;
+DROP MATERIALIZED VIEW IF EXISTS top_contracts;
+DROP MATERIALIZED VIEW IF EXISTS top_accounts;
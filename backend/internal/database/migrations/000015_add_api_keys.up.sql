@@ -0,0 +1,34 @@
+-- ============================================================================
+-- API KEYS
+-- Keys identify API clients and lift them from the anonymous per-IP rate
+-- limit to their tier's. Only a SHA-256 hash of each key is stored; the key
+-- itself is shown once, when it is created.
+-- ============================================================================
+
+CREATE TABLE api_tiers (
+    name VARCHAR(32) PRIMARY KEY,
+    description TEXT,
+    requests_per_minute INT NOT NULL CHECK (requests_per_minute > 0),
+    burst INT NOT NULL CHECK (burst > 0),
+    daily_quota BIGINT CHECK (daily_quota > 0) -- NULL: unlimited
+);
+
+INSERT INTO api_tiers (name, description, requests_per_minute, burst, daily_quota) VALUES
+    ('free', 'Personal projects', 300, 60, 100000),
+    ('standard', 'Production apps', 1200, 200, 1000000),
+    ('premium', 'Indexers and heavy integrations', 6000, 1000, NULL);
+
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    -- First characters of the key, to tell keys apart without the secret
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    tier VARCHAR(32) NOT NULL REFERENCES api_tiers(name),
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_created ON api_keys(created_at DESC);
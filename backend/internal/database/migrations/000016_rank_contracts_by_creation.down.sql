@@ -0,0 +1,45 @@
+DROP MATERIALIZED VIEW IF EXISTS top_contracts;
+DROP MATERIALIZED VIEW IF EXISTS top_accounts;
+DROP INDEX IF EXISTS idx_tx_chain_contract;
+
+CREATE MATERIALIZED VIEW top_accounts AS
+SELECT * FROM (
+    SELECT chain_id, address, balance, tx_count, is_contract,
+           ROW_NUMBER() OVER (PARTITION BY chain_id ORDER BY balance DESC, address) AS balance_rank,
+           ROW_NUMBER() OVER (PARTITION BY chain_id ORDER BY tx_count DESC, address) AS tx_count_rank,
+           NOW() AS refreshed_at
+    FROM addresses
+) ranked
+WHERE balance_rank <= 1000 OR tx_count_rank <= 1000;
+
+-- Required for REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX idx_top_accounts_address ON top_accounts(chain_id, address);
+CREATE INDEX idx_top_accounts_balance ON top_accounts(chain_id, balance_rank);
+CREATE INDEX idx_top_accounts_tx_count ON top_accounts(chain_id, tx_count_rank);
+
+-- Contract activity over the last day, week and month
+CREATE MATERIALIZED VIEW top_contracts AS
+SELECT * FROM (
+    SELECT time_window, chain_id, address, tx_count, unique_callers, gas_used,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY tx_count DESC, address) AS tx_count_rank,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY unique_callers DESC, address) AS unique_callers_rank,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY gas_used DESC, address) AS gas_used_rank,
+           NOW() AS refreshed_at
+    FROM (
+        SELECT w.time_window, t.chain_id, t.to_address AS address,
+               COUNT(*) AS tx_count,
+               COUNT(DISTINCT t.from_address) AS unique_callers,
+               COALESCE(SUM(t.gas_used), 0)::NUMERIC(38, 0) AS gas_used
+        FROM (VALUES ('24h', INTERVAL '1 day'), ('7d', INTERVAL '7 days'), ('30d', INTERVAL '30 days'))
+             AS w(time_window, span)
+        JOIN transactions t ON t.timestamp >= (NOW() AT TIME ZONE 'UTC') - w.span
+        JOIN addresses a ON a.chain_id = t.chain_id AND a.address = t.to_address AND a.is_contract
+        GROUP BY w.time_window, t.chain_id, t.to_address
+    ) activity
+) ranked
+WHERE tx_count_rank <= 1000 OR unique_callers_rank <= 1000 OR gas_used_rank <= 1000;
+
+CREATE UNIQUE INDEX idx_top_contracts_address ON top_contracts(chain_id, time_window, address);
+CREATE INDEX idx_top_contracts_tx_count ON top_contracts(chain_id, time_window, tx_count_rank);
+CREATE INDEX idx_top_contracts_callers ON top_contracts(chain_id, time_window, unique_callers_rank);
+CREATE INDEX idx_top_contracts_gas ON top_contracts(chain_id, time_window, gas_used_rank);
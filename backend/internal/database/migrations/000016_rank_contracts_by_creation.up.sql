@@ -0,0 +1,57 @@
+-- ============================================================================
+-- RANKINGS: CONTRACTS BY CREATION
+-- addresses.is_contract and addresses.balance aren't populated by the
+-- indexer, so contracts are found through the creation receipts in
+-- transactions.contract_address instead, and accounts are no longer ranked
+-- by balance until balances are indexed.
+-- ============================================================================
+
+CREATE INDEX idx_tx_chain_contract ON transactions(chain_id, contract_address) WHERE contract_address IS NOT NULL;
+
+DROP MATERIALIZED VIEW top_accounts;
+DROP MATERIALIZED VIEW top_contracts;
+
+CREATE MATERIALIZED VIEW top_accounts AS
+SELECT * FROM (
+    SELECT a.chain_id, a.address, a.tx_count,
+           EXISTS (
+               SELECT 1 FROM transactions c
+               WHERE c.chain_id = a.chain_id AND c.contract_address = a.address
+           ) AS is_contract,
+           ROW_NUMBER() OVER (PARTITION BY a.chain_id ORDER BY a.tx_count DESC, a.address) AS tx_count_rank,
+           NOW() AS refreshed_at
+    FROM addresses a
+) ranked
+WHERE tx_count_rank <= 1000;
+
+CREATE UNIQUE INDEX idx_top_accounts_address ON top_accounts(chain_id, address);
+CREATE INDEX idx_top_accounts_tx_count ON top_accounts(chain_id, tx_count_rank);
+
+CREATE MATERIALIZED VIEW top_contracts AS
+SELECT * FROM (
+    SELECT time_window, chain_id, address, tx_count, unique_callers, gas_used,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY tx_count DESC, address) AS tx_count_rank,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY unique_callers DESC, address) AS unique_callers_rank,
+           ROW_NUMBER() OVER (PARTITION BY chain_id, time_window ORDER BY gas_used DESC, address) AS gas_used_rank,
+           NOW() AS refreshed_at
+    FROM (
+        SELECT w.time_window, t.chain_id, t.to_address AS address,
+               COUNT(*) AS tx_count,
+               COUNT(DISTINCT t.from_address) AS unique_callers,
+               COALESCE(SUM(t.gas_used), 0)::NUMERIC(38, 0) AS gas_used
+        FROM (VALUES ('24h', INTERVAL '1 day'), ('7d', INTERVAL '7 days'), ('30d', INTERVAL '30 days'))
+             AS w(time_window, span)
+        JOIN transactions t ON t.timestamp >= (NOW() AT TIME ZONE 'UTC') - w.span
+        WHERE EXISTS (
+            SELECT 1 FROM transactions c
+            WHERE c.chain_id = t.chain_id AND c.contract_address = t.to_address
+        )
+        GROUP BY w.time_window, t.chain_id, t.to_address
+    ) activity
+) ranked
+WHERE tx_count_rank <= 1000 OR unique_callers_rank <= 1000 OR gas_used_rank <= 1000;
+
+CREATE UNIQUE INDEX idx_top_contracts_address ON top_contracts(chain_id, time_window, address);
+CREATE INDEX idx_top_contracts_tx_count ON top_contracts(chain_id, time_window, tx_count_rank);
+CREATE INDEX idx_top_contracts_callers ON top_contracts(chain_id, time_window, unique_callers_rank);
+CREATE INDEX idx_top_contracts_gas ON top_contracts(chain_id, time_window, gas_used_rank);
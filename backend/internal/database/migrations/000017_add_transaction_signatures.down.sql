@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS v,
+    DROP COLUMN IF EXISTS r,
+    DROP COLUMN IF EXISTS s;
@@ -0,0 +1,6 @@
+-- Signature values, so transactions can be served over JSON-RPC from the
+-- index. Rows indexed before this migration leave them NULL.
+ALTER TABLE transactions
+    ADD COLUMN v VARCHAR(66),
+    ADD COLUMN r VARCHAR(66),
+    ADD COLUMN s VARCHAR(66);
@@ -0,0 +1,8 @@
+-- Requests each API key made per UTC day, counted here rather than in the
+-- API processes so daily quotas hold across restarts and replicas.
+CREATE TABLE api_key_usage (
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL,
+    PRIMARY KEY (api_key_id, day)
+);
@@ -0,0 +1,5 @@
+-- Deliveries still pending for a block a reorg dropped are cancelled rather
+-- than sent.
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS webhook_deliveries_status_check;
+ALTER TABLE webhook_deliveries ADD CONSTRAINT webhook_deliveries_status_check
+    CHECK (status IN ('pending', 'delivered', 'dead', 'cancelled'));
@@ -0,0 +1,17 @@
+DROP MATERIALIZED VIEW IF EXISTS top_accounts;
+
+CREATE MATERIALIZED VIEW top_accounts AS
+SELECT * FROM (
+    SELECT a.chain_id, a.address, a.tx_count,
+           EXISTS (
+               SELECT 1 FROM transactions c
+               WHERE c.chain_id = a.chain_id AND c.contract_address = a.address
+           ) AS is_contract,
+           ROW_NUMBER() OVER (PARTITION BY a.chain_id ORDER BY a.tx_count DESC, a.address) AS tx_count_rank,
+           NOW() AS refreshed_at
+    FROM addresses a
+) ranked
+WHERE tx_count_rank <= 1000;
+
+CREATE UNIQUE INDEX idx_top_accounts_address ON top_accounts(chain_id, address);
+CREATE INDEX idx_top_accounts_tx_count ON top_accounts(chain_id, tx_count_rank);
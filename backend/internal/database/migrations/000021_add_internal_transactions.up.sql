@@ -0,0 +1,30 @@
+-- ============================================================================
+-- INTERNAL TRANSACTIONS (calls made by contracts, from callTracer traces)
+-- ============================================================================
+
+CREATE TABLE internal_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    chain_id BIGINT NOT NULL REFERENCES chains(chain_id) ON DELETE CASCADE,
+    transaction_hash VARCHAR(66) NOT NULL,
+    -- trace_index orders the calls of a transaction depth first;
+    -- trace_address is the call's path in the call tree, e.g. 0,2,1
+    trace_index INT NOT NULL,
+    trace_address VARCHAR(255) NOT NULL,
+    call_type VARCHAR(20) NOT NULL,
+    from_address VARCHAR(42) NOT NULL,
+    to_address VARCHAR(42),
+    value NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    gas BIGINT,
+    gas_used BIGINT,
+    error TEXT,
+    block_number BIGINT NOT NULL,
+    transaction_index INT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(chain_id, transaction_hash, trace_index)
+);
+
+CREATE INDEX idx_internal_transactions_chain_from ON internal_transactions(chain_id, from_address, block_number DESC);
+CREATE INDEX idx_internal_transactions_chain_to ON internal_transactions(chain_id, to_address, block_number DESC);
+CREATE INDEX idx_internal_transactions_block ON internal_transactions(chain_id, block_number DESC);
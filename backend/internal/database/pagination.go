@@ -0,0 +1,66 @@
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Position identifies a row in chain order for keyset pagination: the block
+// it was included in and its index within that block (transaction index for
+// transactions, log index for logs and token transfers).
+type Position struct {
+	BlockNumber int64
+	Index       int
+}
+
+// Cursor is an opaque pagination token. It points at the row a page ends on
+// and says whether the next request continues after it or pages back before
+// it.
+type Cursor struct {
+	Position
+	Backward bool
+}
+
+func (c Cursor) Encode() string {
+	dir := "n"
+	if c.Backward {
+		dir = "p"
+	}
+	raw := fmt.Sprintf("%s:%d:%d", dir, c.BlockNumber, c.Index)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || (parts[0] != "n" && parts[0] != "p") {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	blockNumber, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	index, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &Cursor{
+		Position: Position{BlockNumber: blockNumber, Index: index},
+		Backward: parts[0] == "p",
+	}, nil
+}
+
+// keysetCondition returns a condition selecting rows strictly after pos in
+// the given order, comparing (blockCol, indexCol) as a row value.
+func keysetCondition(blockCol, indexCol string, pos Position, ascending bool, addArg func(interface{}) string) string {
+	op := "<"
+	if ascending {
+		op = ">"
+	}
+	return fmt.Sprintf("(%s, %s) %s (%s, %s)", blockCol, indexCol, op, addArg(pos.BlockNumber), addArg(pos.Index))
+}
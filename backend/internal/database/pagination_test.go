@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, cursor := range []Cursor{
+		{Position: Position{BlockNumber: 123456, Index: 7}},
+		{Position: Position{BlockNumber: 5}, Backward: true},
+	} {
+		decoded, err := DecodeCursor(cursor.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+	}
+
+	for _, invalid := range []string{"", "not a cursor", "eDoxOjI"} {
+		_, err := DecodeCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBuildLogsQueryBefore(t *testing.T) {
+	query, args := buildLogsQuery(&LogFilter{ChainID: 1337, Before: &Position{BlockNumber: 9, Index: 1}, Limit: 11})
+	assert.Contains(t, query, "(block_number, log_index) < ($2, $3)")
+	assert.Contains(t, query, "ORDER BY block_number DESC, log_index DESC")
+	assert.Len(t, args, 5)
+}
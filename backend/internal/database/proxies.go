@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+func (db *DB) UpsertProxyContract(ctx context.Context, proxy *models.ProxyContract) error {
+	query := `
+		INSERT INTO proxy_contracts (
+			chain_id, proxy_address, proxy_type, implementation_address,
+			admin_address, beacon_address, detected_at_block, last_upgraded_block
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (chain_id, proxy_address) DO UPDATE SET
+			proxy_type = EXCLUDED.proxy_type,
+			implementation_address = COALESCE(EXCLUDED.implementation_address, proxy_contracts.implementation_address),
+			admin_address = COALESCE(EXCLUDED.admin_address, proxy_contracts.admin_address),
+			beacon_address = COALESCE(EXCLUDED.beacon_address, proxy_contracts.beacon_address),
+			last_upgraded_block = GREATEST(EXCLUDED.last_upgraded_block, proxy_contracts.last_upgraded_block),
+			updated_at = NOW()
+		RETURNING id
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		proxy.ChainID, proxy.ProxyAddress, proxy.ProxyType, proxy.ImplementationAddress,
+		proxy.AdminAddress, proxy.BeaconAddress, proxy.DetectedAtBlock, proxy.LastUpgradedBlock,
+	).Scan(&proxy.ID)
+	if err != nil {
+		return fmt.Errorf("failed to upsert proxy contract: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) UpdateProxyAdmin(ctx context.Context, chainID int64, proxyAddress, adminAddress string) error {
+	query := `
+		UPDATE proxy_contracts
+		SET admin_address = $3, updated_at = NOW()
+		WHERE chain_id = $1 AND proxy_address = $2
+	`
+	_, err := db.conn.ExecContext(ctx, query, chainID, proxyAddress, adminAddress)
+	if err != nil {
+		return fmt.Errorf("failed to update proxy admin: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) InsertProxyImplementation(ctx context.Context, impl *models.ProxyImplementation) error {
+	query := `
+		INSERT INTO proxy_implementations (
+			chain_id, proxy_address, implementation_address, block_number,
+			transaction_hash, log_index, source
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (chain_id, proxy_address, block_number, implementation_address) DO NOTHING
+		RETURNING id
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		impl.ChainID, impl.ProxyAddress, impl.ImplementationAddress, impl.BlockNumber,
+		impl.TransactionHash, impl.LogIndex, impl.Source,
+	).Scan(&impl.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to insert proxy implementation: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetProxyContract(ctx context.Context, chainID int64, address string) (*models.ProxyContract, error) {
+	query := `
+		SELECT id, chain_id, proxy_address, proxy_type, implementation_address,
+			   admin_address, beacon_address, detected_at_block, last_upgraded_block,
+			   created_at, updated_at
+		FROM proxy_contracts
+		WHERE chain_id = $1 AND proxy_address = $2
+	`
+	proxy := &models.ProxyContract{}
+	err := db.conn.QueryRowContext(ctx, query, chainID, address).Scan(
+		&proxy.ID, &proxy.ChainID, &proxy.ProxyAddress, &proxy.ProxyType,
+		&proxy.ImplementationAddress, &proxy.AdminAddress, &proxy.BeaconAddress,
+		&proxy.DetectedAtBlock, &proxy.LastUpgradedBlock, &proxy.CreatedAt, &proxy.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy contract: %w", err)
+	}
+	return proxy, nil
+}
+
+// GetProxiesByBeacon returns every proxy that delegates through the given
+// beacon, so a beacon upgrade can be fanned out to all of them.
+func (db *DB) GetProxiesByBeacon(ctx context.Context, chainID int64, beaconAddress string) ([]*models.ProxyContract, error) {
+	query := `
+		SELECT id, chain_id, proxy_address, proxy_type, implementation_address,
+			   admin_address, beacon_address, detected_at_block, last_upgraded_block,
+			   created_at, updated_at
+		FROM proxy_contracts
+		WHERE chain_id = $1 AND beacon_address = $2
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, beaconAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxies by beacon: %w", err)
+	}
+	defer rows.Close()
+
+	var proxies []*models.ProxyContract
+	for rows.Next() {
+		proxy := &models.ProxyContract{}
+		err := rows.Scan(
+			&proxy.ID, &proxy.ChainID, &proxy.ProxyAddress, &proxy.ProxyType,
+			&proxy.ImplementationAddress, &proxy.AdminAddress, &proxy.BeaconAddress,
+			&proxy.DetectedAtBlock, &proxy.LastUpgradedBlock, &proxy.CreatedAt, &proxy.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy contract: %w", err)
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
+
+func (db *DB) GetProxyImplementations(ctx context.Context, chainID int64, proxyAddress string) ([]*models.ProxyImplementation, error) {
+	query := `
+		SELECT id, chain_id, proxy_address, implementation_address, block_number,
+			   transaction_hash, log_index, source, created_at
+		FROM proxy_implementations
+		WHERE chain_id = $1 AND proxy_address = $2
+		ORDER BY block_number DESC, log_index DESC NULLS LAST
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy implementations: %w", err)
+	}
+	defer rows.Close()
+
+	var impls []*models.ProxyImplementation
+	for rows.Next() {
+		impl := &models.ProxyImplementation{}
+		err := rows.Scan(
+			&impl.ID, &impl.ChainID, &impl.ProxyAddress, &impl.ImplementationAddress,
+			&impl.BlockNumber, &impl.TransactionHash, &impl.LogIndex, &impl.Source, &impl.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy implementation: %w", err)
+		}
+		impls = append(impls, impl)
+	}
+	return impls, nil
+}
+
+// GetImplementationAt returns the implementation a proxy delegated to at the
+// given block, or an empty string if the address is not a known proxy.
+func (db *DB) GetImplementationAt(ctx context.Context, chainID int64, proxyAddress string, blockNumber int64) (string, error) {
+	query := `
+		SELECT implementation_address
+		FROM proxy_implementations
+		WHERE chain_id = $1 AND proxy_address = $2 AND block_number <= $3
+		ORDER BY block_number DESC, log_index DESC NULLS LAST
+		LIMIT 1
+	`
+	var implementation string
+	err := db.conn.QueryRowContext(ctx, query, chainID, proxyAddress, blockNumber).Scan(&implementation)
+	if err == sql.ErrNoRows {
+		// Fall back to whatever the proxy currently points at
+		proxy, err := db.GetProxyContract(ctx, chainID, proxyAddress)
+		if err != nil || proxy == nil || proxy.ImplementationAddress == nil {
+			return "", err
+		}
+		return *proxy.ImplementationAddress, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get implementation: %w", err)
+	}
+	return implementation, nil
+}
+
+// rollbackProxies drops the proxy history recorded from height up. Proxies
+// first seen there are removed; the rest point back at their last remaining
+// implementation.
+func rollbackProxies(ctx context.Context, tx *sql.Tx, chainID, height int64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM proxy_implementations WHERE chain_id = $1 AND block_number >= $2`, chainID, height); err != nil {
+		return fmt.Errorf("failed to delete proxy implementations: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM proxy_contracts WHERE chain_id = $1 AND detected_at_block >= $2`, chainID, height); err != nil {
+		return fmt.Errorf("failed to delete proxy contracts: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE proxy_contracts pc SET
+			implementation_address = last.implementation_address,
+			last_upgraded_block = last.block_number,
+			updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (proxy_address) proxy_address, implementation_address, block_number
+			FROM proxy_implementations
+			WHERE chain_id = $1
+			ORDER BY proxy_address, block_number DESC, log_index DESC NULLS LAST
+		) last
+		WHERE pc.chain_id = $1 AND pc.proxy_address = last.proxy_address AND pc.last_upgraded_block >= $2
+	`, chainID, height)
+	if err != nil {
+		return fmt.Errorf("failed to reset proxy contracts: %w", err)
+	}
+	return nil
+}
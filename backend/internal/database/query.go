@@ -0,0 +1,199 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// where accumulates the conditions of a query and their positional arguments
+// so filters can be composed from the reusable parts below.
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere(chainCol string, chainID int64) *where {
+	w := &where{}
+	w.add(chainCol + " = " + w.arg(chainID))
+	return w
+}
+
+// arg binds a value and returns its placeholder.
+func (w *where) arg(value interface{}) string {
+	w.args = append(w.args, value)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *where) add(condition string) {
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *where) String() string {
+	return strings.Join(w.conditions, " AND ")
+}
+
+// BlockRange restricts rows to a block and time window. All bounds are
+// inclusive.
+type BlockRange struct {
+	FromBlock *int64
+	ToBlock   *int64
+	FromTime  *time.Time
+	ToTime    *time.Time
+}
+
+// apply filters on blockCol and timeCol. Tables without a timestamp column
+// pass an empty timeCol and have the time bounds resolved through blocks;
+// when no block falls inside a bound the subquery is NULL and nothing
+// matches.
+func (r *BlockRange) apply(w *where, blockCol, timeCol string, chainID int64) {
+	if r.FromBlock != nil {
+		w.add(blockCol + " >= " + w.arg(*r.FromBlock))
+	}
+	if r.ToBlock != nil {
+		w.add(blockCol + " <= " + w.arg(*r.ToBlock))
+	}
+	if timeCol == "" {
+		if r.FromTime != nil {
+			w.add(fmt.Sprintf("%s >= (SELECT MIN(block_number) FROM blocks WHERE chain_id = %s AND timestamp >= %s)",
+				blockCol, w.arg(chainID), w.arg(*r.FromTime)))
+		}
+		if r.ToTime != nil {
+			w.add(fmt.Sprintf("%s <= (SELECT MAX(block_number) FROM blocks WHERE chain_id = %s AND timestamp <= %s)",
+				blockCol, w.arg(chainID), w.arg(*r.ToTime)))
+		}
+		return
+	}
+	if r.FromTime != nil {
+		w.add(timeCol + " >= " + w.arg(*r.FromTime))
+	}
+	if r.ToTime != nil {
+		w.add(timeCol + " <= " + w.arg(*r.ToTime))
+	}
+}
+
+type Direction string
+
+const (
+	DirectionAny  Direction = ""
+	DirectionIn   Direction = "in"
+	DirectionOut  Direction = "out"
+	DirectionSelf Direction = "self"
+)
+
+// Party restricts rows to those involving Address. Direction narrows which
+// side of the transfer it must be on and Counterparty fixes the other side.
+// A Counterparty without an Address matches either side.
+type Party struct {
+	Address      *string
+	Direction    Direction
+	Counterparty *string
+}
+
+func (p *Party) apply(w *where, fromCol, toCol string) {
+	if p.Address == nil {
+		if p.Counterparty != nil {
+			c := w.arg(*p.Counterparty)
+			w.add(fmt.Sprintf("(%s = %s OR %s = %s)", fromCol, c, toCol, c))
+		}
+		return
+	}
+
+	a := w.arg(*p.Address)
+	switch p.Direction {
+	case DirectionIn:
+		w.add(toCol + " = " + a)
+		if p.Counterparty != nil {
+			w.add(fromCol + " = " + w.arg(*p.Counterparty))
+		}
+	case DirectionOut:
+		w.add(fromCol + " = " + a)
+		if p.Counterparty != nil {
+			w.add(toCol + " = " + w.arg(*p.Counterparty))
+		}
+	case DirectionSelf:
+		w.add(fmt.Sprintf("%s = %s AND %s = %s", fromCol, a, toCol, a))
+	default:
+		if p.Counterparty != nil {
+			c := w.arg(*p.Counterparty)
+			w.add(fmt.Sprintf("((%s = %s AND %s = %s) OR (%s = %s AND %s = %s))", fromCol, a, toCol, c, fromCol, c, toCol, a))
+		} else {
+			w.add(fmt.Sprintf("(%s = %s OR %s = %s)", fromCol, a, toCol, a))
+		}
+	}
+}
+
+// ValueRange bounds a NUMERIC amount column. Bounds are inclusive decimal
+// strings in the smallest unit.
+type ValueRange struct {
+	MinValue *string
+	MaxValue *string
+}
+
+func (v *ValueRange) apply(w *where, col string) {
+	if v.MinValue != nil {
+		w.add(col + " >= " + w.arg(*v.MinValue) + "::numeric")
+	}
+	if v.MaxValue != nil {
+		w.add(col + " <= " + w.arg(*v.MaxValue) + "::numeric")
+	}
+}
+
+type SortField string
+
+const (
+	// SortBlock orders by chain position and supports keyset cursors
+	SortBlock SortField = ""
+	// SortValue orders by amount and pages by offset only
+	SortValue SortField = "value"
+)
+
+type SortOrder string
+
+const (
+	OrderDefault SortOrder = ""
+	OrderAsc     SortOrder = "asc"
+	OrderDesc    SortOrder = "desc"
+)
+
+// Sort orders a listing. An OrderDefault sort uses the listing's natural
+// order: newest first for transactions and transfers, oldest first for logs.
+type Sort struct {
+	Field SortField
+	Order SortOrder
+}
+
+func (s Sort) ascending(defaultAscending bool) bool {
+	if s.Order == OrderDefault {
+		return defaultAscending
+	}
+	return s.Order == OrderAsc
+}
+
+// orderBy returns the ORDER BY clause of a chain-ordered listing and adds the
+// keyset bound for after or before. A before bound walks the listing
+// backwards, so those rows come back reversed and the caller restores
+// listing order. Keyset bounds are ignored when sorting by value.
+func (w *where) orderBy(s Sort, defaultAscending bool, blockCol, indexCol, valueCol string, after, before *Position) string {
+	ascending := s.ascending(defaultAscending)
+	dir := func(asc bool) string {
+		if asc {
+			return "ASC"
+		}
+		return "DESC"
+	}
+
+	if s.Field == SortValue && valueCol != "" {
+		d := dir(ascending)
+		return fmt.Sprintf("%s %s NULLS LAST, %s %s, %s %s", valueCol, d, blockCol, d, indexCol, d)
+	}
+
+	if after != nil {
+		w.add(keysetCondition(blockCol, indexCol, *after, ascending, w.arg))
+	} else if before != nil {
+		ascending = !ascending
+		w.add(keysetCondition(blockCol, indexCol, *before, ascending, w.arg))
+	}
+	d := dir(ascending)
+	return fmt.Sprintf("%s %s, %s %s", blockCol, d, indexCol, d)
+}
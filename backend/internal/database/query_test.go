@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartyDirections(t *testing.T) {
+	address := "0xA"
+	counterparty := "0xB"
+	cases := []struct {
+		party Party
+		want  string
+	}{
+		{Party{Address: &address}, "chain_id = $1 AND (from_address = $2 OR to_address = $2)"},
+		{Party{Address: &address, Direction: DirectionIn}, "chain_id = $1 AND to_address = $2"},
+		{Party{Address: &address, Direction: DirectionOut, Counterparty: &counterparty}, "chain_id = $1 AND from_address = $2 AND to_address = $3"},
+		{Party{Address: &address, Direction: DirectionSelf}, "chain_id = $1 AND from_address = $2 AND to_address = $2"},
+		{Party{Address: &address, Counterparty: &counterparty}, "chain_id = $1 AND ((from_address = $2 AND to_address = $3) OR (from_address = $3 AND to_address = $2))"},
+		{Party{Counterparty: &counterparty}, "chain_id = $1 AND (from_address = $2 OR to_address = $2)"},
+	}
+	for _, tc := range cases {
+		w := newWhere("chain_id", 1)
+		tc.party.apply(w, "from_address", "to_address")
+		assert.Equal(t, tc.want, w.String())
+	}
+}
+
+func TestBlockRangeWithoutTimeColumn(t *testing.T) {
+	from := time.Unix(1700000000, 0)
+	w := newWhere("chain_id", 1337)
+	r := BlockRange{FromTime: &from}
+	r.apply(w, "block_number", "", 1337)
+	// No COALESCE: a window without blocks must match nothing
+	assert.Contains(t, w.String(), "block_number >= (SELECT MIN(block_number) FROM blocks WHERE chain_id = $2 AND timestamp >= $3)")
+	assert.Len(t, w.args, 3)
+}
+
+func TestOrderByValueIgnoresKeyset(t *testing.T) {
+	w := newWhere("chain_id", 1337)
+	order := w.orderBy(Sort{Field: SortValue, Order: OrderAsc}, false, "block_number", "log_index", "value", &Position{BlockNumber: 5}, nil)
+	assert.Equal(t, "value ASC NULLS LAST, block_number ASC, log_index ASC", order)
+	assert.Len(t, w.args, 1)
+}
+
+func TestTokenTransferFilterComposes(t *testing.T) {
+	address := "0xA"
+	minValue := "1000"
+	w := newWhere("tt.chain_id", 1337)
+	filter := TokenTransferFilter{
+		Party:      Party{Address: &address, Direction: DirectionIn},
+		ValueRange: ValueRange{MinValue: &minValue},
+	}
+	filter.Party.apply(w, "tt.from_address", "tt.to_address")
+	filter.ValueRange.apply(w, "tt.value")
+	order := w.orderBy(filter.Sort, false, "tt.block_number", "tt.log_index", "tt.value", nil, &Position{BlockNumber: 9, Index: 2})
+	assert.Equal(t, "tt.chain_id = $1 AND tt.to_address = $2 AND tt.value >= $3::numeric AND (tt.block_number, tt.log_index) > ($4, $5)", w.String())
+	assert.Equal(t, "tt.block_number ASC, tt.log_index ASC", order)
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// Ranking selects the order of a top list.
+type Ranking string
+
+const (
+	RankByBalance       Ranking = "balance"
+	RankByTxCount       Ranking = "tx_count"
+	RankByUniqueCallers Ranking = "unique_callers"
+	RankByGasUsed       Ranking = "gas_used"
+	RankByHolders       Ranking = "holders"
+	RankByTransfers     Ranking = "transfers"
+)
+
+// Rankings each top list supports
+var (
+	AccountRankings  = []Ranking{RankByBalance, RankByTxCount}
+	ContractRankings = []Ranking{RankByTxCount, RankByUniqueCallers, RankByGasUsed}
+	TokenRankings    = []Ranking{RankByHolders, RankByTransfers}
+	// ContractWindows are the time windows contract activity is ranked over
+	ContractWindows = []string{"24h", "7d", "30d"}
+)
+
+// MaxRank is how deep the ranking views go.
+const MaxRank = 1000
+
+// RankingFilter selects a page of a top list. Window only applies to
+// contracts.
+type RankingFilter struct {
+	ChainID int64
+	By      Ranking
+	Window  string
+	Limit   int
+	Offset  int
+}
+
+// RankingPage is a page of a top list, with the number of ranked entries and
+// when the view behind it was last refreshed.
+type RankingPage[T any] struct {
+	Items       []T
+	Total       int64
+	RefreshedAt *time.Time
+}
+
+// rankColumn returns the rank column of by, if the list supports it.
+func rankColumn(by Ranking, supported []Ranking) (string, error) {
+	for _, r := range supported {
+		if r == by {
+			return string(by) + "_rank", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported ranking: %s", by)
+}
+
+func (db *DB) GetTopAccounts(ctx context.Context, f *RankingFilter) (*RankingPage[*models.TopAccount], error) {
+	rank, err := rankColumn(f.By, AccountRankings)
+	if err != nil {
+		return nil, err
+	}
+	page := &RankingPage[*models.TopAccount]{Items: []*models.TopAccount{}}
+	where := fmt.Sprintf("chain_id = $1 AND %s <= %d", rank, MaxRank)
+	if err := db.rankingSummary(ctx, "top_accounts", where, &page.Total, &page.RefreshedAt, f.ChainID); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, address, balance, tx_count, is_contract
+		FROM top_accounts
+		WHERE %s
+		ORDER BY %s
+		LIMIT $2 OFFSET $3
+	`, rank, where, rank)
+	rows, err := db.conn.QueryContext(ctx, query, f.ChainID, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top accounts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a := &models.TopAccount{}
+		if err := rows.Scan(&a.Rank, &a.Address, &a.Balance, &a.TxCount, &a.IsContract); err != nil {
+			return nil, fmt.Errorf("failed to scan top account: %w", err)
+		}
+		page.Items = append(page.Items, a)
+	}
+	return page, nil
+}
+
+func (db *DB) GetTopContracts(ctx context.Context, f *RankingFilter) (*RankingPage[*models.TopContract], error) {
+	rank, err := rankColumn(f.By, ContractRankings)
+	if err != nil {
+		return nil, err
+	}
+	page := &RankingPage[*models.TopContract]{Items: []*models.TopContract{}}
+	where := fmt.Sprintf("chain_id = $1 AND time_window = $2 AND %s <= %d", rank, MaxRank)
+	if err := db.rankingSummary(ctx, "top_contracts", where, &page.Total, &page.RefreshedAt, f.ChainID, f.Window); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, address, tx_count, unique_callers, gas_used
+		FROM top_contracts
+		WHERE %s
+		ORDER BY %s
+		LIMIT $3 OFFSET $4
+	`, rank, where, rank)
+	rows, err := db.conn.QueryContext(ctx, query, f.ChainID, f.Window, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top contracts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := &models.TopContract{}
+		if err := rows.Scan(&c.Rank, &c.Address, &c.TxCount, &c.UniqueCallers, &c.GasUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan top contract: %w", err)
+		}
+		page.Items = append(page.Items, c)
+	}
+	return page, nil
+}
+
+func (db *DB) GetTopTokens(ctx context.Context, f *RankingFilter) (*RankingPage[*models.TopToken], error) {
+	rank, err := rankColumn(f.By, TokenRankings)
+	if err != nil {
+		return nil, err
+	}
+	page := &RankingPage[*models.TopToken]{Items: []*models.TopToken{}}
+	where := fmt.Sprintf("chain_id = $1 AND %s <= %d", rank, MaxRank)
+	if err := db.rankingSummary(ctx, "top_tokens", where, &page.Total, &page.RefreshedAt, f.ChainID); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, address, type, name, symbol, decimals, total_supply, holder_count, transfer_count
+		FROM top_tokens
+		WHERE %s
+		ORDER BY %s
+		LIMIT $2 OFFSET $3
+	`, rank, where, rank)
+	rows, err := db.conn.QueryContext(ctx, query, f.ChainID, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top tokens: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t := &models.TopToken{}
+		err := rows.Scan(&t.Rank, &t.Address, &t.Type, &t.Name, &t.Symbol, &t.Decimals,
+			&t.TotalSupply, &t.HolderCount, &t.TransferCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan top token: %w", err)
+		}
+		page.Items = append(page.Items, t)
+	}
+	return page, nil
+}
+
+// rankingSummary counts the ranked rows of view matching where and reads
+// when the view was refreshed.
+func (db *DB) rankingSummary(ctx context.Context, view, where string, total *int64, refreshedAt **time.Time, args ...interface{}) error {
+	var refreshed sql.NullTime
+	query := fmt.Sprintf(`SELECT COUNT(*), MAX(refreshed_at) FROM %s WHERE %s`, view, where)
+	if err := db.conn.QueryRowContext(ctx, query, args...).Scan(total, &refreshed); err != nil {
+		return fmt.Errorf("failed to count %s: %w", view, err)
+	}
+	if refreshed.Valid {
+		*refreshedAt = &refreshed.Time
+	}
+	return nil
+}
+
+// RefreshRankings rebuilds the ranking views. Concurrent refreshes keep the
+// views readable while they rebuild.
+func (db *DB) RefreshRankings(ctx context.Context) error {
+	for _, view := range []string{"top_accounts", "top_contracts", "top_tokens"} {
+		if _, err := db.conn.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+	return nil
+}
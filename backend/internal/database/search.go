@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// Scores of fuzzy matches: an exact match ranks above a prefix match, which
+// ranks above a trigram match. Trigram similarity (0 to 1) orders matches
+// within each tier.
+const (
+	scoreExact   = 3
+	scorePrefix  = 2
+	scoreTrigram = 1
+)
+
+// searchPattern lowercases q and returns it with its LIKE prefix pattern.
+func searchPattern(q string) (string, string) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	return q, strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q) + "%"
+}
+
+// SearchTokens finds tokens on chainIDs whose symbol or name equals, starts
+// with or resembles q.
+func (db *DB) SearchTokens(ctx context.Context, chainIDs []int64, q string, limit int) ([]*models.SearchResult, error) {
+	q, prefix := searchPattern(q)
+	query := `
+		SELECT ` + tokenColumns + `,
+			CASE
+				WHEN LOWER(symbol) = $2 OR LOWER(name) = $2 THEN ` + fmt.Sprint(scoreExact) + `
+				WHEN LOWER(symbol) LIKE $3 OR LOWER(name) LIKE $3 THEN ` + fmt.Sprint(scorePrefix) + `
+				ELSE ` + fmt.Sprint(scoreTrigram) + `
+			END + GREATEST(similarity(LOWER(COALESCE(symbol, '')), $2), similarity(LOWER(COALESCE(name, '')), $2)) AS score
+		FROM tokens
+		WHERE chain_id = ANY($1)
+		  AND (LOWER(symbol) LIKE $3 OR LOWER(name) LIKE $3 OR LOWER(symbol) % $2 OR LOWER(name) % $2)
+		ORDER BY score DESC, holder_count DESC, id
+		LIMIT $4
+	`
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(chainIDs), q, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*models.SearchResult
+	for rows.Next() {
+		token := &models.Token{}
+		result := &models.SearchResult{Type: models.SearchToken, Result: token}
+		err := rows.Scan(
+			&token.ID, &token.ChainID, &token.Address, &token.Type,
+			&token.Name, &token.Symbol, &token.Decimals, &token.TotalSupply,
+			&token.HolderCount, &token.TransferCount, &token.CreatedAt, &token.UpdatedAt,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		result.ChainID, result.Value = token.ChainID, token.Address
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SearchAddressLabels finds labels on chainIDs that equal, start with or
+// resemble q.
+func (db *DB) SearchAddressLabels(ctx context.Context, chainIDs []int64, q string, limit int) ([]*models.SearchResult, error) {
+	q, prefix := searchPattern(q)
+	query := `
+		SELECT ` + addressLabelColumns + `,
+			CASE
+				WHEN LOWER(label) = $2 THEN ` + fmt.Sprint(scoreExact) + `
+				WHEN LOWER(label) LIKE $3 THEN ` + fmt.Sprint(scorePrefix) + `
+				ELSE ` + fmt.Sprint(scoreTrigram) + `
+			END + similarity(LOWER(label), $2) AS score
+		FROM address_labels
+		WHERE chain_id = ANY($1) AND (LOWER(label) LIKE $3 OR LOWER(label) % $2)
+		ORDER BY score DESC, label, address
+		LIMIT $4
+	`
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(chainIDs), q, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search address labels: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*models.SearchResult
+	for rows.Next() {
+		l := &models.AddressLabel{}
+		result := &models.SearchResult{Type: models.SearchLabel, Result: l}
+		err := rows.Scan(&l.ID, &l.ChainID, &l.Address, &l.Label, &l.Description, &l.CreatedAt, &l.UpdatedAt, &result.Score)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address label: %w", err)
+		}
+		result.ChainID, result.Value = l.ChainID, l.Address
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,41 @@
+package database_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTokensFindsIndexedMetadata(t *testing.T) {
+	db := database.SetupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	// The indexer reads name and symbol from the token the first time it
+	// sees a transfer
+	const address = "0x000000000000000000000000000000000000C0DE"
+	node := blockchaintest.NewNode(t)
+	node.SetContract(address, (&blockchaintest.Token{
+		Name: "USD Coin", Symbol: "USDC", Decimals: 6, TotalSupply: big.NewInt(1_000_000),
+	}).Contract())
+	require.NoError(t, db.UpsertToken(ctx, indexer.DescribeToken(ctx, node.Client(t), blockchaintest.ChainID, address, nil)))
+
+	for _, q := range []string{"usdc", "USD Co", "usd coin"} {
+		results, err := db.SearchTokens(ctx, []int64{blockchaintest.ChainID}, q, 10)
+		require.NoError(t, err, q)
+		require.Len(t, results, 1, q)
+		assert.Equal(t, address, results[0].Value)
+		token := results[0].Result.(*models.Token)
+		require.NotNil(t, token.Symbol)
+		assert.Equal(t, "USDC", *token.Symbol)
+		require.NotNil(t, token.Decimals)
+		assert.Equal(t, 6, *token.Decimals)
+	}
+}
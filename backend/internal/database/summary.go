@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// GetAddressSummary summarises an address's indexed activity with its
+// busiest counterparties, leaving token holdings to GetTokensByAddress. The
+// balance is the last indexed one. It returns nil if the address is neither
+// indexed nor party to any transaction.
+func (db *DB) GetAddressSummary(ctx context.Context, chainID int64, address string, counterparties int) (*models.AddressSummary, error) {
+	s := &models.AddressSummary{
+		ChainID:        chainID,
+		Address:        address,
+		Balance:        "0",
+		BalanceSource:  models.BalanceSourceIndexed,
+		Counterparties: []*models.Counterparty{},
+	}
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COALESCE(balance, 0), COALESCE(is_contract, false) FROM addresses WHERE chain_id = $1 AND address = $2`,
+		chainID, address).Scan(&s.Balance, &s.IsContract)
+	indexed := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	query := `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE from_address = $2),
+			COUNT(*) FILTER (WHERE to_address = $2),
+			COUNT(*) FILTER (WHERE status = 1),
+			COUNT(*) FILTER (WHERE status = 0),
+			COALESCE(SUM(gas_used) FILTER (WHERE from_address = $2), 0),
+			COALESCE(SUM(gas_used * COALESCE(effective_gas_price, gas_price)) FILTER (WHERE from_address = $2), 0)
+		FROM transactions
+		WHERE chain_id = $1 AND (from_address = $2 OR to_address = $2)
+	`
+	c := &s.TxCounts
+	err = db.conn.QueryRowContext(ctx, query, chainID, address).Scan(
+		&c.Total, &c.Sent, &c.Received, &c.Success, &c.Failed, &s.GasSpent.GasUsed, &s.GasSpent.FeesPaid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count address transactions: %w", err)
+	}
+	if !indexed && c.Total == 0 {
+		return nil, nil
+	}
+	if c.Total == 0 {
+		return s, nil
+	}
+
+	if s.FirstActivity, err = db.addressActivity(ctx, chainID, address, "ASC"); err != nil {
+		return nil, err
+	}
+	if s.LastActivity, err = db.addressActivity(ctx, chainID, address, "DESC"); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT counterparty, COUNT(*), COUNT(*) FILTER (WHERE outgoing), COUNT(*) FILTER (WHERE NOT outgoing)
+		FROM (
+			SELECT to_address AS counterparty, true AS outgoing
+			FROM transactions
+			WHERE chain_id = $1 AND from_address = $2 AND to_address IS NOT NULL
+			UNION ALL
+			SELECT from_address, false
+			FROM transactions
+			WHERE chain_id = $1 AND to_address = $2
+		) parties
+		WHERE counterparty <> $2
+		GROUP BY counterparty
+		ORDER BY COUNT(*) DESC, counterparty
+		LIMIT $3
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, address, counterparties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counterparties: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		cp := &models.Counterparty{}
+		if err := rows.Scan(&cp.Address, &cp.TxCount, &cp.Sent, &cp.Received); err != nil {
+			return nil, fmt.Errorf("failed to scan counterparty: %w", err)
+		}
+		s.Counterparties = append(s.Counterparties, cp)
+	}
+	return s, nil
+}
+
+// addressActivity returns the first (ASC) or last (DESC) transaction an
+// address is party to.
+func (db *DB) addressActivity(ctx context.Context, chainID int64, address, dir string) (*models.ActivityRef, error) {
+	query := fmt.Sprintf(`
+		SELECT block_number, hash, timestamp
+		FROM transactions
+		WHERE chain_id = $1 AND (from_address = $2 OR to_address = $2)
+		ORDER BY block_number %[1]s, transaction_index %[1]s
+		LIMIT 1
+	`, dir)
+	ref := &models.ActivityRef{}
+	err := db.conn.QueryRowContext(ctx, query, chainID, address).Scan(&ref.BlockNumber, &ref.TransactionHash, &ref.Timestamp)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address activity: %w", err)
+	}
+	return ref, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// UpdateSyncStatus records a sync pass: how far the index has got and the
+// chain head it was syncing towards. The head's timestamp only moves when
+// the head does.
+func (db *DB) UpdateSyncStatus(ctx context.Context, chainID, lastSynced, latest int64, syncing bool) error {
+	query := `
+		INSERT INTO sync_status (chain_id, last_synced_block, latest_block, is_syncing, last_sync_time, latest_block_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), NOW())
+		ON CONFLICT (chain_id) DO UPDATE SET
+			last_synced_block = EXCLUDED.last_synced_block,
+			latest_block_at = CASE
+				WHEN EXCLUDED.latest_block <> sync_status.latest_block OR sync_status.latest_block_at IS NULL
+				THEN NOW() ELSE sync_status.latest_block_at END,
+			latest_block = EXCLUDED.latest_block,
+			is_syncing = EXCLUDED.is_syncing,
+			last_sync_time = NOW(),
+			updated_at = NOW()
+	`
+	if _, err := db.conn.ExecContext(ctx, query, chainID, lastSynced, latest, syncing); err != nil {
+		return fmt.Errorf("failed to update sync status: %w", err)
+	}
+	return nil
+}
+
+// RecordSyncError counts a failed sync pass.
+func (db *DB) RecordSyncError(ctx context.Context, chainID int64, syncErr error) error {
+	query := `
+		INSERT INTO sync_status (chain_id, error_count, last_error, last_error_time, updated_at)
+		VALUES ($1, 1, $2, NOW(), NOW())
+		ON CONFLICT (chain_id) DO UPDATE SET
+			error_count = sync_status.error_count + 1,
+			last_error = EXCLUDED.last_error,
+			last_error_time = NOW(),
+			is_syncing = false,
+			updated_at = NOW()
+	`
+	if _, err := db.conn.ExecContext(ctx, query, chainID, syncErr.Error()); err != nil {
+		return fmt.Errorf("failed to record sync error: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetSyncStatus(ctx context.Context, chainID int64) (*models.SyncStatus, error) {
+	query := `
+		SELECT chain_id, last_synced_block, latest_block, COALESCE(is_syncing, false), last_sync_time,
+			latest_block_at, COALESCE(error_count, 0), last_error, last_error_time, updated_at,
+			EXTRACT(EPOCH FROM NOW() - latest_block_at)::float8
+		FROM sync_status WHERE chain_id = $1
+	`
+	s := &models.SyncStatus{}
+	err := db.conn.QueryRowContext(ctx, query, chainID).Scan(
+		&s.ChainID, &s.LastSyncedBlock, &s.LatestBlock, &s.IsSyncing, &s.LastSyncTime,
+		&s.LatestBlockAt, &s.ErrorCount, &s.LastError, &s.LastErrorTime, &s.UpdatedAt,
+		&s.LatestBlockAge,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync status: %w", err)
+	}
+	return s, nil
+}
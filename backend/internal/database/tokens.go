@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
+	"github.com/lib/pq"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
@@ -46,10 +48,26 @@ func (db *DB) InsertTokenTransfer(ctx context.Context, transfer *models.TokenTra
 	return nil
 }
 
-func (db *DB) GetTokensByAddress(ctx context.Context, chainID int64, address string) ([]*models.Token, error) {
-	query := `SELECT DISTINCT t.id, t.chain_id, t.address, t.type, t.name, t.symbol, t.decimals, t.total_supply, t.holder_count, t.transfer_count, t.created_at, t.updated_at
-	FROM tokens t INNER JOIN token_balances ON t.chain_id = tb.chain_id AND t.address = tb.token_address
-	WHERE t.chain_id = $1 AND tb.holder_address = $2 AND tb.balance!=0`
+const tokenColumns = `id, chain_id, address, type, name, symbol, decimals, total_supply,
+	holder_count, transfer_count, created_at, updated_at`
+
+func scanToken(row rowScanner) (*models.Token, error) {
+	token := &models.Token{}
+	err := row.Scan(
+		&token.ID, &token.ChainID, &token.Address, &token.Type,
+		&token.Name, &token.Symbol, &token.Decimals, &token.TotalSupply,
+		&token.HolderCount, &token.TransferCount, &token.CreatedAt, &token.UpdatedAt,
+	)
+	return token, err
+}
+
+// GetTokensByAddress returns the tokens an address holds a non-zero balance
+// of, with that balance.
+func (db *DB) GetTokensByAddress(ctx context.Context, chainID int64, address string) ([]*models.TokenHolding, error) {
+	query := `SELECT t.id, t.chain_id, t.address, t.type, t.name, t.symbol, t.decimals, t.total_supply, t.holder_count, t.transfer_count, t.created_at, t.updated_at, tb.balance
+	FROM tokens t INNER JOIN token_balances tb ON t.chain_id = tb.chain_id AND t.address = tb.token_address
+	WHERE t.chain_id = $1 AND tb.holder_address = $2 AND tb.balance != 0
+	ORDER BY t.address`
 
 	rows, err := db.conn.QueryContext(ctx, query, chainID, address)
 	if err!=nil {
@@ -57,22 +75,176 @@ func (db *DB) GetTokensByAddress(ctx context.Context, chainID int64, address str
 	}
 	defer rows.Close()
 
-	var tokens []*models.Token
+	var holdings []*models.TokenHolding
 	for rows.Next() {
-		token := &models.Token{}
+		holding := &models.TokenHolding{}
+		token := &holding.Token
 		err := rows.Scan(
 			&token.ID, &token.ChainID, &token.Address, &token.Type,
 			&token.Name, &token.Symbol, &token.Decimals, &token.TotalSupply,
 			&token.HolderCount, &token.TransferCount, &token.CreatedAt, &token.UpdatedAt,
+			&holding.Balance,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan token: %w", err)
 		}
+		holdings = append(holdings, holding)
+	}
+	return holdings, nil
+}
+
+// GetToken returns a token's metadata, or nil if it is not indexed.
+func (db *DB) GetToken(ctx context.Context, chainID int64, address string) (*models.Token, error) {
+	query := `SELECT ` + tokenColumns + ` FROM tokens WHERE chain_id = $1 AND address = $2`
+	token, err := scanToken(db.conn.QueryRowContext(ctx, query, chainID, address))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return token, nil
+}
+
+type TokenSortField string
+
+const (
+	// TokenSortCreated lists tokens in the order they were first seen
+	TokenSortCreated   TokenSortField = ""
+	TokenSortHolders   TokenSortField = "holders"
+	TokenSortTransfers TokenSortField = "transfers"
+)
+
+// TokenListFilter selects tokens. Types is an OR set of token standards;
+// Sort defaults to newest first.
+type TokenListFilter struct {
+	ChainID int64
+	Types   []string
+	Sort    TokenSortField
+	Order   SortOrder
+	Limit   int
+	Offset  int
+}
+
+func (f *TokenListFilter) where() *where {
+	w := newWhere("chain_id", f.ChainID)
+	if len(f.Types) > 0 {
+		w.add("type = ANY(" + w.arg(pq.Array(f.Types)) + ")")
+	}
+	return w
+}
+
+// GetTokens lists tokens matching filter.
+func (db *DB) GetTokens(ctx context.Context, filter *TokenListFilter) ([]*models.Token, error) {
+	w := filter.where()
+	dir := "DESC"
+	if filter.Order == OrderAsc {
+		dir = "ASC"
+	}
+	order := "id " + dir
+	switch filter.Sort {
+	case TokenSortHolders:
+		order = "holder_count " + dir + ", " + order
+	case TokenSortTransfers:
+		order = "transfer_count " + dir + ", " + order
+	}
+
+	query := `SELECT ` + tokenColumns + ` FROM tokens
+		WHERE ` + w.String() + `
+		ORDER BY ` + order + `
+		LIMIT ` + w.arg(filter.Limit) + ` OFFSET ` + w.arg(filter.Offset)
+	rows, err := db.conn.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.Token
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
 		tokens = append(tokens, token)
 	}
 	return tokens, nil
 }
 
+// CountTokens counts the tokens matching filter, ignoring its paging fields.
+func (db *DB) CountTokens(ctx context.Context, filter *TokenListFilter) (int64, error) {
+	w := filter.where()
+	var count int64
+	err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM tokens WHERE `+w.String(), w.args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return count, nil
+}
+
+// GetTokenHolders lists the holders of a token with a positive balance,
+// largest first.
+func (db *DB) GetTokenHolders(ctx context.Context, chainID int64, tokenAddress string, limit, offset int) ([]*models.TokenBalance, error) {
+	query := `
+		SELECT id, chain_id, token_address, holder_address, balance, updated_at
+		FROM token_balances
+		WHERE chain_id = $1 AND token_address = $2 AND balance > 0
+		ORDER BY balance DESC, holder_address ASC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, tokenAddress, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token holders: %w", err)
+	}
+	defer rows.Close()
+
+	var holders []*models.TokenBalance
+	for rows.Next() {
+		balance := &models.TokenBalance{}
+		err := rows.Scan(
+			&balance.ID, &balance.ChainID, &balance.TokenAddress,
+			&balance.HolderAddress, &balance.Balance, &balance.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token holder: %w", err)
+		}
+		holders = append(holders, balance)
+	}
+	return holders, nil
+}
+
+// SumTokenBalances totals the indexed balances of a token. It stands in for
+// the supply of tokens whose total supply is unknown.
+func (db *DB) SumTokenBalances(ctx context.Context, chainID int64, tokenAddress string) (string, error) {
+	var sum string
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(balance), 0)::text FROM token_balances
+		WHERE chain_id = $1 AND token_address = $2 AND balance > 0
+	`, chainID, tokenAddress).Scan(&sum)
+	if err != nil {
+		return "", fmt.Errorf("failed to sum token balances: %w", err)
+	}
+	return sum, nil
+}
+
+// UpdateTokenCounts refreshes a token's holder count from its balances and
+// adds newTransfers to its transfer count.
+func (db *DB) UpdateTokenCounts(ctx context.Context, chainID int64, tokenAddress string, newTransfers int) error {
+	query := `
+		UPDATE tokens SET
+			transfer_count = transfer_count + $3,
+			holder_count = (
+				SELECT COUNT(*) FROM token_balances
+				WHERE chain_id = $1 AND token_address = $2 AND balance > 0
+			),
+			updated_at = NOW()
+		WHERE chain_id = $1 AND address = $2
+	`
+	if _, err := db.conn.ExecContext(ctx, query, chainID, tokenAddress, newTransfers); err != nil {
+		return fmt.Errorf("failed to update token counts: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) UpsertTokenBalance(ctx context.Context, balance *models.TokenBalance) error {
 	query := `
 		INSERT INTO token_balances (chain_id, token_address, holder_address, balance)
@@ -103,4 +275,214 @@ func (db *DB) GetTokenBalance(ctx context.Context, chainID int64, tokenAddress,
 		return nil, nil
 	}
 	return balance, err
-}
\ No newline at end of file
+}
+
+// TokenTransferFilter selects token transfers; nil fields are not filtered
+// on. After continues past the last transfer of the previous page and Before
+// pages back from the first transfer of the next one.
+type TokenTransferFilter struct {
+	ChainID int64
+	Party
+	TokenAddress *string
+	BlockRange
+	ValueRange
+	Sort
+	After  *Position
+	Before *Position
+	Limit  int
+	Offset int
+}
+
+// GetTokenTransfers returns transfers matching filter, joined with the
+// token's metadata. Transfers are listed newest first unless the sort says
+// otherwise.
+func (db *DB) GetTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*models.TokenTransfer, error) {
+	w := newWhere("tt.chain_id", filter.ChainID)
+	filter.Party.apply(w, "tt.from_address", "tt.to_address")
+	if filter.TokenAddress != nil {
+		w.add("tt.token_address = " + w.arg(*filter.TokenAddress))
+	}
+	filter.BlockRange.apply(w, "tt.block_number", "tt.timestamp", filter.ChainID)
+	filter.ValueRange.apply(w, "tt.value")
+	order := w.orderBy(filter.Sort, false, "tt.block_number", "tt.log_index", "tt.value", filter.After, filter.Before)
+
+	query := `
+		SELECT tt.id, tt.chain_id, tt.transaction_hash, tt.log_index, tt.token_address,
+			   tt.from_address, tt.to_address, tt.value, tt.token_id, tt.block_number,
+			   tt.timestamp, tt.created_at, t.type, t.name, t.symbol, t.decimals
+		FROM token_transfers tt
+		LEFT JOIN tokens t ON t.chain_id = tt.chain_id AND t.address = tt.token_address
+		WHERE ` + w.String() + `
+		ORDER BY ` + order + `
+		LIMIT ` + w.arg(filter.Limit) + ` OFFSET ` + w.arg(filter.Offset)
+
+	rows, err := db.conn.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*models.TokenTransfer
+	for rows.Next() {
+		transfer, err := scanTokenTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+	if filter.Before != nil && filter.Field == SortBlock {
+		slices.Reverse(transfers)
+	}
+	return transfers, nil
+}
+
+func scanTokenTransfer(row rowScanner) (*models.TokenTransfer, error) {
+	transfer := &models.TokenTransfer{}
+	err := row.Scan(
+		&transfer.ID, &transfer.ChainID, &transfer.TransactionHash, &transfer.LogIndex,
+		&transfer.TokenAddress, &transfer.FromAddress, &transfer.ToAddress, &transfer.Value,
+		&transfer.TokenID, &transfer.BlockNumber, &transfer.Timestamp, &transfer.CreatedAt,
+		&transfer.TokenType, &transfer.TokenName, &transfer.TokenSymbol, &transfer.TokenDecimals,
+	)
+	return transfer, err
+}
+
+// GetTokenTransfersByTransactions batch-loads the token transfers made by
+// several transactions, keyed by transaction hash and in log index order.
+func (db *DB) GetTokenTransfersByTransactions(ctx context.Context, chainID int64, txHashes []string) (map[string][]*models.TokenTransfer, error) {
+	transfers := make(map[string][]*models.TokenTransfer, len(txHashes))
+	if len(txHashes) == 0 {
+		return transfers, nil
+	}
+	query := `
+		SELECT tt.id, tt.chain_id, tt.transaction_hash, tt.log_index, tt.token_address,
+			   tt.from_address, tt.to_address, tt.value, tt.token_id, tt.block_number,
+			   tt.timestamp, tt.created_at, t.type, t.name, t.symbol, t.decimals
+		FROM token_transfers tt
+		LEFT JOIN tokens t ON t.chain_id = tt.chain_id AND t.address = tt.token_address
+		WHERE tt.chain_id = $1 AND tt.transaction_hash = ANY($2)
+		ORDER BY tt.log_index ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(txHashes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token transfers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transfer, err := scanTokenTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token transfer: %w", err)
+		}
+		transfers[transfer.TransactionHash] = append(transfers[transfer.TransactionHash], transfer)
+	}
+	return transfers, nil
+}
+
+// GetTokensByAddresses batch-loads token metadata keyed by contract address.
+func (db *DB) GetTokensByAddresses(ctx context.Context, chainID int64, addresses []string) (map[string]*models.Token, error) {
+	tokens := make(map[string]*models.Token, len(addresses))
+	if len(addresses) == 0 {
+		return tokens, nil
+	}
+	query := `SELECT ` + tokenColumns + ` FROM tokens WHERE chain_id = $1 AND address = ANY($2)`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens[token.Address] = token
+	}
+	return tokens, nil
+}
+
+// rollbackTokenTransfers deletes a chain's token transfers from height up and
+// recomputes the token counts. Holders left without transfers are dropped; the
+// rest are returned, as only the token knows their balance before height.
+func rollbackTokenTransfers(ctx context.Context, tx *sql.Tx, chainID, height int64) ([]*models.TokenBalance, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM token_transfers WHERE chain_id = $1 AND block_number >= $2
+		RETURNING token_address, from_address, to_address
+	`, chainID, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete token_transfers: %w", err)
+	}
+	removed := make(map[string]int)
+	var tokens, holders []string
+	for rows.Next() {
+		var token, from, to string
+		if err := rows.Scan(&token, &from, &to); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan token transfer: %w", err)
+		}
+		removed[token]++
+		tokens = append(tokens, token, token)
+		holders = append(holders, from, to)
+	}
+	rows.Close()
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM token_balances tb
+		USING unnest($2::text[], $3::text[]) AS a(token_address, holder_address)
+		WHERE tb.chain_id = $1 AND tb.token_address = a.token_address AND tb.holder_address = a.holder_address
+		AND NOT EXISTS (
+			SELECT 1 FROM token_transfers tt
+			WHERE tt.chain_id = $1 AND tt.token_address = a.token_address
+			AND (tt.from_address = a.holder_address OR tt.to_address = a.holder_address)
+		)
+	`, chainID, pq.Array(tokens), pq.Array(holders))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete token balances: %w", err)
+	}
+	rows, err = tx.QueryContext(ctx, `
+		SELECT tb.token_address, tb.holder_address FROM token_balances tb
+		JOIN unnest($2::text[], $3::text[]) AS a(token_address, holder_address)
+			ON tb.token_address = a.token_address AND tb.holder_address = a.holder_address
+		WHERE tb.chain_id = $1
+		GROUP BY tb.token_address, tb.holder_address
+	`, chainID, pq.Array(tokens), pq.Array(holders))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token balances: %w", err)
+	}
+	var stale []*models.TokenBalance
+	for rows.Next() {
+		balance := &models.TokenBalance{ChainID: chainID}
+		if err := rows.Scan(&balance.TokenAddress, &balance.HolderAddress); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan token balance: %w", err)
+		}
+		stale = append(stale, balance)
+	}
+	rows.Close()
+
+	counted := make([]string, 0, len(removed))
+	counts := make([]int64, 0, len(removed))
+	for token, n := range removed {
+		counted = append(counted, token)
+		counts = append(counts, int64(n))
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tokens t SET
+			transfer_count = GREATEST(t.transfer_count - r.removed, 0),
+			holder_count = (
+				SELECT COUNT(*) FROM token_balances
+				WHERE chain_id = t.chain_id AND token_address = t.address AND balance > 0
+			),
+			updated_at = NOW()
+		FROM unnest($2::text[], $3::bigint[]) AS r(token_address, removed)
+		WHERE t.chain_id = $1 AND t.address = r.token_address
+	`, chainID, pq.Array(counted), pq.Array(counts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update token counts: %w", err)
+	}
+	return stale, nil
+}
@@ -4,10 +4,35 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
+	"github.com/lib/pq"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
 )
 
+const transactionColumns = `id, chain_id, hash, block_number, block_hash, transaction_index,
+	from_address, to_address, value, gas, gas_price,
+	max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
+	transaction_type, status, gas_used, cumulative_gas_used,
+	effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	tx := &models.Transaction{}
+	err := row.Scan(
+		&tx.ID, &tx.ChainID, &tx.Hash, &tx.BlockNumber, &tx.BlockHash,
+		&tx.TransactionIndex, &tx.FromAddress, &tx.ToAddress, &tx.Value,
+		&tx.Gas, &tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas,
+		&tx.Input, &tx.Nonce, &tx.TransactionType, &tx.Status, &tx.GasUsed,
+		&tx.CumulativeGasUsed, &tx.EffectiveGasPrice, &tx.ContractAddress,
+		&tx.LogsBloom, &tx.V, &tx.R, &tx.S, &tx.Timestamp, &tx.CreatedAt,
+	)
+	return tx, err
+}
+
 func (db *DB) InsertTransaction(ctx context.Context, tx *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
@@ -15,16 +40,20 @@ func (db *DB) InsertTransaction(ctx context.Context, tx *models.Transaction) err
 			from_address, to_address, value, gas, gas_price,
 			max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
 			transaction_type, status, gas_used, cumulative_gas_used,
-			effective_gas_price, contract_address, logs_bloom, timestamp
+			effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+			$23, $24, $25
 		)
 		ON CONFLICT (chain_id, hash) DO UPDATE SET
 			status = EXCLUDED.status,
 			gas_used = EXCLUDED.gas_used,
 			cumulative_gas_used = EXCLUDED.cumulative_gas_used,
-			effective_gas_price = EXCLUDED.effective_gas_price
+			effective_gas_price = EXCLUDED.effective_gas_price,
+			v = COALESCE(EXCLUDED.v, transactions.v),
+			r = COALESCE(EXCLUDED.r, transactions.r),
+			s = COALESCE(EXCLUDED.s, transactions.s)
 		RETURNING id
 	`
 
@@ -33,7 +62,7 @@ func (db *DB) InsertTransaction(ctx context.Context, tx *models.Transaction) err
 		tx.FromAddress, tx.ToAddress, tx.Value, tx.Gas, tx.GasPrice,
 		tx.MaxFeePerGas, tx.MaxPriorityFeePerGas, tx.Input, tx.Nonce,
 		tx.TransactionType, tx.Status, tx.GasUsed, tx.CumulativeGasUsed,
-		tx.EffectiveGasPrice, tx.ContractAddress, tx.LogsBloom, tx.Timestamp,
+		tx.EffectiveGasPrice, tx.ContractAddress, tx.LogsBloom, tx.V, tx.R, tx.S, tx.Timestamp,
 	).Scan(&tx.ID)
 
 	if err != nil {
@@ -49,7 +78,7 @@ func (db *DB) GetTransactionByHash(ctx context.Context, chainID int64, hash stri
 			   from_address, to_address, value, gas, gas_price,
 			   max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
 			   transaction_type, status, gas_used, cumulative_gas_used,
-			   effective_gas_price, contract_address, logs_bloom, timestamp, created_at
+			   effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp, created_at
 		FROM transactions
 		WHERE chain_id = $1 AND hash = $2
 	`
@@ -61,7 +90,7 @@ func (db *DB) GetTransactionByHash(ctx context.Context, chainID int64, hash stri
 		&tx.Gas, &tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas,
 		&tx.Input, &tx.Nonce, &tx.TransactionType, &tx.Status, &tx.GasUsed,
 		&tx.CumulativeGasUsed, &tx.EffectiveGasPrice, &tx.ContractAddress,
-		&tx.LogsBloom, &tx.Timestamp, &tx.CreatedAt,
+		&tx.LogsBloom, &tx.V, &tx.R, &tx.S, &tx.Timestamp, &tx.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -80,7 +109,7 @@ func (db *DB) GetTransactionsByBlock(ctx context.Context, chainID, blockNumber i
 			   from_address, to_address, value, gas, gas_price,
 			   max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
 			   transaction_type, status, gas_used, cumulative_gas_used,
-			   effective_gas_price, contract_address, logs_bloom, timestamp, created_at
+			   effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp, created_at
 		FROM transactions
 		WHERE chain_id = $1 AND block_number = $2
 		ORDER BY transaction_index ASC
@@ -101,7 +130,7 @@ func (db *DB) GetTransactionsByBlock(ctx context.Context, chainID, blockNumber i
 			&tx.Gas, &tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas,
 			&tx.Input, &tx.Nonce, &tx.TransactionType, &tx.Status, &tx.GasUsed,
 			&tx.CumulativeGasUsed, &tx.EffectiveGasPrice, &tx.ContractAddress,
-			&tx.LogsBloom, &tx.Timestamp, &tx.CreatedAt,
+			&tx.LogsBloom, &tx.V, &tx.R, &tx.S, &tx.Timestamp, &tx.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
@@ -127,7 +156,7 @@ func (db *DB) GetTransactions(ctx context.Context, chainID int64, limit, offset
 			   from_address, to_address, value, gas, gas_price,
 			   max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
 			   transaction_type, status, gas_used, cumulative_gas_used,
-			   effective_gas_price, contract_address, logs_bloom, timestamp, created_at
+			   effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp, created_at
 		FROM transactions
 		WHERE chain_id = $1
 		ORDER BY block_number DESC, transaction_index DESC
@@ -149,7 +178,7 @@ func (db *DB) GetTransactions(ctx context.Context, chainID int64, limit, offset
 			&tx.Gas, &tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas,
 			&tx.Input, &tx.Nonce, &tx.TransactionType, &tx.Status, &tx.GasUsed,
 			&tx.CumulativeGasUsed, &tx.EffectiveGasPrice, &tx.ContractAddress,
-			&tx.LogsBloom, &tx.Timestamp, &tx.CreatedAt,
+			&tx.LogsBloom, &tx.V, &tx.R, &tx.S, &tx.Timestamp, &tx.CreatedAt,
 		)
 		if err!=nil {
 			return nil, fmt.Errorf("Failed to scan transaction: %w", err)
@@ -171,7 +200,7 @@ func (db *DB) GetTransactionsByAddress(ctx context.Context, chainID int64, addre
 			   from_address, to_address, value, gas, gas_price,
 			   max_fee_per_gas, max_priority_fee_per_gas, input, nonce,
 			   transaction_type, status, gas_used, cumulative_gas_used,
-			   effective_gas_price, contract_address, logs_bloom, timestamp, created_at
+			   effective_gas_price, contract_address, logs_bloom, v, r, s, timestamp, created_at
 		FROM transactions
 		WHERE chain_id = $1 AND (from_address = $2 OR to_address = $2)
 		ORDER BY block_number DESC, transaction_index DESC
@@ -192,7 +221,7 @@ func (db *DB) GetTransactionsByAddress(ctx context.Context, chainID int64, addre
 			&tx.Gas, &tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas,
 			&tx.Input, &tx.Nonce, &tx.TransactionType, &tx.Status, &tx.GasUsed,
 			&tx.CumulativeGasUsed, &tx.EffectiveGasPrice, &tx.ContractAddress,
-			&tx.LogsBloom, &tx.Timestamp, &tx.CreatedAt,
+			&tx.LogsBloom, &tx.V, &tx.R, &tx.S, &tx.Timestamp, &tx.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
@@ -200,4 +229,204 @@ func (db *DB) GetTransactionsByAddress(ctx context.Context, chainID int64, addre
 		txs = append(txs, tx)
 	}
 	return txs, nil
-}
\ No newline at end of file
+}
+
+// GetTransactionsByAddressInRange lists transactions sent or received by
+// address between two blocks (inclusive) in the requested order.
+func (db *DB) GetTransactionsByAddressInRange(ctx context.Context, chainID int64, address string, startBlock, endBlock int64, ascending bool, limit, offset int) ([]*models.Transaction, error) {
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE chain_id = $1 AND (from_address = $2 OR to_address = $2)
+		  AND block_number BETWEEN $3 AND $4
+		ORDER BY block_number ` + order + `, transaction_index ` + order + `
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, address, startBlock, endBlock, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// GetTransactionsByHashes batch-loads transactions keyed by hash.
+func (db *DB) GetTransactionsByHashes(ctx context.Context, chainID int64, hashes []string) (map[string]*models.Transaction, error) {
+	txs := make(map[string]*models.Transaction, len(hashes))
+	if len(hashes) == 0 {
+		return txs, nil
+	}
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE chain_id = $1 AND hash = ANY($2)
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(hashes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs[tx.Hash] = tx
+	}
+	return txs, nil
+}
+
+// GetBlockTxFees sums the fees paid by all transactions in a block, in wei.
+func (db *DB) GetBlockTxFees(ctx context.Context, chainID, blockNumber int64) (string, error) {
+	var fees string
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(gas_used::numeric * COALESCE(effective_gas_price, gas_price, 0)), 0)::text
+		FROM transactions
+		WHERE chain_id = $1 AND block_number = $2
+	`, chainID, blockNumber).Scan(&fees)
+	if err != nil {
+		return "", fmt.Errorf("failed to get block fees: %w", err)
+	}
+	return fees, nil
+}
+
+// TransactionFilter selects transactions, newest first unless Sort says
+// otherwise. Party matches the sender and recipient; nil fields are not
+// filtered on. After continues a listing past the last row of the previous
+// page and Before pages back from the first row of the next one.
+type TransactionFilter struct {
+	ChainID int64
+	Party
+	FromAddress *string
+	ToAddress   *string
+	BlockRange
+	ValueRange
+	Status *int
+	// MethodID is a 4-byte selector, or "0x" for calls without calldata
+	MethodID *string
+	Types    []int
+	Sort
+	After  *Position
+	Before *Position
+	Limit  int
+	Offset int
+}
+
+// GetTransactionsByFilter lists transactions matching filter.
+func (db *DB) GetTransactionsByFilter(ctx context.Context, filter *TransactionFilter) ([]*models.Transaction, error) {
+	query, args := buildTransactionsQuery(filter)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs = append(txs, tx)
+	}
+	if filter.Before != nil && filter.Field == SortBlock {
+		slices.Reverse(txs)
+	}
+	return txs, nil
+}
+
+// CountTransactionsByFilter counts the transactions matching filter,
+// ignoring its paging fields.
+func (db *DB) CountTransactionsByFilter(ctx context.Context, filter *TransactionFilter) (int64, error) {
+	w := filter.where()
+	var count int64
+	err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions WHERE `+w.String(), w.args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count, nil
+}
+
+func (f *TransactionFilter) where() *where {
+	w := newWhere("chain_id", f.ChainID)
+	f.Party.apply(w, "from_address", "to_address")
+	if f.FromAddress != nil {
+		w.add("from_address = " + w.arg(*f.FromAddress))
+	}
+	if f.ToAddress != nil {
+		w.add("to_address = " + w.arg(*f.ToAddress))
+	}
+	f.BlockRange.apply(w, "block_number", "timestamp", f.ChainID)
+	f.ValueRange.apply(w, "value")
+	if f.Status != nil {
+		w.add("status = " + w.arg(*f.Status))
+	}
+	if f.MethodID != nil {
+		if *f.MethodID == "0x" {
+			w.add("(input IS NULL OR input = '0x')")
+		} else {
+			w.add("input LIKE " + w.arg(*f.MethodID+"%"))
+		}
+	}
+	if len(f.Types) > 0 {
+		w.add("transaction_type = ANY(" + w.arg(pq.Array(f.Types)) + ")")
+	}
+	return w
+}
+
+func buildTransactionsQuery(filter *TransactionFilter) (string, []interface{}) {
+	w := filter.where()
+	order := w.orderBy(filter.Sort, false, "block_number", "transaction_index", "value", filter.After, filter.Before)
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + w.String() + `
+		ORDER BY ` + order + `
+		LIMIT ` + w.arg(filter.Limit) + ` OFFSET ` + w.arg(filter.Offset)
+
+	return query, w.args
+}
+
+// GetTransactionsByBlocks batch-loads the transactions of several blocks,
+// keyed by block number and in index order.
+func (db *DB) GetTransactionsByBlocks(ctx context.Context, chainID int64, blockNumbers []int64) (map[int64][]*models.Transaction, error) {
+	txs := make(map[int64][]*models.Transaction, len(blockNumbers))
+	if len(blockNumbers) == 0 {
+		return txs, nil
+	}
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE chain_id = $1 AND block_number = ANY($2)
+		ORDER BY block_number ASC, transaction_index ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(blockNumbers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs[tx.BlockNumber] = append(txs[tx.BlockNumber], tx)
+	}
+	return txs, nil
+}
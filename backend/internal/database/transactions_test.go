@@ -60,4 +60,27 @@ func TestGetTransactionByHash(t *testing.T) {
 	retrieved, err := db.GetTransactionByHash(ctx, 1337, "0xtxhash")
 	require.NoError(t, err)
 	assert.Equal(t, "0xtxhash", retrieved.Hash)
-}
\ No newline at end of file
+}
+
+func TestBuildTransactionsQuery(t *testing.T) {
+	address := "0xA"
+	status := 1
+	from := int64(10)
+	filter := &TransactionFilter{
+		ChainID:    1337,
+		Party:      Party{Address: &address},
+		BlockRange: BlockRange{FromBlock: &from},
+		Status:     &status,
+		After:      &Position{BlockNumber: 42, Index: 3},
+		Limit:      21,
+	}
+
+	query, args := buildTransactionsQuery(filter)
+	assert.Contains(t, query, "(from_address = $2 OR to_address = $2)")
+	assert.Contains(t, query, "block_number >= $3")
+	assert.Contains(t, query, "status = $4")
+	assert.Contains(t, query, "(block_number, transaction_index) < ($5, $6)")
+	assert.Contains(t, query, "ORDER BY block_number DESC, transaction_index DESC")
+	assert.Contains(t, query, "LIMIT $7 OFFSET $8")
+	assert.Len(t, args, 8)
+}
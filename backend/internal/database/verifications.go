@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+func (db *DB) CreateContractVerification(ctx context.Context, v *models.ContractVerification) error {
+	query := `
+		INSERT INTO contract_verifications (
+			chain_id, address, contract_name, compiler_version, code_format, source_code,
+			optimization_used, runs, evm_version, constructor_arguments, license_type
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, guid, status, created_at, updated_at
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		v.ChainID, v.Address, v.ContractName, v.CompilerVersion, v.CodeFormat, v.SourceCode,
+		v.OptimizationUsed, v.Runs, v.EVMVersion, v.ConstructorArguments, v.LicenseType,
+	).Scan(&v.ID, &v.GUID, &v.Status, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create contract verification: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetContractVerification(ctx context.Context, guid string) (*models.ContractVerification, error) {
+	query := `
+		SELECT id, guid, chain_id, address, contract_name, compiler_version, code_format,
+			   source_code, optimization_used, runs, evm_version, constructor_arguments,
+			   license_type, status, message, created_at, updated_at
+		FROM contract_verifications
+		WHERE guid::text = $1
+	`
+	v := &models.ContractVerification{}
+	err := db.conn.QueryRowContext(ctx, query, guid).Scan(
+		&v.ID, &v.GUID, &v.ChainID, &v.Address, &v.ContractName, &v.CompilerVersion,
+		&v.CodeFormat, &v.SourceCode, &v.OptimizationUsed, &v.Runs, &v.EVMVersion,
+		&v.ConstructorArguments, &v.LicenseType, &v.Status, &v.Message, &v.CreatedAt, &v.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contract verification: %w", err)
+	}
+	return v, nil
+}
+
+func (db *DB) UpdateContractVerificationStatus(ctx context.Context, id int64, status, message string) error {
+	query := `UPDATE contract_verifications SET status = $2, message = $3 WHERE id = $1`
+	_, err := db.conn.ExecContext(ctx, query, id, status, message)
+	if err != nil {
+		return fmt.Errorf("failed to update contract verification: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) UpsertVerifiedContract(ctx context.Context, vc *models.VerifiedContract) error {
+	query := `
+		INSERT INTO verified_contracts (
+			chain_id, address, contract_name, compiler_version, code_format, source_code,
+			optimization_used, runs, evm_version, constructor_arguments, license_type
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (chain_id, address) DO UPDATE SET
+			contract_name = EXCLUDED.contract_name,
+			compiler_version = EXCLUDED.compiler_version,
+			code_format = EXCLUDED.code_format,
+			source_code = EXCLUDED.source_code,
+			optimization_used = EXCLUDED.optimization_used,
+			runs = EXCLUDED.runs,
+			evm_version = EXCLUDED.evm_version,
+			constructor_arguments = EXCLUDED.constructor_arguments,
+			license_type = EXCLUDED.license_type,
+			verified_at = NOW()
+		RETURNING id, verified_at
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		vc.ChainID, vc.Address, vc.ContractName, vc.CompilerVersion, vc.CodeFormat, vc.SourceCode,
+		vc.OptimizationUsed, vc.Runs, vc.EVMVersion, vc.ConstructorArguments, vc.LicenseType,
+	).Scan(&vc.ID, &vc.VerifiedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert verified contract: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetVerifiedContract(ctx context.Context, chainID int64, address string) (*models.VerifiedContract, error) {
+	query := `
+		SELECT id, chain_id, address, contract_name, compiler_version, code_format,
+			   source_code, optimization_used, runs, evm_version, constructor_arguments,
+			   license_type, verified_at
+		FROM verified_contracts
+		WHERE chain_id = $1 AND address = $2
+	`
+	vc := &models.VerifiedContract{}
+	err := db.conn.QueryRowContext(ctx, query, chainID, address).Scan(
+		&vc.ID, &vc.ChainID, &vc.Address, &vc.ContractName, &vc.CompilerVersion,
+		&vc.CodeFormat, &vc.SourceCode, &vc.OptimizationUsed, &vc.Runs, &vc.EVMVersion,
+		&vc.ConstructorArguments, &vc.LicenseType, &vc.VerifiedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verified contract: %w", err)
+	}
+	return vc, nil
+}
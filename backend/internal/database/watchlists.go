@@ -0,0 +1,289 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// CreateWatchlist stores a watchlist with its initial addresses.
+func (db *DB) CreateWatchlist(ctx context.Context, list *models.Watchlist) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO watchlists (chain_id, name, description) VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, list.ChainID, list.Name, list.Description).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create watchlist: %w", err)
+	}
+	for _, member := range list.Addresses {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO watchlist_addresses (watchlist_id, address) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING added_at
+		`, list.ID, member.Address).Scan(&member.AddedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to add watchlist address: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetWatchlist returns a watchlist with its addresses.
+func (db *DB) GetWatchlist(ctx context.Context, id int64) (*models.Watchlist, error) {
+	list := &models.Watchlist{}
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT id, chain_id, name, description, created_at, updated_at FROM watchlists WHERE id = $1
+	`, id).Scan(&list.ID, &list.ChainID, &list.Name, &list.Description, &list.CreatedAt, &list.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT address, added_at FROM watchlist_addresses WHERE watchlist_id = $1 ORDER BY added_at, address
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist addresses: %w", err)
+	}
+	defer rows.Close()
+
+	list.Addresses = []*models.WatchlistAddress{}
+	for rows.Next() {
+		member := &models.WatchlistAddress{}
+		if err := rows.Scan(&member.Address, &member.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist address: %w", err)
+		}
+		list.Addresses = append(list.Addresses, member)
+	}
+	return list, nil
+}
+
+// GetWatchlists lists watchlists, on one chain if chainID is set, without
+// their addresses.
+func (db *DB) GetWatchlists(ctx context.Context, chainID *int64, limit, offset int) ([]*models.Watchlist, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, chain_id, name, description, created_at, updated_at
+		FROM watchlists WHERE $1::bigint IS NULL OR chain_id = $1
+		ORDER BY id LIMIT $2 OFFSET $3
+	`, chainID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []*models.Watchlist
+	for rows.Next() {
+		list := &models.Watchlist{}
+		if err := rows.Scan(&list.ID, &list.ChainID, &list.Name, &list.Description, &list.CreatedAt, &list.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+func (db *DB) CountWatchlists(ctx context.Context, chainID *int64) (int64, error) {
+	var count int64
+	err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM watchlists WHERE $1::bigint IS NULL OR chain_id = $1`, chainID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count watchlists: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateWatchlist saves a watchlist's name and description.
+func (db *DB) UpdateWatchlist(ctx context.Context, list *models.Watchlist) error {
+	err := db.conn.QueryRowContext(ctx, `
+		UPDATE watchlists SET name = $2, description = $3, updated_at = NOW() WHERE id = $1
+		RETURNING updated_at
+	`, list.ID, list.Name, list.Description).Scan(&list.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update watchlist: %w", err)
+	}
+	return nil
+}
+
+// DeleteWatchlist removes a watchlist. It reports whether it existed.
+func (db *DB) DeleteWatchlist(ctx context.Context, id int64) (bool, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM watchlists WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// AddWatchlistAddresses adds addresses to a watchlist, skipping members.
+func (db *DB) AddWatchlistAddresses(ctx context.Context, id int64, addresses []string) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO watchlist_addresses (watchlist_id, address)
+		SELECT $1, UNNEST($2::text[])
+		ON CONFLICT DO NOTHING
+	`, id, pq.Array(addresses))
+	if err != nil {
+		return fmt.Errorf("failed to add watchlist addresses: %w", err)
+	}
+	_, err = db.conn.ExecContext(ctx, `UPDATE watchlists SET updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update watchlist: %w", err)
+	}
+	return nil
+}
+
+// RemoveWatchlistAddress removes a member. It reports whether it was one.
+func (db *DB) RemoveWatchlistAddress(ctx context.Context, id int64, address string) (bool, error) {
+	result, err := db.conn.ExecContext(ctx,
+		`DELETE FROM watchlist_addresses WHERE watchlist_id = $1 AND address = $2`, id, address)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove watchlist address: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetWatchlistActivity is the combined feed of transactions and token
+// transfers involving any of addresses, newest first. A transaction comes
+// before the token transfers it made.
+func (db *DB) GetWatchlistActivity(ctx context.Context, chainID int64, addresses []string, limit, offset int) ([]*models.WatchlistActivity, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT kind, id FROM (
+			SELECT 'transaction' AS kind, t.id, t.block_number, t.transaction_index, -1 AS log_index
+			FROM transactions t
+			WHERE t.chain_id = $1
+			  AND (t.from_address = ANY($2) OR t.to_address = ANY($2) OR t.contract_address = ANY($2))
+			UNION ALL
+			SELECT 'token_transfer', tt.id, tt.block_number, COALESCE(t.transaction_index, 0), tt.log_index
+			FROM token_transfers tt
+			LEFT JOIN transactions t ON t.chain_id = tt.chain_id AND t.hash = tt.transaction_hash
+			WHERE tt.chain_id = $1 AND (tt.from_address = ANY($2) OR tt.to_address = ANY($2))
+		) feed
+		ORDER BY block_number DESC, transaction_index DESC, log_index ASC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := db.conn.QueryContext(ctx, query, chainID, pq.Array(addresses), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist activity: %w", err)
+	}
+	defer rows.Close()
+
+	type entry struct {
+		kind string
+		id   int64
+	}
+	var entries []entry
+	var txIDs, transferIDs []int64
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.kind, &e.id); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist activity: %w", err)
+		}
+		entries = append(entries, e)
+		if e.kind == models.ActivityTransaction {
+			txIDs = append(txIDs, e.id)
+		} else {
+			transferIDs = append(transferIDs, e.id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get watchlist activity: %w", err)
+	}
+
+	txs, err := db.getTransactionsByIDs(ctx, txIDs)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := db.getTokenTransfersByIDs(ctx, transferIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make([]*models.WatchlistActivity, 0, len(entries))
+	for _, e := range entries {
+		if tx, ok := txs[e.id]; ok && e.kind == models.ActivityTransaction {
+			activity = append(activity, &models.WatchlistActivity{
+				Type:            e.kind,
+				BlockNumber:     tx.BlockNumber,
+				TransactionHash: tx.Hash,
+				Timestamp:       tx.Timestamp,
+				Transaction:     tx,
+			})
+		} else if t, ok := transfers[e.id]; ok && e.kind == models.ActivityTokenTransfer {
+			activity = append(activity, &models.WatchlistActivity{
+				Type:            e.kind,
+				BlockNumber:     t.BlockNumber,
+				TransactionHash: t.TransactionHash,
+				Timestamp:       t.Timestamp,
+				TokenTransfer:   t,
+			})
+		}
+	}
+	return activity, nil
+}
+
+func (db *DB) getTransactionsByIDs(ctx context.Context, ids []int64) (map[int64]*models.Transaction, error) {
+	txs := make(map[int64]*models.Transaction, len(ids))
+	if len(ids) == 0 {
+		return txs, nil
+	}
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		txs[tx.ID] = tx
+	}
+	return txs, nil
+}
+
+func (db *DB) getTokenTransfersByIDs(ctx context.Context, ids []int64) (map[int64]*models.TokenTransfer, error) {
+	transfers := make(map[int64]*models.TokenTransfer, len(ids))
+	if len(ids) == 0 {
+		return transfers, nil
+	}
+	query := `
+		SELECT tt.id, tt.chain_id, tt.transaction_hash, tt.log_index, tt.token_address,
+			   tt.from_address, tt.to_address, tt.value, tt.token_id, tt.block_number,
+			   tt.timestamp, tt.created_at, t.type, t.name, t.symbol, t.decimals
+		FROM token_transfers tt
+		LEFT JOIN tokens t ON t.chain_id = tt.chain_id AND t.address = tt.token_address
+		WHERE tt.id = ANY($1)
+	`
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token transfers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transfer, err := scanTokenTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token transfer: %w", err)
+		}
+		transfers[transfer.ID] = transfer
+	}
+	return transfers, nil
+}
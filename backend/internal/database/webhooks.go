@@ -0,0 +1,359 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+const webhookColumns = `id, chain_id, url, secret, description, addresses, tokens, topics, min_value, event_types, active, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	hook := &models.Webhook{}
+	err := row.Scan(
+		&hook.ID, &hook.ChainID, &hook.URL, &hook.Secret, &hook.Description,
+		pq.Array(&hook.Addresses), pq.Array(&hook.Tokens), pq.Array(&hook.Topics),
+		&hook.MinValue, pq.Array(&hook.EventTypes), &hook.Active, &hook.CreatedAt, &hook.UpdatedAt,
+	)
+	return hook, err
+}
+
+func (db *DB) CreateWebhook(ctx context.Context, hook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (chain_id, url, secret, description, addresses, tokens, topics, min_value, event_types, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	err := db.conn.QueryRowContext(ctx, query,
+		hook.ChainID, hook.URL, hook.Secret, hook.Description,
+		pq.Array(hook.Addresses), pq.Array(hook.Tokens), pq.Array(hook.Topics),
+		hook.MinValue, pq.Array(hook.EventTypes), hook.Active,
+	).Scan(&hook.ID, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	hook, err := scanWebhook(db.conn.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (db *DB) GetWebhooks(ctx context.Context, limit, offset int) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id DESC LIMIT $1 OFFSET $2`
+	return db.queryWebhooks(ctx, query, limit, offset)
+}
+
+// GetActiveWebhooks lists the webhooks the indexer matches blocks against.
+func (db *DB) GetActiveWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE active ORDER BY id`
+	return db.queryWebhooks(ctx, query)
+}
+
+func (db *DB) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*models.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+func (db *DB) CountWebhooks(ctx context.Context) (int64, error) {
+	var count int64
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhooks`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	return count, nil
+}
+
+// SetWebhookActive pauses or resumes a webhook. It reports whether the
+// webhook exists.
+func (db *DB) SetWebhookActive(ctx context.Context, id int64, active bool) (bool, error) {
+	result, err := db.conn.ExecContext(ctx,
+		`UPDATE webhooks SET active = $2, updated_at = NOW() WHERE id = $1`, id, active)
+	if err != nil {
+		return false, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteWebhook removes a webhook with its deliveries. It reports whether the
+// webhook existed.
+func (db *DB) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, chain_id, block_number, block_hash, alert_event_id, payload, status,
+	attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at, delivered_at`
+
+func scanWebhookDelivery(row rowScanner, extra ...interface{}) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	var payload []byte
+	dest := append([]interface{}{
+		&d.ID, &d.WebhookID, &d.ChainID, &d.BlockNumber, &d.BlockHash, &d.AlertEventID, &payload, &d.Status,
+		&d.Attempts, &d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+	}, extra...)
+	err := row.Scan(dest...)
+	d.Payload = payload
+	return d, err
+}
+
+// CreateWebhookDeliveries queues deliveries. A block already queued for a
+// webhook, e.g. when it is re-indexed, is not queued again.
+func (db *DB) CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, chain_id, block_number, block_hash, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (webhook_id, chain_id, block_hash) DO NOTHING
+	`
+	for _, d := range deliveries {
+		if _, err := db.conn.ExecContext(ctx, query, d.WebhookID, d.ChainID, d.BlockNumber, d.BlockHash, []byte(d.Payload)); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// ClaimedDelivery is a due delivery with where and how to send it.
+type ClaimedDelivery struct {
+	Delivery *models.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// ClaimWebhookDeliveries takes up to limit due deliveries of active webhooks
+// and leases them for lease, so concurrent workers never send the same one.
+// A delivery whose worker dies becomes due again when the lease runs out.
+func (db *DB) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*ClaimedDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT dd.id FROM webhook_deliveries dd
+			JOIN webhooks ww ON ww.id = dd.webhook_id
+			WHERE dd.status = 'pending' AND dd.next_attempt_at <= NOW() AND ww.active
+			ORDER BY dd.next_attempt_at, dd.id
+			LIMIT $1
+			FOR UPDATE OF dd SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, d.chain_id, d.block_number, d.block_hash, d.alert_event_id, d.payload, d.status,
+			d.attempts, d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error, d.created_at, d.delivered_at,
+			w.url, w.secret
+	`
+	rows, err := db.conn.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []*ClaimedDelivery
+	for rows.Next() {
+		c := &ClaimedDelivery{}
+		if c.Delivery, err = scanWebhookDelivery(rows, &c.URL, &c.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		claimed = append(claimed, c)
+	}
+	return claimed, nil
+}
+
+// WebhookAttempt is the outcome of one delivery attempt. ResponseStatus is
+// nil when no response was received.
+type WebhookAttempt struct {
+	ResponseStatus *int
+	Error          *string
+}
+
+func (db *DB) MarkWebhookDelivered(ctx context.Context, id int64, attempt *WebhookAttempt) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_attempt_at = NOW(),
+			response_status = $2, last_error = NULL, delivered_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := db.conn.ExecContext(ctx, query, id, attempt.ResponseStatus); err != nil {
+		return fmt.Errorf("failed to mark webhook delivery delivered: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery records a failed attempt and schedules the next.
+func (db *DB) RetryWebhookDelivery(ctx context.Context, id int64, attempt *WebhookAttempt, next time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_attempt_at = NOW(), response_status = $2, last_error = $3,
+			next_attempt_at = $4
+		WHERE id = $1 AND status = 'pending'
+	`
+	if _, err := db.conn.ExecContext(ctx, query, id, attempt.ResponseStatus, attempt.Error, next); err != nil {
+		return fmt.Errorf("failed to reschedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterWebhookDelivery records the final failed attempt, marks the
+// delivery dead and copies it to the dead letter table.
+func (db *DB) DeadLetterWebhookDelivery(ctx context.Context, id int64, attempt *WebhookAttempt) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'dead', attempts = attempts + 1, last_attempt_at = NOW(), response_status = $2, last_error = $3
+		WHERE id = $1 AND status = 'pending'
+	`, id, attempt.ResponseStatus, attempt.Error)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery dead: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_dead_letters (delivery_id, webhook_id, chain_id, block_number, payload, attempts, response_status, last_error)
+		SELECT id, webhook_id, chain_id, block_number, payload, attempts, response_status, last_error
+		FROM webhook_deliveries WHERE id = $1 AND status = 'dead'
+		ON CONFLICT (delivery_id) DO UPDATE SET
+			attempts = EXCLUDED.attempts, response_status = EXCLUDED.response_status,
+			last_error = EXCLUDED.last_error, created_at = NOW()
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter webhook delivery: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetWebhookDeliveries lists a webhook's deliveries, newest first, optionally
+// only those with status.
+func (db *DB) GetWebhookDeliveries(ctx context.Context, webhookID int64, status *string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2::text IS NULL OR status = $2)
+		ORDER BY id DESC LIMIT $3 OFFSET $4`
+	rows, err := db.conn.QueryContext(ctx, query, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (db *DB) CountWebhookDeliveries(ctx context.Context, webhookID int64, status *string) (int64, error) {
+	var count int64
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1 AND ($2::text IS NULL OR status = $2)`,
+		webhookID, status,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	return count, nil
+}
+
+func (db *DB) GetWebhookDeadLetters(ctx context.Context, webhookID int64, limit, offset int) ([]*models.WebhookDeadLetter, error) {
+	query := `
+		SELECT id, delivery_id, webhook_id, chain_id, block_number, payload, attempts, response_status, last_error, created_at
+		FROM webhook_dead_letters WHERE webhook_id = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3
+	`
+	rows, err := db.conn.QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []*models.WebhookDeadLetter
+	for rows.Next() {
+		l := &models.WebhookDeadLetter{}
+		var payload []byte
+		err := rows.Scan(&l.ID, &l.DeliveryID, &l.WebhookID, &l.ChainID, &l.BlockNumber, &payload,
+			&l.Attempts, &l.ResponseStatus, &l.LastError, &l.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook dead letter: %w", err)
+		}
+		l.Payload = payload
+		letters = append(letters, l)
+	}
+	return letters, nil
+}
+
+// RedeliverWebhookDelivery queues a delivery of webhookID to be sent again
+// with a fresh set of attempts, taking it off the dead letter table. It
+// returns nil if there is no such delivery or it was cancelled by a reorg.
+func (db *DB) RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID int64) (*models.WebhookDelivery, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE id = $1 AND webhook_id = $2 AND status <> 'cancelled'
+		RETURNING ` + webhookDeliveryColumns
+	d, err := scanWebhookDelivery(tx.QueryRowContext(ctx, query, deliveryID, webhookID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_dead_letters WHERE delivery_id = $1`, deliveryID); err != nil {
+		return nil, fmt.Errorf("failed to remove webhook dead letter: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return d, nil
+}
+
+// cancelWebhookDeliveries cancels the pending deliveries for blocks a reorg
+// dropped. Retries and dead-lettering of a delivery already being sent leave
+// it cancelled.
+func cancelWebhookDeliveries(ctx context.Context, tx *sql.Tx, chainID int64, blockHashes []string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = 'cancelled', last_error = 'block dropped by a chain reorganisation'
+		WHERE chain_id = $1 AND status = 'pending' AND block_hash = ANY($2)
+	`, chainID, pq.Array(blockHashes))
+	if err != nil {
+		return fmt.Errorf("failed to cancel webhook deliveries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type DecodedCall struct {
+	MethodID  string         `json:"method_id"`
+	Method    string         `json:"method"`
+	Signature string         `json:"signature"`
+	Params    []DecodedParam `json:"params"`
+}
+
+type DecodedParam struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// DecodeInput decodes transaction calldata against a JSON ABI.
+func DecodeInput(abiJSON []byte, input string) (*DecodedCall, error) {
+	parsed, err := abi.JSON(strings.NewReader(string(abiJSON)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse abi: %w", err)
+	}
+
+	data := common.FromHex(input)
+	if len(data) < 4 {
+		return nil, fmt.Errorf("input too short to contain a method selector")
+	}
+
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil, fmt.Errorf("method not found in abi: %w", err)
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack arguments: %w", err)
+	}
+
+	call := &DecodedCall{
+		MethodID:  fmt.Sprintf("0x%x", method.ID),
+		Method:    method.Name,
+		Signature: method.Sig,
+		Params:    make([]DecodedParam, len(values)),
+	}
+	for i, value := range values {
+		arg := method.Inputs[i]
+		call.Params[i] = DecodedParam{
+			Name:  arg.Name,
+			Type:  arg.Type.String(),
+			Value: formatValue(value),
+		}
+	}
+	return call, nil
+}
+
+// formatValue turns ABI values into JSON-friendly forms: addresses and hashes
+// as hex, byte slices as 0x-prefixed hex and big integers as decimal strings.
+func formatValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,30 @@
+package decoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20ABI = `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`
+
+func TestDecodeInput(t *testing.T) {
+	input := "0xa9059cbb" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+
+	call, err := DecodeInput([]byte(erc20ABI), input)
+	require.NoError(t, err)
+	assert.Equal(t, "transfer", call.Method)
+	assert.Equal(t, "0xa9059cbb", call.MethodID)
+	assert.Equal(t, "transfer(address,uint256)", call.Signature)
+	require.Len(t, call.Params, 2)
+	assert.Equal(t, "0x1111111111111111111111111111111111111111", call.Params[0].Value)
+	assert.Equal(t, "1000", call.Params[1].Value)
+}
+
+func TestDecodeInputUnknownMethod(t *testing.T) {
+	_, err := DecodeInput([]byte(erc20ABI), "0xdeadbeef")
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+// Package events fans chain activity out to live subscribers such as the SSE
+// streams. The indexer publishes through Postgres NOTIFY; each API process
+// runs a Listener that turns notifications into Events on its in-process Bus,
+// in the order they were published.
+package events
+
+import (
+	"sync"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// Event is one published change to a chain's index: either a newly indexed
+// block or a reorg that removed blocks.
+type Event struct {
+	ChainID int64
+	Block   *BlockIndexed
+	Reorg   *Reorg
+}
+
+// BlockIndexed carries everything indexed for one block.
+type BlockIndexed struct {
+	Block          *models.Block
+	Transactions   []*models.Transaction
+	Logs           []*models.TransactionLog
+	TokenTransfers []*models.TokenTransfer
+}
+
+// Reorg reports that the blocks from FromBlock up were replaced. Their
+// replacements follow as block events.
+type Reorg struct {
+	FromBlock int64          `json:"from_block"`
+	Dropped   []DroppedBlock `json:"dropped"`
+}
+
+type DroppedBlock struct {
+	Number int64  `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// Publisher accepts events for delivery to subscribers.
+type Publisher interface {
+	Publish(event *Event)
+}
+
+// Bus is an in-process fan-out of events. A subscriber that falls a full
+// buffer behind is dropped rather than allowed to stall the others; its
+// channel is closed and the client is expected to reconnect and resume.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of one chain.
+type Subscription struct {
+	C <-chan *Event
+
+	bus     *Bus
+	ch      chan *Event
+	chainID int64
+}
+
+// Subscribe registers for a chain's events. buffer is the number of events
+// the subscriber may fall behind before it is dropped.
+func (b *Bus) Subscribe(chainID int64, buffer int) *Subscription {
+	ch := make(chan *Event, buffer)
+	sub := &Subscription{C: ch, bus: b, ch: ch, chainID: chainID}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *Bus) Publish(event *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if sub.chainID != event.ChainID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of live subscriptions.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
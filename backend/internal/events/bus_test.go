@@ -0,0 +1,47 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func block(chainID, number int64) *Event {
+	return &Event{ChainID: chainID, Block: &BlockIndexed{Block: &models.Block{ChainID: chainID, BlockNumber: number}}}
+}
+
+func TestBusDeliversPerChain(t *testing.T) {
+	bus := NewBus()
+	local := bus.Subscribe(1337, 4)
+	sepolia := bus.Subscribe(11155111, 4)
+	defer local.Close()
+	defer sepolia.Close()
+
+	bus.Publish(block(1337, 1))
+	bus.Publish(block(11155111, 7))
+
+	require.Len(t, local.C, 1)
+	assert.Equal(t, int64(1), (<-local.C).Block.Block.BlockNumber)
+	require.Len(t, sepolia.C, 1)
+	assert.Equal(t, int64(7), (<-sepolia.C).Block.Block.BlockNumber)
+}
+
+func TestBusDropsLaggingSubscriber(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe(1337, 1)
+	fast := bus.Subscribe(1337, 2)
+	defer fast.Close()
+
+	bus.Publish(block(1337, 1))
+	bus.Publish(block(1337, 2))
+
+	assert.Equal(t, 1, bus.Subscribers())
+	<-slow.C
+	_, ok := <-slow.C
+	assert.False(t, ok, "lagging subscriber's channel is closed")
+	assert.Len(t, fast.C, 2)
+
+	slow.Close()
+}
@@ -0,0 +1,199 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// catchUpBatch is the number of missed events read per query after a
+	// reconnect or a gap in the notification sequence
+	catchUpBatch = 500
+	pingInterval = 90 * time.Second
+	// gapTimeout is how long a gap in the event ids may stay open before the
+	// missing ids are assumed to belong to failed inserts and skipped
+	gapTimeout = 10 * time.Second
+)
+
+// notification is the pointer carried by a chain_events NOTIFY.
+type notification struct {
+	ID          int64   `json:"id"`
+	ChainID     int64   `json:"chain_id"`
+	Type        string  `json:"type"`
+	BlockNumber int64   `json:"block_number"`
+	BlockHash   *string `json:"block_hash"`
+}
+
+func parseNotification(extra string) (*notification, error) {
+	n := &notification{}
+	if err := json.Unmarshal([]byte(extra), n); err != nil {
+		return nil, fmt.Errorf("invalid chain event notification: %w", err)
+	}
+	if n.ID == 0 || (n.Type != models.ChainEventBlock && n.Type != models.ChainEventReorg) {
+		return nil, fmt.Errorf("invalid chain event notification: %s", extra)
+	}
+	return n, nil
+}
+
+// Listener LISTENs for the indexer's chain events and publishes them with
+// their data looked up from the index. Events are delivered in publication
+// order; anything missed while disconnected is read back from chain_events.
+type Listener struct {
+	db        *database.DB
+	connStr   string
+	publisher Publisher
+	logger    *zap.SugaredLogger
+	// last is the id of the last delivered event and gapSince is when the
+	// event after it was first found missing
+	last     int64
+	gapSince time.Time
+}
+
+func NewListener(db *database.DB, connStr string, publisher Publisher, logger *zap.Logger) *Listener {
+	return &Listener{
+		db:        db,
+		connStr:   connStr,
+		publisher: publisher,
+		logger:    logger.Sugar(),
+	}
+}
+
+// Run delivers events until ctx is cancelled. Events published before Run
+// starts are history and are not delivered.
+func (l *Listener) Run(ctx context.Context) error {
+	listener := pq.NewListener(l.connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			l.logger.Warnw("Chain event listener connection error", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(database.ChainEventsChannel); err != nil {
+		return fmt.Errorf("failed to listen for chain events: %w", err)
+	}
+	last, err := l.db.GetLatestChainEventID(ctx)
+	if err != nil {
+		return err
+	}
+	l.last = last
+	l.logger.Infow("Listening for chain events", "channel", database.ChainEventsChannel, "after", last)
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	retry := time.NewTicker(time.Second)
+	defer retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			if n == nil {
+				// The connection was re-established; notifications sent
+				// in between are lost
+				l.catchUp(ctx)
+				continue
+			}
+			l.notify(ctx, n.Extra)
+		case <-retry.C:
+			if !l.gapSince.IsZero() {
+				l.catchUp(ctx)
+			}
+		case <-ping.C:
+			if err := listener.Ping(); err != nil {
+				l.logger.Warnw("Chain event listener ping failed", "error", err)
+			}
+		}
+	}
+}
+
+func (l *Listener) notify(ctx context.Context, extra string) {
+	n, err := parseNotification(extra)
+	if err != nil {
+		l.logger.Warnw("Ignoring chain event notification", "error", err)
+		return
+	}
+	switch {
+	case n.ID <= l.last:
+		// Already delivered by a catch-up
+	case n.ID == l.last+1:
+		l.deliver(ctx, n, nil)
+	default:
+		// Chains publish concurrently, so an event can commit before one
+		// with a lower id; read them back in id order
+		l.catchUp(ctx)
+	}
+}
+
+// catchUp delivers stored events after the last delivered one in id order.
+// It stops at a gap until gapTimeout has passed.
+func (l *Listener) catchUp(ctx context.Context) {
+	for {
+		missed, err := l.db.GetChainEventsAfter(ctx, l.last, catchUpBatch)
+		if err != nil {
+			l.logger.Warnw("Failed to catch up on chain events", "after", l.last, "error", err)
+			return
+		}
+		for _, e := range missed {
+			if e.ID != l.last+1 {
+				if l.gapSince.IsZero() {
+					l.gapSince = time.Now()
+				}
+				if time.Since(l.gapSince) < gapTimeout {
+					return
+				}
+				l.logger.Warnw("Skipping missing chain events", "from", l.last+1, "to", e.ID-1)
+			}
+			l.deliver(ctx, &notification{
+				ID: e.ID, ChainID: e.ChainID, Type: e.EventType,
+				BlockNumber: e.BlockNumber, BlockHash: e.BlockHash,
+			}, e)
+		}
+		if len(missed) < catchUpBatch {
+			return
+		}
+	}
+}
+
+// deliver resolves a notification into an Event and publishes it. row is the
+// stored event when it has already been read.
+func (l *Listener) deliver(ctx context.Context, n *notification, row *models.ChainEvent) {
+	l.last = n.ID
+	l.gapSince = time.Time{}
+	event := &Event{ChainID: n.ChainID}
+
+	switch n.Type {
+	case models.ChainEventBlock:
+		block, err := LoadBlock(ctx, l.db, n.ChainID, n.BlockNumber)
+		if err != nil {
+			l.logger.Warnw("Failed to load indexed block", "chain_id", n.ChainID, "block", n.BlockNumber, "error", err)
+			return
+		}
+		// A block already replaced by a reorg is announced by its own event
+		if block == nil || (n.BlockHash != nil && block.Block.Hash != *n.BlockHash) {
+			return
+		}
+		event.Block = block
+	case models.ChainEventReorg:
+		if row == nil {
+			var err error
+			if row, err = l.db.GetChainEvent(ctx, n.ID); err != nil || row == nil {
+				l.logger.Warnw("Failed to load reorg event", "id", n.ID, "error", err)
+				return
+			}
+		}
+		reorg := &Reorg{}
+		if err := json.Unmarshal(row.Payload, reorg); err != nil {
+			l.logger.Warnw("Invalid reorg event payload", "id", n.ID, "error", err)
+			return
+		}
+		event.Reorg = reorg
+	}
+	l.publisher.Publish(event)
+}
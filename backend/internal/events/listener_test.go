@@ -0,0 +1,21 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotification(t *testing.T) {
+	n, err := parseNotification(`{"id":42,"chain_id":1337,"type":"block","block_number":7,"block_hash":"0xabc"}`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), n.ID)
+	assert.Equal(t, int64(7), n.BlockNumber)
+	assert.Equal(t, "0xabc", *n.BlockHash)
+
+	for _, invalid := range []string{"", "{}", `{"id":1,"type":"unknown"}`} {
+		_, err := parseNotification(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
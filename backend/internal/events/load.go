@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+)
+
+// maxBlockRows caps the logs and token transfers loaded for one block
+const maxBlockRows = 10000
+
+// LoadBlock reads an indexed block and its transactions, logs and token
+// transfers. It returns nil if the block is not indexed.
+func LoadBlock(ctx context.Context, db *database.DB, chainID, number int64) (*BlockIndexed, error) {
+	block, err := db.GetBlockByNumber(ctx, chainID, number)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	event := &BlockIndexed{Block: block}
+
+	if event.Transactions, err = db.GetTransactionsByBlock(ctx, chainID, number); err != nil {
+		return nil, err
+	}
+	blocks := database.BlockRange{FromBlock: &number, ToBlock: &number}
+	event.Logs, err = db.GetLogs(ctx, &database.LogFilter{ChainID: chainID, BlockRange: blocks, Limit: maxBlockRows})
+	if err != nil {
+		return nil, err
+	}
+	event.TokenTransfers, err = db.GetTokenTransfers(ctx, &database.TokenTransferFilter{
+		ChainID:    chainID,
+		BlockRange: blocks,
+		Sort:       database.Sort{Order: database.OrderAsc},
+		Limit:      maxBlockRows,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,194 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"go.uber.org/zap"
+)
+
+const pendingPollInterval = time.Second
+
+// rpcCaller is the part of a chain client the pending feed polls through.
+type rpcCaller interface {
+	CallRaw(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error)
+}
+
+// PendingFeed fans out the hashes of transactions entering a node's mempool.
+// Pending transactions are never indexed, so while a chain has subscribers
+// its node is polled with a pending transaction filter. Like the Bus, a
+// subscriber that falls a full buffer behind is dropped.
+type PendingFeed struct {
+	client   func(chainID int64) (rpcCaller, error)
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	mu     sync.Mutex
+	chains map[int64]*pendingChain
+}
+
+// pendingChain is one chain's poller and its subscribers.
+type pendingChain struct {
+	subs map[*PendingSubscription]struct{}
+	stop context.CancelFunc
+}
+
+func NewPendingFeed(chains *blockchain.ChainManager, logger *zap.Logger) *PendingFeed {
+	return newPendingFeed(func(chainID int64) (rpcCaller, error) {
+		client, err := chains.GetClient(chainID)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}, pendingPollInterval, logger)
+}
+
+func newPendingFeed(client func(chainID int64) (rpcCaller, error), interval time.Duration, logger *zap.Logger) *PendingFeed {
+	return &PendingFeed{
+		client:   client,
+		interval: interval,
+		logger:   logger.Sugar(),
+		chains:   make(map[int64]*pendingChain),
+	}
+}
+
+// PendingSubscription receives the hashes of one chain's pending
+// transactions.
+type PendingSubscription struct {
+	C <-chan string
+
+	feed    *PendingFeed
+	ch      chan string
+	chainID int64
+}
+
+// Subscribe registers for a chain's pending transactions, starting its
+// poller if this is the first subscriber. It fails if the chain has no
+// active client.
+func (f *PendingFeed) Subscribe(chainID int64, buffer int) (*PendingSubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pc, ok := f.chains[chainID]
+	if !ok {
+		client, err := f.client(chainID)
+		if err != nil {
+			return nil, err
+		}
+		ctx, stop := context.WithCancel(context.Background())
+		pc = &pendingChain{subs: make(map[*PendingSubscription]struct{}), stop: stop}
+		f.chains[chainID] = pc
+		go f.poll(ctx, chainID, pc, client)
+	}
+
+	ch := make(chan string, buffer)
+	sub := &PendingSubscription{C: ch, feed: f, ch: ch, chainID: chainID}
+	pc.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *PendingSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
+
+// remove drops a subscription and stops its chain's poller once nobody is
+// left. f.mu must be held.
+func (f *PendingFeed) remove(sub *PendingSubscription) {
+	pc, ok := f.chains[sub.chainID]
+	if !ok {
+		return
+	}
+	if _, ok := pc.subs[sub]; !ok {
+		return
+	}
+	delete(pc.subs, sub)
+	close(sub.ch)
+	if len(pc.subs) == 0 {
+		pc.stop()
+		delete(f.chains, sub.chainID)
+	}
+}
+
+// publish delivers hashes to the subscribers of pc, unless pc has already
+// been stopped and replaced.
+func (f *PendingFeed) publish(chainID int64, pc *pendingChain, hashes []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.chains[chainID] != pc {
+		return
+	}
+	for sub := range pc.subs {
+		for _, hash := range hashes {
+			select {
+			case sub.ch <- hash:
+				continue
+			default:
+				f.remove(sub)
+			}
+			break
+		}
+	}
+}
+
+// poll reads new pending transactions from the node until ctx is cancelled.
+// The filter is recreated whenever the node forgets it.
+func (f *PendingFeed) poll(ctx context.Context, chainID int64, pc *pendingChain, client rpcCaller) {
+	var filterID json.RawMessage
+	failing := false
+	defer func() {
+		if filterID != nil {
+			uninstall, cancel := context.WithTimeout(context.Background(), f.interval)
+			defer cancel()
+			client.CallRaw(uninstall, "eth_uninstallFilter", []json.RawMessage{filterID})
+		}
+	}()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if filterID == nil {
+			id, err := client.CallRaw(ctx, "eth_newPendingTransactionFilter", nil)
+			if err != nil {
+				if ctx.Err() == nil && !failing {
+					f.logger.Warnw("Failed to create pending transaction filter", "chain_id", chainID, "error", err)
+				}
+				failing = true
+				continue
+			}
+			filterID, failing = id, false
+			continue
+		}
+
+		result, err := client.CallRaw(ctx, "eth_getFilterChanges", []json.RawMessage{filterID})
+		if err != nil {
+			// Usually an expired filter; recreate it on the next tick
+			filterID = nil
+			continue
+		}
+		var hashes []string
+		if err := json.Unmarshal(result, &hashes); err != nil {
+			f.logger.Warnw("Invalid pending transaction filter result", "chain_id", chainID, "error", err)
+			continue
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		for i, hash := range hashes {
+			hashes[i] = strings.ToLower(hash)
+		}
+		f.publish(chainID, pc, hashes)
+	}
+}
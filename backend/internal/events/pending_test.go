@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeNode answers pending transaction filter calls from a queue of hashes.
+type fakeNode struct {
+	mu          sync.Mutex
+	pending     []string
+	filters     int
+	uninstalled int
+}
+
+func (n *fakeNode) CallRaw(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch method {
+	case "eth_newPendingTransactionFilter":
+		n.filters++
+		return json.RawMessage(`"0x1"`), nil
+	case "eth_getFilterChanges":
+		hashes := n.pending
+		n.pending = nil
+		if hashes == nil {
+			hashes = []string{}
+		}
+		return json.Marshal(hashes)
+	case "eth_uninstallFilter":
+		n.uninstalled++
+		return json.RawMessage(`true`), nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+func (n *fakeNode) add(hashes ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, hashes...)
+}
+
+func newTestPendingFeed(node *fakeNode) *PendingFeed {
+	return newPendingFeed(func(chainID int64) (rpcCaller, error) {
+		if chainID != 1337 {
+			return nil, fmt.Errorf("chain %d not found or not active", chainID)
+		}
+		return node, nil
+	}, 5*time.Millisecond, zap.NewNop())
+}
+
+func TestPendingFeedDeliversHashes(t *testing.T) {
+	node := &fakeNode{}
+	feed := newTestPendingFeed(node)
+	sub, err := feed.Subscribe(1337, 4)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	node.add("0xABC")
+	select {
+	case hash := <-sub.C:
+		assert.Equal(t, "0xabc", hash)
+	case <-time.After(time.Second):
+		t.Fatal("no pending transaction delivered")
+	}
+}
+
+func TestPendingFeedStopsWithLastSubscriber(t *testing.T) {
+	node := &fakeNode{}
+	feed := newTestPendingFeed(node)
+	first, err := feed.Subscribe(1337, 4)
+	require.NoError(t, err)
+	second, err := feed.Subscribe(1337, 4)
+	require.NoError(t, err)
+
+	first.Close()
+	first.Close()
+	assert.Len(t, feed.chains, 1)
+	second.Close()
+	assert.Empty(t, feed.chains)
+
+	require.Eventually(t, func() bool {
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		return node.filters == 0 || node.uninstalled == node.filters
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPendingFeedRejectsUnknownChain(t *testing.T) {
+	feed := newTestPendingFeed(&fakeNode{})
+	_, err := feed.Subscribe(1, 4)
+	assert.Error(t, err)
+	assert.Empty(t, feed.chains)
+}
+
+func TestPendingFeedDropsLaggingSubscriber(t *testing.T) {
+	feed := newTestPendingFeed(&fakeNode{})
+	sub, err := feed.Subscribe(1337, 1)
+	require.NoError(t, err)
+	pc := feed.chains[1337]
+
+	feed.publish(1337, pc, []string{"0x1", "0x2"})
+
+	assert.Equal(t, "0x1", <-sub.C)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.Empty(t, feed.chains)
+}
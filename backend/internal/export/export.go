@@ -0,0 +1,163 @@
+// Package export writes indexed data out as CSV or NDJSON, streaming rows
+// from the database as they are fetched, or as partitioned Parquet files.
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatCSV, FormatNDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("format must be csv or ndjson")
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Datasets maps the exported datasets' names, as used in URLs and on the
+// command line, to their tables.
+var Datasets = map[string]database.ExportDataset{
+	"blocks":                database.ExportBlocks,
+	"transactions":          database.ExportTransactions,
+	"token-transfers":       database.ExportTokenTransfers,
+	"logs":                  database.ExportLogs,
+	"internal-transactions": database.ExportInternalTransactions,
+}
+
+// ParseDataset looks up a dataset by name.
+func ParseDataset(name string) (database.ExportDataset, error) {
+	if dataset, ok := Datasets[name]; ok {
+		return dataset, nil
+	}
+	return "", fmt.Errorf("dataset must be blocks, transactions, token-transfers, internal-transactions or logs")
+}
+
+// rowWriter encodes rows of one dataset.
+type rowWriter interface {
+	write(values []sql.NullString) error
+	flush() error
+}
+
+// Write exports the rows selected by filter to w and returns how many were
+// written.
+func Write(ctx context.Context, db *database.DB, filter *database.ExportFilter, format Format, w io.Writer) (int64, error) {
+	columns := database.ExportColumns(filter.Dataset)
+	if columns == nil {
+		return 0, fmt.Errorf("unknown export dataset: %s", filter.Dataset)
+	}
+	rw, err := newRowWriter(format, columns, w)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = db.Export(ctx, filter, func(values []sql.NullString) error {
+		n++
+		return rw.write(values)
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, rw.flush()
+}
+
+func newRowWriter(format Format, columns []database.ExportColumn, w io.Writer) (rowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(columns, w)
+	case FormatNDJSON:
+		return &ndjsonWriter{columns: columns, w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown export format: %s", format)
+}
+
+// csvWriter writes a header row and then one record per row, with NULLs as
+// empty fields.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(columns []database.ExportColumn, w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, col := range columns {
+		cw.record[i] = col.Name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) write(values []sql.NullString) error {
+	for i, v := range values {
+		cw.record[i] = v.String
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonWriter writes one JSON object per line with keys in column order.
+// Integers and booleans are JSON numbers and booleans, NULLs are null and
+// everything else is a string.
+type ndjsonWriter struct {
+	columns []database.ExportColumn
+	w       io.Writer
+	buf     []byte
+}
+
+func (nw *ndjsonWriter) write(values []sql.NullString) error {
+	buf := append(nw.buf[:0], '{')
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(nw.columns[i].Name)
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		switch {
+		case !v.Valid:
+			buf = append(buf, "null"...)
+		case nw.columns[i].Kind == database.ExportInteger, nw.columns[i].Kind == database.ExportBoolean:
+			buf = append(buf, v.String...)
+		default:
+			s, _ := json.Marshal(v.String)
+			buf = append(buf, s...)
+		}
+	}
+	buf = append(buf, '}', '\n')
+	nw.buf = buf
+	_, err := nw.w.Write(buf)
+	return err
+}
+
+func (nw *ndjsonWriter) flush() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testColumns = []database.ExportColumn{
+	{Name: "hash", Kind: database.ExportString},
+	{Name: "block_number", Kind: database.ExportInteger},
+	{Name: "value", Kind: database.ExportDecimal},
+	{Name: "removed", Kind: database.ExportBoolean},
+}
+
+var testRows = [][]sql.NullString{
+	{{String: "0xaa", Valid: true}, {String: "12", Valid: true}, {String: "1000000000000000000000", Valid: true}, {String: "false", Valid: true}},
+	{{String: `say "hi", bob`, Valid: true}, {String: "13", Valid: true}, {}, {String: "true", Valid: true}},
+}
+
+func writeRows(t *testing.T, format Format) string {
+	var buf bytes.Buffer
+	rw, err := newRowWriter(format, testColumns, &buf)
+	require.NoError(t, err)
+	for _, row := range testRows {
+		require.NoError(t, rw.write(row))
+	}
+	require.NoError(t, rw.flush())
+	return buf.String()
+}
+
+func TestCSVWriter(t *testing.T) {
+	assert.Equal(t, "hash,block_number,value,removed\n"+
+		"0xaa,12,1000000000000000000000,false\n"+
+		`"say ""hi"", bob",13,,true`+"\n", writeRows(t, FormatCSV))
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	assert.Equal(t,
+		`{"hash":"0xaa","block_number":12,"value":"1000000000000000000000","removed":false}`+"\n"+
+			`{"hash":"say \"hi\", bob","block_number":13,"value":null,"removed":true}`+"\n",
+		writeRows(t, FormatNDJSON))
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", f.ContentType())
+	_, err = ParseFormat("xlsx")
+	assert.Error(t, err)
+}
+
+func TestParseDataset(t *testing.T) {
+	dataset, err := ParseDataset("token-transfers")
+	require.NoError(t, err)
+	assert.Equal(t, database.ExportTokenTransfers, dataset)
+
+	dataset, err = ParseDataset("internal-transactions")
+	require.NoError(t, err)
+	assert.Equal(t, database.ExportInternalTransactions, dataset)
+	_, err = ParseDataset("receipts")
+	assert.Error(t, err)
+}
@@ -0,0 +1,360 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+)
+
+const (
+	// DefaultPartitionSize is how many blocks each Parquet file covers
+	DefaultPartitionSize = 100000
+	// parquetBatch is how many rows are buffered before being written
+	parquetBatch = 1000
+	// decimalPrecision is the number of digits in a DECIMAL(38, 0), the
+	// widest decimal a 16 byte fixed length array holds
+	decimalPrecision = 38
+	decimalBytes     = 16
+)
+
+// maxDecimal is the first value too large for a DECIMAL(38, 0)
+var maxDecimal = new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalPrecision), nil)
+
+// ParquetOptions selects what a Parquet export writes and where.
+type ParquetOptions struct {
+	Dir      string
+	ChainID  int64
+	Datasets []database.ExportDataset
+	// FromBlock and ToBlock bound the export; ToBlock is clamped to the
+	// last final block, MaxReorgDepth below the indexed head, and nil means
+	// up to it
+	FromBlock     int64
+	ToBlock       *int64
+	PartitionSize int64
+	// OnPartition, if set, is called after each partition is written
+	OnPartition func(dataset database.ExportDataset, p *Partition)
+}
+
+// Partition is one Parquet file, holding the rows of FromBlock..ToBlock
+// within the blocks from BlockStart.
+type Partition struct {
+	BlockStart int64     `json:"block_start"`
+	FromBlock  int64     `json:"from_block"`
+	ToBlock    int64     `json:"to_block"`
+	Rows       int64     `json:"rows"`
+	WrittenAt  time.Time `json:"written_at"`
+}
+
+// checkpoint records the partitions of a dataset that have been written, so
+// that an interrupted export resumes where it stopped.
+type checkpoint struct {
+	Dataset       database.ExportDataset `json:"dataset"`
+	ChainID       int64                  `json:"chain_id"`
+	PartitionSize int64                  `json:"partition_size"`
+	Partitions    map[int64]*Partition   `json:"partitions"`
+}
+
+// WriteParquet exports each dataset to
+// <dir>/<dataset>/chain_id=<id>/block_start=<n>/data.parquet, one file per
+// PartitionSize blocks. Partitions are written to a temporary file and
+// renamed into place, then recorded in a checkpoint under
+// <dir>/_checkpoints; partitions the checkpoint shows already cover the
+// requested blocks are skipped, and partially covered ones are rewritten
+// over both ranges. Blocks a reorg could still replace are left out, so a
+// checkpointed partition never needs rewriting.
+func WriteParquet(ctx context.Context, db *database.DB, opts *ParquetOptions) error {
+	if opts.PartitionSize <= 0 {
+		return fmt.Errorf("partition size must be positive")
+	}
+	latest, err := db.GetLatestBlock(ctx, opts.ChainID)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		return fmt.Errorf("no blocks indexed for chain %d", opts.ChainID)
+	}
+	to, err := finalTo(latest.BlockNumber, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, dataset := range opts.Datasets {
+		if err := writeDataset(ctx, db, opts, dataset, opts.FromBlock, to); err != nil {
+			return fmt.Errorf("%s: %w", dataset, err)
+		}
+	}
+	return nil
+}
+
+// finalTo is the last block an export up to opts.ToBlock may write with head
+// indexed: none a reorg could still replace.
+func finalTo(head int64, opts *ParquetOptions) (int64, error) {
+	to := head - indexer.MaxReorgDepth
+	if to < 0 {
+		return 0, fmt.Errorf("no final blocks indexed for chain %d", opts.ChainID)
+	}
+	if opts.ToBlock != nil && *opts.ToBlock < to {
+		to = *opts.ToBlock
+	}
+	if opts.FromBlock > to {
+		return 0, fmt.Errorf("from block %d is past the last final block %d", opts.FromBlock, to)
+	}
+	return to, nil
+}
+
+func writeDataset(ctx context.Context, db *database.DB, opts *ParquetOptions, dataset database.ExportDataset, from, to int64) error {
+	columns := database.ExportColumns(dataset)
+	if columns == nil {
+		return fmt.Errorf("unknown export dataset: %s", dataset)
+	}
+	schema, leaves := parquetSchema(string(dataset), columns)
+
+	cpPath := checkpointPath(opts.Dir, opts.ChainID, dataset)
+	cp, err := loadCheckpoint(cpPath)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		cp = &checkpoint{
+			Dataset:       dataset,
+			ChainID:       opts.ChainID,
+			PartitionSize: opts.PartitionSize,
+			Partitions:    map[int64]*Partition{},
+		}
+	} else if cp.PartitionSize != opts.PartitionSize {
+		return fmt.Errorf("checkpoint %s was written with partition size %d", cpPath, cp.PartitionSize)
+	}
+
+	for start := from - from%opts.PartitionSize; start <= to; start += opts.PartitionSize {
+		p := &Partition{
+			BlockStart: start,
+			FromBlock:  max(start, from),
+			ToBlock:    min(start+opts.PartitionSize-1, to),
+		}
+		if done := cp.Partitions[start]; done != nil {
+			if done.FromBlock <= p.FromBlock && done.ToBlock >= p.ToBlock {
+				continue
+			}
+			p.FromBlock = min(p.FromBlock, done.FromBlock)
+			p.ToBlock = max(p.ToBlock, done.ToBlock)
+		}
+
+		path := partitionPath(opts.Dir, opts.ChainID, dataset, start)
+		if p.Rows, err = writePartition(ctx, db, opts.ChainID, dataset, p, schema, columns, leaves, path); err != nil {
+			return fmt.Errorf("partition %d: %w", start, err)
+		}
+		p.WrittenAt = time.Now().UTC()
+		cp.Partitions[start] = p
+		if err := saveCheckpoint(cpPath, cp); err != nil {
+			return err
+		}
+		if opts.OnPartition != nil {
+			opts.OnPartition(dataset, p)
+		}
+	}
+	return nil
+}
+
+func writePartition(ctx context.Context, db *database.DB, chainID int64, dataset database.ExportDataset, p *Partition, schema *parquet.Schema, columns []database.ExportColumn, leaves []int, path string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	w := parquet.NewWriter(file, schema, parquet.Compression(&parquet.Snappy))
+	batch := make([]parquet.Row, 0, parquetBatch)
+	flush := func() error {
+		_, err := w.WriteRows(batch)
+		batch = batch[:0]
+		return err
+	}
+	var n int64
+	filter := &database.ExportFilter{
+		ChainID:    chainID,
+		Dataset:    dataset,
+		BlockRange: database.BlockRange{FromBlock: &p.FromBlock, ToBlock: &p.ToBlock},
+	}
+	err = db.Export(ctx, filter, func(values []sql.NullString) error {
+		row, err := parquetRow(columns, leaves, values)
+		if err != nil {
+			return err
+		}
+		n++
+		batch = append(batch, row)
+		if len(batch) == parquetBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	if err := flush(); err != nil {
+		return n, err
+	}
+	if err := w.Close(); err != nil {
+		return n, err
+	}
+	if err := file.Sync(); err != nil {
+		return n, err
+	}
+	if err := file.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp, path)
+}
+
+// partitionPath lays partitions out Hive style, so that query engines can
+// prune them by chain and block.
+func partitionPath(dir string, chainID int64, dataset database.ExportDataset, blockStart int64) string {
+	return filepath.Join(dir, string(dataset),
+		"chain_id="+strconv.FormatInt(chainID, 10),
+		"block_start="+strconv.FormatInt(blockStart, 10),
+		"data.parquet")
+}
+
+func checkpointPath(dir string, chainID int64, dataset database.ExportDataset) string {
+	return filepath.Join(dir, "_checkpoints", "chain_id="+strconv.FormatInt(chainID, 10), string(dataset)+".json")
+}
+
+// loadCheckpoint reads a checkpoint, returning nil if there is none.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if cp.Partitions == nil {
+		cp.Partitions = map[int64]*Partition{}
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint replaces the checkpoint atomically, so an interrupted write
+// leaves the previous one.
+func saveCheckpoint(path string, cp *checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parquetSchema builds the schema of a dataset, with every column optional.
+// Parquet orders a group's fields by name, so it also returns each column's
+// leaf index.
+func parquetSchema(name string, columns []database.ExportColumn) (*parquet.Schema, []int) {
+	group := parquet.Group{}
+	for _, col := range columns {
+		var node parquet.Node
+		switch col.Kind {
+		case database.ExportInteger:
+			node = parquet.Int(64)
+		case database.ExportBoolean:
+			node = parquet.Leaf(parquet.BooleanType)
+		case database.ExportDecimal:
+			node = parquet.Decimal(0, decimalPrecision, parquet.FixedLenByteArrayType(decimalBytes))
+		case database.ExportTimestamp:
+			node = parquet.Timestamp(parquet.Millisecond)
+		default:
+			node = parquet.String()
+		}
+		group[col.Name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema(name, group)
+
+	index := map[string]int{}
+	for i, path := range schema.Columns() {
+		index[path[0]] = i
+	}
+	leaves := make([]int, len(columns))
+	for i, col := range columns {
+		leaves[i] = index[col.Name]
+	}
+	return schema, leaves
+}
+
+// parquetRow converts a row of exported text values to Parquet values.
+func parquetRow(columns []database.ExportColumn, leaves []int, values []sql.NullString) (parquet.Row, error) {
+	row := make(parquet.Row, len(values))
+	for i, v := range values {
+		leaf := leaves[i]
+		if !v.Valid {
+			row[leaf] = parquet.NullValue().Level(0, 0, leaf)
+			continue
+		}
+		var value parquet.Value
+		switch columns[i].Kind {
+		case database.ExportInteger:
+			n, err := strconv.ParseInt(v.String, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", columns[i].Name, v.String)
+			}
+			value = parquet.Int64Value(n)
+		case database.ExportBoolean:
+			value = parquet.BooleanValue(v.String == "true")
+		case database.ExportDecimal:
+			b, err := encodeDecimal(v.String)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", columns[i].Name, err)
+			}
+			value = parquet.FixedLenByteArrayValue(b)
+		case database.ExportTimestamp:
+			t, err := time.Parse(time.RFC3339, v.String)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", columns[i].Name, v.String)
+			}
+			value = parquet.Int64Value(t.UnixMilli())
+		default:
+			value = parquet.ByteArrayValue([]byte(v.String))
+		}
+		row[leaf] = value.Level(0, 1, leaf)
+	}
+	return row, nil
+}
+
+// encodeDecimal encodes an integer as the big-endian two's complement 16
+// byte array of a DECIMAL(38, 0).
+func encodeDecimal(s string) ([]byte, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("not an integer: %s", s)
+	}
+	if new(big.Int).Abs(n).Cmp(maxDecimal) >= 0 {
+		return nil, fmt.Errorf("%s exceeds %d digits", s, decimalPrecision)
+	}
+	if n.Sign() < 0 {
+		n.Add(n, new(big.Int).Lsh(big.NewInt(1), decimalBytes*8))
+	}
+	return n.FillBytes(make([]byte, decimalBytes)), nil
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/indexer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"0", "00000000000000000000000000000000"},
+		{"1000000000000000000", "00000000000000000de0b6b3a7640000"},
+		{"-1", "ffffffffffffffffffffffffffffffff"},
+		{strings.Repeat("9", 38), "4b3b4ca85a86c47a098a223fffffffff"},
+	}
+	for _, tt := range tests {
+		b, err := encodeDecimal(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, hex.EncodeToString(b), tt.value)
+	}
+
+	_, err := encodeDecimal("1" + strings.Repeat("0", 38))
+	assert.Error(t, err)
+	_, err = encodeDecimal("1.5")
+	assert.Error(t, err)
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	columns := []database.ExportColumn{
+		{Name: "hash", Kind: database.ExportString},
+		{Name: "block_number", Kind: database.ExportInteger},
+		{Name: "timestamp", Kind: database.ExportTimestamp},
+		{Name: "value", Kind: database.ExportDecimal},
+		{Name: "removed", Kind: database.ExportBoolean},
+	}
+	schema, leaves := parquetSchema("test", columns)
+	assert.Equal(t, []int{1, 0, 3, 4, 2}, leaves)
+
+	value, _ := schema.Lookup("value")
+	assert.Equal(t, "DECIMAL(38,0)", value.Node.Type().String())
+	timestamp, _ := schema.Lookup("timestamp")
+	assert.Equal(t, "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)", timestamp.Node.Type().String())
+
+	rows := make([]parquet.Row, 0, 2)
+	for _, values := range [][]sql.NullString{
+		{{String: "0xaa", Valid: true}, {String: "12", Valid: true}, {String: "2024-01-02T03:04:05Z", Valid: true}, {String: "1000000000000000000", Valid: true}, {String: "true", Valid: true}},
+		{{String: "0xbb", Valid: true}, {String: "13", Valid: true}, {String: "2024-01-02T03:04:17Z", Valid: true}, {}, {}},
+	} {
+		row, err := parquetRow(columns, leaves, values)
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, schema)
+	_, err := w.WriteRows(rows)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r := parquet.NewReader(bytes.NewReader(buf.Bytes()))
+	read := make([]parquet.Row, 2)
+	n, _ := r.ReadRows(read)
+	require.Equal(t, 2, n)
+
+	first := read[0]
+	assert.Equal(t, "0xaa", string(first[leaves[0]].ByteArray()))
+	assert.Equal(t, int64(12), first[leaves[1]].Int64())
+	assert.Equal(t, int64(1704164645000), first[leaves[2]].Int64())
+	assert.Equal(t, "00000000000000000de0b6b3a7640000", hex.EncodeToString(first[leaves[3]].ByteArray()))
+	assert.True(t, first[leaves[4]].Boolean())
+	assert.True(t, read[1][leaves[3]].IsNull())
+	assert.True(t, read[1][leaves[4]].IsNull())
+}
+
+func TestParquetRowRejectsInvalidValues(t *testing.T) {
+	columns := []database.ExportColumn{{Name: "fee", Kind: database.ExportDecimal}}
+	_, leaves := parquetSchema("test", columns)
+	_, err := parquetRow(columns, leaves, []sql.NullString{{String: "1" + strings.Repeat("0", 40), Valid: true}})
+	assert.ErrorContains(t, err, "fee")
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := checkpointPath(dir, 1337, database.ExportLogs)
+	assert.Equal(t, filepath.Join(dir, "_checkpoints", "chain_id=1337", "logs.json"), path)
+
+	cp, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	saved := &checkpoint{
+		Dataset:       database.ExportLogs,
+		ChainID:       1337,
+		PartitionSize: 1000,
+		Partitions:    map[int64]*Partition{2000: {BlockStart: 2000, FromBlock: 2000, ToBlock: 2999, Rows: 42}},
+	}
+	require.NoError(t, saveCheckpoint(path, saved))
+	cp, err = loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, cp)
+}
+
+func TestPartitionPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", "token_transfers", "chain_id=1", "block_start=200000", "data.parquet"),
+		partitionPath("out", 1, database.ExportTokenTransfers, 200000))
+}
+
+func TestFinalTo(t *testing.T) {
+	to, err := finalTo(1000, &ParquetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000-indexer.MaxReorgDepth), to)
+
+	requested := int64(500)
+	to, err = finalTo(1000, &ParquetOptions{ToBlock: &requested})
+	require.NoError(t, err)
+	assert.Equal(t, requested, to)
+
+	// Past the final blocks is clamped, not written
+	requested = 990
+	to, err = finalTo(1000, &ParquetOptions{ToBlock: &requested})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000-indexer.MaxReorgDepth), to)
+
+	_, err = finalTo(1000, &ParquetOptions{FromBlock: 980})
+	assert.Error(t, err)
+	_, err = finalTo(indexer.MaxReorgDepth-1, &ParquetOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,351 @@
+// Package graphql serves the indexed chain data as a GraphQL API with nested
+// relations, keyset cursor pagination and batched database loads.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+const (
+	maxFirst       = 100
+	maxDepth       = 10
+	maxQueryLength = 10000
+	// maxComplexity is the number of objects one request may load
+	maxComplexity = 5000
+)
+
+// Service executes GraphQL queries against the index.
+type Service struct {
+	db     *database.DB
+	schema *gql.Schema
+}
+
+// NewService binds the schema to its resolvers. The schema is a constant, so
+// a binding failure is a programming error and panics.
+func NewService(db *database.DB) *Service {
+	s := gql.MustParseSchema(schema, &Resolver{},
+		gql.MaxDepth(maxDepth),
+		gql.MaxQueryLength(maxQueryLength),
+	)
+	return &Service{db: db, schema: s}
+}
+
+// Exec runs a query with a fresh set of loaders and complexity budget.
+func (s *Service) Exec(ctx context.Context, query, operationName string, variables map[string]interface{}) *gql.Response {
+	req := &request{
+		db:     s.db,
+		budget: &budget{remaining: maxComplexity, limit: maxComplexity},
+		scopes: make(map[int64]*scope),
+	}
+	return s.schema.Exec(context.WithValue(ctx, requestKey{}, req), query, operationName, variables)
+}
+
+// Resolver is the root Query resolver.
+type Resolver struct{}
+
+func chainScope(ctx context.Context, chainID Long) *scope {
+	return requestFrom(ctx).scope(int64(chainID))
+}
+
+// pageSize validates first and charges the page against the budget. One extra
+// row is fetched to tell whether another page follows.
+func (s *scope) pageSize(first int32) (int, error) {
+	n := int(first)
+	if n < 1 || n > maxFirst {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxFirst)
+	}
+	if err := s.budget.charge(n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// parseAfter decodes an endCursor. Connections only page forward.
+func parseAfter(after *string) (*database.Position, error) {
+	if after == nil || *after == "" {
+		return nil, nil
+	}
+	cursor, err := database.DecodeCursor(*after)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Backward {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor.Position, nil
+}
+
+func endCursor(pos database.Position) *string {
+	cursor := database.Cursor{Position: pos}.Encode()
+	return &cursor
+}
+
+func parseAddress(address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("invalid address: %s", address)
+	}
+	return common.HexToAddress(address).Hex(), nil
+}
+
+func parseOptionalAddress(address *string) (*string, error) {
+	if address == nil {
+		return nil, nil
+	}
+	a, err := parseAddress(*address)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+type blockArgs struct {
+	ChainID Long
+	Number  *Long
+	Hash    *string
+}
+
+func (r *Resolver) Block(ctx context.Context, args blockArgs) (*blockResolver, error) {
+	s := chainScope(ctx, args.ChainID)
+	var block *models.Block
+	var err error
+	switch {
+	case args.Hash != nil:
+		block, err = s.db.GetBlockByHash(ctx, s.chainID, strings.ToLower(*args.Hash))
+	case args.Number != nil:
+		block, err = s.blocks.Load(ctx, int64(*args.Number))
+	default:
+		block, err = s.db.GetLatestBlock(ctx, s.chainID)
+	}
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return s.block(block), nil
+}
+
+type connectionArgs struct {
+	ChainID Long
+	First   int32
+	After   *string
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args connectionArgs) (*blockConnection, error) {
+	s := chainScope(ctx, args.ChainID)
+	first, err := s.pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+	after, err := parseAfter(args.After)
+	if err != nil {
+		return nil, err
+	}
+	var below *int64
+	if after != nil {
+		below = &after.BlockNumber
+	}
+
+	blocks, err := s.db.GetBlocksPage(ctx, s.chainID, below, nil, first+1)
+	if err != nil {
+		return nil, err
+	}
+	conn := &blockConnection{}
+	if len(blocks) > first {
+		blocks = blocks[:first]
+		conn.pageInfo.hasNextPage = true
+	}
+	conn.nodes = s.blockList(blocks)
+	if len(blocks) > 0 {
+		conn.pageInfo.endCursor = endCursor(database.Position{BlockNumber: blocks[len(blocks)-1].BlockNumber})
+	}
+	return conn, nil
+}
+
+type transactionArgs struct {
+	ChainID Long
+	Hash    string
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args transactionArgs) (*transactionResolver, error) {
+	s := chainScope(ctx, args.ChainID)
+	tx, err := s.transactions.Load(ctx, strings.ToLower(args.Hash))
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return s.transaction(tx), nil
+}
+
+type transactionFilterInput struct {
+	Address   *string
+	From      *string
+	To        *string
+	FromBlock *Long
+	ToBlock   *Long
+	Status    *int32
+}
+
+type transactionsArgs struct {
+	ChainID Long
+	Filter  *transactionFilterInput
+	First   int32
+	After   *string
+}
+
+func (r *Resolver) Transactions(ctx context.Context, args transactionsArgs) (*transactionConnection, error) {
+	s := chainScope(ctx, args.ChainID)
+	filter := &database.TransactionFilter{ChainID: s.chainID}
+	if f := args.Filter; f != nil {
+		var err error
+		if filter.Address, err = parseOptionalAddress(f.Address); err != nil {
+			return nil, err
+		}
+		if filter.FromAddress, err = parseOptionalAddress(f.From); err != nil {
+			return nil, err
+		}
+		if filter.ToAddress, err = parseOptionalAddress(f.To); err != nil {
+			return nil, err
+		}
+		filter.FromBlock = (*int64)(f.FromBlock)
+		filter.ToBlock = (*int64)(f.ToBlock)
+		if f.Status != nil {
+			status := int(*f.Status)
+			filter.Status = &status
+		}
+	}
+	return s.transactionConnection(ctx, filter, args.First, args.After)
+}
+
+type logFilterInput struct {
+	Addresses *[]string
+	Topics    *[]*[]string
+	FromBlock *Long
+	ToBlock   *Long
+	BlockHash *string
+}
+
+type logsArgs struct {
+	ChainID Long
+	Filter  *logFilterInput
+	First   int32
+	After   *string
+}
+
+func (r *Resolver) Logs(ctx context.Context, args logsArgs) (*logConnection, error) {
+	s := chainScope(ctx, args.ChainID)
+	first, err := s.pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+	filter := &database.LogFilter{ChainID: s.chainID, Limit: first + 1}
+	if filter.After, err = parseAfter(args.After); err != nil {
+		return nil, err
+	}
+	if f := args.Filter; f != nil {
+		if f.Addresses != nil {
+			for _, address := range *f.Addresses {
+				a, err := parseAddress(address)
+				if err != nil {
+					return nil, err
+				}
+				filter.Addresses = append(filter.Addresses, a)
+			}
+		}
+		if f.Topics != nil {
+			if len(*f.Topics) > len(filter.Topics) {
+				return nil, fmt.Errorf("at most %d topic positions are supported", len(filter.Topics))
+			}
+			for i, topics := range *f.Topics {
+				if topics == nil {
+					continue
+				}
+				for _, topic := range *topics {
+					filter.Topics[i] = append(filter.Topics[i], strings.ToLower(topic))
+				}
+			}
+		}
+		if f.BlockHash != nil {
+			hash := strings.ToLower(*f.BlockHash)
+			filter.BlockHash = &hash
+		}
+		filter.FromBlock = (*int64)(f.FromBlock)
+		filter.ToBlock = (*int64)(f.ToBlock)
+	}
+
+	logs, err := s.db.GetLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	conn := &logConnection{}
+	if len(logs) > first {
+		logs = logs[:first]
+		conn.pageInfo.hasNextPage = true
+	}
+	conn.nodes = s.logList(logs)
+	if len(logs) > 0 {
+		last := logs[len(logs)-1]
+		conn.pageInfo.endCursor = endCursor(database.Position{BlockNumber: last.BlockNumber, Index: last.LogIndex})
+	}
+	return conn, nil
+}
+
+type addressArgs struct {
+	ChainID Long
+	Address string
+}
+
+func (r *Resolver) Address(ctx context.Context, args addressArgs) (*accountResolver, error) {
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	return chainScope(ctx, args.ChainID).account(address), nil
+}
+
+func (r *Resolver) Token(ctx context.Context, args addressArgs) (*tokenResolver, error) {
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	s := chainScope(ctx, args.ChainID)
+	token, err := s.tokens.Load(ctx, address)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return &tokenResolver{s: s, token: token}, nil
+}
+
+type tokenTransferFilterInput struct {
+	Address   *string
+	Token     *string
+	FromBlock *Long
+	ToBlock   *Long
+}
+
+type tokenTransfersArgs struct {
+	ChainID Long
+	Filter  *tokenTransferFilterInput
+	First   int32
+	After   *string
+}
+
+func (r *Resolver) TokenTransfers(ctx context.Context, args tokenTransfersArgs) (*tokenTransferConnection, error) {
+	s := chainScope(ctx, args.ChainID)
+	filter := &database.TokenTransferFilter{ChainID: s.chainID}
+	if f := args.Filter; f != nil {
+		var err error
+		if filter.Address, err = parseOptionalAddress(f.Address); err != nil {
+			return nil, err
+		}
+		if filter.TokenAddress, err = parseOptionalAddress(f.Token); err != nil {
+			return nil, err
+		}
+		filter.FromBlock = (*int64)(f.FromBlock)
+		filter.ToBlock = (*int64)(f.ToBlock)
+	}
+	return s.tokenTransferConnection(ctx, filter, args.First, args.After)
+}
@@ -0,0 +1,138 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaMatchesResolvers(t *testing.T) {
+	// Parsing binds every schema field to a resolver method and fails on
+	// any mismatch, so no database is needed
+	assert.NotPanics(t, func() { NewService(nil) })
+}
+
+func TestParseAfterRejectsBackwardCursors(t *testing.T) {
+	pos, err := parseAfter(endCursor(database.Position{BlockNumber: 10, Index: 2}))
+	require.NoError(t, err)
+	assert.Equal(t, database.Position{BlockNumber: 10, Index: 2}, *pos)
+
+	prev := database.Cursor{Position: database.Position{BlockNumber: 10}, Backward: true}.Encode()
+	_, err = parseAfter(&prev)
+	assert.Error(t, err)
+}
+
+func TestLoaderBatchesPrimedKeys(t *testing.T) {
+	var calls [][]int
+	l := newLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		calls = append(calls, keys)
+		result := make(map[int]string)
+		for _, k := range keys {
+			if k != 3 {
+				result[k] = "v"
+			}
+		}
+		return result, nil
+	})
+
+	l.Prime(1, 2, 3)
+	v, err := l.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	// Primed keys, including ones with no row, are served from the batch
+	for _, k := range []int{2, 3} {
+		_, err := l.Load(context.Background(), k)
+		require.NoError(t, err)
+	}
+	require.Len(t, calls, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3}, calls[0])
+
+	_, err = l.Load(context.Background(), 4)
+	require.NoError(t, err)
+	assert.Len(t, calls, 2)
+}
+
+func TestLoaderFetchesWithoutHoldingTheLock(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var calls [][]int
+	l := newLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		mu.Lock()
+		calls = append(calls, keys)
+		mu.Unlock()
+		if keys[0] == 1 {
+			<-release
+		}
+		return map[int]string{keys[0]: "v"}, nil
+	})
+
+	first := make(chan string)
+	go func() {
+		v, _ := l.Load(context.Background(), 1)
+		first <- v
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls) == 1
+	}, time.Second, time.Millisecond)
+
+	// Other keys load while the first fetch is still running
+	v, err := l.Load(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	// A key being fetched waits for that batch rather than fetching again
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Load(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.Equal(t, "v", <-first)
+	v, err = l.Load(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+	assert.Len(t, calls, 2)
+}
+
+func TestBudget(t *testing.T) {
+	b := &budget{remaining: 10, limit: 10}
+	require.NoError(t, b.charge(6))
+	assert.Error(t, b.charge(5))
+	assert.NoError(t, b.charge(4))
+}
+
+func TestLongUnmarshal(t *testing.T) {
+	var l Long
+	require.NoError(t, l.UnmarshalGraphQL(int32(1337)))
+	assert.Equal(t, Long(1337), l)
+	require.NoError(t, l.UnmarshalGraphQL(float64(5000000000)))
+	assert.Equal(t, Long(5000000000), l)
+	require.NoError(t, l.UnmarshalGraphQL("0x10"))
+	assert.Equal(t, Long(16), l)
+	assert.Error(t, l.UnmarshalGraphQL(1.5))
+}
+
+func TestExecWithoutDatabase(t *testing.T) {
+	s := NewService(nil)
+	resp := s.Exec(context.Background(), `{ address(address: "0x00000000000000000000000000000000000000ab") { chainId address } }`, "", nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"address":{"chainId":1337,"address":"0x00000000000000000000000000000000000000AB"}}`, string(resp.Data))
+
+	resp = s.Exec(context.Background(), `{ address(address: "nope") { address } }`, "", nil)
+	assert.NotEmpty(t, resp.Errors)
+}
+
+func TestMaxDepth(t *testing.T) {
+	query := `{ block { transactions { block { transactions { block { transactions { block { transactions { block { transactions { hash } } } } } } } } } } }`
+	resp := NewService(nil).Exec(context.Background(), query, "", nil)
+	require.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0].Message, "depth")
+}
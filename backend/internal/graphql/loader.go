@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// loader batches lookups by key for the duration of one request. Resolvers
+// that produce a list prime the keys their children will ask for, and the
+// first Load fetches every primed key in a single query, so resolving a field
+// across all items of a list costs one query rather than one per item. The
+// lock is not held while fetching; Loads of keys already being fetched wait
+// for that batch instead of querying again.
+type loader[K comparable, V any] struct {
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	mu       sync.Mutex
+	pending  map[K]struct{}
+	inflight map[K]*batch[K, V]
+	cache    map[K]V
+}
+
+// batch is one fetch in progress; done is closed once values and err are set.
+type batch[K comparable, V any] struct {
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:    fetch,
+		pending:  make(map[K]struct{}),
+		inflight: make(map[K]*batch[K, V]),
+		cache:    make(map[K]V),
+	}
+}
+
+// Prime queues keys to be fetched with the next batch.
+func (l *loader[K, V]) Prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		_, cached := l.cache[key]
+		_, fetching := l.inflight[key]
+		if !cached && !fetching {
+			l.pending[key] = struct{}{}
+		}
+	}
+}
+
+// Set caches a value that was already loaded by other means.
+func (l *loader[K, V]) Set(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache[key] = value
+	delete(l.pending, key)
+}
+
+// Load returns the value for key, fetching it together with every pending
+// key if it is not cached yet. Keys the fetch does not return load as the
+// zero value.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if value, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return value, nil
+	}
+	if b, ok := l.inflight[key]; ok {
+		l.mu.Unlock()
+		return b.wait(ctx, key)
+	}
+
+	l.pending[key] = struct{}{}
+	keys := make([]K, 0, len(l.pending))
+	b := &batch[K, V]{done: make(chan struct{})}
+	for k := range l.pending {
+		keys = append(keys, k)
+		l.inflight[k] = b
+	}
+	l.pending = make(map[K]struct{})
+	l.mu.Unlock()
+
+	b.values, b.err = l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	for _, k := range keys {
+		delete(l.inflight, k)
+		if b.err == nil {
+			l.cache[k] = b.values[k]
+		}
+	}
+	l.mu.Unlock()
+	close(b.done)
+	return b.value(key)
+}
+
+// wait returns key's value once the batch fetching it is done.
+func (b *batch[K, V]) wait(ctx context.Context, key K) (V, error) {
+	select {
+	case <-b.done:
+		return b.value(key)
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (b *batch[K, V]) value(key K) (V, error) {
+	if b.err != nil {
+		var zero V
+		return zero, b.err
+	}
+	return b.values[key], nil
+}
+
+// scope holds the per-request, per-chain state shared by resolvers: the
+// batch loaders and the request's complexity budget.
+type scope struct {
+	db      *database.DB
+	chainID int64
+	budget  *budget
+
+	blocks       *loader[int64, *models.Block]
+	blockTxs     *loader[int64, []*models.Transaction]
+	transactions *loader[string, *models.Transaction]
+	logs         *loader[string, []*models.TransactionLog]
+	transfers    *loader[string, []*models.TokenTransfer]
+	tokens       *loader[string, *models.Token]
+	addresses    *loader[string, *models.Address]
+}
+
+func newScope(db *database.DB, chainID int64, b *budget) *scope {
+	s := &scope{db: db, chainID: chainID, budget: b}
+	s.blocks = newLoader(func(ctx context.Context, keys []int64) (map[int64]*models.Block, error) {
+		return db.GetBlocksByNumbers(ctx, chainID, keys)
+	})
+	s.blockTxs = newLoader(func(ctx context.Context, keys []int64) (map[int64][]*models.Transaction, error) {
+		return db.GetTransactionsByBlocks(ctx, chainID, keys)
+	})
+	s.transactions = newLoader(func(ctx context.Context, keys []string) (map[string]*models.Transaction, error) {
+		return db.GetTransactionsByHashes(ctx, chainID, keys)
+	})
+	s.logs = newLoader(func(ctx context.Context, keys []string) (map[string][]*models.TransactionLog, error) {
+		return db.GetLogsByTransactions(ctx, chainID, keys)
+	})
+	s.transfers = newLoader(func(ctx context.Context, keys []string) (map[string][]*models.TokenTransfer, error) {
+		return db.GetTokenTransfersByTransactions(ctx, chainID, keys)
+	})
+	s.tokens = newLoader(func(ctx context.Context, keys []string) (map[string]*models.Token, error) {
+		return db.GetTokensByAddresses(ctx, chainID, keys)
+	})
+	s.addresses = newLoader(func(ctx context.Context, keys []string) (map[string]*models.Address, error) {
+		return db.GetAddresses(ctx, chainID, keys)
+	})
+	return s
+}
+
+// budget caps the number of objects a single request may load. Each list
+// charges for the items it can return before it queries, so a deeply nested
+// query is rejected once it would fan out past the limit.
+type budget struct {
+	mu        sync.Mutex
+	remaining int
+	limit     int
+}
+
+func (b *budget) charge(n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > b.remaining {
+		return fmt.Errorf("query exceeds the complexity limit of %d objects", b.limit)
+	}
+	b.remaining -= n
+	return nil
+}
+
+type requestKey struct{}
+
+// request is the state attached to the context of one GraphQL execution.
+type request struct {
+	db     *database.DB
+	budget *budget
+	mu     sync.Mutex
+	scopes map[int64]*scope
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+func (r *request) scope(chainID int64) *scope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.scopes[chainID]
+	if !ok {
+		s = newScope(r.db, chainID, r.budget)
+		r.scopes[chainID] = s
+	}
+	return s
+}
@@ -0,0 +1,431 @@
+package graphql
+
+import (
+	"context"
+
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+type pageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p pageInfo) HasNextPage() bool  { return p.hasNextPage }
+func (p pageInfo) EndCursor() *string { return p.endCursor }
+
+type blockConnection struct {
+	nodes    []*blockResolver
+	pageInfo pageInfo
+}
+
+func (c *blockConnection) Nodes() []*blockResolver { return c.nodes }
+func (c *blockConnection) PageInfo() pageInfo      { return c.pageInfo }
+
+type transactionConnection struct {
+	nodes    []*transactionResolver
+	pageInfo pageInfo
+}
+
+func (c *transactionConnection) Nodes() []*transactionResolver { return c.nodes }
+func (c *transactionConnection) PageInfo() pageInfo            { return c.pageInfo }
+
+type logConnection struct {
+	nodes    []*logResolver
+	pageInfo pageInfo
+}
+
+func (c *logConnection) Nodes() []*logResolver { return c.nodes }
+func (c *logConnection) PageInfo() pageInfo    { return c.pageInfo }
+
+type tokenTransferConnection struct {
+	nodes    []*tokenTransferResolver
+	pageInfo pageInfo
+}
+
+func (c *tokenTransferConnection) Nodes() []*tokenTransferResolver { return c.nodes }
+func (c *tokenTransferConnection) PageInfo() pageInfo              { return c.pageInfo }
+
+// The list constructors below prime the loaders with every key the items'
+// relations may ask for, so each relation is fetched once for the whole list.
+
+func (s *scope) block(block *models.Block) *blockResolver {
+	return s.blockList([]*models.Block{block})[0]
+}
+
+func (s *scope) blockList(blocks []*models.Block) []*blockResolver {
+	resolvers := make([]*blockResolver, len(blocks))
+	for i, block := range blocks {
+		s.blocks.Set(block.BlockNumber, block)
+		s.blockTxs.Prime(block.BlockNumber)
+		s.addresses.Prime(block.Miner)
+		resolvers[i] = &blockResolver{s: s, block: block}
+	}
+	return resolvers
+}
+
+func (s *scope) transaction(tx *models.Transaction) *transactionResolver {
+	return s.transactionList([]*models.Transaction{tx})[0]
+}
+
+func (s *scope) transactionList(txs []*models.Transaction) []*transactionResolver {
+	resolvers := make([]*transactionResolver, len(txs))
+	for i, tx := range txs {
+		s.transactions.Set(tx.Hash, tx)
+		s.blocks.Prime(tx.BlockNumber)
+		s.logs.Prime(tx.Hash)
+		s.transfers.Prime(tx.Hash)
+		s.addresses.Prime(tx.FromAddress)
+		if tx.ToAddress != nil {
+			s.addresses.Prime(*tx.ToAddress)
+		}
+		resolvers[i] = &transactionResolver{s: s, tx: tx}
+	}
+	return resolvers
+}
+
+func (s *scope) logList(logs []*models.TransactionLog) []*logResolver {
+	resolvers := make([]*logResolver, len(logs))
+	for i, log := range logs {
+		s.transactions.Prime(log.TransactionHash)
+		s.addresses.Prime(log.Address)
+		resolvers[i] = &logResolver{s: s, log: log}
+	}
+	return resolvers
+}
+
+func (s *scope) transferList(transfers []*models.TokenTransfer) []*tokenTransferResolver {
+	resolvers := make([]*tokenTransferResolver, len(transfers))
+	for i, transfer := range transfers {
+		s.transactions.Prime(transfer.TransactionHash)
+		s.tokens.Prime(transfer.TokenAddress)
+		s.addresses.Prime(transfer.FromAddress, transfer.ToAddress)
+		resolvers[i] = &tokenTransferResolver{s: s, transfer: transfer}
+	}
+	return resolvers
+}
+
+func (s *scope) account(address string) *accountResolver {
+	return &accountResolver{s: s, address: address}
+}
+
+func (s *scope) optionalAccount(address *string) *accountResolver {
+	if address == nil {
+		return nil
+	}
+	return s.account(*address)
+}
+
+func (s *scope) transactionConnection(ctx context.Context, filter *database.TransactionFilter, first int32, after *string) (*transactionConnection, error) {
+	limit, err := s.pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	if filter.After, err = parseAfter(after); err != nil {
+		return nil, err
+	}
+	filter.Limit = limit + 1
+
+	txs, err := s.db.GetTransactionsByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	conn := &transactionConnection{}
+	if len(txs) > limit {
+		txs = txs[:limit]
+		conn.pageInfo.hasNextPage = true
+	}
+	conn.nodes = s.transactionList(txs)
+	if len(txs) > 0 {
+		last := txs[len(txs)-1]
+		conn.pageInfo.endCursor = endCursor(database.Position{BlockNumber: last.BlockNumber, Index: last.TransactionIndex})
+	}
+	return conn, nil
+}
+
+func (s *scope) tokenTransferConnection(ctx context.Context, filter *database.TokenTransferFilter, first int32, after *string) (*tokenTransferConnection, error) {
+	limit, err := s.pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	if filter.After, err = parseAfter(after); err != nil {
+		return nil, err
+	}
+	filter.Limit = limit + 1
+
+	transfers, err := s.db.GetTokenTransfers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	conn := &tokenTransferConnection{}
+	if len(transfers) > limit {
+		transfers = transfers[:limit]
+		conn.pageInfo.hasNextPage = true
+	}
+	conn.nodes = s.transferList(transfers)
+	if len(transfers) > 0 {
+		last := transfers[len(transfers)-1]
+		conn.pageInfo.endCursor = endCursor(database.Position{BlockNumber: last.BlockNumber, Index: last.LogIndex})
+	}
+	return conn, nil
+}
+
+type blockResolver struct {
+	s     *scope
+	block *models.Block
+}
+
+func (r *blockResolver) ChainID() Long           { return Long(r.block.ChainID) }
+func (r *blockResolver) Number() Long            { return Long(r.block.BlockNumber) }
+func (r *blockResolver) Hash() string            { return r.block.Hash }
+func (r *blockResolver) ParentHash() string      { return r.block.ParentHash }
+func (r *blockResolver) Miner() *accountResolver { return r.s.account(r.block.Miner) }
+func (r *blockResolver) GasLimit() Long          { return Long(r.block.GasLimit) }
+func (r *blockResolver) GasUsed() Long           { return Long(r.block.GasUsed) }
+func (r *blockResolver) BaseFeePerGas() *BigInt  { return bigIntPtr(r.block.BaseFeePerGas) }
+func (r *blockResolver) Size() *Long             { return longPtr(r.block.Size) }
+func (r *blockResolver) Timestamp() gql.Time     { return gql.Time{Time: r.block.Timestamp} }
+func (r *blockResolver) TransactionCount() int32 { return int32(r.block.TxCount) }
+
+func (r *blockResolver) Parent(ctx context.Context) (*blockResolver, error) {
+	if r.block.BlockNumber == 0 {
+		return nil, nil
+	}
+	parent, err := r.s.blocks.Load(ctx, r.block.BlockNumber-1)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	return r.s.block(parent), nil
+}
+
+func (r *blockResolver) Transactions(ctx context.Context) ([]*transactionResolver, error) {
+	txs, err := r.s.blockTxs.Load(ctx, r.block.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.s.budget.charge(len(txs)); err != nil {
+		return nil, err
+	}
+	return r.s.transactionList(txs), nil
+}
+
+type transactionResolver struct {
+	s  *scope
+	tx *models.Transaction
+}
+
+func (r *transactionResolver) ChainID() Long          { return Long(r.tx.ChainID) }
+func (r *transactionResolver) Hash() string           { return r.tx.Hash }
+func (r *transactionResolver) BlockNumber() Long      { return Long(r.tx.BlockNumber) }
+func (r *transactionResolver) BlockHash() string      { return r.tx.BlockHash }
+func (r *transactionResolver) Index() int32           { return int32(r.tx.TransactionIndex) }
+func (r *transactionResolver) From() *accountResolver { return r.s.account(r.tx.FromAddress) }
+func (r *transactionResolver) To() *accountResolver   { return r.s.optionalAccount(r.tx.ToAddress) }
+func (r *transactionResolver) Value() BigInt          { return BigInt(r.tx.Value) }
+func (r *transactionResolver) Gas() Long              { return Long(r.tx.Gas) }
+func (r *transactionResolver) GasPrice() *BigInt      { return bigIntPtr(r.tx.GasPrice) }
+func (r *transactionResolver) MaxFeePerGas() *BigInt  { return bigIntPtr(r.tx.MaxFeePerGas) }
+func (r *transactionResolver) MaxPriorityFeePerGas() *BigInt {
+	return bigIntPtr(r.tx.MaxPriorityFeePerGas)
+}
+func (r *transactionResolver) EffectiveGasPrice() *BigInt { return bigIntPtr(r.tx.EffectiveGasPrice) }
+func (r *transactionResolver) GasUsed() *Long             { return longPtr(r.tx.GasUsed) }
+func (r *transactionResolver) Input() *string             { return r.tx.Input }
+func (r *transactionResolver) Nonce() Long                { return Long(r.tx.Nonce) }
+func (r *transactionResolver) Type() int32                { return int32(r.tx.TransactionType) }
+func (r *transactionResolver) Status() *int32             { return int32Ptr(r.tx.Status) }
+func (r *transactionResolver) CreatedContract() *accountResolver {
+	return r.s.optionalAccount(r.tx.ContractAddress)
+}
+func (r *transactionResolver) Timestamp() gql.Time { return gql.Time{Time: r.tx.Timestamp} }
+
+func (r *transactionResolver) Block(ctx context.Context) (*blockResolver, error) {
+	block, err := r.s.blocks.Load(ctx, r.tx.BlockNumber)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return r.s.block(block), nil
+}
+
+func (r *transactionResolver) Logs(ctx context.Context) ([]*logResolver, error) {
+	logs, err := r.s.logs.Load(ctx, r.tx.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.s.budget.charge(len(logs)); err != nil {
+		return nil, err
+	}
+	return r.s.logList(logs), nil
+}
+
+func (r *transactionResolver) TokenTransfers(ctx context.Context) ([]*tokenTransferResolver, error) {
+	transfers, err := r.s.transfers.Load(ctx, r.tx.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.s.budget.charge(len(transfers)); err != nil {
+		return nil, err
+	}
+	return r.s.transferList(transfers), nil
+}
+
+type logResolver struct {
+	s   *scope
+	log *models.TransactionLog
+}
+
+func (r *logResolver) Index() int32              { return int32(r.log.LogIndex) }
+func (r *logResolver) Account() *accountResolver { return r.s.account(r.log.Address) }
+func (r *logResolver) Data() *string             { return r.log.Data }
+func (r *logResolver) BlockNumber() Long         { return Long(r.log.BlockNumber) }
+func (r *logResolver) BlockHash() string         { return r.log.BlockHash }
+func (r *logResolver) TransactionHash() string   { return r.log.TransactionHash }
+func (r *logResolver) TransactionIndex() int32   { return int32(r.log.TransactionIndex) }
+
+func (r *logResolver) Topics() []string {
+	var topics []string
+	for _, topic := range []*string{r.log.Topic0, r.log.Topic1, r.log.Topic2, r.log.Topic3} {
+		if topic == nil {
+			break
+		}
+		topics = append(topics, *topic)
+	}
+	return topics
+}
+
+func (r *logResolver) Transaction(ctx context.Context) (*transactionResolver, error) {
+	tx, err := r.s.transactions.Load(ctx, r.log.TransactionHash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return r.s.transaction(tx), nil
+}
+
+type accountResolver struct {
+	s       *scope
+	address string
+}
+
+func (r *accountResolver) ChainID() Long   { return Long(r.s.chainID) }
+func (r *accountResolver) Address() string { return r.address }
+
+// indexed returns the address row, or an empty one for addresses the indexer
+// has not recorded.
+func (r *accountResolver) indexed(ctx context.Context) (*models.Address, error) {
+	addr, err := r.s.addresses.Load(ctx, r.address)
+	if err != nil {
+		return nil, err
+	}
+	if addr == nil {
+		return &models.Address{}, nil
+	}
+	return addr, nil
+}
+
+func (r *accountResolver) Balance(ctx context.Context) (BigInt, error) {
+	addr, err := r.indexed(ctx)
+	if err != nil {
+		return "", err
+	}
+	return BigInt(addr.Balance), nil
+}
+
+func (r *accountResolver) Nonce(ctx context.Context) (Long, error) {
+	addr, err := r.indexed(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(addr.Nonce), nil
+}
+
+func (r *accountResolver) IsContract(ctx context.Context) (bool, error) {
+	addr, err := r.indexed(ctx)
+	if err != nil {
+		return false, err
+	}
+	return addr.IsContract, nil
+}
+
+func (r *accountResolver) TransactionCount(ctx context.Context) (Long, error) {
+	addr, err := r.indexed(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return Long(addr.TxCount), nil
+}
+
+type pageArgs struct {
+	First int32
+	After *string
+}
+
+func (r *accountResolver) Transactions(ctx context.Context, args pageArgs) (*transactionConnection, error) {
+	filter := &database.TransactionFilter{ChainID: r.s.chainID, Party: database.Party{Address: &r.address}}
+	return r.s.transactionConnection(ctx, filter, args.First, args.After)
+}
+
+func (r *accountResolver) TokenTransfers(ctx context.Context, args pageArgs) (*tokenTransferConnection, error) {
+	filter := &database.TokenTransferFilter{ChainID: r.s.chainID, Party: database.Party{Address: &r.address}}
+	return r.s.tokenTransferConnection(ctx, filter, args.First, args.After)
+}
+
+func (r *accountResolver) Token(ctx context.Context) (*tokenResolver, error) {
+	token, err := r.s.tokens.Load(ctx, r.address)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return &tokenResolver{s: r.s, token: token}, nil
+}
+
+type tokenResolver struct {
+	s     *scope
+	token *models.Token
+}
+
+func (r *tokenResolver) ChainID() Long        { return Long(r.token.ChainID) }
+func (r *tokenResolver) Address() string      { return r.token.Address }
+func (r *tokenResolver) Type() string         { return r.token.Type }
+func (r *tokenResolver) Name() *string        { return r.token.Name }
+func (r *tokenResolver) Symbol() *string      { return r.token.Symbol }
+func (r *tokenResolver) Decimals() *int32     { return int32Ptr(r.token.Decimals) }
+func (r *tokenResolver) TotalSupply() *BigInt { return bigIntPtr(r.token.TotalSupply) }
+func (r *tokenResolver) HolderCount() Long    { return Long(r.token.HolderCount) }
+func (r *tokenResolver) TransferCount() Long  { return Long(r.token.TransferCount) }
+
+func (r *tokenResolver) Transfers(ctx context.Context, args pageArgs) (*tokenTransferConnection, error) {
+	filter := &database.TokenTransferFilter{ChainID: r.s.chainID, TokenAddress: &r.token.Address}
+	return r.s.tokenTransferConnection(ctx, filter, args.First, args.After)
+}
+
+type tokenTransferResolver struct {
+	s        *scope
+	transfer *models.TokenTransfer
+}
+
+func (r *tokenTransferResolver) TransactionHash() string { return r.transfer.TransactionHash }
+func (r *tokenTransferResolver) LogIndex() int32         { return int32(r.transfer.LogIndex) }
+func (r *tokenTransferResolver) TokenAddress() string    { return r.transfer.TokenAddress }
+func (r *tokenTransferResolver) From() *accountResolver  { return r.s.account(r.transfer.FromAddress) }
+func (r *tokenTransferResolver) To() *accountResolver    { return r.s.account(r.transfer.ToAddress) }
+func (r *tokenTransferResolver) Value() *BigInt          { return bigIntPtr(r.transfer.Value) }
+func (r *tokenTransferResolver) TokenID() *BigInt        { return bigIntPtr(r.transfer.TokenID) }
+func (r *tokenTransferResolver) BlockNumber() Long       { return Long(r.transfer.BlockNumber) }
+func (r *tokenTransferResolver) Timestamp() gql.Time     { return gql.Time{Time: r.transfer.Timestamp} }
+
+func (r *tokenTransferResolver) Transaction(ctx context.Context) (*transactionResolver, error) {
+	tx, err := r.s.transactions.Load(ctx, r.transfer.TransactionHash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return r.s.transaction(tx), nil
+}
+
+func (r *tokenTransferResolver) Token(ctx context.Context) (*tokenResolver, error) {
+	token, err := r.s.tokens.Load(ctx, r.transfer.TokenAddress)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return &tokenResolver{s: r.s, token: token}, nil
+}
@@ -0,0 +1,99 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Long is a 64-bit integer. GraphQL's Int is only 32 bits wide.
+type Long int64
+
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*l = Long(v)
+	case int64:
+		*l = Long(v)
+	case int:
+		*l = Long(v)
+	case float64:
+		if v != float64(int64(v)) {
+			return fmt.Errorf("invalid Long: %v", v)
+		}
+		*l = Long(v)
+	case string:
+		n, err := strconv.ParseInt(v, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Long: %s", v)
+		}
+		*l = Long(n)
+	default:
+		return fmt.Errorf("invalid Long: %v", input)
+	}
+	return nil
+}
+
+func (l Long) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64(l))
+}
+
+// BigInt is an arbitrary precision integer carried as a decimal string.
+type BigInt string
+
+func (BigInt) ImplementsGraphQLType(name string) bool {
+	return name == "BigInt"
+}
+
+func (b *BigInt) UnmarshalGraphQL(input interface{}) error {
+	var s string
+	switch v := input.(type) {
+	case string:
+		s = v
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', 0, 64)
+	default:
+		return fmt.Errorf("invalid BigInt: %v", input)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return fmt.Errorf("invalid BigInt: %s", s)
+	}
+	*b = BigInt(n.String())
+	return nil
+}
+
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(b))
+}
+
+func bigIntPtr(s *string) *BigInt {
+	if s == nil {
+		return nil
+	}
+	b := BigInt(*s)
+	return &b
+}
+
+func longPtr(n *int64) *Long {
+	if n == nil {
+		return nil
+	}
+	l := Long(*n)
+	return &l
+}
+
+func int32Ptr(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	v := int32(*n)
+	return &v
+}
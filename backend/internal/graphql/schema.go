@@ -0,0 +1,173 @@
+package graphql
+
+const schema = `
+	schema {
+		query: Query
+	}
+
+	# 64-bit integer, used for chain IDs, block numbers and gas amounts
+	scalar Long
+	# Arbitrary precision integer encoded as a decimal string, used for wei amounts
+	scalar BigInt
+	scalar Time
+
+	type Query {
+		# Block by number or hash; the latest indexed block when neither is given
+		block(chainId: Long = 1337, number: Long, hash: String): Block
+		blocks(chainId: Long = 1337, first: Int = 20, after: String): BlockConnection!
+		transaction(chainId: Long = 1337, hash: String!): Transaction
+		transactions(chainId: Long = 1337, filter: TransactionFilter, first: Int = 20, after: String): TransactionConnection!
+		logs(chainId: Long = 1337, filter: LogFilter, first: Int = 20, after: String): LogConnection!
+		address(chainId: Long = 1337, address: String!): Account!
+		token(chainId: Long = 1337, address: String!): Token
+		tokenTransfers(chainId: Long = 1337, filter: TokenTransferFilter, first: Int = 20, after: String): TokenTransferConnection!
+	}
+
+	input TransactionFilter {
+		# Matches either the sender or the recipient
+		address: String
+		from: String
+		to: String
+		fromBlock: Long
+		toBlock: Long
+		# 1 for success, 0 for failure
+		status: Int
+	}
+
+	input LogFilter {
+		addresses: [String!]
+		# One OR set per topic position; a null or empty set matches anything
+		topics: [[String!]]
+		fromBlock: Long
+		toBlock: Long
+		blockHash: String
+	}
+
+	input TokenTransferFilter {
+		# Matches either the sender or the recipient
+		address: String
+		token: String
+		fromBlock: Long
+		toBlock: Long
+	}
+
+	type PageInfo {
+		hasNextPage: Boolean!
+		endCursor: String
+	}
+
+	type BlockConnection {
+		nodes: [Block!]!
+		pageInfo: PageInfo!
+	}
+
+	type TransactionConnection {
+		nodes: [Transaction!]!
+		pageInfo: PageInfo!
+	}
+
+	type LogConnection {
+		nodes: [Log!]!
+		pageInfo: PageInfo!
+	}
+
+	type TokenTransferConnection {
+		nodes: [TokenTransfer!]!
+		pageInfo: PageInfo!
+	}
+
+	type Block {
+		chainId: Long!
+		number: Long!
+		hash: String!
+		parentHash: String!
+		parent: Block
+		miner: Account!
+		gasLimit: Long!
+		gasUsed: Long!
+		baseFeePerGas: BigInt
+		size: Long
+		timestamp: Time!
+		transactionCount: Int!
+		transactions: [Transaction!]!
+	}
+
+	type Transaction {
+		chainId: Long!
+		hash: String!
+		blockNumber: Long!
+		blockHash: String!
+		block: Block
+		index: Int!
+		from: Account!
+		to: Account
+		value: BigInt!
+		gas: Long!
+		gasPrice: BigInt
+		maxFeePerGas: BigInt
+		maxPriorityFeePerGas: BigInt
+		effectiveGasPrice: BigInt
+		gasUsed: Long
+		input: String
+		nonce: Long!
+		type: Int!
+		status: Int
+		createdContract: Account
+		timestamp: Time!
+		logs: [Log!]!
+		tokenTransfers: [TokenTransfer!]!
+	}
+
+	type Log {
+		index: Int!
+		account: Account!
+		data: String
+		topics: [String!]!
+		blockNumber: Long!
+		blockHash: String!
+		transactionHash: String!
+		transactionIndex: Int!
+		transaction: Transaction
+	}
+
+	type Account {
+		chainId: Long!
+		address: String!
+		# Balance as last indexed, in wei
+		balance: BigInt!
+		nonce: Long!
+		isContract: Boolean!
+		transactionCount: Long!
+		transactions(first: Int = 20, after: String): TransactionConnection!
+		tokenTransfers(first: Int = 20, after: String): TokenTransferConnection!
+		# Token metadata when the account is a token contract
+		token: Token
+	}
+
+	type Token {
+		chainId: Long!
+		address: String!
+		type: String!
+		name: String
+		symbol: String
+		decimals: Int
+		totalSupply: BigInt
+		holderCount: Long!
+		transferCount: Long!
+		transfers(first: Int = 20, after: String): TokenTransferConnection!
+	}
+
+	type TokenTransfer {
+		transactionHash: String!
+		transaction: Transaction
+		logIndex: Int!
+		tokenAddress: String!
+		token: Token
+		from: Account!
+		to: Account!
+		value: BigInt
+		tokenId: BigInt
+		blockNumber: Long!
+		timestamp: Time!
+	}
+`
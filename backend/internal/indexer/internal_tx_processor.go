@@ -0,0 +1,77 @@
+package indexer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// traceInternalTransactions traces a transaction and returns the calls made
+// beneath its outermost frame, depth first.
+func traceInternalTransactions(ctx context.Context, client *blockchain.ChainClient, txHash string, blockNum int64, txIndex int, blockTime time.Time, chainID int64) ([]*models.InternalTransaction, error) {
+	frame, err := client.TraceCalls(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	base := models.InternalTransaction{
+		ChainID:          chainID,
+		TransactionHash:  txHash,
+		BlockNumber:      blockNum,
+		TransactionIndex: txIndex,
+		Timestamp:        blockTime,
+	}
+	var calls []*models.InternalTransaction
+	var walk func(frames []blockchain.CallFrame, path []string)
+	walk = func(frames []blockchain.CallFrame, path []string) {
+		for i := range frames {
+			f := &frames[i]
+			callPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+			call := base
+			call.TraceIndex = len(calls)
+			call.TraceAddress = strings.Join(callPath, ",")
+			call.CallType = strings.ToUpper(f.Type)
+			call.FromAddress = f.From.Hex()
+			call.Value = "0"
+			if f.To != nil {
+				to := f.To.Hex()
+				call.ToAddress = &to
+			}
+			if f.Value != nil {
+				call.Value = f.Value.ToInt().String()
+			}
+			if f.Gas != nil {
+				call.Gas = toInt64Ptr(int64(*f.Gas))
+			}
+			if f.GasUsed != nil {
+				call.GasUsed = toInt64Ptr(int64(*f.GasUsed))
+			}
+			if f.Error != "" {
+				call.Error = &f.Error
+			}
+			calls = append(calls, &call)
+			walk(f.Calls, callPath)
+		}
+	}
+	walk(frame.Calls, nil)
+	return calls, nil
+}
+
+// processInternalTransactions records the internal transactions of a
+// transaction on chains that trace calls.
+func (s *Service) processInternalTransactions(ctx context.Context, client *blockchain.ChainClient, txHash string, blockNum int64, txIndex int, blockTime time.Time, chainID int64) {
+	if !client.Config().TraceCalls {
+		return
+	}
+	calls, err := traceInternalTransactions(ctx, client, txHash, blockNum, txIndex, blockTime, chainID)
+	if err != nil {
+		s.logger.Warnw("Failed to trace transaction", "tx_hash", txHash, "error", err)
+		return
+	}
+	if err := s.db.InsertInternalTransactions(ctx, calls); err != nil {
+		s.logger.Warnw("Failed to insert internal transactions", "tx_hash", txHash, "error", err)
+	}
+}
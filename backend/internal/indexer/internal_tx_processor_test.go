@@ -0,0 +1,64 @@
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceInternalTransactions(t *testing.T) {
+	const txHash = "0x00000000000000000000000000000000000000000000000000000000000000aa"
+	user := common.HexToAddress("0x00000000000000000000000000000000000000A1")
+	router := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	pool := common.HexToAddress("0x00000000000000000000000000000000000000c3")
+	gas := hexutil.Uint64(30000)
+	node := blockchaintest.NewNode(t)
+	node.SetTrace(txHash, &blockchain.CallFrame{
+		Type: "CALL", From: user, To: &router, Value: (*hexutil.Big)(big.NewInt(5)),
+		Calls: []blockchain.CallFrame{
+			{Type: "STATICCALL", From: router, To: &pool, Gas: &gas},
+			{Type: "CALL", From: router, To: &pool, Value: (*hexutil.Big)(big.NewInt(3)), Calls: []blockchain.CallFrame{
+				{Type: "CALL", From: pool, To: &user, Value: (*hexutil.Big)(big.NewInt(2)), Error: "execution reverted"},
+			}},
+		},
+	})
+	blockTime := time.Unix(1700000000, 0)
+
+	calls, err := traceInternalTransactions(context.Background(), node.Client(t), txHash, 7, 1, blockTime, blockchaintest.ChainID)
+	require.NoError(t, err)
+	// The outermost frame is the transaction itself and isn't repeated
+	require.Len(t, calls, 3)
+
+	assert.Equal(t, 0, calls[0].TraceIndex)
+	assert.Equal(t, "0", calls[0].TraceAddress)
+	assert.Equal(t, "STATICCALL", calls[0].CallType)
+	assert.Equal(t, "0", calls[0].Value)
+	assert.Equal(t, int64(30000), *calls[0].Gas)
+
+	assert.Equal(t, "1", calls[1].TraceAddress)
+	assert.Equal(t, router.Hex(), calls[1].FromAddress)
+	assert.Equal(t, "3", calls[1].Value)
+	assert.Nil(t, calls[1].Error)
+
+	assert.Equal(t, 2, calls[2].TraceIndex)
+	assert.Equal(t, "1,0", calls[2].TraceAddress)
+	assert.Equal(t, user.Hex(), *calls[2].ToAddress)
+	assert.Equal(t, "execution reverted", *calls[2].Error)
+	for _, call := range calls {
+		assert.Equal(t, txHash, call.TransactionHash)
+		assert.Equal(t, int64(7), call.BlockNumber)
+		assert.Equal(t, 1, call.TransactionIndex)
+		assert.True(t, call.Timestamp.Equal(blockTime))
+	}
+
+	_, err = traceInternalTransactions(context.Background(), node.Client(t), "0xbb", 7, 2, blockTime, blockchaintest.ChainID)
+	assert.Error(t, err)
+}
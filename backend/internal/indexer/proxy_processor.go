@@ -0,0 +1,161 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"go.uber.org/zap"
+)
+
+type ProxyProcessor struct {
+	db     *database.DB
+	client *blockchain.ChainClient
+	logger *zap.SugaredLogger
+}
+
+func NewProxyProcessor(db *database.DB, client *blockchain.ChainClient, logger *zap.Logger) *ProxyProcessor {
+	return &ProxyProcessor{
+		db:     db,
+		client: client,
+		logger: logger.Sugar(),
+	}
+}
+
+// CheckContract inspects the proxy storage slots of a newly created contract
+// and records it if it turns out to be a proxy.
+func (pp *ProxyProcessor) CheckContract(ctx context.Context, address string, blockNumber int64, txHash string, chainID int64) error {
+	info, err := pp.client.DetectProxy(ctx, address, big.NewInt(blockNumber))
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return nil
+	}
+	return pp.recordProxy(ctx, address, info, blockNumber, &txHash, nil, "storage", chainID)
+}
+
+// ProcessLog handles the proxy lifecycle events emitted by EIP-1967 proxies
+// and UpgradeableBeacons. Non-proxy logs are ignored.
+func (pp *ProxyProcessor) ProcessLog(ctx context.Context, log *types.Log, chainID int64) error {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+	blockNumber := int64(log.BlockNumber)
+	txHash := log.TxHash.Hex()
+	logIndex := int(log.Index)
+
+	switch log.Topics[0] {
+	case blockchain.UpgradedEventTopic:
+		if len(log.Topics) < 2 {
+			return nil
+		}
+		impl := common.BytesToAddress(log.Topics[1].Bytes())
+
+		// An UpgradeableBeacon emits the same event, in which case every proxy
+		// pointing at it has switched implementation.
+		proxies, err := pp.db.GetProxiesByBeacon(ctx, chainID, log.Address.Hex())
+		if err != nil {
+			return err
+		}
+		if len(proxies) > 0 {
+			for _, proxy := range proxies {
+				info := &blockchain.ProxyInfo{Type: proxy.ProxyType, Implementation: &impl}
+				if err := pp.recordProxy(ctx, proxy.ProxyAddress, info, blockNumber, &txHash, &logIndex, "event", chainID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		info, err := upgradedProxy(ctx, pp.client, log.Address.Hex(), impl, blockNumber)
+		if err != nil || info == nil {
+			return err
+		}
+		return pp.recordProxy(ctx, log.Address.Hex(), info, blockNumber, &txHash, &logIndex, "event", chainID)
+
+	case blockchain.BeaconUpgradedEventTopic:
+		if len(log.Topics) < 2 {
+			return nil
+		}
+		beacon := common.BytesToAddress(log.Topics[1].Bytes())
+		impl, err := pp.client.GetBeaconImplementation(ctx, beacon.Hex(), big.NewInt(blockNumber))
+		if err != nil {
+			pp.logger.Warnw("Failed to read beacon implementation", "beacon", beacon.Hex(), "error", err)
+		}
+		info := &blockchain.ProxyInfo{Type: blockchain.ProxyTypeBeacon, Implementation: impl, Beacon: &beacon}
+		return pp.recordProxy(ctx, log.Address.Hex(), info, blockNumber, &txHash, &logIndex, "event", chainID)
+
+	case blockchain.AdminChangedEventTopic:
+		if len(log.Data) < 64 {
+			return nil
+		}
+		admin := common.BytesToAddress(log.Data[32:64])
+		return pp.db.UpdateProxyAdmin(ctx, chainID, log.Address.Hex(), admin.Hex())
+	}
+	return nil
+}
+
+// upgradedProxy describes the emitter of an Upgraded event as a proxy now
+// delegating to impl. The event alone isn't enough: UpgradeableBeacons emit it
+// too, from their constructor before any proxy points at them, so it returns
+// nil unless the emitter keeps an EIP-1967 implementation slot.
+func upgradedProxy(ctx context.Context, client *blockchain.ChainClient, address string, impl common.Address, blockNumber int64) (*blockchain.ProxyInfo, error) {
+	info, err := client.DetectProxy(ctx, address, big.NewInt(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy slots of %s: %w", address, err)
+	}
+	if info == nil || (info.Type != blockchain.ProxyTypeTransparent && info.Type != blockchain.ProxyTypeUUPS) {
+		return nil, nil
+	}
+	info.Implementation = &impl
+	return info, nil
+}
+
+func (pp *ProxyProcessor) recordProxy(ctx context.Context, address string, info *blockchain.ProxyInfo, blockNumber int64, txHash *string, logIndex *int, source string, chainID int64) error {
+	proxy := &models.ProxyContract{
+		ChainID:           chainID,
+		ProxyAddress:      address,
+		ProxyType:         info.Type,
+		DetectedAtBlock:   blockNumber,
+		LastUpgradedBlock: &blockNumber,
+	}
+	if info.Implementation != nil {
+		impl := info.Implementation.Hex()
+		proxy.ImplementationAddress = &impl
+	}
+	if info.Admin != nil {
+		admin := info.Admin.Hex()
+		proxy.AdminAddress = &admin
+	}
+	if info.Beacon != nil {
+		beacon := info.Beacon.Hex()
+		proxy.BeaconAddress = &beacon
+	}
+	if err := pp.db.UpsertProxyContract(ctx, proxy); err != nil {
+		return err
+	}
+
+	if proxy.ImplementationAddress == nil {
+		return nil
+	}
+	if err := pp.db.InsertProxyImplementation(ctx, &models.ProxyImplementation{
+		ChainID:               chainID,
+		ProxyAddress:          address,
+		ImplementationAddress: *proxy.ImplementationAddress,
+		BlockNumber:           blockNumber,
+		TransactionHash:       txHash,
+		LogIndex:              logIndex,
+		Source:                source,
+	}); err != nil {
+		return err
+	}
+
+	pp.logger.Debugw("Recorded proxy implementation", "chain_id", chainID, "proxy", address, "type", info.Type, "implementation", *proxy.ImplementationAddress, "block", blockNumber)
+	return nil
+}
@@ -0,0 +1,37 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain/blockchaintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradedProxy(t *testing.T) {
+	const proxy = "0x00000000000000000000000000000000000000A1"
+	const beacon = "0x00000000000000000000000000000000000000b2"
+	impl := common.HexToAddress("0x00000000000000000000000000000000000000c3")
+	node := blockchaintest.NewNode(t)
+	client := node.Client(t)
+
+	// A UUPS proxy keeps the implementation in the EIP-1967 slot
+	node.SetStorage(proxy, blockchain.EIP1967ImplementationSlot, common.BytesToHash(impl.Bytes()))
+	info, err := upgradedProxy(context.Background(), client, proxy, impl, 5)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, blockchain.ProxyTypeUUPS, info.Type)
+	assert.Equal(t, impl, *info.Implementation)
+
+	// An UpgradeableBeacon emits Upgraded from its constructor, but answers
+	// implementation() instead of keeping the slot: it is not a proxy
+	node.SetContract(beacon, func([]byte) ([]byte, error) {
+		return common.LeftPadBytes(impl.Bytes(), 32), nil
+	})
+	info, err = upgradedProxy(context.Background(), client, beacon, impl, 5)
+	require.NoError(t, err)
+	assert.Nil(t, info)
+}
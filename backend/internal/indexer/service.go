@@ -2,25 +2,40 @@ package indexer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
 	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/pulkyeet/eth-devstack/backend/internal/webhooks"
 	"go.uber.org/zap"
 )
 
+// MaxReorgDepth is the deepest reorg the indexer walks back through
+const MaxReorgDepth = 64
+
+// chainEventRetentionHours is how long published chain events are kept for
+// listeners catching up after a disconnect
+const chainEventRetentionHours = 24
+
 type Service struct {
 	db             *database.DB
 	chainManager   *blockchain.ChainManager
 	blockProcessor *BlockProcessor
+	webhooks       *webhooks.Matcher
 	logger         *zap.SugaredLogger
 	stopChan       chan struct{}
 	batchSize      int
+	// describedTokens holds the "chainID:address" of tokens whose metadata
+	// has been stored
+	describedTokens sync.Map
 }
 
 func NewService(db *database.DB, chainManager *blockchain.ChainManager, logger *zap.Logger) *Service {
@@ -28,6 +43,7 @@ func NewService(db *database.DB, chainManager *blockchain.ChainManager, logger *
 		db:             db,
 		chainManager:   chainManager,
 		blockProcessor: NewBlockProcessor(db, logger),
+		webhooks:       webhooks.NewMatcher(db),
 		logger:         logger.Sugar(),
 		stopChan:       make(chan struct{}),
 		batchSize:      100, // Process 100 blocks at a time
@@ -45,6 +61,7 @@ func (s *Service) Start(ctx context.Context) error {
 	for _, chainConfig := range chains {
 		go s.indexChain(ctx, chainConfig.ChainID)
 	}
+	go s.pruneChainEvents(ctx)
 
 	<-s.stopChan
 	s.logger.Info("Indexer service stopped")
@@ -55,6 +72,23 @@ func (s *Service) Stop() {
 	close(s.stopChan)
 }
 
+func (s *Service) pruneChainEvents(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			if err := s.db.PruneChainEvents(ctx, chainEventRetentionHours); err != nil {
+				s.logger.Warnw("Failed to prune chain events", "error", err)
+			}
+		}
+	}
+}
+
 func (s *Service) indexChain(ctx context.Context, chainID int64) {
 	logger := s.logger.With("chain_id", chainID)
 	logger.Info("Starting chain indexer")
@@ -65,6 +99,7 @@ func (s *Service) indexChain(ctx context.Context, chainID int64) {
 	}
 
 	txProcessor := NewTxProcessor(s.db, client, s.logger.Desugar().Sugar().Desugar())
+	proxyProcessor := NewProxyProcessor(s.db, client, s.logger.Desugar())
 
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -78,14 +113,17 @@ func (s *Service) indexChain(ctx context.Context, chainID int64) {
 			logger.Info("Stop signal received. Stopping indexer")
 			return
 		case <-ticker.C:
-			if err := s.syncChain(ctx, client, txProcessor, chainID); err != nil {
+			if err := s.syncChain(ctx, client, txProcessor, proxyProcessor, chainID); err != nil {
 				logger.Errorw("Sync error", "error", err)
+				if err := s.db.RecordSyncError(ctx, chainID, err); err != nil {
+					logger.Warnw("Failed to record sync error", "error", err)
+				}
 			}
 		}
 	}
 }
 
-func (s *Service) syncChain(ctx context.Context, client *blockchain.ChainClient, txProcessor *TxProcessor, chainID int64) error {
+func (s *Service) syncChain(ctx context.Context, client *blockchain.ChainClient, txProcessor *TxProcessor, proxyProcessor *ProxyProcessor, chainID int64) error {
 	latestChainBlock, err := client.GetLatestBlockNumber(ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to get latest block number: %w", err)
@@ -99,13 +137,23 @@ func (s *Service) syncChain(ctx context.Context, client *blockchain.ChainClient,
 	if latestDBBlock == nil {
 		startBlock = 0
 	} else {
+		reorged, err := s.handleReorg(ctx, client, latestDBBlock, chainID)
+		if err != nil {
+			return err
+		}
+		if reorged {
+			// Re-index from the fork point on the next tick
+			return nil
+		}
 		startBlock = latestDBBlock.BlockNumber + 1
 	}
 
 	blocksToSync := int64(latestChainBlock) - startBlock
 	if blocksToSync <= 0 {
+		s.updateSyncStatus(ctx, chainID, startBlock-1, int64(latestChainBlock), false)
 		return nil
 	}
+	s.updateSyncStatus(ctx, chainID, startBlock-1, int64(latestChainBlock), true)
 
 	endBlock := startBlock + int64(s.batchSize)
 	if endBlock > int64(latestChainBlock) {
@@ -115,15 +163,22 @@ func (s *Service) syncChain(ctx context.Context, client *blockchain.ChainClient,
 	s.logger.Infow("Syncing blocks", "chain_id", chainID, "from", startBlock, "to", endBlock, "total_behind", blocksToSync)
 
 	for blockNum := startBlock; blockNum <= endBlock; blockNum++ {
-		if err := s.processBlock(ctx, client, txProcessor, blockNum, chainID); err != nil {
+		if err := s.processBlock(ctx, client, txProcessor, proxyProcessor, blockNum, chainID); err != nil {
 			return fmt.Errorf("Failed to process block %d: %w", blockNum, err)
 		}
 	}
 	s.logger.Infow("Sync batch complete", "chain_id", chainID, "synced_from", startBlock, "synced_to", endBlock)
+	s.updateSyncStatus(ctx, chainID, endBlock, int64(latestChainBlock), endBlock < int64(latestChainBlock))
 	return nil
 }
 
-func (s *Service) processBlock(ctx context.Context, client *blockchain.ChainClient, txProcessor *TxProcessor, blockNum int64, chainID int64) error {
+func (s *Service) updateSyncStatus(ctx context.Context, chainID, lastSynced, latest int64, syncing bool) {
+	if err := s.db.UpdateSyncStatus(ctx, chainID, lastSynced, latest, syncing); err != nil {
+		s.logger.Warnw("Failed to update sync status", "chain_id", chainID, "error", err)
+	}
+}
+
+func (s *Service) processBlock(ctx context.Context, client *blockchain.ChainClient, txProcessor *TxProcessor, proxyProcessor *ProxyProcessor, blockNum int64, chainID int64) error {
 	block, err := client.GetBlockByNumber(ctx, big.NewInt(blockNum))
 	if err != nil {
 		return fmt.Errorf("Failed to get block: %w", err)
@@ -148,16 +203,102 @@ func (s *Service) processBlock(ctx context.Context, client *blockchain.ChainClie
 		receipt, err := client.GetTransactionReceipt(ctx, tx.Hash().Hex())
 		if err == nil && receipt != nil {
 			// Process logs
-			s.processLogs(ctx, receipt, tx, blockTime, chainID)
+			s.processLogs(ctx, client, receipt, tx, blockTime, proxyProcessor, chainID)
+
+			// Newly deployed contracts may already be initialised proxies
+			if receipt.ContractAddress != (common.Address{}) {
+				if err := proxyProcessor.CheckContract(ctx, receipt.ContractAddress.Hex(), blockNum, tx.Hash().Hex(), chainID); err != nil {
+					s.logger.Warnw("Failed to check proxy contract", "address", receipt.ContractAddress.Hex(), "error", err)
+				}
+			}
 		}
 
+		// Record calls made by contracts
+		s.processInternalTransactions(ctx, client, tx.Hash().Hex(), blockNum, txIndex, blockTime, chainID)
+
 		// Update addresses
-		s.updateAddresses(ctx, tx, blockNum, blockTime, chainID)
+		s.updateAddresses(ctx, client, tx, blockNum, blockTime, chainID)
+	}
+
+	hash := block.Hash().Hex()
+	if err := s.db.PublishChainEvent(ctx, &models.ChainEvent{
+		ChainID:     chainID,
+		EventType:   models.ChainEventBlock,
+		BlockNumber: blockNum,
+		BlockHash:   &hash,
+	}); err != nil {
+		s.logger.Warnw("Failed to publish block event", "block", blockNum, "error", err)
+	}
+	if err := s.webhooks.MatchBlock(ctx, chainID, blockNum); err != nil {
+		s.logger.Warnw("Failed to queue webhook deliveries", "block", blockNum, "error", err)
 	}
 	return nil
 }
 
-func (s *Service) processLogs(ctx context.Context, receipt *types.Receipt, tx *types.Transaction, blockTime time.Time, chainID int64) error {
+// handleReorg checks the newest indexed block against the chain. If it was
+// replaced, the index is rolled back to the last block both agree on and a
+// reorg event is published; it reports whether that happened.
+func (s *Service) handleReorg(ctx context.Context, client *blockchain.ChainClient, latest *models.Block, chainID int64) (bool, error) {
+	onChain, err := client.GetBlockByNumber(ctx, big.NewInt(latest.BlockNumber))
+	if err != nil {
+		return false, fmt.Errorf("Failed to get block %d: %w", latest.BlockNumber, err)
+	}
+	if onChain != nil && onChain.Hash().Hex() == latest.Hash {
+		return false, nil
+	}
+
+	// Walk back to the fork point
+	fork := latest.BlockNumber
+	for fork > 0 && latest.BlockNumber-fork < MaxReorgDepth {
+		stored, err := s.db.GetBlockByNumber(ctx, chainID, fork-1)
+		if err != nil {
+			return false, fmt.Errorf("Failed to get indexed block %d: %w", fork-1, err)
+		}
+		onChain, err := client.GetBlockByNumber(ctx, big.NewInt(fork-1))
+		if err != nil {
+			return false, fmt.Errorf("Failed to get block %d: %w", fork-1, err)
+		}
+		if stored == nil || (onChain != nil && onChain.Hash().Hex() == stored.Hash) {
+			break
+		}
+		fork--
+	}
+
+	newHash := ""
+	if replacement, err := client.GetBlockByNumber(ctx, big.NewInt(fork)); err == nil && replacement != nil {
+		newHash = replacement.Hash().Hex()
+	}
+	rollback, err := s.db.RollbackFromHeight(ctx, chainID, fork, newHash)
+	if err != nil {
+		return false, err
+	}
+	s.logger.Warnw("Chain reorganisation detected", "chain_id", chainID, "from_block", fork, "depth", len(rollback.Blocks))
+	for _, address := range rollback.Addresses {
+		if balance := s.nativeBalance(ctx, client, address, fork-1); balance != "" {
+			if err := s.db.UpdateAddressBalance(ctx, chainID, address, balance); err != nil {
+				s.logger.Warnw("Failed to update address balance", "address", address, "error", err)
+			}
+		}
+	}
+	s.refreshTokenBalances(ctx, client, chainID, rollback.Holdings, big.NewInt(fork-1))
+
+	reorg := events.Reorg{FromBlock: fork}
+	for _, block := range rollback.Blocks {
+		reorg.Dropped = append(reorg.Dropped, events.DroppedBlock{Number: block.BlockNumber, Hash: block.Hash})
+	}
+	payload, _ := json.Marshal(reorg)
+	if err := s.db.PublishChainEvent(ctx, &models.ChainEvent{
+		ChainID:     chainID,
+		EventType:   models.ChainEventReorg,
+		BlockNumber: fork,
+		Payload:     payload,
+	}); err != nil {
+		s.logger.Warnw("Failed to publish reorg event", "from_block", fork, "error", err)
+	}
+	return true, nil
+}
+
+func (s *Service) processLogs(ctx context.Context, client *blockchain.ChainClient, receipt *types.Receipt, tx *types.Transaction, blockTime time.Time, proxyProcessor *ProxyProcessor, chainID int64) error {
 	for _, log := range receipt.Logs {
 		logModel := &models.TransactionLog{
 			ChainID:          chainID, // Changed from s.chainID
@@ -190,20 +331,29 @@ func (s *Service) processLogs(ctx context.Context, receipt *types.Receipt, tx *t
 
 		// Detect ERC20 Transfer events
 		if len(log.Topics) == 3 && log.Topics[0].Hex() == "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
-			s.processERC20Transfer(ctx, log, tx, blockTime, chainID)
+			s.processERC20Transfer(ctx, client, log, tx, blockTime, chainID)
+		}
+
+		// Detect ERC20 Approval and ERC721/ERC1155 ApprovalForAll events
+		if len(log.Topics) == 3 && log.Topics[0].Hex() == "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925" {
+			s.processERC20Approval(ctx, log, tx, blockTime, chainID)
+		}
+		if len(log.Topics) == 3 && log.Topics[0].Hex() == "0x17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c31" {
+			s.processApprovalForAll(ctx, log, tx, blockTime, chainID)
+		}
+
+		// Track proxy upgrades
+		if err := proxyProcessor.ProcessLog(ctx, log, chainID); err != nil {
+			s.logger.Warnw("Failed to process proxy event", "address", log.Address.Hex(), "error", err)
 		}
 	}
 	return nil
 }
 
-func (s *Service) processERC20Transfer(ctx context.Context, log *types.Log, tx *types.Transaction, blockTime time.Time, chainID int64) {
-	// Ensure token exists
-	token := &models.Token{
-		ChainID: chainID, // Changed from s.chainID
-		Address: log.Address.Hex(),
-		Type:    "ERC20",
-	}
-	s.db.UpsertToken(ctx, token)
+func (s *Service) processERC20Transfer(ctx context.Context, client *blockchain.ChainClient, log *types.Log, tx *types.Transaction, blockTime time.Time, chainID int64) {
+	tokenAddress := log.Address.Hex()
+	blockNumber := new(big.Int).SetUint64(log.BlockNumber)
+	s.describeToken(ctx, client, chainID, tokenAddress, blockNumber)
 
 	// Parse transfer
 	from := common.HexToAddress(log.Topics[1].Hex())
@@ -214,7 +364,7 @@ func (s *Service) processERC20Transfer(ctx context.Context, log *types.Log, tx *
 		ChainID:         chainID, // Changed from s.chainID
 		TransactionHash: tx.Hash().Hex(),
 		LogIndex:        int(log.Index),
-		TokenAddress:    log.Address.Hex(),
+		TokenAddress:    tokenAddress,
 		FromAddress:     from.Hex(),
 		ToAddress:       to.Hex(),
 		Value:           toStringPtr(value.String()),
@@ -223,26 +373,153 @@ func (s *Service) processERC20Transfer(ctx context.Context, log *types.Log, tx *
 	}
 	s.db.InsertTokenTransfer(ctx, transfer)
 
-	// Update balances (simplified - real impl would call balanceOf)
-	if from.Hex() != "0x0000000000000000000000000000000000000000" {
-		s.db.UpsertTokenBalance(ctx, &models.TokenBalance{
-			ChainID:       chainID, // Changed from s.chainID
-			TokenAddress:  log.Address.Hex(),
-			HolderAddress: from.Hex(),
-			Balance:       "0", // Placeholder
-		})
-	}
-	if to.Hex() != "0x0000000000000000000000000000000000000000" {
-		s.db.UpsertTokenBalance(ctx, &models.TokenBalance{
-			ChainID:       chainID, // Changed from s.chainID
-			TokenAddress:  log.Address.Hex(),
-			HolderAddress: to.Hex(),
-			Balance:       value.String(),
-		})
+	// Balances are read from the token as of this block; a holder the token
+	// won't answer for keeps its last known balance
+	var holdings []*models.TokenBalance
+	for _, holder := range []common.Address{from, to} {
+		if holder != (common.Address{}) {
+			holdings = append(holdings, &models.TokenBalance{ChainID: chainID, TokenAddress: tokenAddress, HolderAddress: holder.Hex()})
+		}
 	}
+	s.readTokenBalances(ctx, client, holdings, blockNumber)
+
+	// Mints and burns change the supply
+	if from == (common.Address{}) || to == (common.Address{}) {
+		if supply, err := client.TotalSupply(ctx, tokenAddress, blockNumber); err == nil {
+			s.db.UpsertToken(ctx, &models.Token{ChainID: chainID, Address: tokenAddress, Type: "ERC20", TotalSupply: toStringPtr(supply.String())})
+		}
+	}
+
+	// Transfers seen before (e.g. on re-sync) leave the transfer count alone
+	newTransfers := 0
+	if transfer.ID != 0 {
+		newTransfers = 1
+	}
+	if err := s.db.UpdateTokenCounts(ctx, chainID, tokenAddress, newTransfers); err != nil {
+		s.logger.Warnw("Failed to update token counts", "token", tokenAddress, "error", err)
+	}
+}
+
+// readTokenBalances stores holdings' balances as the token reports them at
+// blockNumber. Holdings the token won't answer for are left as they are.
+func (s *Service) readTokenBalances(ctx context.Context, client *blockchain.ChainClient, holdings []*models.TokenBalance, blockNumber *big.Int) {
+	for _, holding := range holdings {
+		balance, err := client.BalanceOf(ctx, holding.TokenAddress, holding.HolderAddress, blockNumber)
+		if err != nil {
+			s.logger.Warnw("Failed to read token balance", "token", holding.TokenAddress, "holder", holding.HolderAddress, "error", err)
+			continue
+		}
+		holding.Balance = balance.String()
+		if err := s.db.UpsertTokenBalance(ctx, holding); err != nil {
+			s.logger.Warnw("Failed to update token balance", "token", holding.TokenAddress, "holder", holding.HolderAddress, "error", err)
+		}
+	}
+}
+
+// refreshTokenBalances re-reads the balances a reorg left stale as of the
+// fork's parent block and recounts the holders of their tokens.
+func (s *Service) refreshTokenBalances(ctx context.Context, client *blockchain.ChainClient, chainID int64, holdings []*models.TokenBalance, blockNumber *big.Int) {
+	s.readTokenBalances(ctx, client, holdings, blockNumber)
+	recounted := make(map[string]bool)
+	for _, holding := range holdings {
+		if recounted[holding.TokenAddress] {
+			continue
+		}
+		recounted[holding.TokenAddress] = true
+		if err := s.db.UpdateTokenCounts(ctx, chainID, holding.TokenAddress, 0); err != nil {
+			s.logger.Warnw("Failed to update token counts", "token", holding.TokenAddress, "error", err)
+		}
+	}
+}
+
+// describeToken stores a token's metadata the first time this process sees
+// it. Tokens already described in the index are left alone, so tokens first
+// indexed without metadata are filled in after a restart.
+func (s *Service) describeToken(ctx context.Context, client *blockchain.ChainClient, chainID int64, address string, blockNumber *big.Int) {
+	key := fmt.Sprintf("%d:%s", chainID, address)
+	if _, ok := s.describedTokens.Load(key); ok {
+		return
+	}
+	existing, err := s.db.GetToken(ctx, chainID, address)
+	if err != nil {
+		s.logger.Warnw("Failed to get token", "token", address, "error", err)
+		return
+	}
+	if existing == nil || (existing.Name == nil && existing.Symbol == nil && existing.Decimals == nil) {
+		if err := s.db.UpsertToken(ctx, DescribeToken(ctx, client, chainID, address, blockNumber)); err != nil {
+			s.logger.Warnw("Failed to upsert token", "token", address, "error", err)
+			return
+		}
+	}
+	s.describedTokens.Store(key, struct{}{})
 }
 
-func (s *Service) updateAddresses(ctx context.Context, tx *types.Transaction, blockNum int64, blockTime time.Time, chainID int64) error {
+// DescribeToken reads an ERC20 token's name, symbol, decimals and total
+// supply from the chain, as the indexer stores them.
+func DescribeToken(ctx context.Context, client *blockchain.ChainClient, chainID int64, address string, blockNumber *big.Int) *models.Token {
+	meta := client.TokenMetadata(ctx, address, blockNumber)
+	token := &models.Token{
+		ChainID:  chainID,
+		Address:  address,
+		Type:     "ERC20",
+		Name:     meta.Name,
+		Symbol:   meta.Symbol,
+		Decimals: meta.Decimals,
+	}
+	if meta.TotalSupply != nil {
+		token.TotalSupply = toStringPtr(meta.TotalSupply.String())
+	}
+	return token
+}
+
+func (s *Service) processERC20Approval(ctx context.Context, log *types.Log, tx *types.Transaction, blockTime time.Time, chainID int64) {
+	owner := common.HexToAddress(log.Topics[1].Hex())
+	spender := common.HexToAddress(log.Topics[2].Hex())
+	value := new(big.Int).SetBytes(log.Data)
+
+	approval := &models.TokenApproval{
+		ChainID:         chainID,
+		TokenAddress:    log.Address.Hex(),
+		OwnerAddress:    owner.Hex(),
+		SpenderAddress:  spender.Hex(),
+		ApprovalType:    "ALLOWANCE",
+		Value:           bigIntToStringPtr(value),
+		Approved:        value.Sign() > 0,
+		TransactionHash: tx.Hash().Hex(),
+		LogIndex:        int(log.Index),
+		BlockNumber:     int64(log.BlockNumber),
+		Timestamp:       blockTime,
+	}
+	if err := s.db.UpsertTokenApproval(ctx, approval); err != nil {
+		s.logger.Warnw("Failed to upsert approval", "tx_hash", tx.Hash().Hex(), "error", err)
+	}
+}
+
+func (s *Service) processApprovalForAll(ctx context.Context, log *types.Log, tx *types.Transaction, blockTime time.Time, chainID int64) {
+	owner := common.HexToAddress(log.Topics[1].Hex())
+	operator := common.HexToAddress(log.Topics[2].Hex())
+
+	approval := &models.TokenApproval{
+		ChainID:         chainID,
+		TokenAddress:    log.Address.Hex(),
+		OwnerAddress:    owner.Hex(),
+		SpenderAddress:  operator.Hex(),
+		ApprovalType:    "APPROVAL_FOR_ALL",
+		Approved:        new(big.Int).SetBytes(log.Data).Sign() != 0,
+		TransactionHash: tx.Hash().Hex(),
+		LogIndex:        int(log.Index),
+		BlockNumber:     int64(log.BlockNumber),
+		Timestamp:       blockTime,
+	}
+	if err := s.db.UpsertTokenApproval(ctx, approval); err != nil {
+		s.logger.Warnw("Failed to upsert approval for all", "tx_hash", tx.Hash().Hex(), "error", err)
+	}
+}
+
+// updateAddresses records the sender and recipient of tx, with their native
+// balances as of the block. An address the node won't report a balance for
+// keeps the one stored.
+func (s *Service) updateAddresses(ctx context.Context, client *blockchain.ChainClient, tx *types.Transaction, blockNum int64, blockTime time.Time, chainID int64) error {
 	signer := types.LatestSignerForChainID(big.NewInt(chainID))
 	from, _ := types.Sender(signer, tx)
 
@@ -250,7 +527,7 @@ func (s *Service) updateAddresses(ctx context.Context, tx *types.Transaction, bl
 	fromAddr := &models.Address{
 		ChainID:        chainID, // Changed from s.chainID
 		Address:        from.Hex(),
-		Balance:        0,
+		Balance:        s.nativeBalance(ctx, client, from.Hex(), blockNum),
 		Nonce:          int64(tx.Nonce()),
 		FirstSeenBlock: &blockNum,
 		LastSeenBlock:  &blockNum,
@@ -264,7 +541,7 @@ func (s *Service) updateAddresses(ctx context.Context, tx *types.Transaction, bl
 		toAddr := &models.Address{
 			ChainID:        chainID, // Changed from s.chainID
 			Address:        tx.To().Hex(),
-			Balance:        0,
+			Balance:        s.nativeBalance(ctx, client, tx.To().Hex(), blockNum),
 			Nonce:          0,
 			FirstSeenBlock: &blockNum,
 			LastSeenBlock:  &blockNum,
@@ -276,3 +553,14 @@ func (s *Service) updateAddresses(ctx context.Context, tx *types.Transaction, bl
 
 	return nil
 }
+
+// nativeBalance is address's balance in wei at blockNum, or empty if the node
+// won't say.
+func (s *Service) nativeBalance(ctx context.Context, client *blockchain.ChainClient, address string, blockNum int64) string {
+	balance, err := client.GetBalance(ctx, address, big.NewInt(blockNum))
+	if err != nil {
+		s.logger.Warnw("Failed to read balance", "address", address, "block", blockNum, "error", err)
+		return ""
+	}
+	return balance.String()
+}
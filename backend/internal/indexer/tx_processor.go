@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
 	"github.com/pulkyeet/eth-devstack/backend/internal/database"
@@ -57,6 +58,11 @@ func (tp *TxProcessor) ProcessTransaction(ctx context.Context, tx *types.Transac
 		Timestamp:        blockTime,
 	}
 	
+	v, r, sig := tx.RawSignatureValues()
+	txModel.V = toStringPtr(hexutil.EncodeBig(v))
+	txModel.R = toStringPtr(hexutil.EncodeBig(r))
+	txModel.S = toStringPtr(hexutil.EncodeBig(sig))
+
 	if tx.To() != nil {
 		to := tx.To().Hex()
 		txModel.ToAddress = &to
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Alert rule types
+const (
+	AlertRuleBalanceBelow = "balance_below"
+	AlertRuleEventEmitted = "event_emitted"
+	AlertRuleFailedTxRate = "failed_tx_rate"
+	AlertRuleNoNewBlock   = "no_new_block"
+)
+
+// Rule states, and the transitions recorded as alert events
+const (
+	AlertStateOK       = "ok"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// AlertRule is a condition on one chain. Params hold the type-specific
+// settings; WebhookID, if set, receives every firing and resolution.
+type AlertRule struct {
+	ID              int64           `json:"id" db:"id"`
+	Name            string          `json:"name" db:"name"`
+	Description     *string         `json:"description,omitempty" db:"description"`
+	ChainID         int64           `json:"chain_id" db:"chain_id"`
+	RuleType        string          `json:"type" db:"rule_type"`
+	Params          json.RawMessage `json:"params" db:"params"`
+	WebhookID       *int64          `json:"webhook_id,omitempty" db:"webhook_id"`
+	Enabled         bool            `json:"enabled" db:"enabled"`
+	State           string          `json:"state" db:"state"`
+	StateSince      *time.Time      `json:"state_since,omitempty" db:"state_since"`
+	LastEvaluatedAt *time.Time      `json:"last_evaluated_at,omitempty" db:"last_evaluated_at"`
+	LastError       *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// AlertEvent records a rule firing or resolving, with what was observed.
+type AlertEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	RuleID    int64           `json:"rule_id" db:"rule_id"`
+	ChainID   int64           `json:"chain_id" db:"chain_id"`
+	State     string          `json:"state" db:"state"`
+	Details   json.RawMessage `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
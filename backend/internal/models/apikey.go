@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// APITier sets the rate limit and daily quota of the keys on it.
+type APITier struct {
+	Name              string  `json:"name" db:"name"`
+	Description       *string `json:"description,omitempty" db:"description"`
+	RequestsPerMinute int     `json:"requests_per_minute" db:"requests_per_minute"`
+	Burst             int     `json:"burst" db:"burst"`
+	DailyQuota        *int64  `json:"daily_quota" db:"daily_quota"`
+}
+
+// APIKey identifies an API client. Key is only set when the key is created;
+// afterwards it is known by its Prefix.
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Key        string     `json:"key,omitempty" db:"-"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Tier       *APITier   `json:"tier" db:"-"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// Usable reports whether the key authenticates requests at t.
+func (k *APIKey) Usable(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
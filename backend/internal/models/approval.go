@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type TokenApproval struct {
+	ID             int64   `json:"id" db:"id"`
+	ChainID        int64   `json:"chain_id" db:"chain_id"`
+	TokenAddress   string  `json:"token_address" db:"token_address"`
+	OwnerAddress   string  `json:"owner_address" db:"owner_address"`
+	SpenderAddress string  `json:"spender_address" db:"spender_address"`
+	ApprovalType   string  `json:"approval_type" db:"approval_type"`
+	Value          *string `json:"value,omitempty" db:"value"`
+	// ValueSource says whether an allowance's Value was read from the token
+	// or is the amount last approved, which transferFrom spends silently
+	ValueSource     string    `json:"value_source,omitempty" db:"-"`
+	Approved        bool      `json:"approved" db:"approved"`
+	TransactionHash string    `json:"transaction_hash" db:"transaction_hash"`
+	LogIndex        int       `json:"log_index" db:"log_index"`
+	BlockNumber     int64     `json:"block_number" db:"block_number"`
+	Timestamp       time.Time `json:"timestamp" db:"timestamp"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -25,4 +25,7 @@ type Block struct {
 	BaseFeePerGas    *string   `json:"base_fee_per_gas,omitempty" db:"base_fee_per_gas"`
 	TxCount          int       `json:"tx_count" db:"tx_count"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+
+	// Address labels, populated by the API
+	MinerLabels []string `json:"miner_labels,omitempty" db:"-"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ChainEventBlock = "block"
+	ChainEventReorg = "reorg"
+)
+
+// ChainEvent records that the indexer finished a block or rolled back a
+// reorg. Payload holds type-specific detail such as a reorg's dropped blocks.
+type ChainEvent struct {
+	ID          int64           `json:"id" db:"id"`
+	ChainID     int64           `json:"chain_id" db:"chain_id"`
+	EventType   string          `json:"event_type" db:"event_type"`
+	BlockNumber int64           `json:"block_number" db:"block_number"`
+	BlockHash   *string         `json:"block_hash,omitempty" db:"block_hash"`
+	Payload     json.RawMessage `json:"payload,omitempty" db:"payload"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Sources a gas estimate can be computed from
+const (
+	GasSourceFeeHistory = "fee_history"
+	GasSourceIndexed    = "indexed_blocks"
+	GasSourceNode       = "node"
+)
+
+// Base fee trends
+const (
+	BaseFeeRising  = "rising"
+	BaseFeeFalling = "falling"
+	BaseFeeStable  = "stable"
+)
+
+// GasEstimate holds slow, standard and fast fee suggestions for a chain.
+// Legacy chains are priced with GasPrice only; EIP-1559 chains also carry
+// the base fee and the tiers' fee caps. Amounts are decimal strings in wei.
+type GasEstimate struct {
+	ChainID      int64    `json:"chain_id"`
+	Oracle       string   `json:"oracle"`
+	Source       string   `json:"source"`
+	BlockNumber  int64    `json:"block_number"`
+	BaseFee      *string  `json:"base_fee,omitempty"`
+	NextBaseFee  *string  `json:"next_base_fee,omitempty"`
+	BaseFeeTrend string   `json:"base_fee_trend,omitempty"`
+	NodeGasPrice *string  `json:"node_gas_price,omitempty"`
+	Slow         *GasTier `json:"slow"`
+	Standard     *GasTier `json:"standard"`
+	Fast         *GasTier `json:"fast"`
+}
+
+type GasTier struct {
+	GasPrice             string  `json:"gas_price"`
+	MaxPriorityFeePerGas *string `json:"max_priority_fee_per_gas,omitempty"`
+	MaxFeePerGas         *string `json:"max_fee_per_gas,omitempty"`
+}
+
+// GasPricePoint summarises the gas prices paid in one hour or day.
+type GasPricePoint struct {
+	BucketStart    time.Time `json:"bucket_start" db:"bucket_start"`
+	BlockCount     int64     `json:"block_count" db:"block_count"`
+	TxCount        int64     `json:"tx_count" db:"tx_count"`
+	AvgBaseFee     *string   `json:"avg_base_fee,omitempty" db:"avg_base_fee"`
+	MinGasPrice    *string   `json:"min_gas_price,omitempty" db:"min_gas_price"`
+	MedianGasPrice *string   `json:"median_gas_price,omitempty" db:"median_gas_price"`
+	AvgGasPrice    *string   `json:"avg_gas_price,omitempty" db:"avg_gas_price"`
+	MaxGasPrice    *string   `json:"max_gas_price,omitempty" db:"max_gas_price"`
+}
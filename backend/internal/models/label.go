@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// AddressLabel tags an address on a chain. An address may carry several
+// labels.
+type AddressLabel struct {
+	ID          int64     `json:"id" db:"id"`
+	ChainID     int64     `json:"chain_id" db:"chain_id"`
+	Address     string    `json:"address" db:"address"`
+	Label       string    `json:"label" db:"label"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Watchlist is a named group of addresses on one chain.
+type Watchlist struct {
+	ID          int64               `json:"id" db:"id"`
+	ChainID     int64               `json:"chain_id" db:"chain_id"`
+	Name        string              `json:"name" db:"name"`
+	Description *string             `json:"description,omitempty" db:"description"`
+	Addresses   []*WatchlistAddress `json:"addresses,omitempty" db:"-"`
+	CreatedAt   time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+type WatchlistAddress struct {
+	Address string    `json:"address" db:"address"`
+	AddedAt time.Time `json:"added_at" db:"added_at"`
+
+	// Populated by the API
+	Labels []string `json:"labels,omitempty" db:"-"`
+}
+
+// Watchlist activity types
+const (
+	ActivityTransaction   = "transaction"
+	ActivityTokenTransfer = "token_transfer"
+)
+
+// WatchlistActivity is one entry of a watchlist's feed: a transaction or a
+// token transfer involving at least one member.
+type WatchlistActivity struct {
+	Type            string         `json:"type"`
+	BlockNumber     int64          `json:"block_number"`
+	TransactionHash string         `json:"transaction_hash"`
+	Timestamp       time.Time      `json:"timestamp"`
+	Transaction     *Transaction   `json:"transaction,omitempty"`
+	TokenTransfer   *TokenTransfer `json:"token_transfer,omitempty"`
+}
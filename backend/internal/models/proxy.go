@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ContractABI struct {
+	ID        int64           `json:"id" db:"id"`
+	ChainID   int64           `json:"chain_id" db:"chain_id"`
+	Address   string          `json:"address" db:"address"`
+	Name      *string         `json:"name,omitempty" db:"name"`
+	ABI       json.RawMessage `json:"abi" db:"abi"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type ProxyContract struct {
+	ID                    int64     `json:"id" db:"id"`
+	ChainID               int64     `json:"chain_id" db:"chain_id"`
+	ProxyAddress          string    `json:"proxy_address" db:"proxy_address"`
+	ProxyType             string    `json:"proxy_type" db:"proxy_type"`
+	ImplementationAddress *string   `json:"implementation_address,omitempty" db:"implementation_address"`
+	AdminAddress          *string   `json:"admin_address,omitempty" db:"admin_address"`
+	BeaconAddress         *string   `json:"beacon_address,omitempty" db:"beacon_address"`
+	DetectedAtBlock       int64     `json:"detected_at_block" db:"detected_at_block"`
+	LastUpgradedBlock     *int64    `json:"last_upgraded_block,omitempty" db:"last_upgraded_block"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type ProxyImplementation struct {
+	ID                    int64     `json:"id" db:"id"`
+	ChainID               int64     `json:"chain_id" db:"chain_id"`
+	ProxyAddress          string    `json:"proxy_address" db:"proxy_address"`
+	ImplementationAddress string    `json:"implementation_address" db:"implementation_address"`
+	BlockNumber           int64     `json:"block_number" db:"block_number"`
+	TransactionHash       *string   `json:"transaction_hash,omitempty" db:"transaction_hash"`
+	LogIndex              *int      `json:"log_index,omitempty" db:"log_index"`
+	Source                string    `json:"source" db:"source"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Entries of the top accounts, contracts and tokens lists. Rank starts at 1
+// in the order requested.
+
+type TopAccount struct {
+	Rank       int64  `json:"rank" db:"rank"`
+	Address    string `json:"address" db:"address"`
+	Balance    string `json:"balance" db:"balance"`
+	TxCount    int64  `json:"tx_count" db:"tx_count"`
+	IsContract bool   `json:"is_contract" db:"is_contract"`
+
+	// Populated by the API
+	Labels []string `json:"labels,omitempty" db:"-"`
+}
+
+// TopContract is a contract's activity over a time window.
+type TopContract struct {
+	Rank          int64  `json:"rank" db:"rank"`
+	Address       string `json:"address" db:"address"`
+	TxCount       int64  `json:"tx_count" db:"tx_count"`
+	UniqueCallers int64  `json:"unique_callers" db:"unique_callers"`
+	GasUsed       string `json:"gas_used" db:"gas_used"`
+
+	// Populated by the API
+	Labels []string `json:"labels,omitempty" db:"-"`
+}
+
+type TopToken struct {
+	Rank          int64   `json:"rank" db:"rank"`
+	Address       string  `json:"address" db:"address"`
+	Type          string  `json:"type" db:"type"`
+	Name          *string `json:"name,omitempty" db:"name"`
+	Symbol        *string `json:"symbol,omitempty" db:"symbol"`
+	Decimals      *int    `json:"decimals,omitempty" db:"decimals"`
+	TotalSupply   *string `json:"total_supply,omitempty" db:"total_supply"`
+	HolderCount   int64   `json:"holder_count" db:"holder_count"`
+	TransferCount int64   `json:"transfer_count" db:"transfer_count"`
+
+	// TotalSupply adjusted for Decimals, populated by the API
+	TotalSupplyFormatted *string `json:"total_supply_formatted,omitempty" db:"-"`
+}
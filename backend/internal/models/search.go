@@ -0,0 +1,23 @@
+package models
+
+// Search result types
+const (
+	SearchBlock       = "block"
+	SearchTransaction = "transaction"
+	SearchAddress     = "address"
+	SearchToken       = "token"
+	SearchLabel       = "label"
+	SearchName        = "name"
+)
+
+// SearchResult is one ranked match, higher scores first. Title and Value are
+// enough to show a suggestion and link to it; Result is the matched entity
+// and is left out of autocomplete suggestions.
+type SearchResult struct {
+	Type    string      `json:"type"`
+	ChainID int64       `json:"chain_id"`
+	Score   float64     `json:"score"`
+	Title   string      `json:"title"`
+	Value   string      `json:"value"`
+	Result  interface{} `json:"result,omitempty"`
+}
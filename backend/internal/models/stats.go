@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Stats rollup periods
+const (
+	StatsPeriodHour = "hour"
+	StatsPeriodDay  = "day"
+)
+
+// ChainStats aggregates a chain's activity over one hour or day starting at
+// BucketStart (UTC). Amounts are decimal strings in wei; AvgBlockTime is in
+// seconds and unset for buckets with a single block.
+type ChainStats struct {
+	ChainID            int64     `json:"chain_id" db:"chain_id"`
+	Period             string    `json:"period" db:"period"`
+	BucketStart        time.Time `json:"bucket_start" db:"bucket_start"`
+	BlockCount         int64     `json:"block_count" db:"block_count"`
+	TxCount            int64     `json:"tx_count" db:"tx_count"`
+	FailedTxCount      int64     `json:"failed_tx_count" db:"failed_tx_count"`
+	ActiveAddresses    int64     `json:"active_addresses" db:"active_addresses"`
+	UniqueSenders      int64     `json:"unique_senders" db:"unique_senders"`
+	UniqueReceivers    int64     `json:"unique_receivers" db:"unique_receivers"`
+	NewContracts       int64     `json:"new_contracts" db:"new_contracts"`
+	GasUsed            string    `json:"gas_used" db:"gas_used"`
+	TotalValue         string    `json:"total_value" db:"total_value"`
+	AvgGasPrice        *string   `json:"avg_gas_price,omitempty" db:"avg_gas_price"`
+	AvgBlockTime       *float64  `json:"avg_block_time,omitempty" db:"avg_block_time"`
+	TokenTransferCount int64     `json:"token_transfer_count" db:"token_transfer_count"`
+	TokensTransferred  int64     `json:"tokens_transferred" db:"tokens_transferred"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// AddressSummary collects an address's balances and activity on one chain.
+// Amounts are decimal strings in wei.
+type AddressSummary struct {
+	ChainID    int64  `json:"chain_id"`
+	Address    string `json:"address"`
+	IsContract bool   `json:"is_contract"`
+	Balance    string `json:"balance"`
+	// BalanceSource says whether Balance was read from the node or is the
+	// last indexed value
+	BalanceSource    string          `json:"balance_source"`
+	BalanceFormatted *string         `json:"balance_formatted,omitempty"`
+	FirstActivity    *ActivityRef    `json:"first_activity,omitempty"`
+	LastActivity     *ActivityRef    `json:"last_activity,omitempty"`
+	TxCounts         TxCounts        `json:"tx_counts"`
+	GasSpent         GasSpent        `json:"gas_spent"`
+	TokenHoldings    []*TokenHolding `json:"token_holdings"`
+	Counterparties   []*Counterparty `json:"counterparties"`
+
+	// Populated by the API
+	Labels []string `json:"labels,omitempty"`
+}
+
+// PortfolioChain is an address's summary on one chain of a portfolio.
+type PortfolioChain struct {
+	Name         string `json:"name"`
+	NativeSymbol string `json:"native_symbol"`
+	*AddressSummary
+}
+
+// Balance sources
+const (
+	BalanceSourceNode    = "node"
+	BalanceSourceIndexed = "indexed"
+)
+
+// ActivityRef points at the transaction of an address's first or last
+// activity.
+type ActivityRef struct {
+	BlockNumber     int64     `json:"block_number"`
+	TransactionHash string    `json:"transaction_hash"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// TxCounts breaks down the transactions an address sent or received. Total
+// counts self-transfers once; Success and Failed leave out transactions
+// without a receipt status.
+type TxCounts struct {
+	Total    int64 `json:"total"`
+	Sent     int64 `json:"sent"`
+	Received int64 `json:"received"`
+	Success  int64 `json:"success"`
+	Failed   int64 `json:"failed"`
+}
+
+// GasSpent totals the gas of the transactions an address sent.
+type GasSpent struct {
+	GasUsed           string  `json:"gas_used"`
+	FeesPaid          string  `json:"fees_paid"`
+	FeesPaidFormatted *string `json:"fees_paid_formatted,omitempty"`
+}
+
+// Counterparty is an address an address transacted with.
+type Counterparty struct {
+	Address  string `json:"address"`
+	TxCount  int64  `json:"tx_count"`
+	Sent     int64  `json:"sent"`
+	Received int64  `json:"received"`
+
+	// Populated by the API
+	Labels []string `json:"labels,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SyncStatus is the indexer's progress on a chain. LatestBlockAt is when the
+// chain head last advanced.
+type SyncStatus struct {
+	ChainID         int64      `json:"chain_id" db:"chain_id"`
+	LastSyncedBlock int64      `json:"last_synced_block" db:"last_synced_block"`
+	LatestBlock     int64      `json:"latest_block" db:"latest_block"`
+	IsSyncing       bool       `json:"is_syncing" db:"is_syncing"`
+	LastSyncTime    *time.Time `json:"last_sync_time,omitempty" db:"last_sync_time"`
+	LatestBlockAt   *time.Time `json:"latest_block_at,omitempty" db:"latest_block_at"`
+	ErrorCount      int        `json:"error_count" db:"error_count"`
+	LastError       *string    `json:"last_error,omitempty" db:"last_error"`
+	LastErrorTime   *time.Time `json:"last_error_time,omitempty" db:"last_error_time"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Seconds since the head advanced by the database clock, for staleness
+	// checks that shouldn't depend on the caller's clock
+	LatestBlockAge *float64 `json:"latest_block_age_seconds,omitempty" db:"-"`
+}
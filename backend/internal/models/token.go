@@ -15,6 +15,9 @@ type Token struct {
 	TransferCount int64     `json:"transfer_count" db:"transfer_count"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// TotalSupply adjusted for Decimals, populated by the API
+	TotalSupplyFormatted *string `json:"total_supply_formatted,omitempty" db:"-"`
 }
 
 type TokenTransfer struct {
@@ -30,6 +33,20 @@ type TokenTransfer struct {
 	BlockNumber     int64     `json:"block_number" db:"block_number"`
 	Timestamp       time.Time `json:"timestamp" db:"timestamp"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+
+	// Token metadata, populated when the query joins the tokens table
+	TokenType     *string `json:"token_type,omitempty" db:"-"`
+	TokenName     *string `json:"token_name,omitempty" db:"-"`
+	TokenSymbol   *string `json:"token_symbol,omitempty" db:"-"`
+	TokenDecimals *int    `json:"token_decimals,omitempty" db:"-"`
+
+	// Value adjusted for TokenDecimals, populated by the API
+	ValueFormatted *string `json:"value_formatted,omitempty" db:"-"`
+
+	// Address labels, populated by the API
+	TokenLabels []string `json:"token_labels,omitempty" db:"-"`
+	FromLabels  []string `json:"from_labels,omitempty" db:"-"`
+	ToLabels    []string `json:"to_labels,omitempty" db:"-"`
 }
 
 type TokenBalance struct {
@@ -39,4 +56,18 @@ type TokenBalance struct {
 	HolderAddress string    `json:"holder_address" db:"holder_address"`
 	Balance       string    `json:"balance" db:"balance"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// Populated by the API when listing holders
+	BalanceFormatted *string  `json:"balance_formatted,omitempty" db:"-"`
+	Percentage       *float64 `json:"percentage,omitempty" db:"-"`
+}
+
+// TokenHolding is a token held by an address, with its balance.
+type TokenHolding struct {
+	Token
+	Balance string `json:"balance" db:"balance"`
+	// BalanceSource says whether Balance was read from the token or is the
+	// last indexed value
+	BalanceSource    string  `json:"balance_source,omitempty" db:"-"`
+	BalanceFormatted *string `json:"balance_formatted,omitempty" db:"-"`
 }
\ No newline at end of file
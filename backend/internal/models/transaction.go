@@ -25,6 +25,35 @@ type Transaction struct {
 	EffectiveGasPrice    *string   `json:"effective_gas_price,omitempty" db:"effective_gas_price"`
 	ContractAddress      *string   `json:"contract_address,omitempty" db:"contract_address"`
 	LogsBloom            *string   `json:"logs_bloom,omitempty" db:"logs_bloom"`
+	V                    *string   `json:"v,omitempty" db:"v"`
+	R                    *string   `json:"r,omitempty" db:"r"`
+	S                    *string   `json:"s,omitempty" db:"s"`
 	Timestamp            time.Time `json:"timestamp" db:"timestamp"`
 	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+
+	// Address labels, populated by the API
+	FromLabels     []string `json:"from_labels,omitempty" db:"-"`
+	ToLabels       []string `json:"to_labels,omitempty" db:"-"`
+	ContractLabels []string `json:"contract_labels,omitempty" db:"-"`
+}
+
+// InternalTransaction is a call made by a contract while executing a
+// transaction, from a callTracer trace.
+type InternalTransaction struct {
+	ID               int64     `json:"id" db:"id"`
+	ChainID          int64     `json:"chain_id" db:"chain_id"`
+	TransactionHash  string    `json:"transaction_hash" db:"transaction_hash"`
+	TraceIndex       int       `json:"trace_index" db:"trace_index"`
+	TraceAddress     string    `json:"trace_address" db:"trace_address"`
+	CallType         string    `json:"call_type" db:"call_type"`
+	FromAddress      string    `json:"from_address" db:"from_address"`
+	ToAddress        *string   `json:"to_address,omitempty" db:"to_address"`
+	Value            string    `json:"value" db:"value"`
+	Gas              *int64    `json:"gas,omitempty" db:"gas"`
+	GasUsed          *int64    `json:"gas_used,omitempty" db:"gas_used"`
+	Error            *string   `json:"error,omitempty" db:"error"`
+	BlockNumber      int64     `json:"block_number" db:"block_number"`
+	TransactionIndex int       `json:"transaction_index" db:"transaction_index"`
+	Timestamp        time.Time `json:"timestamp" db:"timestamp"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
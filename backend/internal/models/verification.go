@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type ContractVerification struct {
+	ID                   int64     `json:"id" db:"id"`
+	GUID                 string    `json:"guid" db:"guid"`
+	ChainID              int64     `json:"chain_id" db:"chain_id"`
+	Address              string    `json:"address" db:"address"`
+	ContractName         string    `json:"contract_name" db:"contract_name"`
+	CompilerVersion      string    `json:"compiler_version" db:"compiler_version"`
+	CodeFormat           string    `json:"code_format" db:"code_format"`
+	SourceCode           string    `json:"source_code" db:"source_code"`
+	OptimizationUsed     bool      `json:"optimization_used" db:"optimization_used"`
+	Runs                 int       `json:"runs" db:"runs"`
+	EVMVersion           *string   `json:"evm_version,omitempty" db:"evm_version"`
+	ConstructorArguments *string   `json:"constructor_arguments,omitempty" db:"constructor_arguments"`
+	LicenseType          *string   `json:"license_type,omitempty" db:"license_type"`
+	Status               string    `json:"status" db:"status"`
+	Message              *string   `json:"message,omitempty" db:"message"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type VerifiedContract struct {
+	ID                   int64     `json:"id" db:"id"`
+	ChainID              int64     `json:"chain_id" db:"chain_id"`
+	Address              string    `json:"address" db:"address"`
+	ContractName         string    `json:"contract_name" db:"contract_name"`
+	CompilerVersion      string    `json:"compiler_version" db:"compiler_version"`
+	CodeFormat           string    `json:"code_format" db:"code_format"`
+	SourceCode           string    `json:"source_code" db:"source_code"`
+	OptimizationUsed     bool      `json:"optimization_used" db:"optimization_used"`
+	Runs                 int       `json:"runs" db:"runs"`
+	EVMVersion           *string   `json:"evm_version,omitempty" db:"evm_version"`
+	ConstructorArguments *string   `json:"constructor_arguments,omitempty" db:"constructor_arguments"`
+	LicenseType          *string   `json:"license_type,omitempty" db:"license_type"`
+	VerifiedAt           time.Time `json:"verified_at" db:"verified_at"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types a webhook can be notified of
+const (
+	WebhookEventTransaction   = "transaction"
+	WebhookEventTokenTransfer = "token_transfer"
+	WebhookEventLog           = "log"
+)
+
+// Delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+	// Cancelled deliveries were for a block dropped by a reorg
+	WebhookDeliveryCancelled = "cancelled"
+)
+
+// Webhook is a registered receiver and the activity it wants to hear about.
+// A nil ChainID matches every chain and empty EventTypes match every type.
+// Secret signs deliveries and is only shown when the webhook is created.
+type Webhook struct {
+	ID          int64     `json:"id" db:"id"`
+	ChainID     *int64    `json:"chain_id,omitempty" db:"chain_id"`
+	URL         string    `json:"url" db:"url"`
+	Secret      string    `json:"secret,omitempty" db:"secret"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	Addresses   []string  `json:"addresses" db:"addresses"`
+	Tokens      []string  `json:"tokens" db:"tokens"`
+	Topics      []string  `json:"topics" db:"topics"`
+	MinValue    *string   `json:"min_value,omitempty" db:"min_value"`
+	EventTypes  []string  `json:"event_types" db:"event_types"`
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery is one block's matching activity, or one alert
+// notification, queued for a webhook along with the outcome of its latest
+// attempt.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      int64           `json:"webhook_id" db:"webhook_id"`
+	ChainID        int64           `json:"chain_id" db:"chain_id"`
+	BlockNumber    *int64          `json:"block_number,omitempty" db:"block_number"`
+	BlockHash      *string         `json:"block_hash,omitempty" db:"block_hash"`
+	AlertEventID   *int64          `json:"alert_event_id,omitempty" db:"alert_event_id"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookDeadLetter is a delivery that failed every attempt.
+type WebhookDeadLetter struct {
+	ID             int64           `json:"id" db:"id"`
+	DeliveryID     int64           `json:"delivery_id" db:"delivery_id"`
+	WebhookID      int64           `json:"webhook_id" db:"webhook_id"`
+	ChainID        int64           `json:"chain_id" db:"chain_id"`
+	BlockNumber    *int64          `json:"block_number,omitempty" db:"block_number"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,140 @@
+// Package ratelimit implements token-bucket rate limits and daily quotas.
+// Each API process keeps its own buckets, so rate limits apply per process;
+// quotas are counted in shared state, so they hold across processes.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled are dropped.
+const sweepInterval = time.Minute
+
+// Limit is a bucket that holds Burst tokens and refills at PerMinute tokens
+// a minute. Each request takes a token.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of taking a token, for the X-RateLimit-* headers.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available, when none was
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds the tokens earned since the bucket was last updated.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*b.limit.rate())
+	b.updated = now
+}
+
+func (b *bucket) untilFull() time.Duration {
+	return seconds((float64(b.limit.Burst) - b.tokens) / b.limit.rate())
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Limiter keeps a bucket per key, such as an API key or client IP. Buckets
+// that have refilled are forgotten, so idle clients cost nothing.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from key's bucket. A bucket whose limit changed, such
+// as a key moved to another tier, starts again full.
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	b := l.buckets[key]
+	if b == nil || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.rate())
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = b.untilFull()
+	return result
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Counter keeps each key's request count per UTC day in state shared by
+// every API process; *database.DB implements it.
+type Counter interface {
+	// TakeAPIKeyRequest counts a request against key's quota of limit
+	// requests on day unless it is used up, returning the day's count
+	TakeAPIKeyRequest(ctx context.Context, key int64, day time.Time, limit int64) (int64, bool, error)
+}
+
+// Quota enforces daily request quotas per key, resetting at midnight UTC.
+type Quota struct {
+	counter Counter
+	now     func() time.Time
+}
+
+func NewQuota(counter Counter) *Quota {
+	return &Quota{counter: counter, now: time.Now}
+}
+
+// Take counts a request against key's daily quota of limit requests,
+// unless it is used up. It returns the requests left today and how long
+// until the quota resets.
+func (q *Quota) Take(ctx context.Context, key int64, limit int64) (ok bool, remaining int64, reset time.Duration, err error) {
+	now := q.now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	reset = day.Add(24 * time.Hour).Sub(now)
+	used, ok, err := q.counter.TakeAPIKeyRequest(ctx, key, day, limit)
+	if err != nil {
+		return false, 0, reset, err
+	}
+	return ok, max(limit-used, 0), reset, nil
+}
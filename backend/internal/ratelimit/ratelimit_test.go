@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a settable time source.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newClock() *clock {
+	return &clock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+}
+
+func newTestLimiter(c *clock) *Limiter {
+	l := NewLimiter()
+	l.now = c.now
+	return l
+}
+
+// counter is a Counter shared by the quotas built on it, like the database.
+type counter struct {
+	counts map[string]int64
+	err    error
+}
+
+func (c *counter) TakeAPIKeyRequest(_ context.Context, key int64, day time.Time, limit int64) (int64, bool, error) {
+	if c.err != nil {
+		return 0, false, c.err
+	}
+	k := fmt.Sprintf("%d/%s", key, day.Format(time.DateOnly))
+	if c.counts[k] >= limit {
+		return c.counts[k], false, nil
+	}
+	c.counts[k]++
+	return c.counts[k], true, nil
+}
+
+func newTestQuota(c *clock, counts *counter) *Quota {
+	q := NewQuota(counts)
+	q.now = c.now
+	return q
+}
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c)
+	limit := Limit{PerMinute: 60, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		r := l.Allow("ip", limit)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, 3, r.Limit)
+		assert.Equal(t, i, r.Remaining)
+	}
+	r := l.Allow("ip", limit)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+	assert.Equal(t, time.Second, r.RetryAfter)
+	assert.Equal(t, 3*time.Second, r.Reset)
+
+	// Other keys have their own bucket
+	assert.True(t, l.Allow("other", limit).Allowed)
+
+	c.advance(time.Second)
+	assert.True(t, l.Allow("ip", limit).Allowed)
+	assert.False(t, l.Allow("ip", limit).Allowed)
+
+	c.advance(time.Hour)
+	r = l.Allow("ip", limit)
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 2, r.Remaining)
+}
+
+func TestLimiterNewLimitStartsFull(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c)
+	assert.True(t, l.Allow("key", Limit{PerMinute: 1, Burst: 1}).Allowed)
+	assert.False(t, l.Allow("key", Limit{PerMinute: 1, Burst: 1}).Allowed)
+	r := l.Allow("key", Limit{PerMinute: 600, Burst: 10})
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 9, r.Remaining)
+}
+
+func TestLimiterSweepsFullBuckets(t *testing.T) {
+	c := newClock()
+	l := newTestLimiter(c)
+	limit := Limit{PerMinute: 60, Burst: 10}
+	l.Allow("a", limit)
+	l.Allow("b", limit)
+	assert.Len(t, l.buckets, 2)
+
+	c.advance(sweepInterval)
+	l.Allow("c", limit)
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "c")
+}
+
+func TestQuota(t *testing.T) {
+	c := newClock()
+	counts := &counter{counts: map[string]int64{}}
+	q := newTestQuota(c, counts)
+	ctx := context.Background()
+
+	ok, remaining, reset, err := q.Take(ctx, 1, 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), remaining)
+	assert.Equal(t, 20*time.Hour+55*time.Minute+55*time.Second, reset)
+
+	// Another process counts against the same quota
+	ok, remaining, _, _ = newTestQuota(c, counts).Take(ctx, 1, 2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), remaining)
+	ok, remaining, _, _ = q.Take(ctx, 1, 2)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), remaining)
+
+	ok, _, _, _ = q.Take(ctx, 2, 2)
+	assert.True(t, ok)
+
+	// Quotas reset at midnight UTC
+	c.advance(21 * time.Hour)
+	ok, remaining, _, _ = q.Take(ctx, 1, 2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), remaining)
+
+	counts.err = errors.New("connection refused")
+	_, _, _, err = q.Take(ctx, 1, 2)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package responses
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -25,11 +26,37 @@ type Meta struct {
 	Version   string `json:"version"`
 }
 
+// PaginationMeta describes a page of a list. Keyset pages carry opaque
+// cursors for the neighbouring pages; pages of a counted list in the legacy
+// page/limit mode carry Page, Total and TotalPages instead.
 type PaginationMeta struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"total_pages"`
+	Page       int     `json:"page,omitempty"`
+	Limit      int     `json:"limit"`
+	Total      int64   `json:"total,omitempty"`
+	TotalPages int     `json:"total_pages,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+	PrevCursor *string `json:"prev_cursor,omitempty"`
+}
+
+// MarshalJSON reports the totals of legacy pages, which start at page 1,
+// even when they are zero.
+func (m PaginationMeta) MarshalJSON() ([]byte, error) {
+	if m.Page == 0 {
+		type keyset PaginationMeta
+		return json.Marshal(keyset(m))
+	}
+	return json.Marshal(struct {
+		Page       int   `json:"page"`
+		Limit      int   `json:"limit"`
+		Total      int64 `json:"total"`
+		TotalPages int   `json:"total_pages"`
+	}{m.Page, m.Limit, m.Total, m.TotalPages})
+}
+
+// PageMeta describes a page of a list that isn't counted.
+type PageMeta struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
 }
 
 func Success(c *fiber.Ctx, data interface{}, chainID *int64) error {
@@ -0,0 +1,131 @@
+// Package stats maintains precomputed statistics. The Aggregator keeps the
+// hourly and daily chain_stats rollups, recomputing the newest bucket of
+// every active chain on each pass so the current hour and day stay live, and
+// backfilling older history in bounded chunks. The RankingRefresher rebuilds
+// the views behind the top lists.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	rollupInterval = time.Minute
+	// maxHourlySpan and maxDailySpan bound the history one rollup query
+	// covers, so a backfill doesn't run as a single long statement
+	maxHourlySpan = 7 * 24 * time.Hour
+	maxDailySpan  = 30 * 24 * time.Hour
+)
+
+// Periods lists the rollup periods the Aggregator maintains.
+var Periods = []string{models.StatsPeriodHour, models.StatsPeriodDay}
+
+// Aggregator keeps chain_stats up to date with the indexed data. Running
+// more than one is harmless since rollups are idempotent upserts.
+type Aggregator struct {
+	db     *database.DB
+	logger *zap.SugaredLogger
+}
+
+func NewAggregator(db *database.DB, logger *zap.Logger) *Aggregator {
+	return &Aggregator{db: db, logger: logger.Sugar()}
+}
+
+// Run rolls up all active chains immediately and then every minute until ctx
+// is cancelled.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(rollupInterval)
+	defer ticker.Stop()
+	for {
+		a.rollupAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Aggregator) rollupAll(ctx context.Context) {
+	chains, err := a.db.GetChains(ctx)
+	if err != nil {
+		a.logger.Warnw("Failed to load chains for stats rollup", "error", err)
+		return
+	}
+	for _, chain := range chains {
+		if !chain.IsActive {
+			continue
+		}
+		for _, period := range Periods {
+			if err := a.rollup(ctx, chain.ChainID, period); err != nil {
+				a.logger.Warnw("Failed to roll up chain stats", "chain_id", chain.ChainID, "period", period, "error", err)
+			}
+		}
+	}
+}
+
+// rollup recomputes a chain's buckets from its newest rolled up bucket, which
+// may have been partial, to the bucket of the latest indexed block. Stretches
+// without blocks are skipped rather than scanned.
+func (a *Aggregator) rollup(ctx context.Context, chainID int64, period string) error {
+	latest, err := a.db.GetLatestBlock(ctx, chainID)
+	if err != nil || latest == nil {
+		return err
+	}
+	end := BucketStart(latest.Timestamp, period).Add(Length(period))
+
+	var since time.Time
+	if last, err := a.db.GetLatestStatsBucket(ctx, chainID, period); err != nil {
+		return err
+	} else if last != nil {
+		since = *last
+	}
+
+	for ctx.Err() == nil {
+		first, err := a.db.GetFirstBlockTimeSince(ctx, chainID, since)
+		if err != nil || first == nil {
+			return err
+		}
+		from := BucketStart(*first, period)
+		if !from.Before(end) {
+			return nil
+		}
+		to := chunkEnd(from, end, period)
+		if err := a.db.RollupChainStats(ctx, chainID, period, from, to); err != nil {
+			return err
+		}
+		since = to
+	}
+	return ctx.Err()
+}
+
+// chunkEnd returns where a rollup starting at from stops: end, or earlier if
+// that would exceed the period's maximum span.
+func chunkEnd(from, end time.Time, period string) time.Time {
+	span := maxHourlySpan
+	if period == models.StatsPeriodDay {
+		span = maxDailySpan
+	}
+	if to := from.Add(span); to.Before(end) {
+		return to
+	}
+	return end
+}
+
+// BucketStart returns the start of the period bucket containing t, in UTC.
+func BucketStart(t time.Time, period string) time.Time {
+	return t.UTC().Truncate(Length(period))
+}
+
+// Length returns the duration of a period bucket.
+func Length(period string) time.Duration {
+	if period == models.StatsPeriodDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
@@ -0,0 +1,30 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketStart(t *testing.T) {
+	ts := time.Date(2024, 3, 9, 17, 42, 5, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 9, 17, 0, 0, 0, time.UTC), BucketStart(ts, models.StatsPeriodHour))
+	assert.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), BucketStart(ts, models.StatsPeriodDay))
+
+	// Buckets are UTC whatever the input's zone
+	local := ts.In(time.FixedZone("UTC+5", 5*3600))
+	assert.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), BucketStart(local, models.StatsPeriodDay))
+}
+
+func TestChunkEnd(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	end := from.Add(3 * time.Hour)
+	assert.Equal(t, end, chunkEnd(from, end, models.StatsPeriodHour))
+
+	end = from.AddDate(0, 1, 0)
+	assert.Equal(t, from.Add(maxHourlySpan), chunkEnd(from, end, models.StatsPeriodHour))
+	assert.Equal(t, from.Add(maxDailySpan), chunkEnd(from, from.AddDate(1, 0, 0), models.StatsPeriodDay))
+}
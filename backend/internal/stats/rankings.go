@@ -0,0 +1,42 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"go.uber.org/zap"
+)
+
+const rankingsInterval = 5 * time.Minute
+
+// RankingRefresher periodically rebuilds the materialized views behind the
+// top accounts, contracts and tokens lists.
+type RankingRefresher struct {
+	db     *database.DB
+	logger *zap.SugaredLogger
+}
+
+func NewRankingRefresher(db *database.DB, logger *zap.Logger) *RankingRefresher {
+	return &RankingRefresher{db: db, logger: logger.Sugar()}
+}
+
+// Run refreshes the rankings immediately and then every five minutes until
+// ctx is cancelled.
+func (r *RankingRefresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(rankingsInterval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := r.db.RefreshRankings(ctx); err != nil {
+			r.logger.Warnw("Failed to refresh rankings", "error", err)
+		} else {
+			r.logger.Debugw("Refreshed rankings", "duration", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
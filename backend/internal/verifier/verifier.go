@@ -0,0 +1,366 @@
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pulkyeet/eth-devstack/backend/internal/blockchain"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	CodeFormatSingleFile   = "solidity-single-file"
+	CodeFormatStandardJSON = "solidity-standard-json-input"
+
+	verifyTimeout = 2 * time.Minute
+	// maxRunning bounds the solc processes run at once and maxPending the
+	// verifications running or waiting for one of them.
+	maxRunning = 2
+	maxPending = 16
+)
+
+// ErrBusy is returned by Submit while maxPending verifications are queued.
+var ErrBusy = errors.New("too many verifications in progress, try again later")
+
+// Verifier checks submitted Solidity sources against deployed bytecode by
+// compiling them with a local solc binary.
+type Verifier struct {
+	db           *database.DB
+	chainManager *blockchain.ChainManager
+	solcPath     string
+	solcDir      string
+	logger       *zap.SugaredLogger
+
+	pending chan struct{}
+	running chan struct{}
+}
+
+// NewVerifier creates a verifier. solcPath is the default compiler binary;
+// if solcDir is set it is searched first for a binary matching the requested
+// compiler version (solc-<version> or solc-linux-amd64-<version>).
+func NewVerifier(db *database.DB, chainManager *blockchain.ChainManager, solcPath, solcDir string, logger *zap.Logger) *Verifier {
+	return &Verifier{
+		db:           db,
+		chainManager: chainManager,
+		solcPath:     solcPath,
+		solcDir:      solcDir,
+		logger:       logger.Sugar(),
+		pending:      make(chan struct{}, maxPending),
+		running:      make(chan struct{}, maxRunning),
+	}
+}
+
+// Submit records a verification request and queues it to be verified in the
+// background. The returned request carries the GUID to poll. When the queue
+// is full nothing is recorded and ErrBusy is returned.
+func (v *Verifier) Submit(ctx context.Context, req *models.ContractVerification) error {
+	if req.CodeFormat != CodeFormatSingleFile && req.CodeFormat != CodeFormatStandardJSON {
+		return fmt.Errorf("unsupported code format %q", req.CodeFormat)
+	}
+	select {
+	case v.pending <- struct{}{}:
+	default:
+		return ErrBusy
+	}
+	if err := v.db.CreateContractVerification(ctx, req); err != nil {
+		<-v.pending
+		return err
+	}
+	go func() {
+		defer func() { <-v.pending }()
+		v.running <- struct{}{}
+		defer func() { <-v.running }()
+		v.run(req)
+	}()
+	return nil
+}
+
+func (v *Verifier) run(req *models.ContractVerification) {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	logger := v.logger.With("guid", req.GUID, "chain_id", req.ChainID, "address", req.Address)
+	contractABI, err := v.verify(ctx, req)
+	if err != nil {
+		logger.Infow("Contract verification failed", "error", err)
+		if err := v.db.UpdateContractVerificationStatus(ctx, req.ID, "fail", "Fail - Unable to verify. "+err.Error()); err != nil {
+			logger.Errorw("Failed to record verification result", "error", err)
+		}
+		return
+	}
+
+	name := req.ContractName
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	verified := &models.VerifiedContract{
+		ChainID:              req.ChainID,
+		Address:              req.Address,
+		ContractName:         name,
+		CompilerVersion:      req.CompilerVersion,
+		CodeFormat:           req.CodeFormat,
+		SourceCode:           req.SourceCode,
+		OptimizationUsed:     req.OptimizationUsed,
+		Runs:                 req.Runs,
+		EVMVersion:           req.EVMVersion,
+		ConstructorArguments: req.ConstructorArguments,
+		LicenseType:          req.LicenseType,
+	}
+	if err := v.db.UpsertVerifiedContract(ctx, verified); err != nil {
+		logger.Errorw("Failed to store verified contract", "error", err)
+		v.db.UpdateContractVerificationStatus(ctx, req.ID, "fail", "Fail - Unable to verify. internal error")
+		return
+	}
+	if err := v.db.UpsertContractABI(ctx, &models.ContractABI{
+		ChainID: req.ChainID,
+		Address: req.Address,
+		Name:    &name,
+		ABI:     contractABI,
+	}); err != nil {
+		logger.Errorw("Failed to store contract abi", "error", err)
+	}
+	if err := v.db.UpdateContractVerificationStatus(ctx, req.ID, "pass", "Pass - Verified"); err != nil {
+		logger.Errorw("Failed to record verification result", "error", err)
+	}
+	logger.Infow("Contract verified", "contract", name)
+}
+
+type solcOutput struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Contracts map[string]map[string]struct {
+		ABI json.RawMessage `json:"abi"`
+		EVM struct {
+			DeployedBytecode struct {
+				Object              string                    `json:"object"`
+				ImmutableReferences map[string][]immutableRef `json:"immutableReferences"`
+			} `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+type immutableRef struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+func (v *Verifier) verify(ctx context.Context, req *models.ContractVerification) (json.RawMessage, error) {
+	input, file, name, err := buildStandardInput(req)
+	if err != nil {
+		return nil, err
+	}
+
+	solc, err := v.solcBinary(ctx, req.CompilerVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, solc, "--standard-json")
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("solc failed: %w", err)
+	}
+
+	var output solcOutput
+	if err := json.Unmarshal(out, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse solc output: %w", err)
+	}
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			return nil, fmt.Errorf("compilation error: %s", e.FormattedMessage)
+		}
+	}
+
+	var found bool
+	var contractABI json.RawMessage
+	var object string
+	var immutables []immutableRef
+	for f, contracts := range output.Contracts {
+		if file != "" && f != file {
+			continue
+		}
+		if c, ok := contracts[name]; ok {
+			found = true
+			contractABI = c.ABI
+			object = c.EVM.DeployedBytecode.Object
+			for _, refs := range c.EVM.DeployedBytecode.ImmutableReferences {
+				immutables = append(immutables, refs...)
+			}
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("contract %s not found in compiler output", req.ContractName)
+	}
+	if strings.Contains(object, "__$") {
+		return nil, fmt.Errorf("contracts with unlinked libraries are not supported")
+	}
+	compiled := common.FromHex(object)
+
+	client, err := v.chainManager.GetClient(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	onchain, err := client.GetCode(ctx, req.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deployed code: %w", err)
+	}
+	if len(onchain) == 0 {
+		return nil, fmt.Errorf("no contract code at %s", req.Address)
+	}
+
+	if !bytecodeMatches(onchain, compiled, immutables) {
+		return nil, fmt.Errorf("deployed bytecode does not match compiled bytecode")
+	}
+	return contractABI, nil
+}
+
+// buildStandardInput turns a submission into solc standard JSON input and
+// returns the source file and contract name to look for in the output.
+func buildStandardInput(req *models.ContractVerification) ([]byte, string, string, error) {
+	file, name := "", req.ContractName
+	if i := strings.LastIndex(req.ContractName, ":"); i >= 0 {
+		file, name = req.ContractName[:i], req.ContractName[i+1:]
+	}
+
+	outputSelection := map[string]interface{}{
+		"*": map[string]interface{}{
+			"*": []string{"abi", "evm.deployedBytecode.object", "evm.deployedBytecode.immutableReferences"},
+		},
+	}
+
+	var input map[string]interface{}
+	switch req.CodeFormat {
+	case CodeFormatStandardJSON:
+		if err := json.Unmarshal([]byte(req.SourceCode), &input); err != nil {
+			return nil, "", "", fmt.Errorf("invalid standard json input: %w", err)
+		}
+		settings, _ := input["settings"].(map[string]interface{})
+		if settings == nil {
+			settings = map[string]interface{}{}
+		}
+		settings["outputSelection"] = outputSelection
+		input["settings"] = settings
+
+	case CodeFormatSingleFile:
+		if file == "" {
+			file = name + ".sol"
+		}
+		settings := map[string]interface{}{
+			"optimizer": map[string]interface{}{
+				"enabled": req.OptimizationUsed,
+				"runs":    req.Runs,
+			},
+			"outputSelection": outputSelection,
+		}
+		if req.EVMVersion != nil && *req.EVMVersion != "" && *req.EVMVersion != "default" {
+			settings["evmVersion"] = *req.EVMVersion
+		}
+		input = map[string]interface{}{
+			"language": "Solidity",
+			"sources": map[string]interface{}{
+				file: map[string]interface{}{"content": req.SourceCode},
+			},
+			"settings": settings,
+		}
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported code format %q", req.CodeFormat)
+	}
+
+	data, err := json.Marshal(input)
+	return data, file, name, err
+}
+
+// solcBinary finds a compiler binary for the requested version, e.g.
+// "v0.8.20+commit.a1b79de6".
+func (v *Verifier) solcBinary(ctx context.Context, version string) (string, error) {
+	version = strings.TrimPrefix(version, "v")
+	if v.solcDir != "" {
+		for _, name := range []string{"solc-v" + version, "solc-" + version, "solc-linux-amd64-v" + version} {
+			path := filepath.Join(v.solcDir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+
+	out, err := exec.CommandContext(ctx, v.solcPath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("solc not available: %w", err)
+	}
+	if !solcVersionMatches(string(out), version) {
+		return "", fmt.Errorf("compiler version %s not available", version)
+	}
+	return v.solcPath, nil
+}
+
+// solcVersionMatches checks the Version line of solc --version output, e.g.
+// "Version: 0.8.20+commit.a1b79de6.Linux.g++", against a requested version
+// with or without its commit.
+func solcVersionMatches(output, version string) bool {
+	wantRelease, wantBuild, _ := strings.Cut(version, "+")
+	for _, line := range strings.Split(output, "\n") {
+		installed, ok := strings.CutPrefix(strings.TrimSpace(line), "Version:")
+		if !ok {
+			continue
+		}
+		release, build, _ := strings.Cut(strings.TrimSpace(installed), "+")
+		if release != wantRelease {
+			return false
+		}
+		return wantBuild == "" || build == wantBuild || strings.HasPrefix(build, wantBuild+".")
+	}
+	return false
+}
+
+// bytecodeMatches compares deployed runtime code with compiler output,
+// ignoring the trailing CBOR metadata and any immutable values, which solc
+// leaves zeroed in the compiled object.
+func bytecodeMatches(onchain, compiled []byte, immutables []immutableRef) bool {
+	onchain = stripMetadata(onchain)
+	compiled = stripMetadata(compiled)
+	if len(onchain) != len(compiled) {
+		return false
+	}
+
+	masked := make([]byte, len(onchain))
+	copy(masked, onchain)
+	for _, ref := range immutables {
+		if ref.Start < 0 || ref.Start+ref.Length > len(masked) {
+			return false
+		}
+		for i := ref.Start; i < ref.Start+ref.Length; i++ {
+			masked[i] = 0
+		}
+	}
+	return bytes.Equal(masked, compiled)
+}
+
+// stripMetadata removes the CBOR-encoded metadata solc appends to runtime
+// code. Its length is stored big-endian in the final two bytes.
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	n := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	if n+2 > len(code) {
+		return code
+	}
+	return code[:len(code)-2-n]
+}
@@ -0,0 +1,85 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolcVersionMatches(t *testing.T) {
+	output := "solc, the solidity compiler commandline interface\nVersion: 0.8.20+commit.a1b79de6.Linux.g++\n"
+	assert.True(t, solcVersionMatches(output, "0.8.20"))
+	assert.True(t, solcVersionMatches(output, "0.8.20+commit.a1b79de6"))
+	assert.False(t, solcVersionMatches(output, "0.8.2"))
+	assert.False(t, solcVersionMatches(output, "0.8.20+commit.a1b79de"))
+	assert.False(t, solcVersionMatches(output, "0.8.20+commit.deadbeef"))
+	assert.False(t, solcVersionMatches("no version here", "0.8.20"))
+}
+
+func TestSubmitRejectsWhenBusy(t *testing.T) {
+	v := &Verifier{pending: make(chan struct{}, 1)}
+	v.pending <- struct{}{}
+	err := v.Submit(context.Background(), &models.ContractVerification{CodeFormat: CodeFormatSingleFile})
+	assert.ErrorIs(t, err, ErrBusy)
+
+	err = v.Submit(context.Background(), &models.ContractVerification{CodeFormat: "vyper"})
+	assert.EqualError(t, err, `unsupported code format "vyper"`)
+}
+
+func TestStripMetadata(t *testing.T) {
+	// 0x6080 followed by 3 bytes of metadata and its 2-byte length
+	code := common.FromHex("0x6080aabbcc0003")
+	assert.Equal(t, common.FromHex("0x6080"), stripMetadata(code))
+
+	// A length longer than the code is left alone
+	code = common.FromHex("0x6080ffff")
+	assert.Equal(t, code, stripMetadata(code))
+}
+
+func TestBytecodeMatches(t *testing.T) {
+	compiled := common.FromHex("0x60800000000000" + "11110002")
+	onchain := common.FromHex("0x60801234567800" + "22220002")
+
+	assert.False(t, bytecodeMatches(onchain, compiled, nil))
+	assert.True(t, bytecodeMatches(onchain, compiled, []immutableRef{{Start: 2, Length: 4}}))
+	assert.False(t, bytecodeMatches(onchain, compiled, []immutableRef{{Start: 2, Length: 40}}))
+}
+
+func TestBuildStandardInputSingleFile(t *testing.T) {
+	req := &models.ContractVerification{
+		ContractName:     "Token",
+		CodeFormat:       CodeFormatSingleFile,
+		SourceCode:       "contract Token {}",
+		OptimizationUsed: true,
+		Runs:             1000,
+	}
+	data, file, name, err := buildStandardInput(req)
+	require.NoError(t, err)
+	assert.Equal(t, "Token.sol", file)
+	assert.Equal(t, "Token", name)
+
+	var input map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &input))
+	settings := input["settings"].(map[string]interface{})
+	optimizer := settings["optimizer"].(map[string]interface{})
+	assert.Equal(t, true, optimizer["enabled"])
+	assert.Equal(t, float64(1000), optimizer["runs"])
+}
+
+func TestBuildStandardInputStandardJSON(t *testing.T) {
+	req := &models.ContractVerification{
+		ContractName: "src/Counter.sol:Counter",
+		CodeFormat:   CodeFormatStandardJSON,
+		SourceCode:   `{"language":"Solidity","sources":{"src/Counter.sol":{"content":""}},"settings":{"optimizer":{"enabled":false}}}`,
+	}
+	data, file, name, err := buildStandardInput(req)
+	require.NoError(t, err)
+	assert.Equal(t, "src/Counter.sol", file)
+	assert.Equal(t, "Counter", name)
+	assert.Contains(t, string(data), "evm.deployedBytecode.object")
+}
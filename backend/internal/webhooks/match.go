@@ -0,0 +1,121 @@
+// Package webhooks notifies registered receivers of indexed activity. The
+// indexer matches every indexed block against the active webhooks and queues
+// one delivery per matching webhook; the Worker POSTs queued deliveries,
+// signed with the webhook's secret, retrying with backoff until they succeed
+// or are dead-lettered.
+package webhooks
+
+import (
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// Payload types, telling receivers what a delivery carries
+const (
+	PayloadBlock = "block"
+	PayloadAlert = "alert"
+)
+
+// Payload is the body POSTed for a block's matching activity.
+type Payload struct {
+	Type        string    `json:"type"`
+	WebhookID   int64     `json:"webhook_id"`
+	ChainID     int64     `json:"chain_id"`
+	BlockNumber int64     `json:"block_number"`
+	BlockHash   string    `json:"block_hash"`
+	Timestamp   time.Time `json:"timestamp"`
+	Events      []Event   `json:"events"`
+}
+
+// Event is one matching transaction, token transfer or log.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Match returns the activity in block that hook asked for.
+//
+// Transactions match on addresses (sender, recipient or created contract).
+// Token transfers match on addresses (sender or recipient) and tokens. Logs
+// match on addresses (emitting contract) and topics (topic0). An event type
+// is only sent when it is selected by at least one filter and every filter
+// set applies to it, so a webhook for one token's transfers is not also sent
+// the transactions of the addresses it lists. MinValue is a threshold on
+// transaction and transfer values.
+func Match(hook *models.Webhook, chainID int64, block *events.BlockIndexed) []Event {
+	if hook.ChainID != nil && *hook.ChainID != chainID {
+		return nil
+	}
+	var minValue *big.Int
+	if hook.MinValue != nil {
+		minValue, _ = new(big.Int).SetString(*hook.MinValue, 10)
+	}
+	wants := func(eventType string) bool {
+		return len(hook.EventTypes) == 0 || slices.Contains(hook.EventTypes, eventType)
+	}
+	watched := func(address string) bool {
+		return slices.Contains(hook.Addresses, address)
+	}
+	var matched []Event
+
+	if wants(models.WebhookEventTransaction) && len(hook.Addresses) > 0 &&
+		len(hook.Tokens) == 0 && len(hook.Topics) == 0 {
+		for _, tx := range block.Transactions {
+			if !watched(tx.FromAddress) && (tx.ToAddress == nil || !watched(*tx.ToAddress)) &&
+				(tx.ContractAddress == nil || !watched(*tx.ContractAddress)) {
+				continue
+			}
+			if !atLeast(&tx.Value, minValue) {
+				continue
+			}
+			matched = append(matched, Event{Type: models.WebhookEventTransaction, Data: tx})
+		}
+	}
+
+	if wants(models.WebhookEventTokenTransfer) && (len(hook.Addresses) > 0 || len(hook.Tokens) > 0) &&
+		len(hook.Topics) == 0 {
+		for _, t := range block.TokenTransfers {
+			if len(hook.Addresses) > 0 && !watched(t.FromAddress) && !watched(t.ToAddress) {
+				continue
+			}
+			if len(hook.Tokens) > 0 && !slices.Contains(hook.Tokens, t.TokenAddress) {
+				continue
+			}
+			if !atLeast(t.Value, minValue) {
+				continue
+			}
+			matched = append(matched, Event{Type: models.WebhookEventTokenTransfer, Data: t})
+		}
+	}
+
+	if wants(models.WebhookEventLog) && (len(hook.Addresses) > 0 || len(hook.Topics) > 0) &&
+		len(hook.Tokens) == 0 {
+		for _, l := range block.Logs {
+			if len(hook.Addresses) > 0 && !watched(l.Address) {
+				continue
+			}
+			if len(hook.Topics) > 0 && (l.Topic0 == nil || !slices.Contains(hook.Topics, *l.Topic0)) {
+				continue
+			}
+			matched = append(matched, Event{Type: models.WebhookEventLog, Data: l})
+		}
+	}
+	return matched
+}
+
+// atLeast reports whether value is at least min. Without a minimum anything
+// passes; with one, unknown values don't.
+func atLeast(value *string, min *big.Int) bool {
+	if min == nil {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	v, ok := new(big.Int).SetString(*value, 10)
+	return ok && v.Cmp(min) >= 0
+}
@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hotWallet = "0x000000000000000000000000000000000000bEEF"
+	other     = "0x0000000000000000000000000000000000000001"
+	token     = "0x00000000000000000000000000000000000070Ce"
+	transfer  = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+func strPtr(s string) *string { return &s }
+
+func testBlock() *events.BlockIndexed {
+	return &events.BlockIndexed{
+		Block: &models.Block{BlockNumber: 10, Hash: "0xblock"},
+		Transactions: []*models.Transaction{
+			{Hash: "0x1", FromAddress: hotWallet, ToAddress: strPtr(other), Value: "5000"},
+			{Hash: "0x2", FromAddress: other, ToAddress: strPtr(token), Value: "0"},
+		},
+		TokenTransfers: []*models.TokenTransfer{
+			{TokenAddress: token, FromAddress: other, ToAddress: hotWallet, Value: strPtr("100")},
+		},
+		Logs: []*models.TransactionLog{
+			{Address: token, Topic0: strPtr(transfer)},
+		},
+	}
+}
+
+func types(matched []Event) []string {
+	var out []string
+	for _, e := range matched {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestMatchAddresses(t *testing.T) {
+	hook := &models.Webhook{Addresses: []string{hotWallet}}
+	assert.Equal(t, []string{"transaction", "token_transfer"}, types(Match(hook, 1337, testBlock())))
+
+	hook.EventTypes = []string{models.WebhookEventTokenTransfer}
+	assert.Equal(t, []string{"token_transfer"}, types(Match(hook, 1337, testBlock())))
+}
+
+func TestMatchTokensAndTopics(t *testing.T) {
+	// A token filter alone selects its transfers, not every transaction
+	hook := &models.Webhook{Tokens: []string{token}}
+	assert.Equal(t, []string{"token_transfer"}, types(Match(hook, 1337, testBlock())))
+
+	hook = &models.Webhook{Topics: []string{transfer}}
+	assert.Equal(t, []string{"log"}, types(Match(hook, 1337, testBlock())))
+
+	// The contract's own activity: transactions to it and logs it emitted
+	hook = &models.Webhook{Addresses: []string{token}}
+	assert.Equal(t, []string{"transaction", "log"}, types(Match(hook, 1337, testBlock())))
+
+	// One token's transfers involving an address
+	hook = &models.Webhook{Tokens: []string{token}, Addresses: []string{other}}
+	assert.Equal(t, []string{"token_transfer"}, types(Match(hook, 1337, testBlock())))
+	hook.Addresses = []string{token}
+	assert.Empty(t, Match(hook, 1337, testBlock()))
+
+	// One contract's events with a given topic
+	hook = &models.Webhook{Addresses: []string{token}, Topics: []string{transfer}}
+	assert.Equal(t, []string{"log"}, types(Match(hook, 1337, testBlock())))
+}
+
+func TestMatchMinValueAndChain(t *testing.T) {
+	hook := &models.Webhook{Addresses: []string{hotWallet}, MinValue: strPtr("1000")}
+	assert.Equal(t, []string{"transaction"}, types(Match(hook, 1337, testBlock())))
+
+	hook.MinValue = strPtr("10000")
+	assert.Empty(t, Match(hook, 1337, testBlock()))
+
+	chainID := int64(11155111)
+	hook = &models.Webhook{ChainID: &chainID, Addresses: []string{hotWallet}}
+	assert.Empty(t, Match(hook, 1337, testBlock()))
+	assert.NotEmpty(t, Match(hook, chainID, testBlock()))
+}
@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/events"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+)
+
+// webhookRefresh is how long the active webhooks are cached between reloads
+const webhookRefresh = 30 * time.Second
+
+// Matcher queues deliveries for the webhooks matching each indexed block.
+type Matcher struct {
+	db *database.DB
+
+	mu       sync.Mutex
+	hooks    []*models.Webhook
+	loadedAt time.Time
+}
+
+func NewMatcher(db *database.DB) *Matcher {
+	return &Matcher{db: db}
+}
+
+// MatchBlock loads an indexed block and queues a delivery for every webhook
+// with matching activity in it.
+func (m *Matcher) MatchBlock(ctx context.Context, chainID, number int64) error {
+	hooks, err := m.activeHooks(ctx)
+	if err != nil {
+		return err
+	}
+	var candidates []*models.Webhook
+	for _, hook := range hooks {
+		if hook.ChainID == nil || *hook.ChainID == chainID {
+			candidates = append(candidates, hook)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	block, err := events.LoadBlock(ctx, m.db, chainID, number)
+	if err != nil {
+		return fmt.Errorf("failed to load block for webhooks: %w", err)
+	}
+	if block == nil {
+		return nil
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for _, hook := range candidates {
+		matched := Match(hook, chainID, block)
+		if len(matched) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(&Payload{
+			Type:        PayloadBlock,
+			WebhookID:   hook.ID,
+			ChainID:     chainID,
+			BlockNumber: number,
+			BlockHash:   block.Block.Hash,
+			Timestamp:   block.Block.Timestamp,
+			Events:      matched,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			WebhookID:   hook.ID,
+			ChainID:     chainID,
+			BlockNumber: &number,
+			BlockHash:   &block.Block.Hash,
+			Payload:     payload,
+		})
+	}
+	return m.db.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+// activeHooks returns the active webhooks, reloading them once the cached
+// list is older than webhookRefresh.
+func (m *Matcher) activeHooks(ctx context.Context) ([]*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.loadedAt) < webhookRefresh {
+		return m.hooks, nil
+	}
+	hooks, err := m.db.GetActiveWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.hooks, m.loadedAt = hooks, time.Now()
+	return hooks, nil
+}
@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxAttempts is the number of attempts before a delivery is
+	// dead-lettered
+	MaxAttempts     = 8
+	deliveryTimeout = 10 * time.Second
+	// deliveryLease must outlast an attempt so a claimed delivery is not
+	// picked up again while it is being sent
+	deliveryLease = time.Minute
+	claimBatch    = 20
+	pollInterval  = time.Second
+	firstRetry    = 30 * time.Second
+	maxRetry      = time.Hour
+	// maxErrorBody is how much of a failed response is kept as its error
+	maxErrorBody = 512
+)
+
+// Worker sends queued deliveries. Any number of workers may run against the
+// same database.
+type Worker struct {
+	db     *database.DB
+	client *http.Client
+	logger *zap.SugaredLogger
+}
+
+func NewWorker(db *database.DB, cfg config.WebhookConfig, logger *zap.Logger) *Worker {
+	return &Worker{
+		db:     db,
+		client: deliveryClient(cfg.AllowPrivateTargets),
+		logger: logger.Sugar(),
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Keep draining while full batches come back
+		for ctx.Err() == nil {
+			claimed, err := w.db.ClaimWebhookDeliveries(ctx, claimBatch, deliveryLease)
+			if err != nil {
+				w.logger.Warnw("Failed to claim webhook deliveries", "error", err)
+				break
+			}
+			var wg sync.WaitGroup
+			for _, c := range claimed {
+				wg.Add(1)
+				go func(c *database.ClaimedDelivery) {
+					defer wg.Done()
+					w.process(ctx, c)
+				}(c)
+			}
+			wg.Wait()
+			if len(claimed) < claimBatch {
+				break
+			}
+		}
+	}
+}
+
+// process makes one attempt at a delivery and records the outcome.
+func (w *Worker) process(ctx context.Context, c *database.ClaimedDelivery) {
+	d := c.Delivery
+	attempt := w.send(ctx, c)
+	var err error
+	switch {
+	case attempt.Error == nil:
+		err = w.db.MarkWebhookDelivered(ctx, d.ID, attempt)
+	case d.Attempts+1 >= MaxAttempts:
+		w.logger.Warnw("Webhook delivery dead-lettered", "webhook_id", d.WebhookID, "delivery_id", d.ID, "error", *attempt.Error)
+		err = w.db.DeadLetterWebhookDelivery(ctx, d.ID, attempt)
+	default:
+		err = w.db.RetryWebhookDelivery(ctx, d.ID, attempt, time.Now().Add(backoff(d.Attempts+1)))
+	}
+	if err != nil {
+		w.logger.Warnw("Failed to record webhook attempt", "delivery_id", d.ID, "error", err)
+	}
+}
+
+// send POSTs a delivery's payload. Any response other than 2xx is a failure.
+func (w *Worker) send(ctx context.Context, c *database.ClaimedDelivery) *database.WebhookAttempt {
+	d := c.Delivery
+	failed := func(status *int, format string, args ...interface{}) *database.WebhookAttempt {
+		msg := fmt.Sprintf(format, args...)
+		return &database.WebhookAttempt{ResponseStatus: status, Error: &msg}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return failed(nil, "invalid request: %v", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "eth-devstack-webhooks/1.0")
+	req.Header.Set(HeaderWebhookID, strconv.FormatInt(d.WebhookID, 10))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(c.Secret, timestamp, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return failed(nil, "%v", err)
+	}
+	defer resp.Body.Close()
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return failed(&status, "unexpected status %d: %s", status, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return &database.WebhookAttempt{ResponseStatus: &status}
+}
+
+// backoff is the wait after the given number of failed attempts: doubling
+// from firstRetry up to maxRetry.
+func backoff(failures int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < failures && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetry)
+}
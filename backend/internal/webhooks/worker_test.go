@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pulkyeet/eth-devstack/backend/internal/config"
+	"github.com/pulkyeet/eth-devstack/backend/internal/database"
+	"github.com/pulkyeet/eth-devstack/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"webhook_id":1}`)
+	now := time.Now().Unix()
+	stamp := strconv.FormatInt(now, 10)
+	sig := Sign("whsec_test", now, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+
+	assert.NoError(t, Verify("whsec_test", stamp, sig, body, time.Minute))
+	assert.Error(t, Verify("whsec_other", stamp, sig, body, time.Minute))
+	assert.Error(t, Verify("whsec_test", stamp, sig, []byte(`{"webhook_id":2}`), time.Minute))
+
+	old := now - 3600
+	assert.Error(t, Verify("whsec_test", strconv.FormatInt(old, 10), Sign("whsec_test", old, body), body, time.Minute))
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	require.NoError(t, err)
+	b, err := NewSecret()
+	require.NoError(t, err)
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, a)
+	assert.NotEqual(t, a, b)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, time.Minute, backoff(2))
+	assert.Equal(t, 8*time.Minute, backoff(5))
+	assert.Equal(t, time.Hour, backoff(MaxAttempts+10))
+}
+
+// receiver is a local webhook endpoint that verifies what it is sent.
+func receiver(t *testing.T, secret string, status int) (*httptest.Server, <-chan http.Header) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute))
+		assert.JSONEq(t, `{"webhook_id":3,"events":[]}`, string(body))
+		received <- r.Header
+		w.WriteHeader(status)
+		w.Write([]byte("receiver says no\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func claimed(url string) *database.ClaimedDelivery {
+	return &database.ClaimedDelivery{
+		Delivery: &models.WebhookDelivery{ID: 42, WebhookID: 3, Payload: []byte(`{"webhook_id":3,"events":[]}`)},
+		URL:      url,
+		Secret:   "whsec_test",
+	}
+}
+
+func TestSendDelivers(t *testing.T) {
+	server, received := receiver(t, "whsec_test", http.StatusNoContent)
+	w := NewWorker(nil, config.WebhookConfig{}, zap.NewNop())
+	w.client = server.Client()
+
+	attempt := w.send(t.Context(), claimed(server.URL))
+	require.Nil(t, attempt.Error)
+	assert.Equal(t, http.StatusNoContent, *attempt.ResponseStatus)
+
+	headers := <-received
+	assert.Equal(t, "3", headers.Get(HeaderWebhookID))
+	assert.Equal(t, "42", headers.Get(HeaderDelivery))
+}
+
+func TestSendFailures(t *testing.T) {
+	server, _ := receiver(t, "whsec_test", http.StatusInternalServerError)
+	w := NewWorker(nil, config.WebhookConfig{}, zap.NewNop())
+	w.client = server.Client()
+
+	attempt := w.send(t.Context(), claimed(server.URL))
+	require.NotNil(t, attempt.Error)
+	assert.Equal(t, http.StatusInternalServerError, *attempt.ResponseStatus)
+	assert.Equal(t, "unexpected status 500: receiver says no", *attempt.Error)
+
+	server.Close()
+	attempt = w.send(t.Context(), claimed(server.URL))
+	require.NotNil(t, attempt.Error)
+	assert.Nil(t, attempt.ResponseStatus)
+}
+
+func TestSendRefusesPrivateTargets(t *testing.T) {
+	server, _ := receiver(t, "whsec_test", http.StatusNoContent)
+	w := NewWorker(nil, config.WebhookConfig{}, zap.NewNop())
+
+	// The receiver listens on loopback
+	attempt := w.send(t.Context(), claimed(server.URL))
+	require.NotNil(t, attempt.Error)
+	assert.Contains(t, *attempt.Error, "is not public")
+	assert.Nil(t, attempt.ResponseStatus)
+}
+
+func TestSendAllowsPrivateTargets(t *testing.T) {
+	server, received := receiver(t, "whsec_test", http.StatusNoContent)
+	w := NewWorker(nil, config.WebhookConfig{AllowPrivateTargets: true}, zap.NewNop())
+
+	attempt := w.send(t.Context(), claimed(server.URL))
+	require.Nil(t, attempt.Error)
+	assert.Equal(t, http.StatusNoContent, *attempt.ResponseStatus)
+	<-received
+}
+
+func TestCheckTarget(t *testing.T) {
+	for _, host := range []string{"example.com", "93.184.216.34", "[2606:2800:220:1::]"} {
+		assert.NoError(t, CheckTarget(host, false), host)
+	}
+	for _, host := range []string{
+		"localhost", "api.localhost", "LOCALHOST.",
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0",
+		"[::1]", "[fe80::1]", "[fd00::1]", "[::ffff:127.0.0.1]",
+	} {
+		assert.Error(t, CheckTarget(host, false), host)
+		assert.NoError(t, CheckTarget(host, true), host)
+	}
+}